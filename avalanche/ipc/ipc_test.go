@@ -0,0 +1,86 @@
+package ipc
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/hashing"
+	"github.com/kurtosis-tech/kurtosis/commons/networks"
+	"github.com/stretchr/testify/assert"
+	"go.nanomsg.org/mangos/v3/protocol/pub"
+)
+
+func testID(index byte) ids.ID {
+	return ids.NewID([32]byte{index})
+}
+
+func TestSubscriberReceivesAcceptedContainers(t *testing.T) {
+	tempDirpath, err := ioutil.TempDir("", "ipc-test")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tempDirpath)
+	socketFilepath := filepath.Join(tempDirpath, "12345-consensus")
+	publisher, err := pub.NewSocket()
+	assert.NoError(t, err)
+	defer publisher.Close()
+	assert.NoError(t, publisher.Listen("ipc://"+socketFilepath))
+
+	subscriber, err := NewSubscriber(socketFilepath, 10)
+	assert.NoError(t, err)
+	containers := [][]byte{[]byte("container 1"), []byte("container 2")}
+	expectedIDs := []ids.ID{
+		ids.NewID(hashing.ComputeHash256Array(containers[0])),
+		ids.NewID(hashing.ComputeHash256Array(containers[1])),
+	}
+
+	// The subscriber connects asynchronously, and messages published before it's connected are lost
+	published := false
+	for attempt := 0; attempt < 50 && !published; attempt++ {
+		assert.NoError(t, publisher.Send(containers[0]))
+		published = subscriber.AwaitAccepted(100*time.Millisecond, expectedIDs[0]) == nil
+	}
+	assert.True(t, published)
+	assert.NoError(t, publisher.Send(containers[1]))
+	assert.NoError(t, subscriber.AwaitAccepted(time.Second, expectedIDs...))
+	assert.Error(t, subscriber.AwaitAccepted(10*time.Millisecond, testID(1)))
+
+	accepted := subscriber.GetAccepted()
+	assert.True(t, accepted[len(accepted)-1].Equals(expectedIDs[1]))
+	event := <-subscriber.Events()
+	assert.True(t, event.ContainerID.Equals(expectedIDs[0]))
+	assert.Equal(t, containers[0], event.Container)
+
+	assert.NoError(t, subscriber.Close())
+	for range subscriber.Events() {
+	}
+	assert.Error(t, subscriber.AwaitAccepted(time.Second, testID(1)), "A closed subscriber should stop waiting")
+}
+
+func TestVerifySameOrder(t *testing.T) {
+	var (
+		node1 networks.ServiceID = "node-1"
+		node2 networks.ServiceID = "node-2"
+	)
+	// A node that's behind, or that accepted a container the other didn't, agrees on the containers they both accepted
+	assert.NoError(t, VerifySameOrder(map[networks.ServiceID][]ids.ID{
+		node1: {testID(1), testID(2), testID(3), testID(4)},
+		node2: {testID(1), testID(5), testID(2), testID(3)},
+	}))
+	assert.Error(t, VerifySameOrder(map[networks.ServiceID][]ids.ID{
+		node1: {testID(1), testID(2), testID(3)},
+		node2: {testID(1), testID(3), testID(2)},
+	}))
+
+	// A container that a node received twice only counts where it was first accepted
+	assert.NoError(t, VerifySameOrder(map[networks.ServiceID][]ids.ID{
+		node1: {testID(1), testID(1), testID(2)},
+		node2: {testID(1), testID(2), testID(1)},
+	}))
+	assert.Error(t, VerifySameOrder(map[networks.ServiceID][]ids.ID{
+		node1: {testID(1), testID(2)},
+		node2: {testID(2), testID(1), testID(2)},
+	}))
+}
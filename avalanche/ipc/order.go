@@ -0,0 +1,79 @@
+package ipc
+
+import (
+	"sort"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/kurtosis-tech/kurtosis/commons/networks"
+	"github.com/palantir/stacktrace"
+)
+
+// VerifySameOrder returns an error if any two nodes accepted the containers that they all accepted in a different
+// order, given a mapping of service ID -> the IDs of the containers the node accepted, in order (e.g. from
+// Subscriber.GetAccepted). Containers that only some of the nodes accepted so far are ignored, so that nodes that are
+// behind don't fail the check, and only the first time a node accepted a container counts if it was published twice.
+// NOTE: Only linear chains (e.g. the P Chain) accept their blocks in the same order on every node; the vertices of a
+// 	DAG (e.g. the X Chain) that don't depend on each other may be accepted in different orders
+func VerifySameOrder(acceptedByNode map[networks.ServiceID][]ids.ID) error {
+	serviceIDs := make([]networks.ServiceID, 0, len(acceptedByNode))
+	for serviceID := range acceptedByNode {
+		serviceIDs = append(serviceIDs, serviceID)
+	}
+	sort.Slice(serviceIDs, func(i, j int) bool { return serviceIDs[i] < serviceIDs[j] })
+	if len(serviceIDs) < 2 {
+		return nil
+	}
+
+	// Mapping of service ID -> the IDs of the containers the node accepted, each only the first time
+	dedupedByNode := make(map[networks.ServiceID][]ids.ID, len(acceptedByNode))
+	// Mapping of container ID -> the number of nodes that accepted it
+	acceptedCounts := make(map[[32]byte]int)
+	for serviceID, accepted := range acceptedByNode {
+		deduped := []ids.ID{}
+		seen := ids.Set{}
+		for _, containerID := range accepted {
+			if seen.Contains(containerID) {
+				continue
+			}
+			seen.Add(containerID)
+			deduped = append(deduped, containerID)
+			acceptedCounts[containerID.Key()]++
+		}
+		dedupedByNode[serviceID] = deduped
+	}
+	getCommonOrder := func(accepted []ids.ID) []ids.ID {
+		result := []ids.ID{}
+		for _, containerID := range accepted {
+			if acceptedCounts[containerID.Key()] == len(acceptedByNode) {
+				result = append(result, containerID)
+			}
+		}
+		return result
+	}
+
+	expectedOrder := getCommonOrder(dedupedByNode[serviceIDs[0]])
+	for _, serviceID := range serviceIDs[1:] {
+		actualOrder := getCommonOrder(dedupedByNode[serviceID])
+		if len(actualOrder) != len(expectedOrder) {
+			return stacktrace.NewError(
+				"Node %v accepted %v of the containers every node accepted, but node %v accepted %v",
+				serviceID,
+				len(actualOrder),
+				serviceIDs[0],
+				len(expectedOrder))
+		}
+		for i, containerID := range actualOrder {
+			if !containerID.Equals(expectedOrder[i]) {
+				return stacktrace.NewError(
+					"Node %v accepted container %v where node %v accepted container %v, at position %v of the %v containers every node accepted",
+					serviceID,
+					containerID,
+					serviceIDs[0],
+					expectedOrder[i],
+					i,
+					len(expectedOrder))
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package ipc
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/hashing"
+	"github.com/palantir/stacktrace"
+	"github.com/sirupsen/logrus"
+	"go.nanomsg.org/mangos/v3"
+	"go.nanomsg.org/mangos/v3/protocol/sub"
+
+	// Registers the ipc:// transport
+	_ "go.nanomsg.org/mangos/v3/transport/ipc"
+)
+
+// Event is a container (a transaction, block or vertex) that a node accepted, as published on one of its IPC sockets
+type Event struct {
+	ContainerID ids.ID
+	Container   []byte
+
+	// When the event was received, which is shortly after the node accepted the container
+	Time time.Time
+}
+
+// Subscriber receives the containers that a node accepts from one of the IPC sockets it publishes a blockchain on (the
+// consensus socket for the vertices or blocks, or the decisions socket for the transactions too), keeps their IDs in
+// the order they were accepted, and sends them to a channel
+// NOTE: Only containers accepted after the subscriber connected are received
+type Subscriber struct {
+	socket mangos.Socket
+
+	// Guards accepted, acceptedSet, acceptedChan, err and droppedEvents
+	mutex *sync.Mutex
+
+	// The IDs of the containers accepted so far, in the order they were accepted
+	accepted    []ids.ID
+	acceptedSet ids.Set
+
+	// Closed (and replaced) whenever a container is accepted or the subscriber stops receiving, to wake up waiters
+	acceptedChan chan struct{}
+
+	// Why the subscriber stopped receiving, if it did
+	err error
+
+	// The channel accepted containers are sent to, or nil if nobody reads them
+	events chan Event
+
+	// Whether an event was dropped because the events channel was full, so that it's only warned about once
+	droppedEvents bool
+
+	doneChan chan struct{}
+}
+
+// NewSubscriber connects to the IPC socket at [socketFilepath] and starts receiving the containers published on it,
+// sending them to the Events channel, which buffers up to [bufferSize] events, if [bufferSize] is positive
+func NewSubscriber(socketFilepath string, bufferSize int) (*Subscriber, error) {
+	socket, err := sub.NewSocket()
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to create a subscriber socket")
+	}
+	// An empty topic subscribes to every message
+	if err := socket.SetOption(mangos.OptionSubscribe, []byte{}); err != nil {
+		socket.Close()
+		return nil, stacktrace.Propagate(err, "Failed to subscribe to every message")
+	}
+	if err := socket.Dial("ipc://" + socketFilepath); err != nil {
+		socket.Close()
+		return nil, stacktrace.Propagate(err, "Failed to connect to IPC socket %v", socketFilepath)
+	}
+
+	subscriber := &Subscriber{
+		socket:       socket,
+		mutex:        &sync.Mutex{},
+		acceptedSet:  ids.Set{},
+		acceptedChan: make(chan struct{}),
+		doneChan:     make(chan struct{}),
+	}
+	if bufferSize > 0 {
+		subscriber.events = make(chan Event, bufferSize)
+	}
+	go subscriber.receive()
+	return subscriber, nil
+}
+
+// Events returns the channel that the subscriber sends each accepted container to, which is closed once the subscriber
+// stops receiving, or nil if the subscriber was created with a [bufferSize] of 0. Events are dropped (with a warning
+// the first time) rather than holding up receiving if the channel's buffer is full, but they're still kept by
+// GetAccepted.
+func (subscriber *Subscriber) Events() <-chan Event {
+	return subscriber.events
+}
+
+// GetAccepted returns the IDs of the containers accepted so far, in the order they were accepted
+func (subscriber *Subscriber) GetAccepted() []ids.ID {
+	subscriber.mutex.Lock()
+	defer subscriber.mutex.Unlock()
+	return append([]ids.ID{}, subscriber.accepted...)
+}
+
+// AwaitAccepted waits until every container with the given IDs was accepted (including before this was called), and
+// returns an error naming the ones that weren't if that takes longer than [timeout] or the subscriber stops receiving
+func (subscriber *Subscriber) AwaitAccepted(timeout time.Duration, containerIDs ...ids.ID) error {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		subscriber.mutex.Lock()
+		missing := []ids.ID{}
+		for _, containerID := range containerIDs {
+			if !subscriber.acceptedSet.Contains(containerID) {
+				missing = append(missing, containerID)
+			}
+		}
+		acceptedChan := subscriber.acceptedChan
+		err := subscriber.err
+		subscriber.mutex.Unlock()
+
+		if len(missing) == 0 {
+			return nil
+		}
+		if err != nil {
+			return stacktrace.Propagate(err, "Stopped receiving before containers %v were accepted", missing)
+		}
+		select {
+		case <-acceptedChan:
+		case <-timer.C:
+			return stacktrace.NewError("Containers %v weren't accepted within %v", missing, timeout)
+		}
+	}
+}
+
+// Close disconnects from the socket, and blocks until the subscriber stops receiving
+func (subscriber *Subscriber) Close() error {
+	err := subscriber.socket.Close()
+	<-subscriber.doneChan
+	if err != nil && err != mangos.ErrClosed {
+		return stacktrace.Propagate(err, "Failed to close the subscriber socket")
+	}
+	return nil
+}
+
+// ================= Helper functions ===================
+
+// receive records the containers published on the socket until it's closed or fails
+func (subscriber *Subscriber) receive() {
+	defer close(subscriber.doneChan)
+	for {
+		container, err := subscriber.socket.Recv()
+		if err != nil {
+			if err != mangos.ErrClosed {
+				logrus.Warnf("Stopped receiving from IPC socket: %v", err)
+			}
+			subscriber.stop(err)
+			return
+		}
+		subscriber.record(container)
+	}
+}
+
+// record adds a container to the accepted ones, and sends it to the events channel if there is one
+func (subscriber *Subscriber) record(container []byte) {
+	// Transactions, blocks and vertices are all identified by the hash of their bytes
+	event := Event{
+		ContainerID: ids.NewID(hashing.ComputeHash256Array(container)),
+		Container:   container,
+		Time:        time.Now(),
+	}
+
+	subscriber.mutex.Lock()
+	defer subscriber.mutex.Unlock()
+	subscriber.accepted = append(subscriber.accepted, event.ContainerID)
+	subscriber.acceptedSet.Add(event.ContainerID)
+	close(subscriber.acceptedChan)
+	subscriber.acceptedChan = make(chan struct{})
+	if subscriber.events == nil {
+		return
+	}
+	select {
+	case subscriber.events <- event:
+	default:
+		if !subscriber.droppedEvents {
+			logrus.Warnf("Dropped the event of accepted container %v because the subscriber's events aren't read fast enough; later dropped events won't be logged", event.ContainerID)
+			subscriber.droppedEvents = true
+		}
+	}
+}
+
+// stop records why the subscriber stopped receiving, wakes up its waiters and closes its events channel if there is one
+func (subscriber *Subscriber) stop(err error) {
+	subscriber.mutex.Lock()
+	defer subscriber.mutex.Unlock()
+	subscriber.err = err
+	close(subscriber.acceptedChan)
+	subscriber.acceptedChan = make(chan struct{})
+	if subscriber.events != nil {
+		close(subscriber.events)
+	}
+}
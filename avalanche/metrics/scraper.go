@@ -0,0 +1,332 @@
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kurtosis-tech/kurtosis/commons/networks"
+	"github.com/palantir/stacktrace"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	capturedMetricsFilePerms = 0644
+)
+
+// Sample is the value a series had when it was scraped
+type Sample struct {
+	Time  time.Time `json:"time"`
+	Value float64   `json:"value"`
+}
+
+// Series is the values that one metric, with one set of labels, had on one node over a test
+// Summaries and histograms are captured as two series each, the count and the sum, named with the _count and _sum
+// suffixes that Prometheus gives them.
+type Series struct {
+	Name    string            `json:"name"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	Samples []Sample          `json:"samples"`
+}
+
+// Source returns the current metrics of a node, by metric family name
+type Source func() (map[string]*dto.MetricFamily, error)
+
+// Scraper scrapes the metrics of a network's nodes on an interval and keeps every sample, by service ID, so that tests
+// can assert on how the metrics changed and the captured series can be written out once the test is over
+// NOTE: Counters go back to zero when a node restarts, so assertions about nodes that are restarted during a test
+// 	should be made on the samples since the last restart
+type Scraper struct {
+	interval time.Duration
+
+	// Guards sources and series
+	mutex *sync.Mutex
+
+	// Mapping of service ID -> the source of the metrics of the nodes that are still being scraped
+	sources map[networks.ServiceID]Source
+
+	// Mapping of service ID -> series key -> the series captured from the node
+	series map[networks.ServiceID]map[string]*Series
+
+	stopChan chan struct{}
+	doneChan chan struct{}
+	stopOnce *sync.Once
+}
+
+// NewScraper creates a Scraper that scrapes its nodes every [interval] once it's started
+func NewScraper(interval time.Duration) *Scraper {
+	return &Scraper{
+		interval: interval,
+		mutex:    &sync.Mutex{},
+		sources:  make(map[networks.ServiceID]Source),
+		series:   make(map[networks.ServiceID]map[string]*Series),
+		stopChan: make(chan struct{}),
+		doneChan: make(chan struct{}),
+		stopOnce: &sync.Once{},
+	}
+}
+
+// AddNode starts scraping the node with the given service ID, replacing the node's source if it was already added
+func (scraper *Scraper) AddNode(serviceID networks.ServiceID, source Source) {
+	scraper.mutex.Lock()
+	defer scraper.mutex.Unlock()
+	scraper.sources[serviceID] = source
+}
+
+// RemoveNode stops scraping the node with the given service ID, keeping the series captured from it so far
+func (scraper *Scraper) RemoveNode(serviceID networks.ServiceID) {
+	scraper.mutex.Lock()
+	defer scraper.mutex.Unlock()
+	delete(scraper.sources, serviceID)
+}
+
+// Start scrapes the nodes every interval in the background, until Stop is called
+func (scraper *Scraper) Start() {
+	go func() {
+		defer close(scraper.doneChan)
+		ticker := time.NewTicker(scraper.interval)
+		defer ticker.Stop()
+		scraper.Scrape()
+		for {
+			select {
+			case <-scraper.stopChan:
+				return
+			case <-ticker.C:
+				scraper.Scrape()
+			}
+		}
+	}()
+}
+
+// Stop stops the background scraping, and blocks until any scrape in progress is done; it's safe to call more than once
+// NOTE: Must only be called after Start
+func (scraper *Scraper) Stop() {
+	scraper.stopOnce.Do(func() {
+		close(scraper.stopChan)
+	})
+	<-scraper.doneChan
+}
+
+// Scrape scrapes every node once right away, e.g. so that an assertion sees the latest values rather than the ones of
+// the last interval. Nodes that can't be scraped (e.g. because they're stopped) are skipped.
+func (scraper *Scraper) Scrape() {
+	scraper.mutex.Lock()
+	sources := make(map[networks.ServiceID]Source, len(scraper.sources))
+	for serviceID, source := range scraper.sources {
+		sources[serviceID] = source
+	}
+	scraper.mutex.Unlock()
+
+	// A node that's down takes as long as its retries to fail, which shouldn't hold up scraping the others
+	wg := sync.WaitGroup{}
+	for serviceID, source := range sources {
+		wg.Add(1)
+		go func(serviceID networks.ServiceID, source Source) {
+			defer wg.Done()
+			families, err := source()
+			if err != nil {
+				logrus.Debugf("Couldn't scrape the metrics of %v: %v", serviceID, err)
+				return
+			}
+			scraper.record(serviceID, time.Now(), families)
+		}(serviceID, source)
+	}
+	wg.Wait()
+}
+
+// GetSeries returns copies of the series captured from the node with the given service ID, sorted by name and labels
+func (scraper *Scraper) GetSeries(serviceID networks.ServiceID) []Series {
+	scraper.mutex.Lock()
+	defer scraper.mutex.Unlock()
+	return scraper.getSeries(serviceID)
+}
+
+// GetLatest returns the last value of a metric on the node with the given service ID, summed across its label sets
+// (e.g. the accepted transactions of every chain)
+func (scraper *Scraper) GetLatest(serviceID networks.ServiceID, name string) (float64, error) {
+	matching, err := scraper.getMatchingSeries(serviceID, name)
+	if err != nil {
+		return 0, stacktrace.Propagate(err, "Failed to get the series of metric %v on %v", name, serviceID)
+	}
+	total := 0.0
+	for _, series := range matching {
+		total += series.Samples[len(series.Samples)-1].Value
+	}
+	return total, nil
+}
+
+// GetIncrease returns how much a metric on the node with the given service ID went up between its first and last
+// samples, summed across its label sets
+func (scraper *Scraper) GetIncrease(serviceID networks.ServiceID, name string) (float64, error) {
+	matching, err := scraper.getMatchingSeries(serviceID, name)
+	if err != nil {
+		return 0, stacktrace.Propagate(err, "Failed to get the series of metric %v on %v", name, serviceID)
+	}
+	total := 0.0
+	for _, series := range matching {
+		total += series.Samples[len(series.Samples)-1].Value - series.Samples[0].Value
+	}
+	return total, nil
+}
+
+// AssertNoIncrease returns an error if a metric went up on any of the nodes with the given service IDs (e.g. "no
+// dropped messages"), or on any node that was scraped if none are given
+func (scraper *Scraper) AssertNoIncrease(name string, serviceIDs ...networks.ServiceID) error {
+	for _, serviceID := range scraper.getServiceIDs(serviceIDs) {
+		increase, err := scraper.GetIncrease(serviceID, name)
+		if err != nil {
+			return stacktrace.Propagate(err, "Failed to get the increase of metric %v on %v", name, serviceID)
+		}
+		if increase > 0 {
+			return stacktrace.NewError("Metric %v went up by %v on %v", name, increase, serviceID)
+		}
+	}
+	return nil
+}
+
+// AssertAtLeast returns an error if the last value of a metric is below [min] on any of the nodes with the given
+// service IDs (e.g. "accepted tx count >= N"), or on any node that was scraped if none are given
+func (scraper *Scraper) AssertAtLeast(name string, min float64, serviceIDs ...networks.ServiceID) error {
+	for _, serviceID := range scraper.getServiceIDs(serviceIDs) {
+		latest, err := scraper.GetLatest(serviceID, name)
+		if err != nil {
+			return stacktrace.Propagate(err, "Failed to get the last value of metric %v on %v", name, serviceID)
+		}
+		if latest < min {
+			return stacktrace.NewError("Metric %v is %v on %v, which is below %v", name, latest, serviceID, min)
+		}
+	}
+	return nil
+}
+
+// WriteFile writes every series captured so far to a JSON file, as a mapping of service ID -> series
+func (scraper *Scraper) WriteFile(filepath string) error {
+	scraper.mutex.Lock()
+	allSeries := make(map[networks.ServiceID][]Series, len(scraper.series))
+	for serviceID := range scraper.series {
+		allSeries[serviceID] = scraper.getSeries(serviceID)
+	}
+	scraper.mutex.Unlock()
+
+	bytes, err := json.MarshalIndent(allSeries, "", "  ")
+	if err != nil {
+		return stacktrace.Propagate(err, "Failed to serialize the captured metrics")
+	}
+	if err := ioutil.WriteFile(filepath, bytes, capturedMetricsFilePerms); err != nil {
+		return stacktrace.Propagate(err, "Failed to write the captured metrics to %v", filepath)
+	}
+	return nil
+}
+
+// ================= Helper functions ===================
+
+// record adds the values of the given metric families to the node's series
+func (scraper *Scraper) record(serviceID networks.ServiceID, scrapeTime time.Time, families map[string]*dto.MetricFamily) {
+	scraper.mutex.Lock()
+	defer scraper.mutex.Unlock()
+	nodeSeries, found := scraper.series[serviceID]
+	if !found {
+		nodeSeries = make(map[string]*Series)
+		scraper.series[serviceID] = nodeSeries
+	}
+	add := func(name string, labels map[string]string, value float64) {
+		key := getSeriesKey(name, labels)
+		series, found := nodeSeries[key]
+		if !found {
+			series = &Series{Name: name, Labels: labels}
+			nodeSeries[key] = series
+		}
+		series.Samples = append(series.Samples, Sample{Time: scrapeTime, Value: value})
+	}
+
+	for name, family := range families {
+		for _, metric := range family.GetMetric() {
+			var labels map[string]string
+			if len(metric.GetLabel()) > 0 {
+				labels = make(map[string]string, len(metric.GetLabel()))
+				for _, label := range metric.GetLabel() {
+					labels[label.GetName()] = label.GetValue()
+				}
+			}
+			switch family.GetType() {
+			case dto.MetricType_COUNTER:
+				add(name, labels, metric.GetCounter().GetValue())
+			case dto.MetricType_GAUGE:
+				add(name, labels, metric.GetGauge().GetValue())
+			case dto.MetricType_UNTYPED:
+				add(name, labels, metric.GetUntyped().GetValue())
+			case dto.MetricType_SUMMARY:
+				add(name+"_count", labels, float64(metric.GetSummary().GetSampleCount()))
+				add(name+"_sum", labels, metric.GetSummary().GetSampleSum())
+			case dto.MetricType_HISTOGRAM:
+				add(name+"_count", labels, float64(metric.GetHistogram().GetSampleCount()))
+				add(name+"_sum", labels, metric.GetHistogram().GetSampleSum())
+			}
+		}
+	}
+}
+
+// getSeries returns copies of a node's series; the mutex must be held
+func (scraper *Scraper) getSeries(serviceID networks.ServiceID) []Series {
+	nodeSeries := scraper.series[serviceID]
+	keys := make([]string, 0, len(nodeSeries))
+	for key := range nodeSeries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	result := make([]Series, len(keys))
+	for i, key := range keys {
+		series := nodeSeries[key]
+		result[i] = Series{
+			Name:    series.Name,
+			Labels:  series.Labels,
+			Samples: append([]Sample{}, series.Samples...),
+		}
+	}
+	return result
+}
+
+// getMatchingSeries returns the series of a node with the given metric name, which must have been captured
+func (scraper *Scraper) getMatchingSeries(serviceID networks.ServiceID, name string) ([]Series, error) {
+	matching := []Series{}
+	for _, series := range scraper.GetSeries(serviceID) {
+		if series.Name == name {
+			matching = append(matching, series)
+		}
+	}
+	// A metric that was never captured is more likely a misspelled name than a metric that's zero
+	if len(matching) == 0 {
+		return nil, stacktrace.NewError("No samples of metric %v were captured from %v", name, serviceID)
+	}
+	return matching, nil
+}
+
+// getServiceIDs returns the given service IDs, or the IDs of every node that was scraped if there are none
+func (scraper *Scraper) getServiceIDs(serviceIDs []networks.ServiceID) []networks.ServiceID {
+	if len(serviceIDs) > 0 {
+		return serviceIDs
+	}
+	scraper.mutex.Lock()
+	defer scraper.mutex.Unlock()
+	result := make([]networks.ServiceID, 0, len(scraper.series))
+	for serviceID := range scraper.series {
+		result = append(result, serviceID)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+	return result
+}
+
+// getSeriesKey returns a key that's unique to a metric name and set of labels
+func getSeriesKey(name string, labels map[string]string) string {
+	labelStrs := make([]string, 0, len(labels))
+	for labelName, value := range labels {
+		labelStrs = append(labelStrs, fmt.Sprintf("%v=%q", labelName, value))
+	}
+	sort.Strings(labelStrs)
+	return fmt.Sprintf("%v{%v}", name, strings.Join(labelStrs, ","))
+}
@@ -0,0 +1,107 @@
+package metrics
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kurtosis-tech/kurtosis/commons/networks"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testServiceID networks.ServiceID = "node-0"
+)
+
+// newTestSource returns a source that serves the given metrics texts one after another, repeating the last one
+func newTestSource(t *testing.T, texts ...string) Source {
+	scrapes := 0
+	return func() (map[string]*dto.MetricFamily, error) {
+		text := texts[len(texts)-1]
+		if scrapes < len(texts) {
+			text = texts[scrapes]
+		}
+		scrapes++
+		parser := expfmt.TextParser{}
+		families, err := parser.TextToMetricFamilies(strings.NewReader(text))
+		assert.NoError(t, err)
+		return families, nil
+	}
+}
+
+func TestScraperAssertions(t *testing.T) {
+	scraper := NewScraper(0)
+	scraper.AddNode(testServiceID, newTestSource(t,
+		`# TYPE accepted counter
+accepted{chain="X"} 3
+accepted{chain="P"} 1
+# TYPE dropped counter
+dropped 2
+`,
+		`# TYPE accepted counter
+accepted{chain="X"} 10
+accepted{chain="P"} 4
+# TYPE dropped counter
+dropped 2
+`))
+	scraper.Scrape()
+	scraper.Scrape()
+
+	latest, err := scraper.GetLatest(testServiceID, "accepted")
+	assert.NoError(t, err)
+	assert.Equal(t, 14.0, latest)
+	increase, err := scraper.GetIncrease(testServiceID, "accepted")
+	assert.NoError(t, err)
+	assert.Equal(t, 10.0, increase)
+
+	assert.NoError(t, scraper.AssertNoIncrease("dropped"))
+	assert.Error(t, scraper.AssertNoIncrease("accepted", testServiceID))
+	assert.NoError(t, scraper.AssertAtLeast("accepted", 14))
+	assert.Error(t, scraper.AssertAtLeast("accepted", 15, testServiceID))
+	assert.Error(t, scraper.AssertAtLeast("misspelled", 0), "Metrics that were never captured should fail assertions")
+}
+
+func TestScraperRecordsSummariesAsCountAndSum(t *testing.T) {
+	scraper := NewScraper(0)
+	scraper.AddNode(testServiceID, newTestSource(t, `# TYPE latency summary
+latency_sum 1.5
+latency_count 3
+`))
+	scraper.Scrape()
+
+	series := scraper.GetSeries(testServiceID)
+	assert.Len(t, series, 2)
+	assert.Equal(t, "latency_count", series[0].Name)
+	assert.Equal(t, 3.0, series[0].Samples[0].Value)
+	assert.Equal(t, "latency_sum", series[1].Name)
+	assert.Equal(t, 1.5, series[1].Samples[0].Value)
+}
+
+func TestScraperKeepsSeriesOfRemovedNodes(t *testing.T) {
+	scraper := NewScraper(0)
+	scraper.AddNode(testServiceID, newTestSource(t, "gauge 1\n", "gauge 2\n"))
+	scraper.Scrape()
+	scraper.RemoveNode(testServiceID)
+	scraper.Scrape()
+
+	series := scraper.GetSeries(testServiceID)
+	assert.Len(t, series, 1)
+	assert.Len(t, series[0].Samples, 1)
+
+	tempDirpath, err := ioutil.TempDir("", "scraper-test")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tempDirpath)
+	metricsFilepath := filepath.Join(tempDirpath, "metrics.json")
+	assert.NoError(t, scraper.WriteFile(metricsFilepath))
+	contents, err := ioutil.ReadFile(metricsFilepath)
+	assert.NoError(t, err)
+	written := make(map[networks.ServiceID][]Series)
+	assert.NoError(t, json.Unmarshal(contents, &written))
+	assert.Len(t, written[testServiceID], 1)
+	assert.Equal(t, "gauge", written[testServiceID][0].Name)
+}
@@ -0,0 +1,336 @@
+package monitor
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ava-labs/avalanche-testing/avalanche_client/apis/health"
+	"github.com/kurtosis-tech/kurtosis/commons/networks"
+	"github.com/palantir/stacktrace"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	transitionsFilePerms = 0644
+)
+
+// Status is the health of a node, as seen by the last poll of its health API
+type Status string
+
+const (
+	// Healthy means that the node reported that all of its health checks pass
+	Healthy Status = "healthy"
+
+	// Unhealthy means that the node reported that some of its health checks fail
+	Unhealthy Status = "unhealthy"
+
+	// Unreachable means that the node's health API couldn't be queried (e.g. because the node is stopped)
+	Unreachable Status = "unreachable"
+)
+
+// Transition is a change in a node's health; the first poll of a node is a transition from no status
+type Transition struct {
+	ServiceID networks.ServiceID `json:"serviceID"`
+	Time      time.Time          `json:"time"`
+	From      Status             `json:"from,omitempty"`
+	To        Status             `json:"to"`
+
+	// Mapping of the names of the checks that were failing -> why, for transitions to Unhealthy
+	FailingChecks map[string]string `json:"failingChecks,omitempty"`
+
+	// Why the node couldn't be queried, for transitions to Unreachable
+	Error string `json:"error,omitempty"`
+}
+
+// Source returns the current health of a node
+type Source func() (*health.LivenessReply, error)
+
+// HealthMonitor polls the health of a network's nodes on an interval and keeps every transition in their health, so
+// that tests can assert that no node went unhealthy during some phase (e.g. while byzantine nodes were running or load
+// was applied) and subscribers can follow the transitions as they happen
+type HealthMonitor struct {
+	interval time.Duration
+
+	// Serializes polls, so that a slow poll can't record a node's status after a later poll already has
+	pollMutex *sync.Mutex
+
+	// Guards sources, statuses, transitions and subscribers
+	mutex *sync.Mutex
+
+	// Mapping of service ID -> the source of the health of the nodes that are still being polled
+	sources map[networks.ServiceID]Source
+
+	// Mapping of service ID -> the status of the node as of the last poll
+	statuses map[networks.ServiceID]Status
+
+	// Every transition so far, in the order they were seen
+	transitions []Transition
+
+	subscribers []chan Transition
+
+	stopChan chan struct{}
+	doneChan chan struct{}
+	stopOnce *sync.Once
+}
+
+// NewHealthMonitor creates a HealthMonitor that polls its nodes every [interval] once it's started
+func NewHealthMonitor(interval time.Duration) *HealthMonitor {
+	return &HealthMonitor{
+		interval:  interval,
+		pollMutex: &sync.Mutex{},
+		mutex:     &sync.Mutex{},
+		sources:   make(map[networks.ServiceID]Source),
+		statuses:  make(map[networks.ServiceID]Status),
+		stopChan:  make(chan struct{}),
+		doneChan:  make(chan struct{}),
+		stopOnce:  &sync.Once{},
+	}
+}
+
+// AddNode starts polling the node with the given service ID, replacing the node's source if it was already added
+func (monitor *HealthMonitor) AddNode(serviceID networks.ServiceID, source Source) {
+	monitor.mutex.Lock()
+	defer monitor.mutex.Unlock()
+	monitor.sources[serviceID] = source
+}
+
+// RemoveNode stops polling the node with the given service ID, keeping the transitions seen so far
+func (monitor *HealthMonitor) RemoveNode(serviceID networks.ServiceID) {
+	monitor.mutex.Lock()
+	defer monitor.mutex.Unlock()
+	delete(monitor.sources, serviceID)
+	delete(monitor.statuses, serviceID)
+}
+
+// Subscribe returns a channel that every transition seen from now on is sent to, which is closed when the monitor is
+// stopped. Transitions are dropped (with a warning) rather than holding up polling if the channel's buffer of
+// [bufferSize] is full.
+func (monitor *HealthMonitor) Subscribe(bufferSize int) <-chan Transition {
+	monitor.mutex.Lock()
+	defer monitor.mutex.Unlock()
+	subscriber := make(chan Transition, bufferSize)
+	monitor.subscribers = append(monitor.subscribers, subscriber)
+	return subscriber
+}
+
+// Start polls the nodes once right away, so that every node added so far has a status from when the monitor was
+// started on, and then every interval in the background until Stop is called
+func (monitor *HealthMonitor) Start() {
+	monitor.Poll()
+	go func() {
+		defer close(monitor.doneChan)
+		ticker := time.NewTicker(monitor.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-monitor.stopChan:
+				return
+			case <-ticker.C:
+				monitor.Poll()
+			}
+		}
+	}()
+}
+
+// Stop stops the background polling, blocks until any poll in progress is done and closes the subscribers' channels;
+// it's safe to call more than once
+// NOTE: Must only be called after Start
+func (monitor *HealthMonitor) Stop() {
+	monitor.stopOnce.Do(func() {
+		close(monitor.stopChan)
+		<-monitor.doneChan
+		monitor.mutex.Lock()
+		defer monitor.mutex.Unlock()
+		for _, subscriber := range monitor.subscribers {
+			close(subscriber)
+		}
+		monitor.subscribers = nil
+	})
+	<-monitor.doneChan
+}
+
+// Poll polls every node once right away, e.g. so that an assertion sees the nodes' latest health rather than their
+// health as of the last interval
+func (monitor *HealthMonitor) Poll() {
+	monitor.pollMutex.Lock()
+	defer monitor.pollMutex.Unlock()
+
+	monitor.mutex.Lock()
+	sources := make(map[networks.ServiceID]Source, len(monitor.sources))
+	for serviceID, source := range monitor.sources {
+		sources[serviceID] = source
+	}
+	monitor.mutex.Unlock()
+
+	// A node that's down takes as long as its retries to fail, which shouldn't hold up polling the others
+	wg := sync.WaitGroup{}
+	for serviceID, source := range sources {
+		wg.Add(1)
+		go func(serviceID networks.ServiceID, source Source) {
+			defer wg.Done()
+			transition := Transition{ServiceID: serviceID}
+			reply, err := source()
+			transition.Time = time.Now()
+			switch {
+			case err != nil:
+				transition.To = Unreachable
+				transition.Error = err.Error()
+			case !reply.Healthy:
+				transition.To = Unhealthy
+				transition.FailingChecks = reply.GetFailingChecks()
+			default:
+				transition.To = Healthy
+			}
+			monitor.record(transition)
+		}(serviceID, source)
+	}
+	wg.Wait()
+}
+
+// GetStatus returns the status of the node with the given service ID as of the last poll, or false if it hasn't been
+// polled since it was added
+func (monitor *HealthMonitor) GetStatus(serviceID networks.ServiceID) (Status, bool) {
+	monitor.mutex.Lock()
+	defer monitor.mutex.Unlock()
+	status, found := monitor.statuses[serviceID]
+	return status, found
+}
+
+// GetTransitions returns the transitions seen at or after [since], in the order they were seen
+func (monitor *HealthMonitor) GetTransitions(since time.Time) []Transition {
+	monitor.mutex.Lock()
+	defer monitor.mutex.Unlock()
+	result := []Transition{}
+	for _, transition := range monitor.transitions {
+		if !transition.Time.Before(since) {
+			result = append(result, transition)
+		}
+	}
+	return result
+}
+
+// AssertStayedHealthy polls the nodes with the given service IDs (or every node being polled if none are given), and
+// returns an error if any of them wasn't healthy as of the last poll before [since], went from healthy to anything
+// else at or after [since], or isn't healthy now
+func (monitor *HealthMonitor) AssertStayedHealthy(since time.Time, serviceIDs ...networks.ServiceID) error {
+	monitor.Poll()
+	if len(serviceIDs) == 0 {
+		serviceIDs = monitor.getServiceIDs()
+	}
+	asserted := make(map[networks.ServiceID]bool, len(serviceIDs))
+	for _, serviceID := range serviceIDs {
+		asserted[serviceID] = true
+	}
+
+	for _, serviceID := range serviceIDs {
+		status, found := monitor.getStatusBefore(serviceID, since)
+		if !found {
+			return stacktrace.NewError("Node %v wasn't polled before %v, so it isn't known to have been healthy then", serviceID, since)
+		}
+		if status != Healthy {
+			return stacktrace.NewError("Node %v was already %v before %v", serviceID, status, since)
+		}
+	}
+
+	for _, transition := range monitor.GetTransitions(since) {
+		if asserted[transition.ServiceID] && transition.From == Healthy {
+			return stacktrace.NewError(
+				"Node %v went from %v to %v at %v; failing checks: %v, error: %v",
+				transition.ServiceID,
+				transition.From,
+				transition.To,
+				transition.Time,
+				transition.FailingChecks,
+				transition.Error)
+		}
+	}
+	for _, serviceID := range serviceIDs {
+		status, found := monitor.GetStatus(serviceID)
+		if !found {
+			return stacktrace.NewError("The health of %v isn't being monitored", serviceID)
+		}
+		if status != Healthy {
+			return stacktrace.NewError("Node %v is %v", serviceID, status)
+		}
+	}
+	return nil
+}
+
+// WriteFile writes every transition seen so far to a JSON file, in the order they were seen
+func (monitor *HealthMonitor) WriteFile(filepath string) error {
+	bytes, err := json.MarshalIndent(monitor.GetTransitions(time.Time{}), "", "  ")
+	if err != nil {
+		return stacktrace.Propagate(err, "Failed to serialize the health transitions")
+	}
+	if err := ioutil.WriteFile(filepath, bytes, transitionsFilePerms); err != nil {
+		return stacktrace.Propagate(err, "Failed to write the health transitions to %v", filepath)
+	}
+	return nil
+}
+
+// ================= Helper functions ===================
+
+// record updates the status of a node, keeping the transition and sending it to the subscribers if it changed
+func (monitor *HealthMonitor) record(transition Transition) {
+	monitor.mutex.Lock()
+	defer monitor.mutex.Unlock()
+	// A node that was removed while it was being polled shouldn't get a status again
+	if _, found := monitor.sources[transition.ServiceID]; !found {
+		return
+	}
+	transition.From = monitor.statuses[transition.ServiceID]
+	monitor.statuses[transition.ServiceID] = transition.To
+	if transition.From == transition.To {
+		return
+	}
+
+	if transition.To == Healthy {
+		logrus.Debugf("Node %v went from %v to %v", transition.ServiceID, transition.From, transition.To)
+	} else {
+		logrus.Infof("Node %v went from %v to %v; failing checks: %v, error: %v", transition.ServiceID, transition.From, transition.To, transition.FailingChecks, transition.Error)
+	}
+	monitor.transitions = append(monitor.transitions, transition)
+	for _, subscriber := range monitor.subscribers {
+		select {
+		case subscriber <- transition:
+		default:
+			logrus.Warnf("Dropped a health transition of %v because a subscriber isn't keeping up", transition.ServiceID)
+		}
+	}
+}
+
+// getStatusBefore returns the status of the node with the given service ID as of its last transition before [before],
+// or false if it had no transitions by then
+func (monitor *HealthMonitor) getStatusBefore(serviceID networks.ServiceID, before time.Time) (Status, bool) {
+	monitor.mutex.Lock()
+	defer monitor.mutex.Unlock()
+	var status Status
+	found := false
+	var statusTime time.Time
+	// Polls stamp their transitions before recording them, concurrently for every node, so the transitions of different
+	//  nodes aren't necessarily in time order
+	for _, transition := range monitor.transitions {
+		if transition.ServiceID != serviceID || !transition.Time.Before(before) || transition.Time.Before(statusTime) {
+			continue
+		}
+		status = transition.To
+		statusTime = transition.Time
+		found = true
+	}
+	return status, found
+}
+
+// getServiceIDs returns the IDs of every node being polled
+func (monitor *HealthMonitor) getServiceIDs() []networks.ServiceID {
+	monitor.mutex.Lock()
+	defer monitor.mutex.Unlock()
+	result := make([]networks.ServiceID, 0, len(monitor.sources))
+	for serviceID := range monitor.sources {
+		result = append(result, serviceID)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+	return result
+}
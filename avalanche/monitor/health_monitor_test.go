@@ -0,0 +1,129 @@
+package monitor
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ava-labs/avalanche-testing/avalanche_client/apis/health"
+	"github.com/kurtosis-tech/kurtosis/commons/networks"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testServiceID networks.ServiceID = "node-0"
+)
+
+var (
+	healthyReply   = &health.LivenessReply{Healthy: true}
+	unhealthyReply = &health.LivenessReply{
+		Checks: map[string]health.CheckResult{
+			"network": {Error: &health.CheckError{Message: "no peers"}, ContiguousFailures: 2},
+		},
+		Healthy: false,
+	}
+)
+
+// newTestSource returns a source that serves the given replies one after another, repeating the last one; a nil reply
+// is served as an error
+func newTestSource(replies ...*health.LivenessReply) Source {
+	polls := 0
+	return func() (*health.LivenessReply, error) {
+		reply := replies[len(replies)-1]
+		if polls < len(replies) {
+			reply = replies[polls]
+		}
+		polls++
+		if reply == nil {
+			return nil, errors.New("connection refused")
+		}
+		return reply, nil
+	}
+}
+
+func TestHealthMonitorRecordsTransitions(t *testing.T) {
+	monitor := NewHealthMonitor(0)
+	subscription := monitor.Subscribe(10)
+	monitor.AddNode(testServiceID, newTestSource(unhealthyReply, healthyReply, healthyReply, nil))
+	for i := 0; i < 4; i++ {
+		monitor.Poll()
+	}
+
+	transitions := monitor.GetTransitions(time.Time{})
+	assert.Len(t, transitions, 3)
+	assert.Equal(t, Status(""), transitions[0].From)
+	assert.Equal(t, Unhealthy, transitions[0].To)
+	assert.Equal(t, map[string]string{"network": "no peers (failed 2 times in a row)"}, transitions[0].FailingChecks)
+	assert.Equal(t, Unhealthy, transitions[1].From)
+	assert.Equal(t, Healthy, transitions[1].To)
+	assert.Equal(t, Healthy, transitions[2].From)
+	assert.Equal(t, Unreachable, transitions[2].To)
+	assert.Equal(t, "connection refused", transitions[2].Error)
+	for _, expected := range transitions {
+		assert.Equal(t, expected, <-subscription)
+	}
+	status, found := monitor.GetStatus(testServiceID)
+	assert.True(t, found)
+	assert.Equal(t, Unreachable, status)
+}
+
+func TestHealthMonitorAssertStayedHealthy(t *testing.T) {
+	monitor := NewHealthMonitor(0)
+	monitor.AddNode(testServiceID, newTestSource(unhealthyReply, healthyReply, healthyReply, unhealthyReply))
+	monitor.Poll()
+	monitor.Poll()
+	since := time.Now()
+
+	// Being unhealthy before the phase started doesn't count if the node recovered by then, but going unhealthy during
+	// it does
+	assert.NoError(t, monitor.AssertStayedHealthy(since))
+	assert.Error(t, monitor.AssertStayedHealthy(since, testServiceID))
+	assert.Error(t, monitor.AssertStayedHealthy(time.Time{}, "misspelled"), "Nodes that aren't monitored should fail assertions")
+}
+
+func TestHealthMonitorAssertStayedHealthyChecksStatusAtStart(t *testing.T) {
+	monitor := NewHealthMonitor(0)
+	monitor.AddNode(testServiceID, newTestSource(unhealthyReply, healthyReply))
+	monitor.Poll()
+	since := time.Now()
+
+	// The node recovered during the phase, but it wasn't healthy when the phase started
+	assert.Error(t, monitor.AssertStayedHealthy(since))
+}
+
+func TestHealthMonitorAssertStayedHealthyRequiresEarlierPoll(t *testing.T) {
+	monitor := NewHealthMonitor(0)
+	monitor.AddNode(testServiceID, newTestSource(healthyReply))
+	since := time.Now()
+
+	// The node is healthy now, but nothing is known about its health when the phase started
+	assert.Error(t, monitor.AssertStayedHealthy(since))
+}
+
+func TestHealthMonitorStopClosesSubscriptions(t *testing.T) {
+	monitor := NewHealthMonitor(time.Hour)
+	subscription := monitor.Subscribe(0)
+	monitor.Start()
+	monitor.Stop()
+	monitor.Stop()
+	_, open := <-subscription
+	assert.False(t, open)
+}
+
+func TestHealthMonitorStatusBeforeIgnoresRecordingOrder(t *testing.T) {
+	monitor := NewHealthMonitor(0)
+	var otherServiceID networks.ServiceID = "node-1"
+	since := time.Now()
+
+	// A slow poll of another node recorded a later transition before this node's earlier one
+	monitor.transitions = []Transition{
+		{ServiceID: otherServiceID, Time: since.Add(time.Second), To: Healthy},
+		{ServiceID: testServiceID, Time: since.Add(-2 * time.Second), To: Unhealthy},
+		{ServiceID: testServiceID, Time: since.Add(-time.Second), To: Healthy},
+	}
+	status, found := monitor.getStatusBefore(testServiceID, since)
+	assert.True(t, found)
+	assert.Equal(t, Healthy, status)
+	_, found = monitor.getStatusBefore(otherServiceID, since)
+	assert.False(t, found)
+}
@@ -7,7 +7,10 @@ import (
 
 	"strconv"
 	"strings"
+	"sync"
 
+	"github.com/ava-labs/avalanche-testing/avalanche/metrics"
+	"github.com/ava-labs/avalanche-testing/avalanche/monitor"
 	avalancheService "github.com/ava-labs/avalanche-testing/avalanche/services"
 	"github.com/ava-labs/avalanche-testing/avalanche/services/certs"
 	"github.com/ava-labs/avalanche-testing/avalanche_client/apis"
@@ -39,6 +42,42 @@ type TestAvalancheNetwork struct {
 	networks.Network
 
 	svcNetwork *networks.ServiceNetwork
+
+	// The number of bootstrapper nodes the network was initialized with (one per genesis staker)
+	numBootNodes int
+
+	// Gives access to the Docker containers of the network's services, for things Kurtosis doesn't support
+	containerManager *containerManager
+
+	// The traffic rules (partitions, dropped & delayed links) currently applied between the network's services
+	topology *networkTopology
+
+	// Mapping of configuration ID -> the availability checker core that services with that configuration are checked with
+	availabilityCheckerCores map[networks.ConfigurationID]*avalancheService.AvalancheServiceAvailabilityCheckerCore
+
+	// Mapping of service ID -> the configuration the service was started with, guarded by servicesMutex
+	serviceConfigIDs map[networks.ServiceID]networks.ConfigurationID
+	servicesMutex    *sync.Mutex
+
+	// Mapping of configuration ID -> the node config that services started with that configuration get, shared with
+	//  the configurations' initializer cores and guarded by nodeConfigsMutex
+	serviceNodeConfigs map[networks.ConfigurationID]*avalancheService.NodeConfig
+	nodeConfigsMutex   *sync.RWMutex
+
+	// Mapping of configuration ID -> the provider that derives the certs of services started with that configuration
+	//  from their service IDs, for the configurations that use deterministic certs. certsMutex is held while such a
+	//  service is added, so the provider knows which service its next cert is for.
+	deterministicCertProviders map[networks.ConfigurationID]*certs.DeterministicAvalancheCertProvider
+	certsMutex                 *sync.Mutex
+
+	// Scrapes the metrics of the network's services, or nil if metrics aren't being captured
+	metricsScraper *metrics.Scraper
+
+	// Follows the health of the network's services
+	healthMonitor *monitor.HealthMonitor
+
+	// The settings of the environment that the network runs in
+	options NetworkOptions
 }
 
 // GetAvalancheClient returns the API Client for the node with the given service ID
@@ -56,7 +95,7 @@ func (network TestAvalancheNetwork) GetAvalancheClient(serviceID networks.Servic
 // GetAllBootServiceIDs returns the service IDs of all the boot nodes in the network
 func (network TestAvalancheNetwork) GetAllBootServiceIDs() map[networks.ServiceID]bool {
 	result := make(map[networks.ServiceID]bool)
-	for i := 0; i < len(DefaultLocalNetGenesisConfig.Stakers); i++ {
+	for i := 0; i < network.numBootNodes; i++ {
 		bootID := networks.ServiceID(bootNodeServiceIDPrefix + strconv.Itoa(i))
 		result[bootID] = true
 	}
@@ -70,20 +109,81 @@ func (network TestAvalancheNetwork) GetAllBootServiceIDs() map[networks.ServiceI
 // Returns:
 // 		An availability checker that will return true when teh newly-added service is available
 func (network TestAvalancheNetwork) AddService(configurationID networks.ConfigurationID, serviceID networks.ServiceID) (*services.ServiceAvailabilityChecker, error) {
+	if certProvider, found := network.deterministicCertProviders[configurationID]; found {
+		network.certsMutex.Lock()
+		defer network.certsMutex.Unlock()
+		certProvider.SetNextIdentity(string(serviceID))
+	}
+
+	// The service's start command is built from the configuration's node config while it's being added
+	network.nodeConfigsMutex.RLock()
 	availabilityChecker, err := network.svcNetwork.AddService(configurationID, serviceID, network.GetAllBootServiceIDs())
+	network.nodeConfigsMutex.RUnlock()
 	if err != nil {
 		return nil, stacktrace.Propagate(err, "An error occurred adding service with service ID %v, configuration ID %v", serviceID, configurationID)
 	}
+	network.servicesMutex.Lock()
+	network.serviceConfigIDs[serviceID] = configurationID
+	network.servicesMutex.Unlock()
+
+	if network.metricsScraper != nil {
+		source, err := getMetricsSource(network, serviceID)
+		if err != nil {
+			return nil, stacktrace.Propagate(err, "Failed to get the metrics source of service %v", serviceID)
+		}
+		network.metricsScraper.AddNode(serviceID, source)
+	}
+	healthSource, err := getHealthSource(network, serviceID)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to get the health source of service %v", serviceID)
+	}
+	network.healthMonitor.AddNode(serviceID, healthSource)
 	return availabilityChecker, nil
 }
 
+// UpdateNodeConfig changes the node config that services started with the given configuration from now on will get,
+// for settings whose values are only known once the network is running (e.g. the IDs of the subnets a node should validate)
+// NOTE: This doesn't affect services that are already running, even if they're restarted, because a container's command
+// 	is fixed when it's created.
+// Args:
+// 	configurationID: The ID of the configuration to update; boot node configurations can't be changed
+// 	update: Function that modifies the node config; the change is discarded if the result isn't valid
+func (network TestAvalancheNetwork) UpdateNodeConfig(configurationID networks.ConfigurationID, update func(*avalancheService.NodeConfig)) error {
+	network.nodeConfigsMutex.Lock()
+	defer network.nodeConfigsMutex.Unlock()
+	nodeConfig, found := network.serviceNodeConfigs[configurationID]
+	if !found {
+		return stacktrace.NewError("No service configuration with ID %v", configurationID)
+	}
+	// WithDefaults copies the config, so the update can't leave it half-changed
+	updatedConfig := nodeConfig.WithDefaults()
+	update(&updatedConfig)
+	if err := updatedConfig.Validate(); err != nil {
+		return stacktrace.Propagate(err, "The updated node config of configuration %v is invalid", configurationID)
+	}
+	*nodeConfig = updatedConfig
+	return nil
+}
+
 // RemoveService removes the service with the given service ID from the network
 // Args:
 // 	serviceID: The ID of the service to remove from the network
 func (network TestAvalancheNetwork) RemoveService(serviceID networks.ServiceID) error {
+	// A service that was upgraded runs in a container that Kurtosis doesn't know about, which has to be removed too;
+	//  upgrading a service caches its container, so one that can't be found wasn't upgraded
+	containerID, containerErr := network.getServiceContainerID(serviceID)
+	if network.metricsScraper != nil {
+		network.metricsScraper.RemoveNode(serviceID)
+	}
+	network.healthMonitor.RemoveNode(serviceID)
 	if err := network.svcNetwork.RemoveService(serviceID, containerStopTimeout); err != nil {
 		return stacktrace.Propagate(err, "An error occurred removing service with ID %v", serviceID)
 	}
+	if containerErr == nil {
+		if err := network.containerManager.removeReplacementContainer(containerID); err != nil {
+			return stacktrace.Propagate(err, "An error occurred removing the container of upgraded service %v", serviceID)
+		}
+	}
 	return nil
 }
 
@@ -98,24 +198,15 @@ type TestAvalancheNetworkServiceConfig struct {
 	//  for testing how the network performs using duplicate node IDs)
 	varyCerts bool
 
-	// The log level that the Avalanche service should use
-	serviceLogLevel avalancheService.AvalancheLogLevel
-
 	// The image name that Avalanche services started from this configuration should use
 	// Used primarily for Byzantine tests but can also test heterogenous Avalanche versions, for example.
 	imageName string
 
-	// The Snow protocol quroum size that Avalanche services started from this configuration should have
-	snowQuorumSize int
-
-	// The Snow protocol sample size that Avalanche services started from this configuration should have
-	snowSampleSize int
+	// The configuration of the Avalanche services started from this configuration
+	nodeConfig avalancheService.NodeConfig
 
-	networkInitialTimeout time.Duration
-
-	// TODO Make these named parameters, so we don't have an arbitrary bag of extra CLI args!
-	// A list of extra CLI args that should be passed to the Avalanche services started with this configuration
-	additionalCLIArgs map[string]string
+	// The criteria that Avalanche services started from this configuration must meet to be considered available
+	readinessCriteria []avalancheService.ReadinessCriterion
 }
 
 // NewTestAvalancheNetworkServiceConfig creates a new Avalanche network service config with the given parameters
@@ -123,27 +214,21 @@ type TestAvalancheNetworkServiceConfig struct {
 // 		varyCerts: True if the Avalanche services created with this configuration will have differing certs (and therefore
 // 			differing node IDs), or the same cert (used for a test to see how the Avalanche network behaves with duplicate node
 // 			IDs)
-// 		serviceLogLevel: The log level that Avalanche services started with this configuration will use
 // 		imageName: The name of the Docker image that Avalanche services started with this configuration will use
-// 		snowQuroumSize: The Snow protocol quorum size that Avalanche services started with this configuration will use
-// 		snowSampleSize: The Snow protocol sample size that Avalanche services started with this configuration will use
-// 		cliArgs: A key-value mapping of extra CLI args that will be passed to Avalanche services started with this configuration
+// 		nodeConfig: The avalanchego configuration of the services started with this configuration; fields that every node
+// 			needs get their defaults if left empty
+// 		readinessCriteria: The criteria Avalanche services started with this configuration must meet to be considered
+// 			available, checked in order; if none are given, avalancheService.DefaultReadinessCriteria are used
 func NewTestAvalancheNetworkServiceConfig(
 	varyCerts bool,
-	serviceLogLevel avalancheService.AvalancheLogLevel,
 	imageName string,
-	snowQuorumSize int,
-	snowSampleSize int,
-	networkInitialTimeout time.Duration,
-	additionalCLIArgs map[string]string) *TestAvalancheNetworkServiceConfig {
+	nodeConfig avalancheService.NodeConfig,
+	readinessCriteria ...avalancheService.ReadinessCriterion) *TestAvalancheNetworkServiceConfig {
 	return &TestAvalancheNetworkServiceConfig{
-		varyCerts:             varyCerts,
-		serviceLogLevel:       serviceLogLevel,
-		imageName:             imageName,
-		snowQuorumSize:        snowQuorumSize,
-		snowSampleSize:        snowSampleSize,
-		networkInitialTimeout: networkInitialTimeout,
-		additionalCLIArgs:     additionalCLIArgs,
+		varyCerts:         varyCerts,
+		imageName:         imageName,
+		nodeConfig:        nodeConfig,
+		readinessCriteria: readinessCriteria,
 	}
 }
 
@@ -157,9 +242,6 @@ type TestAvalancheNetworkLoader struct {
 	// The Docker image that should be used for the Avalanche boot nodes
 	bootNodeImage string
 
-	// The log level that the Avalanche boot nodes should use
-	bootNodeLogLevel avalancheService.AvalancheLogLevel
-
 	// Whether the nodes that get added to the network (boot node and otherwise) will have staking enabled
 	isStaking bool
 
@@ -169,17 +251,31 @@ type TestAvalancheNetworkLoader struct {
 	// A mapping of (service ID) -> (service config ID) for the services that the network will initialize with
 	desiredServiceConfig map[networks.ServiceID]networks.ConfigurationID
 
-	// The Snow quorum size that the bootstrapper nodes of the network will use
-	bootstrapperSnowQuorumSize int
-
-	// The Snow sample size that the bootstrapper nodes of the network will use
-	bootstrapperSnowSampleSize int
-
 	// The fixed transaction fee for the network
 	txFee uint64
 
-	// The initial timeout for the network
-	networkInitialTimeout time.Duration
+	// The genesis that the network will start with, which also determines how many bootstrapper nodes get started
+	genesisConfig NetworkGenesisConfig
+
+	// Mapping of configuration ID -> availability checker core, filled in by ConfigureNetwork
+	availabilityCheckerCores map[networks.ConfigurationID]*avalancheService.AvalancheServiceAvailabilityCheckerCore
+
+	// Mapping of service ID -> configuration ID for the services started by InitializeNetwork
+	initialServiceConfigIDs map[networks.ServiceID]networks.ConfigurationID
+
+	// The node config that the Avalanche boot nodes should use
+	bootNodeConfig avalancheService.NodeConfig
+
+	// Mapping of configuration ID -> the node config of the user-custom configurations with defaults applied, which the
+	//  network can change after it's started
+	serviceNodeConfigs map[networks.ConfigurationID]*avalancheService.NodeConfig
+
+	// Mapping of configuration ID -> the provider of the user-custom configurations' certs when they're derived
+	//  deterministically, filled in by ConfigureNetwork
+	deterministicCertProviders map[networks.ConfigurationID]*certs.DeterministicAvalancheCertProvider
+
+	// The settings of the environment that the network runs in
+	options NetworkOptions
 }
 
 // NewTestAvalancheNetworkLoader creates a new loader to create a TestAvalancheNetwork with the specified parameters, transparently handling the creation
@@ -192,6 +288,8 @@ type TestAvalancheNetworkLoader struct {
 // 	bootNodeLogLevel: The log level that the boot nodes will launch with
 // 	bootstrapperSnowQuorumSize: The Snow consensus sample size used for nodes in the network
 // 	bootstrapperSnowSampleSize: The Snow consensus quorum size used for nodes in the network
+// 	genesisConfig: The genesis the network will start with; one bootstrapper node is started per genesis staker. Pass
+// 		DefaultLocalNetGenesisConfig to use avalanchego's hardcoded local network genesis.
 // 	serviceConfigs: A mapping of service config ID -> config info that the network will provide to the test for use
 // 	desiredServiceConfigs: A map of service_id -> config_id, one per node, that this network will initialize with
 func NewTestAvalancheNetworkLoader(
@@ -202,10 +300,33 @@ func NewTestAvalancheNetworkLoader(
 	bootstrapperSnowSampleSize int,
 	txFee uint64,
 	networkInitialTimeout time.Duration,
+	genesisConfig NetworkGenesisConfig,
 	serviceConfigs map[networks.ConfigurationID]TestAvalancheNetworkServiceConfig,
 	desiredServiceConfigs map[networks.ServiceID]networks.ConfigurationID) (*TestAvalancheNetworkLoader, error) {
+	if len(genesisConfig.Stakers) == 0 {
+		return nil, stacktrace.NewError("The genesis config must have at least one staker to bootstrap the network from")
+	}
+	if len(genesisConfig.GenesisFileContents) > 0 {
+		if networkName, isStandard := getStandardNetworkName(genesisConfig.NetworkID); isStandard {
+			return nil, stacktrace.NewError(
+				"The genesis config has a custom genesis file but the ID of standard network %v, whose genesis avalanchego refuses to override",
+				networkName)
+		}
+	}
+
+	bootNodeConfig := avalancheService.NodeConfig{
+		LogLevel:              bootNodeLogLevel,
+		SnowSampleSize:        bootstrapperSnowSampleSize,
+		SnowQuorumSize:        bootstrapperSnowQuorumSize,
+		NetworkInitialTimeout: networkInitialTimeout,
+	}.WithDefaults()
+	if err := bootNodeConfig.Validate(); err != nil {
+		return nil, stacktrace.Propagate(err, "The boot node config is invalid")
+	}
+
 	// Defensive copy
 	serviceConfigsCopy := make(map[networks.ConfigurationID]TestAvalancheNetworkServiceConfig)
+	serviceNodeConfigs := make(map[networks.ConfigurationID]*avalancheService.NodeConfig)
 	for configID, configParams := range serviceConfigs {
 		if strings.HasPrefix(string(configID), bootNodeConfigIDPrefix) {
 			return nil, stacktrace.NewError("Config ID %v cannot be used because prefix %v is reserved for boot node configurations. Choose a configuration id that does not begin with %v.",
@@ -214,6 +335,12 @@ func NewTestAvalancheNetworkLoader(
 				bootNodeConfigIDPrefix)
 		}
 		serviceConfigsCopy[configID] = configParams
+
+		nodeConfig := configParams.nodeConfig.WithDefaults()
+		if err := nodeConfig.Validate(); err != nil {
+			return nil, stacktrace.Propagate(err, "The node config of configuration %v is invalid", configID)
+		}
+		serviceNodeConfigs[configID] = &nodeConfig
 	}
 
 	// Defensive copy
@@ -230,47 +357,74 @@ func NewTestAvalancheNetworkLoader(
 
 	return &TestAvalancheNetworkLoader{
 		bootNodeImage:              bootNodeImage,
-		bootNodeLogLevel:           bootNodeLogLevel,
 		isStaking:                  isStaking,
 		serviceConfigs:             serviceConfigsCopy,
 		desiredServiceConfig:       desiredServiceConfigsCopy,
-		bootstrapperSnowQuorumSize: bootstrapperSnowQuorumSize,
-		bootstrapperSnowSampleSize: bootstrapperSnowSampleSize,
 		txFee:                      txFee,
-		networkInitialTimeout:      networkInitialTimeout,
+		genesisConfig:              genesisConfig,
+		availabilityCheckerCores:   make(map[networks.ConfigurationID]*avalancheService.AvalancheServiceAvailabilityCheckerCore),
+		initialServiceConfigIDs:    make(map[networks.ServiceID]networks.ConfigurationID),
+		bootNodeConfig:             bootNodeConfig,
+		serviceNodeConfigs:         serviceNodeConfigs,
+		deterministicCertProviders: make(map[networks.ConfigurationID]*certs.DeterministicAvalancheCertProvider),
 	}, nil
 }
 
+// UpdateBootNodeConfig changes the node config that the boot nodes will start with, for the settings that every
+// validator of the network needs to agree on (e.g. the minimum stake duration). It must be called before the network
+// is configured.
+func (loader *TestAvalancheNetworkLoader) UpdateBootNodeConfig(update func(config *avalancheService.NodeConfig)) error {
+	updated := loader.bootNodeConfig.WithDefaults()
+	update(&updated)
+	if err := updated.Validate(); err != nil {
+		return stacktrace.Propagate(err, "The updated boot node config is invalid")
+	}
+	loader.bootNodeConfig = updated
+	return nil
+}
+
+// UseOptions makes the network run with the settings of the environment it's run in. It must be called before the
+// network is configured.
+func (loader *TestAvalancheNetworkLoader) UseOptions(options NetworkOptions) {
+	loader.options = options
+}
+
 // ConfigureNetwork defines the netwrok's service configurations to be used
 func (loader TestAvalancheNetworkLoader) ConfigureNetwork(builder *networks.ServiceNetworkBuilder) error {
-	localNetGenesisStakers := DefaultLocalNetGenesisConfig.Stakers
-	bootNodeIDs := make([]string, 0, len(localNetGenesisStakers))
-	for _, staker := range DefaultLocalNetGenesisConfig.Stakers {
+	genesisStakers := loader.genesisConfig.Stakers
+	bootNodeIDs := make([]string, 0, len(genesisStakers))
+	for _, staker := range genesisStakers {
 		bootNodeIDs = append(bootNodeIDs, staker.NodeID)
 	}
 
 	// Add boot node configs
-	for i := 0; i < len(DefaultLocalNetGenesisConfig.Stakers); i++ {
+	for i := 0; i < len(genesisStakers); i++ {
 		configID := networks.ConfigurationID(bootNodeConfigIDPrefix + strconv.Itoa(i))
 
-		certString := localNetGenesisStakers[i].TLSCert
-		keyString := localNetGenesisStakers[i].PrivateKey
+		certString := genesisStakers[i].TLSCert
+		keyString := genesisStakers[i].PrivateKey
 
 		certBytes := bytes.NewBufferString(certString)
 		keyBytes := bytes.NewBufferString(keyString)
 
+		bootNodeConfig := loader.bootNodeConfig
 		initializerCore := avalancheService.NewAvalancheServiceInitializerCore(
-			loader.bootstrapperSnowSampleSize,
-			loader.bootstrapperSnowQuorumSize,
+			&bootNodeConfig,
 			loader.txFee,
 			loader.isStaking,
-			loader.networkInitialTimeout,
-			make(map[string]string), // No additional CLI args for the default network
-			bootNodeIDs[0:i],        // Only the node IDs of the already-started nodes
+			loader.genesisConfig.NetworkID,
+			loader.genesisConfig.GenesisFileContents,
+			bootNodeIDs[0:i], // Only the node IDs of the already-started nodes
 			certs.NewStaticAvalancheCertProvider(*keyBytes, *certBytes),
-			loader.bootNodeLogLevel,
 		)
-		availabilityCheckerCore := avalancheService.AvalancheServiceAvailabilityCheckerCore{}
+		// A boot node bootstraps from the boot nodes started before it, so it isn't available until it's connected to all of
+		//  them; this way every boot node is connected to every other once the last one is available, and the peer
+		//  counts that tests check right after setup don't race the boot nodes' connections
+		readinessCriteria := append(avalancheService.DefaultReadinessCriteria(), avalancheService.NewMinPeersCriterion(i))
+		availabilityCheckerCore := avalancheService.NewAvalancheServiceAvailabilityChecker(
+			avalancheService.DefaultAvailabilityTimeout,
+			readinessCriteria...)
+		loader.availabilityCheckerCores[configID] = availabilityCheckerCore
 
 		if err := builder.AddConfiguration(configID, loader.bootNodeImage, initializerCore, availabilityCheckerCore); err != nil {
 			return stacktrace.Propagate(err, "An error occurred adding bootstrapper node with config ID %v", configID)
@@ -279,21 +433,30 @@ func (loader TestAvalancheNetworkLoader) ConfigureNetwork(builder *networks.Serv
 
 	// Add user-custom configs
 	for configID, configParams := range loader.serviceConfigs {
-		certProvider := certs.NewRandomAvalancheCertProvider(configParams.varyCerts)
+		var certProvider certs.AvalancheCertProvider = certs.NewRandomAvalancheCertProvider(configParams.varyCerts)
+		if loader.options.CertGenerator != nil {
+			deterministicCertProvider := certs.NewDeterministicAvalancheCertProvider(
+				loader.options.CertGenerator,
+				string(configID),
+				configParams.varyCerts)
+			loader.deterministicCertProviders[configID] = deterministicCertProvider
+			certProvider = deterministicCertProvider
+		}
 		imageName := configParams.imageName
 
 		initializerCore := avalancheService.NewAvalancheServiceInitializerCore(
-			configParams.snowSampleSize,
-			configParams.snowQuorumSize,
+			loader.serviceNodeConfigs[configID],
 			loader.txFee,
 			loader.isStaking,
-			configParams.networkInitialTimeout,
-			configParams.additionalCLIArgs,
+			loader.genesisConfig.NetworkID,
+			loader.genesisConfig.GenesisFileContents,
 			bootNodeIDs,
 			certProvider,
-			configParams.serviceLogLevel,
 		)
-		availabilityCheckerCore := avalancheService.AvalancheServiceAvailabilityCheckerCore{}
+		availabilityCheckerCore := avalancheService.NewAvalancheServiceAvailabilityChecker(
+			avalancheService.DefaultAvailabilityTimeout,
+			configParams.readinessCriteria...)
+		loader.availabilityCheckerCores[configID] = availabilityCheckerCore
 		if err := builder.AddConfiguration(configID, imageName, initializerCore, availabilityCheckerCore); err != nil {
 			return stacktrace.Propagate(err, "An error occurred adding Avalanche node configuration with ID %v", configID)
 		}
@@ -310,7 +473,7 @@ func (loader TestAvalancheNetworkLoader) InitializeNetwork(network *networks.Ser
 
 	// Add the bootstrapper nodes
 	bootstrapperServiceIDs := make(map[networks.ServiceID]bool)
-	for i := 0; i < len(DefaultLocalNetGenesisConfig.Stakers); i++ {
+	for i := 0; i < len(loader.genesisConfig.Stakers); i++ {
 		configID := networks.ConfigurationID(bootNodeConfigIDPrefix + strconv.Itoa(i))
 		serviceID := networks.ServiceID(bootNodeServiceIDPrefix + strconv.Itoa(i))
 		checker, err := network.AddService(configID, serviceID, bootstrapperServiceIDs)
@@ -322,22 +485,74 @@ func (loader TestAvalancheNetworkLoader) InitializeNetwork(network *networks.Ser
 		// have only the first node as a dependency
 		bootstrapperServiceIDs[serviceID] = true
 		availabilityCheckers[serviceID] = *checker
+		loader.initialServiceConfigIDs[serviceID] = configID
 	}
 
 	// Additional user defined nodes
 	for serviceID, configID := range loader.desiredServiceConfig {
+		if certProvider, found := loader.deterministicCertProviders[configID]; found {
+			certProvider.SetNextIdentity(string(serviceID))
+		}
 		checker, err := network.AddService(configID, serviceID, bootstrapperServiceIDs)
 		if err != nil {
 			return nil, stacktrace.Propagate(err, "Error occurred when adding non-boot node with ID %v and config ID %v", serviceID, configID)
 		}
 		availabilityCheckers[serviceID] = *checker
+		loader.initialServiceConfigIDs[serviceID] = configID
+	}
+
+	// Kurtosis waits for the services to be available too, but its error only says that a service timed out, so the
+	//  services are waited for here first to fail the setup with the readiness criterion a service is still failing
+	setupStartTime := time.Now()
+	for serviceID := range availabilityCheckers {
+		configID := loader.initialServiceConfigIDs[serviceID]
+		availabilityCheckerCore := loader.availabilityCheckerCores[configID]
+		node, err := network.GetService(serviceID)
+		if err != nil {
+			return nil, stacktrace.Propagate(err, "An error occurred retrieving service node with ID %v", serviceID)
+		}
+		ipAddr := node.Service.(avalancheService.AvalancheService).GetJSONRPCSocket().GetIpAddr()
+		deadline := setupStartTime.Add(availabilityCheckerCore.GetTimeout())
+		if err := awaitServiceUp(availabilityCheckerCore, node.Service, ipAddr, deadline); err != nil {
+			return nil, stacktrace.Propagate(err, "Service %v with config ID %v didn't come up during setup", serviceID, configID)
+		}
 	}
 	return availabilityCheckers, nil
 }
 
 // WrapNetwork implements a networks.NetworkLoader function and wraps the underlying networks.ServiceNetwork with the TestAvalancheNetwork
 func (loader TestAvalancheNetworkLoader) WrapNetwork(network *networks.ServiceNetwork) (networks.Network, error) {
-	return TestAvalancheNetwork{
-		svcNetwork: network,
-	}, nil
+	containerManager, err := newContainerManager()
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "An error occurred creating the container manager")
+	}
+	serviceConfigIDs := make(map[networks.ServiceID]networks.ConfigurationID)
+	for serviceID, configID := range loader.initialServiceConfigIDs {
+		serviceConfigIDs[serviceID] = configID
+	}
+	avalancheNetwork := TestAvalancheNetwork{
+		svcNetwork:                 network,
+		numBootNodes:               len(loader.genesisConfig.Stakers),
+		containerManager:           containerManager,
+		topology:                   newNetworkTopology(),
+		availabilityCheckerCores:   loader.availabilityCheckerCores,
+		serviceConfigIDs:           serviceConfigIDs,
+		servicesMutex:              &sync.Mutex{},
+		serviceNodeConfigs:         loader.serviceNodeConfigs,
+		nodeConfigsMutex:           &sync.RWMutex{},
+		deterministicCertProviders: loader.deterministicCertProviders,
+		certsMutex:                 &sync.Mutex{},
+		options:                    loader.options,
+	}
+	metricsScraper, err := startCapturingMetrics(avalancheNetwork, serviceConfigIDs)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "An error occurred starting to capture the network's metrics")
+	}
+	avalancheNetwork.metricsScraper = metricsScraper
+	healthMonitor, err := startMonitoringHealth(avalancheNetwork, serviceConfigIDs)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "An error occurred starting to monitor the network's health")
+	}
+	avalancheNetwork.healthMonitor = healthMonitor
+	return avalancheNetwork, nil
 }
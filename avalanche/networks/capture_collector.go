@@ -0,0 +1,55 @@
+package networks
+
+import (
+	"sync"
+
+	"github.com/ava-labs/avalanche-testing/avalanche/metrics"
+	"github.com/ava-labs/avalanche-testing/avalanche/monitor"
+)
+
+// CaptureCollector keeps track of what every network wrapped with the NetworkOptions it's in captures while the test
+// runs, as each network only lives for as long as the test that uses it
+type CaptureCollector struct {
+	mutex *sync.Mutex
+
+	// The metrics scrapers of the wrapped networks that capture metrics, in the order the networks were wrapped
+	metricsScrapers []*metrics.Scraper
+
+	// The health monitors of the wrapped networks, in the order the networks were wrapped
+	healthMonitors []*monitor.HealthMonitor
+}
+
+// NewCaptureCollector creates a collector that hasn't collected anything yet
+func NewCaptureCollector() *CaptureCollector {
+	return &CaptureCollector{
+		mutex: &sync.Mutex{},
+	}
+}
+
+// GetMetricsScrapers returns the metrics scrapers of the wrapped networks, in the order the networks were wrapped
+func (collector *CaptureCollector) GetMetricsScrapers() []*metrics.Scraper {
+	collector.mutex.Lock()
+	defer collector.mutex.Unlock()
+	return append([]*metrics.Scraper{}, collector.metricsScrapers...)
+}
+
+// GetHealthMonitors returns the health monitors of the wrapped networks, in the order the networks were wrapped
+func (collector *CaptureCollector) GetHealthMonitors() []*monitor.HealthMonitor {
+	collector.mutex.Lock()
+	defer collector.mutex.Unlock()
+	return append([]*monitor.HealthMonitor{}, collector.healthMonitors...)
+}
+
+// ================= Helper functions ===================
+
+func (collector *CaptureCollector) addMetricsScraper(scraper *metrics.Scraper) {
+	collector.mutex.Lock()
+	defer collector.mutex.Unlock()
+	collector.metricsScrapers = append(collector.metricsScrapers, scraper)
+}
+
+func (collector *CaptureCollector) addHealthMonitor(healthMonitor *monitor.HealthMonitor) {
+	collector.mutex.Lock()
+	defer collector.mutex.Unlock()
+	collector.healthMonitors = append(collector.healthMonitors, healthMonitor)
+}
@@ -0,0 +1,338 @@
+package networks
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/strslice"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/palantir/stacktrace"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// The signal used to kill a container without giving it the chance to shut down cleanly
+	killSignal = "SIGKILL"
+
+	// The network-admin capability that the helper containers need to be able to modify a service container's networking
+	netAdminCapability = "NET_ADMIN"
+
+	// Prefix used to make a helper container share the network namespace of another container
+	containerNetworkModePrefix = "container:"
+
+	// Suffix given to the name of a container that replaces another
+	replacementContainerNameSuffix = "-replacement"
+)
+
+// containerManager gives TestAvalancheNetwork the direct access to the Docker containers of its services that Kurtosis'
+// ServiceNetwork doesn't expose. It talks to the same Docker engine as the test controller, which has the engine's socket
+// mounted so that it can start services.
+type containerManager struct {
+	dockerClient *client.Client
+
+	mutex *sync.Mutex
+
+	// Cache of IP address -> container ID, since a container's IP disappears from Docker's listing when it's stopped
+	containerIDsByIP map[string]string
+
+	// Set of the containers created by replaceContainer, which Kurtosis doesn't know about and so won't clean up
+	replacementContainerIDs map[string]bool
+}
+
+func newContainerManager() (*containerManager, error) {
+	dockerClient, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Could not create a Docker client")
+	}
+	return &containerManager{
+		dockerClient:            dockerClient,
+		mutex:                   &sync.Mutex{},
+		containerIDsByIP:        make(map[string]string),
+		replacementContainerIDs: make(map[string]bool),
+	}, nil
+}
+
+// getContainerID returns the ID of the container that has the given IP address
+// NOTE: IPs are unique across tests because Kurtosis gives every test its own subnet
+func (manager *containerManager) getContainerID(ipAddr string) (string, error) {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	if containerID, found := manager.containerIDsByIP[ipAddr]; found {
+		return containerID, nil
+	}
+
+	containers, err := manager.dockerClient.ContainerList(context.Background(), types.ContainerListOptions{})
+	if err != nil {
+		return "", stacktrace.Propagate(err, "Failed to list the Docker containers")
+	}
+	for _, containerSummary := range containers {
+		if containerSummary.NetworkSettings == nil {
+			continue
+		}
+		for _, endpoint := range containerSummary.NetworkSettings.Networks {
+			if endpoint != nil && endpoint.IPAddress == ipAddr {
+				manager.containerIDsByIP[ipAddr] = containerSummary.ID
+				return containerSummary.ID, nil
+			}
+		}
+	}
+	return "", stacktrace.NewError("Could not find a running container with IP %v", ipAddr)
+}
+
+// stopContainer gracefully stops a container, killing it if it hasn't stopped after the given timeout
+func (manager *containerManager) stopContainer(containerID string, timeout time.Duration) error {
+	if err := manager.dockerClient.ContainerStop(context.Background(), containerID, &timeout); err != nil {
+		return stacktrace.Propagate(err, "Failed to stop container %v", containerID)
+	}
+	return nil
+}
+
+// killContainer stops a container immediately with SIGKILL
+func (manager *containerManager) killContainer(containerID string) error {
+	if err := manager.dockerClient.ContainerKill(context.Background(), containerID, killSignal); err != nil {
+		return stacktrace.Propagate(err, "Failed to kill container %v", containerID)
+	}
+	return nil
+}
+
+// startContainer starts a stopped container back up; the container keeps its filesystem and static IP from before it
+// was stopped
+func (manager *containerManager) startContainer(containerID string) error {
+	if err := manager.dockerClient.ContainerStart(context.Background(), containerID, types.ContainerStartOptions{}); err != nil {
+		return stacktrace.Propagate(err, "Failed to start container %v", containerID)
+	}
+	return nil
+}
+
+// replaceContainer stops a container and starts a new one in its place from the given image, with the same command,
+// environment, mounts and IP address, carrying over the contents of the given directory of the old container's
+// filesystem. The old container is left stopped. If the new container can't be started, the old one is started back up.
+// Args:
+// 	oldContainerID: The container to replace, which must be running
+// 	image: The image of the new container
+// 	dataDirpath: The directory to copy from the old container to the new one; its parent must exist in the new image
+// 	stopTimeout: How long the old container gets to stop gracefully before it's killed
+// Returns:
+// 	The ID of the new container
+func (manager *containerManager) replaceContainer(oldContainerID string, image string, dataDirpath string, stopTimeout time.Duration) (string, error) {
+	ctx := context.Background()
+	if err := manager.pullImageIfMissing(image); err != nil {
+		return "", stacktrace.Propagate(err, "An error occurred making sure image %v is available", image)
+	}
+	// The old container's network settings have to be read while it's running, as a stopped container has no IP
+	oldContainer, err := manager.dockerClient.ContainerInspect(ctx, oldContainerID)
+	if err != nil {
+		return "", stacktrace.Propagate(err, "Failed to inspect container %v", oldContainerID)
+	}
+	endpointsConfig := make(map[string]*network.EndpointSettings)
+	for networkName, endpoint := range oldContainer.NetworkSettings.Networks {
+		endpointsConfig[networkName] = &network.EndpointSettings{
+			IPAMConfig: &network.EndpointIPAMConfig{IPv4Address: endpoint.IPAddress},
+			Aliases:    endpoint.Aliases,
+		}
+	}
+
+	if err := manager.stopContainer(oldContainerID, stopTimeout); err != nil {
+		return "", stacktrace.Propagate(err, "An error occurred stopping container %v", oldContainerID)
+	}
+	newContainerID, err := manager.startReplacementContainer(oldContainer, image, endpointsConfig, dataDirpath)
+	if err != nil {
+		if startErr := manager.startContainer(oldContainerID); startErr != nil {
+			logrus.Errorf("Failed to start container %v back up after failing to replace it: %v", oldContainerID, startErr)
+		}
+		return "", stacktrace.Propagate(err, "An error occurred replacing container %v", oldContainerID)
+	}
+
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	for ipAddr, containerID := range manager.containerIDsByIP {
+		if containerID == oldContainerID {
+			manager.containerIDsByIP[ipAddr] = newContainerID
+		}
+	}
+	delete(manager.replacementContainerIDs, oldContainerID)
+	manager.replacementContainerIDs[newContainerID] = true
+	return newContainerID, nil
+}
+
+// removeReplacementContainer stops and removes a container created by replaceContainer; containers that weren't are
+// left to Kurtosis
+func (manager *containerManager) removeReplacementContainer(containerID string) error {
+	manager.mutex.Lock()
+	isReplacement := manager.replacementContainerIDs[containerID]
+	manager.mutex.Unlock()
+	if !isReplacement {
+		return nil
+	}
+	if err := manager.dockerClient.ContainerRemove(context.Background(), containerID, types.ContainerRemoveOptions{Force: true}); err != nil {
+		return stacktrace.Propagate(err, "Failed to remove replacement container %v", containerID)
+	}
+
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	delete(manager.replacementContainerIDs, containerID)
+	for ipAddr, cachedContainerID := range manager.containerIDsByIP {
+		if cachedContainerID == containerID {
+			delete(manager.containerIDsByIP, ipAddr)
+		}
+	}
+	return nil
+}
+
+// startReplacementContainer creates a container like the given one but from another image, copies the data directory
+// over from the old container, and starts it, removing it again if anything fails
+func (manager *containerManager) startReplacementContainer(
+	oldContainer types.ContainerJSON,
+	image string,
+	endpointsConfig map[string]*network.EndpointSettings,
+	dataDirpath string) (string, error) {
+	ctx := context.Background()
+	containerConfig := *oldContainer.Config
+	containerConfig.Image = image
+	containerName := strings.TrimPrefix(oldContainer.Name, "/") + replacementContainerNameSuffix
+	createResp, err := manager.dockerClient.ContainerCreate(
+		ctx,
+		&containerConfig,
+		oldContainer.HostConfig,
+		&network.NetworkingConfig{EndpointsConfig: endpointsConfig},
+		containerName)
+	if err != nil {
+		return "", stacktrace.Propagate(err, "Failed to create the replacement of container %v", oldContainer.ID)
+	}
+	newContainerID := createResp.ID
+
+	if err := manager.copyDirectory(oldContainer.ID, newContainerID, dataDirpath); err != nil {
+		manager.removeContainerAfterFailure(newContainerID)
+		return "", stacktrace.Propagate(err, "An error occurred copying %v to the replacement container", dataDirpath)
+	}
+	if err := manager.startContainer(newContainerID); err != nil {
+		manager.removeContainerAfterFailure(newContainerID)
+		return "", stacktrace.Propagate(err, "An error occurred starting the replacement container")
+	}
+	return newContainerID, nil
+}
+
+// copyDirectory copies a directory from one container to the same path in another; a directory that doesn't exist in
+// the source container is skipped
+func (manager *containerManager) copyDirectory(srcContainerID string, destContainerID string, dirpath string) error {
+	ctx := context.Background()
+	dirContents, _, err := manager.dockerClient.CopyFromContainer(ctx, srcContainerID, dirpath)
+	if client.IsErrNotFound(err) {
+		logrus.Debugf("Container %v has no %v to copy", srcContainerID, dirpath)
+		return nil
+	}
+	if err != nil {
+		return stacktrace.Propagate(err, "Failed to copy %v out of container %v", dirpath, srcContainerID)
+	}
+	defer dirContents.Close()
+	// The archive holds the directory itself, so it's extracted into the directory's parent
+	if err := manager.dockerClient.CopyToContainer(ctx, destContainerID, path.Dir(dirpath), dirContents, types.CopyToContainerOptions{}); err != nil {
+		return stacktrace.Propagate(err, "Failed to copy %v into container %v", dirpath, destContainerID)
+	}
+	return nil
+}
+
+func (manager *containerManager) removeContainerAfterFailure(containerID string) {
+	if err := manager.dockerClient.ContainerRemove(context.Background(), containerID, types.ContainerRemoveOptions{Force: true}); err != nil {
+		logrus.Warnf("Failed to remove container %v: %v", containerID, err)
+	}
+}
+
+// runInNetworkNamespace runs a shell script in a short-lived helper container that shares the network namespace of the
+// target container, which lets us modify the target's networking without needing any tools or privileges inside it
+// Args:
+// 	targetContainerID: The container whose network namespace the script should run in
+// 	image: The image of the helper container, which must contain a shell and whatever tools the script uses
+// 	script: The shell script to run
+func (manager *containerManager) runInNetworkNamespace(targetContainerID string, image string, script string) error {
+	ctx := context.Background()
+	if err := manager.pullImageIfMissing(image); err != nil {
+		return stacktrace.Propagate(err, "An error occurred making sure image %v is available", image)
+	}
+
+	containerConfig := &container.Config{
+		Image: image,
+		Cmd:   strslice.StrSlice{"sh", "-c", script},
+	}
+	hostConfig := &container.HostConfig{
+		NetworkMode: container.NetworkMode(containerNetworkModePrefix + targetContainerID),
+		CapAdd:      strslice.StrSlice{netAdminCapability},
+	}
+	createResp, err := manager.dockerClient.ContainerCreate(ctx, containerConfig, hostConfig, nil, "")
+	if err != nil {
+		return stacktrace.Propagate(err, "Failed to create helper container in the network namespace of container %v", targetContainerID)
+	}
+	helperContainerID := createResp.ID
+	defer func() {
+		if err := manager.dockerClient.ContainerRemove(ctx, helperContainerID, types.ContainerRemoveOptions{Force: true}); err != nil {
+			logrus.Warnf("Failed to remove helper container %v: %v", helperContainerID, err)
+		}
+	}()
+
+	if err := manager.dockerClient.ContainerStart(ctx, helperContainerID, types.ContainerStartOptions{}); err != nil {
+		return stacktrace.Propagate(err, "Failed to start helper container %v", helperContainerID)
+	}
+
+	statusChan, errChan := manager.dockerClient.ContainerWait(ctx, helperContainerID, container.WaitConditionNotRunning)
+	select {
+	case err := <-errChan:
+		return stacktrace.Propagate(err, "An error occurred waiting for helper container %v to exit", helperContainerID)
+	case status := <-statusChan:
+		if status.StatusCode != 0 {
+			return stacktrace.NewError(
+				"Helper container %v exited with code %v running script '%v'; output: %v",
+				helperContainerID,
+				status.StatusCode,
+				script,
+				manager.getLogs(helperContainerID))
+		}
+	}
+	return nil
+}
+
+func (manager *containerManager) pullImageIfMissing(image string) error {
+	ctx := context.Background()
+	if _, _, err := manager.dockerClient.ImageInspectWithRaw(ctx, image); err == nil {
+		return nil
+	}
+	logrus.Debugf("Image %v isn't available locally; pulling it...", image)
+	pullOutput, err := manager.dockerClient.ImagePull(ctx, image, types.ImagePullOptions{})
+	if err != nil {
+		return stacktrace.Propagate(err, "Failed to pull image %v", image)
+	}
+	defer pullOutput.Close()
+	// The pull only completes once its progress output has been fully consumed
+	if _, err := io.Copy(ioutil.Discard, pullOutput); err != nil {
+		return stacktrace.Propagate(err, "An error occurred reading the pull output of image %v", image)
+	}
+	return nil
+}
+
+// getLogs returns the combined stdout & stderr of a container, for use in error messages
+func (manager *containerManager) getLogs(containerID string) string {
+	logsReader, err := manager.dockerClient.ContainerLogs(context.Background(), containerID, types.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+	})
+	if err != nil {
+		return "<could not get logs: " + err.Error() + ">"
+	}
+	defer logsReader.Close()
+
+	output := &bytes.Buffer{}
+	if _, err := stdcopy.StdCopy(output, output, logsReader); err != nil {
+		return "<could not read logs: " + err.Error() + ">"
+	}
+	return output.String()
+}
@@ -1,5 +1,9 @@
 package networks
 
+import (
+	"github.com/ava-labs/avalanchego/utils/constants"
+)
+
 // DefaultLocalNetGenesisConfig contains the private keys and node IDs that come from avalanchego for the 5 bootstrapper nodes.
 // When using avalanchego with the 'local' testnet option, the P-chain comes preloaded with five bootstrapper nodes whose node
 // IDs are hardcoded in avalanchego source. Node IDs are determined based off the TLS keys of the nodes, so to ensure that
@@ -17,6 +21,7 @@ var DefaultLocalNetGenesisConfig = NetworkGenesisConfig{
 		*/
 		"PrivateKey-ewoqjP7PxY4yr3iLTpLisriqt94hdyDFNgchSxGGztUrTXtNN",
 	},
+	NetworkID: constants.LocalID,
 }
 
 /*
@@ -0,0 +1,21 @@
+package networks
+
+import (
+	"strconv"
+
+	"github.com/ava-labs/avalanche-testing/avalanche/services/certs"
+)
+
+const (
+	// The prefix of the identities that the stakers of generated genesis configs derive their certs from
+	genesisStakerIdentityPrefix = "genesis-staker-"
+)
+
+// getStakerCertProvider returns the provider of the cert of the [index]th staker of a generated genesis config, which
+// derives it from [certGenerator] or generates a random one if [certGenerator] is nil
+func getStakerCertProvider(certGenerator *certs.DeterministicCertGenerator, index int) certs.AvalancheCertProvider {
+	if certGenerator == nil {
+		return certs.NewRandomAvalancheCertProvider(false)
+	}
+	return certs.NewDeterministicAvalancheCertProvider(certGenerator, genesisStakerIdentityPrefix+strconv.Itoa(index), false)
+}
@@ -0,0 +1,278 @@
+package networks
+
+import (
+	"encoding/json"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/ava-labs/avalanche-testing/avalanche/services/certs"
+	"github.com/ava-labs/avalanchego/utils/constants"
+	"github.com/ava-labs/avalanchego/utils/crypto"
+	"github.com/ava-labs/avalanchego/utils/formatting"
+	"github.com/ava-labs/avalanchego/utils/units"
+	"github.com/palantir/stacktrace"
+)
+
+const (
+	// Amount of X Chain AVAX the default genesis funded address gets in generated genesis configs, which matches
+	//  what avalanchego gives it in the hardcoded local network genesis
+	defaultFundedAddressAmount = 300 * units.MegaAvax
+
+	// Amount of the default genesis funded address' AVAX that gets split between the generated stakers on the P Chain,
+	//  which also matches what it stakes in the hardcoded local network genesis
+	defaultStakedAmount = 20 * units.MegaAvax
+
+	// How long the generated stakers will validate for, counting from the genesis start time
+	initialStakeDuration = 365 * 24 * time.Hour
+
+	// Offset between the end times of consecutive generated stakers, so they don't all stop validating at once
+	initialStakeDurationOffset = 90 * time.Minute
+
+	// How far in the past the genesis start time is set, so that the generated stakers are already validating when
+	//  the nodes start up
+	genesisStartTimeDelay = time.Hour
+
+	// Delegation fee of the generated stakers, in units of 1/10,000th of a percent
+	initialStakerDelegationFee = 1000000
+
+	// The chain alias used for the addresses in the genesis file
+	xChainAlias = "X"
+
+	genesisMessage = "Generated by the Avalanche E2E test suite"
+
+	// The C Chain genesis avalanchego uses for the local network, minus the precompiled contract allocations
+	localCChainGenesis = `{"config":{"chainId":43112,"homesteadBlock":0,"daoForkBlock":0,"daoForkSupport":true,"eip150Block":0,"eip150Hash":"0x2086799aeebeae135c246c65021c82b4e15a2c451340993aacfd2751886514f0","eip155Block":0,"eip158Block":0,"byzantiumBlock":0,"constantinopleBlock":0,"petersburgBlock":0,"istanbulBlock":0,"muirGlacierBlock":0},"nonce":"0x0","timestamp":"0x0","extraData":"0x00","gasLimit":"0x5f5e100","difficulty":"0x0","mixHash":"0x0000000000000000000000000000000000000000000000000000000000000000","coinbase":"0x0000000000000000000000000000000000000000","alloc":{},"number":"0x0","gasUsed":"0x0","parentHash":"0x0000000000000000000000000000000000000000000000000000000000000000"}`
+
+	// The genesis format requires an Ethereum address on every allocation even though it's only informational
+	placeholderETHAddress = "0x0000000000000000000000000000000000000000"
+
+	// The range that the IDs of generated networks are picked from, which avoids the small IDs of avalanchego's
+	//  standard networks (the local network's ID is also in it, but standard IDs are skipped)
+	minGeneratedNetworkID  = 1000
+	numGeneratedNetworkIDs = 1000000
+
+	// The label that the network ID is derived from the cert generator's seed under
+	networkIDLabel = "networkID"
+)
+
+// GenesisAllocation is an amount of AVAX that a generated genesis will give to an address on the X Chain
+type GenesisAllocation struct {
+	// The address to fund, in the "X-local1..." form; it's funded on the generated network, whose addresses use the
+	//  "custom" HRP, so it's re-encoded with that HRP in the genesis file
+	Address string
+
+	// The amount of nAVAX to give the address
+	Amount uint64
+}
+
+// NewGeneratedNetworkGenesisConfig generates a genesis config for a custom network with an arbitrary number of stakers.
+// The network gets a network ID that isn't one of avalanchego's standard network IDs, because avalanchego refuses to
+// start a standard network with a custom genesis. It's derived from the seed of the cert generator if there is one, and
+// randomly picked otherwise.
+// NOTE: The default genesis funded address from DefaultLocalNetGenesisConfig is always funded (and provides the stake for
+// 	the generated stakers) so that workflows which import the genesis funds keep working against generated networks.
+// Args:
+// 	numStakers: The number of staker identities (and therefore bootstrapper nodes) to generate
+// 	allocations: Extra addresses that should be funded at genesis
+// 	certGenerator: The generator that the stakers' certs are derived from, so that they get the same node IDs on every
+// 		run with the same seed, or nil to generate random certs and a random network ID
+// Returns:
+// 	A genesis config containing the generated staker identities and the contents of the genesis file that nodes in the
+// 		network should be started with
+func NewGeneratedNetworkGenesisConfig(
+	numStakers int,
+	allocations []GenesisAllocation,
+	certGenerator *certs.DeterministicCertGenerator) (*NetworkGenesisConfig, error) {
+	if numStakers < 1 {
+		return nil, stacktrace.NewError("A network needs at least one staker, but %v were requested", numStakers)
+	}
+
+	stakers := make([]StakerIdentity, 0, numStakers)
+	for i := 0; i < numStakers; i++ {
+		staker, err := generateStakerIdentity(getStakerCertProvider(certGenerator, i))
+		if err != nil {
+			return nil, stacktrace.Propagate(err, "An error occurred generating the identity for staker %v", i)
+		}
+		stakers = append(stakers, *staker)
+	}
+
+	randomSeed := time.Now().UnixNano()
+	if certGenerator != nil {
+		randomSeed = certGenerator.DeriveInt64(networkIDLabel)
+	}
+	networkID := generateNetworkID(randomSeed)
+	fundedAddress := DefaultLocalNetGenesisConfig.FundedAddresses
+	fundedXChainAddress, err := getXChainAddress(networkID, fundedAddress.PrivateKey)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to get the X Chain address of the default genesis funded address")
+	}
+
+	genesisFileContents, err := createGenesisFileContents(
+		networkID,
+		stakers,
+		fundedXChainAddress,
+		allocations,
+		time.Now().Add(-genesisStartTimeDelay))
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "An error occurred creating the genesis file contents")
+	}
+
+	return &NetworkGenesisConfig{
+		Stakers:             stakers,
+		FundedAddresses:     fundedAddress,
+		NetworkID:           networkID,
+		GenesisFileContents: genesisFileContents,
+	}, nil
+}
+
+// ================= Helper functions ===================
+// These structs mirror the JSON genesis file format that avalanchego parses with its --genesis flag
+type genesisLockedAmount struct {
+	Amount   uint64 `json:"amount"`
+	Locktime uint64 `json:"locktime"`
+}
+
+type genesisAllocationJSON struct {
+	ETHAddr        string                `json:"ethAddr"`
+	AVAXAddr       string                `json:"avaxAddr"`
+	InitialAmount  uint64                `json:"initialAmount"`
+	UnlockSchedule []genesisLockedAmount `json:"unlockSchedule"`
+}
+
+type genesisStakerJSON struct {
+	NodeID        string `json:"nodeID"`
+	RewardAddress string `json:"rewardAddress"`
+	DelegationFee uint32 `json:"delegationFee"`
+}
+
+type genesisFileJSON struct {
+	NetworkID                  uint32                  `json:"networkID"`
+	Allocations                []genesisAllocationJSON `json:"allocations"`
+	StartTime                  uint64                  `json:"startTime"`
+	InitialStakeDuration       uint64                  `json:"initialStakeDuration"`
+	InitialStakeDurationOffset uint64                  `json:"initialStakeDurationOffset"`
+	InitialStakedFunds         []string                `json:"initialStakedFunds"`
+	InitialStakers             []genesisStakerJSON     `json:"initialStakers"`
+	CChainGenesis              string                  `json:"cChainGenesis"`
+	Message                    string                  `json:"message"`
+}
+
+func createGenesisFileContents(
+	networkID uint32,
+	stakers []StakerIdentity,
+	fundedXChainAddress string,
+	allocations []GenesisAllocation,
+	startTime time.Time) ([]byte, error) {
+	allocationsJSON := []genesisAllocationJSON{
+		{
+			ETHAddr:       placeholderETHAddress,
+			AVAXAddr:      fundedXChainAddress,
+			InitialAmount: defaultFundedAddressAmount,
+			UnlockSchedule: []genesisLockedAmount{
+				{Amount: defaultStakedAmount},
+			},
+		},
+	}
+	for _, allocation := range allocations {
+		chainAlias, _, addressBytes, err := formatting.ParseAddress(allocation.Address)
+		if err != nil {
+			return nil, stacktrace.Propagate(err, "Failed to parse genesis allocation address %v", allocation.Address)
+		}
+		if chainAlias != xChainAlias {
+			return nil, stacktrace.NewError("Genesis allocation address %v is not an X Chain address", allocation.Address)
+		}
+		address, err := formatting.FormatAddress(xChainAlias, constants.GetHRP(networkID), addressBytes)
+		if err != nil {
+			return nil, stacktrace.Propagate(err, "Failed to format genesis allocation address %v for network %v", allocation.Address, networkID)
+		}
+		allocationsJSON = append(allocationsJSON, genesisAllocationJSON{
+			ETHAddr:       placeholderETHAddress,
+			AVAXAddr:      address,
+			InitialAmount: allocation.Amount,
+		})
+	}
+
+	stakersJSON := make([]genesisStakerJSON, 0, len(stakers))
+	for _, staker := range stakers {
+		stakersJSON = append(stakersJSON, genesisStakerJSON{
+			NodeID:        staker.NodeID,
+			RewardAddress: fundedXChainAddress,
+			DelegationFee: initialStakerDelegationFee,
+		})
+	}
+
+	genesis := genesisFileJSON{
+		NetworkID:                  networkID,
+		Allocations:                allocationsJSON,
+		StartTime:                  uint64(startTime.Unix()),
+		InitialStakeDuration:       uint64(initialStakeDuration / time.Second),
+		InitialStakeDurationOffset: uint64(initialStakeDurationOffset / time.Second),
+		InitialStakedFunds:         []string{fundedXChainAddress},
+		InitialStakers:             stakersJSON,
+		CChainGenesis:              localCChainGenesis,
+		Message:                    genesisMessage,
+	}
+	genesisBytes, err := json.MarshalIndent(genesis, "", "    ")
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to serialize the genesis file")
+	}
+	return genesisBytes, nil
+}
+
+func generateStakerIdentity(certProvider certs.AvalancheCertProvider) (*StakerIdentity, error) {
+	certPEM, keyPEM, err := certProvider.GetCertAndKey()
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to generate a staking cert and key")
+	}
+	nodeID, err := certs.GetNodeIDFromCert(certPEM.Bytes())
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to compute the node ID of the generated staking cert")
+	}
+	return &StakerIdentity{
+		NodeID:     nodeID,
+		PrivateKey: keyPEM.String(),
+		TLSCert:    certPEM.String(),
+	}, nil
+}
+
+// generateNetworkID picks the ID of a generated network from the IDs that aren't standard network IDs, using a random
+// source with the given seed
+func generateNetworkID(randomSeed int64) uint32 {
+	random := rand.New(rand.NewSource(randomSeed))
+	for {
+		networkID := uint32(minGeneratedNetworkID + random.Intn(numGeneratedNetworkIDs))
+		if _, isStandard := getStandardNetworkName(networkID); !isStandard {
+			return networkID
+		}
+	}
+}
+
+// getStandardNetworkName returns the name of the standard network with the given ID, or false if the ID isn't one of
+// avalanchego's standard network IDs
+func getStandardNetworkName(networkID uint32) (string, bool) {
+	networkName, isStandard := constants.NetworkIDToNetworkName[networkID]
+	return networkName, isStandard
+}
+
+// getXChainAddress returns the X Chain address on the network with the given ID, in the "X-custom1..." form for
+// generated networks, of a "PrivateKey-..." string
+func getXChainAddress(networkID uint32, privateKeyStr string) (string, error) {
+	if !strings.HasPrefix(privateKeyStr, constants.SecretKeyPrefix) {
+		return "", stacktrace.NewError("Private key is missing the %v prefix", constants.SecretKeyPrefix)
+	}
+	formattedPrivateKey := formatting.CB58{}
+	if err := formattedPrivateKey.FromString(strings.TrimPrefix(privateKeyStr, constants.SecretKeyPrefix)); err != nil {
+		return "", stacktrace.Propagate(err, "Failed to parse private key")
+	}
+	factory := crypto.FactorySECP256K1R{}
+	privateKey, err := factory.ToPrivateKey(formattedPrivateKey.Bytes)
+	if err != nil {
+		return "", stacktrace.Propagate(err, "Failed to convert bytes to a private key")
+	}
+	address, err := formatting.FormatAddress(xChainAlias, constants.GetHRP(networkID), privateKey.PublicKey().Address().Bytes())
+	if err != nil {
+		return "", stacktrace.Propagate(err, "Failed to format the address of the private key")
+	}
+	return address, nil
+}
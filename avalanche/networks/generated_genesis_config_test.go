@@ -0,0 +1,78 @@
+package networks
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ava-labs/avalanche-testing/avalanche/services/certs"
+	"github.com/ava-labs/avalanchego/utils/constants"
+	"github.com/ava-labs/avalanchego/utils/formatting"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testAllocationAddress = "X-local1ur873jhz9qnaqv5qthk5sn3e8nj3e0kmzpjrhp"
+	testAllocationAmount  = uint64(1000)
+)
+
+func TestGeneratedGenesisConfig(t *testing.T) {
+	numStakers := 3
+	allocations := []GenesisAllocation{
+		{Address: testAllocationAddress, Amount: testAllocationAmount},
+	}
+	genesisConfig, err := NewGeneratedNetworkGenesisConfig(numStakers, allocations, nil)
+	assert.NoError(t, err, "An error occurred generating the genesis config")
+	assert.Equal(t, DefaultLocalNetGenesisConfig.FundedAddresses, genesisConfig.FundedAddresses)
+	_, isStandard := constants.NetworkIDToNetworkName[genesisConfig.NetworkID]
+	assert.False(t, isStandard, "Generated network ID %v is a standard network ID", genesisConfig.NetworkID)
+
+	nodeIDs := make(map[string]bool)
+	for _, staker := range genesisConfig.Stakers {
+		nodeIDs[staker.NodeID] = true
+	}
+	assert.Equal(t, numStakers, len(nodeIDs), "Generated stakers should all have distinct node IDs")
+
+	genesis := genesisFileJSON{}
+	assert.NoError(t, json.Unmarshal(genesisConfig.GenesisFileContents, &genesis))
+	assert.Equal(t, genesisConfig.NetworkID, genesis.NetworkID)
+	assert.Equal(t, numStakers, len(genesis.InitialStakers))
+	for _, staker := range genesis.InitialStakers {
+		assert.True(t, nodeIDs[staker.NodeID], "Genesis staker %v isn't one of the generated stakers", staker.NodeID)
+	}
+
+	// The default funded address always comes first, followed by the requested allocations
+	assert.Equal(t, 2, len(genesis.Allocations))
+	assert.Equal(t, []string{genesis.Allocations[0].AVAXAddr}, genesis.InitialStakedFunds)
+	// The allocation is re-encoded with the HRP of the generated network
+	_, _, expectedAddressBytes, err := formatting.ParseAddress(testAllocationAddress)
+	assert.NoError(t, err)
+	alias, hrp, addressBytes, err := formatting.ParseAddress(genesis.Allocations[1].AVAXAddr)
+	assert.NoError(t, err)
+	assert.Equal(t, "X", alias)
+	assert.Equal(t, constants.GetHRP(genesisConfig.NetworkID), hrp)
+	assert.Equal(t, expectedAddressBytes, addressBytes)
+	assert.Equal(t, testAllocationAmount, genesis.Allocations[1].InitialAmount)
+}
+
+func TestGeneratedNetworkIDFollowsCertSeed(t *testing.T) {
+	getNetworkID := func(seed string) uint32 {
+		certGenerator, err := certs.NewDeterministicCertGenerator(seed, certs.ECDSAKeyType, "")
+		assert.NoError(t, err)
+		genesisConfig, err := NewGeneratedNetworkGenesisConfig(1, nil, certGenerator)
+		assert.NoError(t, err)
+		return genesisConfig.NetworkID
+	}
+	networkID := getNetworkID("seed")
+	assert.Equal(t, networkID, getNetworkID("seed"))
+	assert.NotEqual(t, networkID, getNetworkID("other-seed"))
+	_, isStandard := constants.NetworkIDToNetworkName[networkID]
+	assert.False(t, isStandard, "Generated network ID %v is a standard network ID", networkID)
+}
+
+func TestGeneratedGenesisConfigRejectsBadInput(t *testing.T) {
+	_, err := NewGeneratedNetworkGenesisConfig(0, nil, nil)
+	assert.Error(t, err, "Generating a genesis without stakers should fail")
+
+	_, err = NewGeneratedNetworkGenesisConfig(1, []GenesisAllocation{{Address: "P-local1abc", Amount: 1}}, nil)
+	assert.Error(t, err, "Generating a genesis with a non-X Chain allocation should fail")
+}
@@ -0,0 +1,49 @@
+package networks
+
+import (
+	"time"
+
+	"github.com/ava-labs/avalanche-testing/avalanche/monitor"
+	"github.com/kurtosis-tech/kurtosis/commons/networks"
+	"github.com/palantir/stacktrace"
+)
+
+const (
+	// How often the health of every node in a network is polled
+	healthPollInterval = 5 * time.Second
+)
+
+// GetHealthMonitor returns the monitor that follows the health of the network's nodes, for tests to subscribe to and
+// assert on (e.g. that no node went unhealthy while load was applied)
+func (network TestAvalancheNetwork) GetHealthMonitor() *monitor.HealthMonitor {
+	return network.healthMonitor
+}
+
+// ================= Helper functions ===================
+
+// startMonitoringHealth starts a health monitor for the given network's initial services, registering it with the
+// network options' capture collector if there is one
+func startMonitoringHealth(network TestAvalancheNetwork, serviceIDs map[networks.ServiceID]networks.ConfigurationID) (*monitor.HealthMonitor, error) {
+	healthMonitor := monitor.NewHealthMonitor(healthPollInterval)
+	for serviceID := range serviceIDs {
+		source, err := getHealthSource(network, serviceID)
+		if err != nil {
+			return nil, stacktrace.Propagate(err, "Failed to get the health source of service %v", serviceID)
+		}
+		healthMonitor.AddNode(serviceID, source)
+	}
+	healthMonitor.Start()
+	if network.options.Captures != nil {
+		network.options.Captures.addHealthMonitor(healthMonitor)
+	}
+	return healthMonitor, nil
+}
+
+// getHealthSource returns the source of the health of one of the network's services
+func getHealthSource(network TestAvalancheNetwork, serviceID networks.ServiceID) (monitor.Source, error) {
+	client, err := network.GetAvalancheClient(serviceID)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to get the client of service %v to monitor its health", serviceID)
+	}
+	return client.HealthAPI().GetLiveness, nil
+}
@@ -0,0 +1,69 @@
+package networks
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/ava-labs/avalanche-testing/avalanche/ipc"
+	avalancheService "github.com/ava-labs/avalanche-testing/avalanche/services"
+	"github.com/ava-labs/avalanchego/api/ipcs"
+	"github.com/kurtosis-tech/kurtosis/commons/networks"
+	"github.com/palantir/stacktrace"
+)
+
+const (
+	// How many accepted containers a subscriber buffers for a test that reads its events channel
+	ipcEventBufferSize = 1024
+)
+
+// SubscribeToConsensus has the node with the given service ID, which must have been started with NodeConfig.ShareIPCs,
+// publish the blockchain with the given ID or alias, and subscribes to the vertices or blocks that the node accepts on
+// it from now on
+func (network TestAvalancheNetwork) SubscribeToConsensus(serviceID networks.ServiceID, blockchainID string) (*ipc.Subscriber, error) {
+	return network.subscribeToBlockchain(serviceID, blockchainID, func(reply *ipcs.PublishBlockchainReply) string {
+		return reply.ConsensusURL
+	})
+}
+
+// SubscribeToDecisions has the node with the given service ID, which must have been started with NodeConfig.ShareIPCs,
+// publish the blockchain with the given ID or alias, and subscribes to the transactions (as well as the vertices or
+// blocks) that the node accepts on it from now on
+func (network TestAvalancheNetwork) SubscribeToDecisions(serviceID networks.ServiceID, blockchainID string) (*ipc.Subscriber, error) {
+	return network.subscribeToBlockchain(serviceID, blockchainID, func(reply *ipcs.PublishBlockchainReply) string {
+		return reply.DecisionsURL
+	})
+}
+
+// ================= Helper functions ===================
+
+// subscribeToBlockchain publishes a blockchain on one of the network's nodes, and subscribes to the socket that
+// [getSocketURL] picks out of the reply
+func (network TestAvalancheNetwork) subscribeToBlockchain(
+	serviceID networks.ServiceID,
+	blockchainID string,
+	getSocketURL func(reply *ipcs.PublishBlockchainReply) string) (*ipc.Subscriber, error) {
+	if network.options.TestVolumeMountpoint == "" {
+		return nil, stacktrace.NewError("The test volume's mountpoint isn't known; the network must be run with NetworkOptions.TestVolumeMountpoint")
+	}
+	ipAddr, err := network.getServiceIPAddr(serviceID)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to get the IP address of service %v to find its IPC sockets", serviceID)
+	}
+	client, err := network.GetAvalancheClient(serviceID)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to get the client of service %v to publish blockchain %v", serviceID, blockchainID)
+	}
+	reply, err := client.IpcsAPI().PublishBlockchain(blockchainID)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to publish blockchain %v on %v", blockchainID, serviceID)
+	}
+
+	// The socket is where the node put it on the test volume, which is mounted somewhere else here
+	socketFilename := filepath.Base(strings.TrimPrefix(getSocketURL(reply), "ipc://"))
+	socketFilepath := filepath.Join(avalancheService.GetIPCsDirpath(network.options.TestVolumeMountpoint, ipAddr), socketFilename)
+	subscriber, err := ipc.NewSubscriber(socketFilepath, ipcEventBufferSize)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to subscribe to blockchain %v on %v", blockchainID, serviceID)
+	}
+	return subscriber, nil
+}
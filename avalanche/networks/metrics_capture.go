@@ -0,0 +1,50 @@
+package networks
+
+import (
+	"github.com/ava-labs/avalanche-testing/avalanche/metrics"
+	"github.com/kurtosis-tech/kurtosis/commons/networks"
+	"github.com/palantir/stacktrace"
+)
+
+// GetMetrics returns the scraper that captures the metrics of the network's nodes, for tests to assert on
+func (network TestAvalancheNetwork) GetMetrics() (*metrics.Scraper, error) {
+	if network.metricsScraper == nil {
+		return nil, stacktrace.NewError("Metrics aren't being captured; the network must be run with a positive NetworkOptions.MetricsScrapeInterval")
+	}
+	return network.metricsScraper, nil
+}
+
+// ================= Helper functions ===================
+
+// startCapturingMetrics starts a scraper for the given network's initial services if the network's options have it
+// capture metrics, and hands the scraper to the options' collector, or returns nil otherwise
+func startCapturingMetrics(network TestAvalancheNetwork, serviceIDs map[networks.ServiceID]networks.ConfigurationID) (*metrics.Scraper, error) {
+	options := network.options
+	if options.MetricsScrapeInterval <= 0 {
+		return nil, nil
+	}
+	scraper := metrics.NewScraper(options.MetricsScrapeInterval)
+	for serviceID := range serviceIDs {
+		source, err := getMetricsSource(network, serviceID)
+		if err != nil {
+			return nil, stacktrace.Propagate(err, "Failed to get the metrics source of service %v", serviceID)
+		}
+		scraper.AddNode(serviceID, source)
+	}
+	scraper.Start()
+	if options.Captures != nil {
+		options.Captures.addMetricsScraper(scraper)
+	}
+	return scraper, nil
+}
+
+// getMetricsSource returns the source of the metrics of one of the network's services
+// NOTE: A service keeps its IP when it's restarted or upgraded, so the source stays valid for as long as it's in the
+// 	network
+func getMetricsSource(network TestAvalancheNetwork, serviceID networks.ServiceID) (metrics.Source, error) {
+	client, err := network.GetAvalancheClient(serviceID)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to get the client of service %v to scrape its metrics", serviceID)
+	}
+	return client.MetricsAPI().GetMetrics, nil
+}
@@ -5,6 +5,23 @@ package networks
 type NetworkGenesisConfig struct {
 	Stakers         []StakerIdentity
 	FundedAddresses FundedAddress
+
+	// The ID of the network, which must not be one of avalanchego's standard network IDs if GenesisFileContents is set
+	//  because avalanchego refuses to override the genesis of a standard network
+	NetworkID uint32
+
+	// The contents of the custom genesis file that nodes in the network will be started with, or empty if the nodes
+	//  should use the local network genesis that's hardcoded in avalanchego
+	GenesisFileContents []byte
+}
+
+// GetStakerWeight returns the stake of each of the genesis stakers, which avalanchego splits evenly from the AVAX that
+// the funded address stakes at genesis (the same amount in the hardcoded local network genesis and generated ones)
+func (config NetworkGenesisConfig) GetStakerWeight() uint64 {
+	if len(config.Stakers) == 0 {
+		return 0
+	}
+	return defaultStakedAmount / uint64(len(config.Stakers))
 }
 
 // FundedAddress encapsulates a pre-funded address
@@ -0,0 +1,37 @@
+package networks
+
+import (
+	"time"
+
+	"github.com/ava-labs/avalanche-testing/avalanche/services/certs"
+)
+
+// NetworkOptions are the settings of the environment that a test network runs in, which whoever runs the test decides
+// rather than the test itself
+type NetworkOptions struct {
+	// The generator that the certs of the nodes started with user-custom configurations are derived from, using their
+	//  service IDs as their identities so that they get the same node IDs on every run with the same seed, or nil to
+	//  generate random certs
+	// NOTE: Boot nodes started from DefaultLocalNetGenesisConfig always have the same node IDs regardless.
+	CertGenerator *certs.DeterministicCertGenerator
+
+	// The directory that CertGenerator caches the certs it derives in, so that they're only derived once across the
+	//  runs (and concurrent tests) that share it, or empty if they're only cached in memory
+	CertCacheDirpath string
+
+	// How often the network scrapes the metrics of every one of its nodes, from when it's wrapped until whoever ran the
+	//  test stops the scraper, or 0 to not scrape them
+	MetricsScrapeInterval time.Duration
+
+	// Where the test volume that the network's nodes share is mounted for whoever runs the test, so that tests can get
+	//  at what nodes put on it (e.g. profiles and IPC sockets)
+	TestVolumeMountpoint string
+
+	// The directory that the profiles of each profiling phase are copied to when the phase ends, or empty if tests
+	//  can't profile the network's nodes
+	ProfilesDirpath string
+
+	// Collects what the network captures while the test runs, so that whoever ran the test can stop capturing once it's
+	//  over and write out what was captured, or nil if nobody does
+	Captures *CaptureCollector
+}
@@ -0,0 +1,410 @@
+package networks
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	avalancheService "github.com/ava-labs/avalanche-testing/avalanche/services"
+	"github.com/kurtosis-tech/kurtosis/commons/networks"
+	"github.com/palantir/stacktrace"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// Image of the helper containers that modify the networking of Avalanche service containers, which needs to have
+	//  iptables and tc (iproute2) installed
+	trafficShaperImage = "nicolaka/netshoot:latest"
+
+	// The interface that a service container uses to talk to the rest of the test network
+	serviceNetworkInterface = "eth0"
+
+	// Each delayed link gets its own band of a prio qdisc on the sending container. Bands 1-3 carry the normal traffic,
+	//  so delayed links use the remaining bands.
+	numPrioBands       = 16
+	firstDelayPrioBand = 4
+
+	// The handle of the root prio qdisc, which the handles of the delayed links' netem qdiscs must never be
+	rootQdiscHandle = 1
+)
+
+// The partition group of services that haven't been assigned to any group
+const unpartitionedGroup = -1
+
+// serviceLink is a directed link between two services in the network
+type serviceLink struct {
+	from networks.ServiceID
+	to   networks.ServiceID
+}
+
+// linkDelay is the delay applied to a link, and the prio band on the sending service that the delay is applied to
+type linkDelay struct {
+	band  int
+	delay time.Duration
+}
+
+// networkTopology tracks the traffic rules that have been applied between the services of a TestAvalancheNetwork
+type networkTopology struct {
+	mutex *sync.Mutex
+
+	// Mapping of service ID -> index of the partition group it's in
+	partitionGroups map[networks.ServiceID]int
+
+	// Links whose traffic gets dropped; a dropped link is dropped in both directions, so only one direction is stored
+	droppedLinks map[serviceLink]bool
+
+	// Links whose traffic gets delayed
+	delayedLinks map[serviceLink]linkDelay
+
+	// Mapping of service ID -> next free prio band for delayed links on that service
+	nextDelayBand map[networks.ServiceID]int
+}
+
+func newNetworkTopology() *networkTopology {
+	return &networkTopology{
+		mutex:           &sync.Mutex{},
+		partitionGroups: make(map[networks.ServiceID]int),
+		droppedLinks:    make(map[serviceLink]bool),
+		delayedLinks:    make(map[serviceLink]linkDelay),
+		nextDelayBand:   make(map[networks.ServiceID]int),
+	}
+}
+
+// PartitionNetwork splits services into groups that can only talk to services in the same group, replacing any traffic
+// rules that were in place before. Services that aren't in any group keep talking to every service. The links of the
+// new partition are cut before the old rules are removed, so links that are cut both before and after never open.
+// Args:
+// 	groups: The sets of service IDs that should end up in the same partition; a service can't be in more than one group
+func (network TestAvalancheNetwork) PartitionNetwork(groups []map[networks.ServiceID]bool) error {
+	partitionGroups := make(map[networks.ServiceID]int)
+	for groupIdx, group := range groups {
+		for serviceID := range group {
+			if otherGroupIdx, found := partitionGroups[serviceID]; found {
+				return stacktrace.NewError("Service %v is in both partition group %v and %v", serviceID, otherGroupIdx, groupIdx)
+			}
+			partitionGroups[serviceID] = groupIdx
+		}
+	}
+
+	network.topology.mutex.Lock()
+	defer network.topology.mutex.Unlock()
+	cutLinks := make(map[serviceLink]bool)
+	for serviceID1, groupIdx1 := range partitionGroups {
+		for serviceID2, groupIdx2 := range partitionGroups {
+			// Each pair only needs to be cut once, since dropping is bidirectional
+			if groupIdx1 == groupIdx2 || serviceID1 >= serviceID2 {
+				continue
+			}
+			if err := network.dropTrafficUnlocked(serviceID1, serviceID2); err != nil {
+				return stacktrace.Propagate(err, "An error occurred cutting the link between %v and %v", serviceID1, serviceID2)
+			}
+			cutLinks[serviceLink{from: serviceID1, to: serviceID2}] = true
+		}
+	}
+	for link := range network.topology.droppedLinks {
+		if cutLinks[link] || cutLinks[serviceLink{from: link.to, to: link.from}] {
+			continue
+		}
+		if err := network.undropTrafficUnlocked(link); err != nil {
+			return stacktrace.Propagate(err, "An error occurred reopening the link between %v and %v", link.from, link.to)
+		}
+	}
+	if err := network.clearDelaysUnlocked(); err != nil {
+		return stacktrace.Propagate(err, "An error occurred clearing the delayed links before partitioning")
+	}
+	network.topology.partitionGroups = partitionGroups
+	logrus.Infof("Partitioned the network into groups: %v", groups)
+	return nil
+}
+
+// HealPartitions removes every partition, dropped link and delayed link from the network, so that all services can talk to
+// each other normally again
+func (network TestAvalancheNetwork) HealPartitions() error {
+	network.topology.mutex.Lock()
+	defer network.topology.mutex.Unlock()
+
+	shapedServices := make(map[networks.ServiceID]bool)
+	for link := range network.topology.droppedLinks {
+		shapedServices[link.from] = true
+	}
+	for link := range network.topology.delayedLinks {
+		shapedServices[link.from] = true
+	}
+
+	healScript := getHealScript()
+	for serviceID := range shapedServices {
+		if err := network.runTrafficShapingScript(serviceID, healScript); err != nil {
+			return stacktrace.Propagate(err, "An error occurred clearing the traffic rules of service %v", serviceID)
+		}
+	}
+
+	network.topology.partitionGroups = make(map[networks.ServiceID]int)
+	network.topology.droppedLinks = make(map[serviceLink]bool)
+	network.topology.delayedLinks = make(map[serviceLink]linkDelay)
+	network.topology.nextDelayBand = make(map[networks.ServiceID]int)
+	return nil
+}
+
+// DropTraffic drops all traffic between two services, in both directions
+func (network TestAvalancheNetwork) DropTraffic(serviceID1 networks.ServiceID, serviceID2 networks.ServiceID) error {
+	network.topology.mutex.Lock()
+	defer network.topology.mutex.Unlock()
+	return network.dropTrafficUnlocked(serviceID1, serviceID2)
+}
+
+// DelayTraffic delays the traffic that one service sends to another. Calling it again for the same pair of services
+// changes the delay.
+// Args:
+// 	fromServiceID: The service whose outgoing traffic will be delayed
+// 	toServiceID: The service that the delayed traffic is going to
+// 	delay: How long the traffic should be delayed by
+func (network TestAvalancheNetwork) DelayTraffic(fromServiceID networks.ServiceID, toServiceID networks.ServiceID, delay time.Duration) error {
+	network.topology.mutex.Lock()
+	defer network.topology.mutex.Unlock()
+	return network.delayTrafficUnlocked(fromServiceID, toServiceID, delay)
+}
+
+// GetPartitionMap returns a mapping of service ID -> index of the partition group the service is in, as passed to
+// PartitionNetwork, for the services that are currently partitioned
+func (network TestAvalancheNetwork) GetPartitionMap() map[networks.ServiceID]int {
+	network.topology.mutex.Lock()
+	defer network.topology.mutex.Unlock()
+
+	result := make(map[networks.ServiceID]int)
+	for serviceID, groupIdx := range network.topology.partitionGroups {
+		result[serviceID] = groupIdx
+	}
+	return result
+}
+
+// GetPartitionGroup returns the index of the partition group the given service is in, or -1 if it isn't partitioned
+func (network TestAvalancheNetwork) GetPartitionGroup(serviceID networks.ServiceID) int {
+	network.topology.mutex.Lock()
+	defer network.topology.mutex.Unlock()
+
+	if groupIdx, found := network.topology.partitionGroups[serviceID]; found {
+		return groupIdx
+	}
+	return unpartitionedGroup
+}
+
+// ================= Helper functions ===================
+// NOTE: The topology mutex must be held when calling this
+func (network TestAvalancheNetwork) dropTrafficUnlocked(serviceID1 networks.ServiceID, serviceID2 networks.ServiceID) error {
+	link := serviceLink{from: serviceID1, to: serviceID2}
+	if network.topology.droppedLinks[link] || network.topology.droppedLinks[serviceLink{from: serviceID2, to: serviceID1}] {
+		return nil
+	}
+
+	ipAddr2, err := network.getServiceIPAddr(serviceID2)
+	if err != nil {
+		return stacktrace.Propagate(err, "An error occurred getting the IP of service %v", serviceID2)
+	}
+	if err := network.runTrafficShapingScript(serviceID1, getDropScript(ipAddr2)); err != nil {
+		return stacktrace.Propagate(err, "An error occurred dropping the traffic between %v and %v", serviceID1, serviceID2)
+	}
+	network.topology.droppedLinks[link] = true
+	return nil
+}
+
+// undropTrafficUnlocked removes the rules that drop the traffic of the given dropped link
+// NOTE: The topology mutex must be held when calling this
+func (network TestAvalancheNetwork) undropTrafficUnlocked(link serviceLink) error {
+	toIPAddr, err := network.getServiceIPAddr(link.to)
+	if err != nil {
+		return stacktrace.Propagate(err, "An error occurred getting the IP of service %v", link.to)
+	}
+	if err := network.runTrafficShapingScript(link.from, getUndropScript(toIPAddr)); err != nil {
+		return stacktrace.Propagate(err, "An error occurred removing the rules that drop the traffic between %v and %v", link.from, link.to)
+	}
+	delete(network.topology.droppedLinks, link)
+	return nil
+}
+
+// clearDelaysUnlocked removes every delayed link from the network
+// NOTE: The topology mutex must be held when calling this
+func (network TestAvalancheNetwork) clearDelaysUnlocked() error {
+	delayedServices := make(map[networks.ServiceID]bool)
+	for link := range network.topology.delayedLinks {
+		delayedServices[link.from] = true
+	}
+	clearScript := getClearDelaysScript()
+	for serviceID := range delayedServices {
+		if err := network.runTrafficShapingScript(serviceID, clearScript); err != nil {
+			return stacktrace.Propagate(err, "An error occurred clearing the delayed links of service %v", serviceID)
+		}
+	}
+	network.topology.delayedLinks = make(map[serviceLink]linkDelay)
+	network.topology.nextDelayBand = make(map[networks.ServiceID]int)
+	return nil
+}
+
+// NOTE: The topology mutex must be held when calling this
+func (network TestAvalancheNetwork) delayTrafficUnlocked(fromServiceID networks.ServiceID, toServiceID networks.ServiceID, delay time.Duration) error {
+	toIPAddr, err := network.getServiceIPAddr(toServiceID)
+	if err != nil {
+		return stacktrace.Propagate(err, "An error occurred getting the IP of service %v", toServiceID)
+	}
+	link := serviceLink{from: fromServiceID, to: toServiceID}
+	if existingDelay, found := network.topology.delayedLinks[link]; found {
+		changeScript := getChangeDelayScript(existingDelay.band, delay)
+		if err := network.runTrafficShapingScript(fromServiceID, changeScript); err != nil {
+			return stacktrace.Propagate(err, "An error occurred changing the delay from %v to %v", fromServiceID, toServiceID)
+		}
+		network.topology.delayedLinks[link] = linkDelay{band: existingDelay.band, delay: delay}
+		return nil
+	}
+
+	band, found := network.topology.nextDelayBand[fromServiceID]
+	if !found {
+		band = firstDelayPrioBand
+	}
+	if band > numPrioBands {
+		return stacktrace.NewError("Service %v already has the maximum of %v delayed links", fromServiceID, numPrioBands-firstDelayPrioBand+1)
+	}
+
+	// The root qdisc is only created the first time a link from this service gets delayed
+	addScript := getAddDelayScript(band, delay, toIPAddr, !found)
+	if err := network.runTrafficShapingScript(fromServiceID, addScript); err != nil {
+		return stacktrace.Propagate(err, "An error occurred delaying the traffic from %v to %v", fromServiceID, toServiceID)
+	}
+	network.topology.delayedLinks[link] = linkDelay{band: band, delay: delay}
+	network.topology.nextDelayBand[fromServiceID] = band + 1
+	return nil
+}
+
+// restoreTrafficRules re-applies the traffic rules that live in the network namespace of the given service, which Docker
+// recreates (empty) whenever the service's container is restarted
+func (network TestAvalancheNetwork) restoreTrafficRules(serviceID networks.ServiceID) error {
+	network.topology.mutex.Lock()
+	defer network.topology.mutex.Unlock()
+
+	// Drop rules always live on the "from" side of the stored link, and delay rules on the sending side
+	droppedPeers := []networks.ServiceID{}
+	for link := range network.topology.droppedLinks {
+		if link.from == serviceID {
+			droppedPeers = append(droppedPeers, link.to)
+			delete(network.topology.droppedLinks, link)
+		}
+	}
+	delays := make(map[networks.ServiceID]time.Duration)
+	for link, delay := range network.topology.delayedLinks {
+		if link.from == serviceID {
+			delays[link.to] = delay.delay
+			delete(network.topology.delayedLinks, link)
+		}
+	}
+	delete(network.topology.nextDelayBand, serviceID)
+
+	for _, peerID := range droppedPeers {
+		if err := network.dropTrafficUnlocked(serviceID, peerID); err != nil {
+			return stacktrace.Propagate(err, "An error occurred restoring the dropped link between %v and %v", serviceID, peerID)
+		}
+	}
+	for peerID, delay := range delays {
+		if err := network.delayTrafficUnlocked(serviceID, peerID, delay); err != nil {
+			return stacktrace.Propagate(err, "An error occurred restoring the delayed link from %v to %v", serviceID, peerID)
+		}
+	}
+	return nil
+}
+
+// getHealScript returns the script that clears every traffic rule of a service
+func getHealScript() string {
+	// Deleting the root qdisc fails if there isn't one, which is fine
+	return fmt.Sprintf(
+		"iptables -F INPUT && iptables -F OUTPUT && (tc qdisc del dev %v root 2>/dev/null || true)",
+		serviceNetworkInterface)
+}
+
+// getDropScript returns the script that makes a service drop all traffic to and from the given IP
+func getDropScript(ipAddr string) string {
+	// Dropping both incoming and outgoing packets on one side is enough to cut the link in both directions
+	return fmt.Sprintf(
+		"iptables -I INPUT -s %v -j DROP && iptables -I OUTPUT -d %v -j DROP",
+		ipAddr,
+		ipAddr)
+}
+
+// getUndropScript returns the script that removes the rules that getDropScript added for the given IP
+func getUndropScript(ipAddr string) string {
+	return fmt.Sprintf(
+		"iptables -D INPUT -s %v -j DROP && iptables -D OUTPUT -d %v -j DROP",
+		ipAddr,
+		ipAddr)
+}
+
+// getClearDelaysScript returns the script that removes every delayed link of a service, along with its root qdisc
+func getClearDelaysScript() string {
+	return fmt.Sprintf("tc qdisc del dev %v root", serviceNetworkInterface)
+}
+
+// getDelayQdiscHandle returns the handle of the netem qdisc of the given prio band, which is distinct for every band
+// and never the root qdisc's
+func getDelayQdiscHandle(band int) int {
+	return band * 0x10
+}
+
+// getAddDelayScript returns the script that makes a service delay the traffic it sends to the given IP, through the
+// given prio band, creating the service's root prio qdisc first if [addRootQdisc] is set
+// NOTE: tc reads class IDs and handles as hex, so they're formatted as such
+func getAddDelayScript(band int, delay time.Duration, toIPAddr string, addRootQdisc bool) string {
+	scriptLines := []string{}
+	if addRootQdisc {
+		scriptLines = append(scriptLines, fmt.Sprintf(
+			"tc qdisc add dev %v root handle %x: prio bands %v priomap 1 2 2 2 1 2 0 0 1 1 1 1 1 1 1 1",
+			serviceNetworkInterface,
+			rootQdiscHandle,
+			numPrioBands))
+	}
+	scriptLines = append(
+		scriptLines,
+		fmt.Sprintf(
+			"tc qdisc add dev %v parent %x:%x handle %x: netem delay %vms",
+			serviceNetworkInterface,
+			rootQdiscHandle,
+			band,
+			getDelayQdiscHandle(band),
+			delay.Milliseconds()),
+		fmt.Sprintf(
+			"tc filter add dev %v protocol ip parent %x: prio 1 u32 match ip dst %v/32 flowid %x:%x",
+			serviceNetworkInterface,
+			rootQdiscHandle,
+			toIPAddr,
+			rootQdiscHandle,
+			band),
+	)
+	return strings.Join(scriptLines, " && ")
+}
+
+// getChangeDelayScript returns the script that changes the delay of the link that goes through the given prio band
+func getChangeDelayScript(band int, delay time.Duration) string {
+	return fmt.Sprintf(
+		"tc qdisc change dev %v parent %x:%x handle %x: netem delay %vms",
+		serviceNetworkInterface,
+		rootQdiscHandle,
+		band,
+		getDelayQdiscHandle(band),
+		delay.Milliseconds())
+}
+
+func (network TestAvalancheNetwork) runTrafficShapingScript(serviceID networks.ServiceID, script string) error {
+	containerID, err := network.getServiceContainerID(serviceID)
+	if err != nil {
+		return stacktrace.Propagate(err, "An error occurred getting the container of service %v", serviceID)
+	}
+	if err := network.containerManager.runInNetworkNamespace(containerID, trafficShaperImage, script); err != nil {
+		return stacktrace.Propagate(err, "An error occurred running traffic shaping script in the container of service %v", serviceID)
+	}
+	return nil
+}
+
+func (network TestAvalancheNetwork) getServiceIPAddr(serviceID networks.ServiceID) (string, error) {
+	node, err := network.svcNetwork.GetService(serviceID)
+	if err != nil {
+		return "", stacktrace.Propagate(err, "An error occurred retrieving service node with ID %v", serviceID)
+	}
+	avalancheService := node.Service.(avalancheService.AvalancheService)
+	return avalancheService.GetJSONRPCSocket().GetIpAddr(), nil
+}
@@ -0,0 +1,67 @@
+package networks
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testPeerIPAddr = "172.17.0.3"
+
+func TestHealScript(t *testing.T) {
+	expected := "iptables -F INPUT && iptables -F OUTPUT && (tc qdisc del dev eth0 root 2>/dev/null || true)"
+	assert.Equal(t, expected, getHealScript())
+}
+
+func TestDropScript(t *testing.T) {
+	expected := "iptables -I INPUT -s 172.17.0.3 -j DROP && iptables -I OUTPUT -d 172.17.0.3 -j DROP"
+	assert.Equal(t, expected, getDropScript(testPeerIPAddr))
+}
+
+func TestUndropScript(t *testing.T) {
+	expected := "iptables -D INPUT -s 172.17.0.3 -j DROP && iptables -D OUTPUT -d 172.17.0.3 -j DROP"
+	assert.Equal(t, expected, getUndropScript(testPeerIPAddr))
+}
+
+func TestClearDelaysScript(t *testing.T) {
+	assert.Equal(t, "tc qdisc del dev eth0 root", getClearDelaysScript())
+}
+
+func TestFirstDelayScript(t *testing.T) {
+	expected := "tc qdisc add dev eth0 root handle 1: prio bands 16 priomap 1 2 2 2 1 2 0 0 1 1 1 1 1 1 1 1 && " +
+		"tc qdisc add dev eth0 parent 1:4 handle 40: netem delay 250ms && " +
+		"tc filter add dev eth0 protocol ip parent 1: prio 1 u32 match ip dst 172.17.0.3/32 flowid 1:4"
+	assert.Equal(t, expected, getAddDelayScript(firstDelayPrioBand, 250*time.Millisecond, testPeerIPAddr, true))
+}
+
+func TestLaterDelayScript(t *testing.T) {
+	// The root qdisc already exists, so only the link's band gets a netem qdisc and a filter
+	expected := "tc qdisc add dev eth0 parent 1:5 handle 50: netem delay 2000ms && " +
+		"tc filter add dev eth0 protocol ip parent 1: prio 1 u32 match ip dst 172.17.0.3/32 flowid 1:5"
+	assert.Equal(t, expected, getAddDelayScript(firstDelayPrioBand+1, 2*time.Second, testPeerIPAddr, false))
+}
+
+func TestChangeDelayScript(t *testing.T) {
+	expected := "tc qdisc change dev eth0 parent 1:4 handle 40: netem delay 100ms"
+	assert.Equal(t, expected, getChangeDelayScript(firstDelayPrioBand, 100*time.Millisecond))
+}
+
+func TestDelayBandsAreHex(t *testing.T) {
+	// tc reads class IDs and handles as hex, so band 10 is class 1:a and band 16 is class 1:10
+	expected := "tc qdisc add dev eth0 parent 1:a handle a0: netem delay 100ms && " +
+		"tc filter add dev eth0 protocol ip parent 1: prio 1 u32 match ip dst 172.17.0.3/32 flowid 1:a"
+	assert.Equal(t, expected, getAddDelayScript(10, 100*time.Millisecond, testPeerIPAddr, false))
+	expected = "tc qdisc change dev eth0 parent 1:10 handle 100: netem delay 100ms"
+	assert.Equal(t, expected, getChangeDelayScript(numPrioBands, 100*time.Millisecond))
+}
+
+func TestDelayQdiscHandlesDontCollide(t *testing.T) {
+	handles := map[int]bool{rootQdiscHandle: true}
+	for band := firstDelayPrioBand; band <= numPrioBands; band++ {
+		handle := getDelayQdiscHandle(band)
+		assert.False(t, handles[handle], "Band %v's handle %x collides with another qdisc's", band, handle)
+		assert.True(t, handle <= 0xffff, "Band %v's handle %x doesn't fit in a qdisc handle's major number", band, handle)
+		handles[handle] = true
+	}
+}
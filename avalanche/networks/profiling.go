@@ -0,0 +1,158 @@
+package networks
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+
+	avalancheService "github.com/ava-labs/avalanche-testing/avalanche/services"
+	"github.com/ava-labs/avalanche-testing/avalanche_client/apis/admin"
+	"github.com/kurtosis-tech/kurtosis/commons/networks"
+	"github.com/palantir/stacktrace"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	profilesDirPerms = 0755
+)
+
+// ProfilingPhase is a part of a test (e.g. the issuing of transactions in a bombard test) that some of the network's
+// nodes are CPU profiled during
+type ProfilingPhase struct {
+	name                 string
+	testVolumeMountpoint string
+	destDirpath          string
+
+	// Mapping of service ID -> the node being profiled
+	nodes map[networks.ServiceID]profiledNode
+}
+
+type profiledNode struct {
+	ipAddr string
+	client *admin.Client
+}
+
+// StartProfilingPhase starts CPU profiling the nodes with the given service IDs, which must have been started with
+// NodeConfig.CaptureProfiles, until the returned phase is ended
+func (network TestAvalancheNetwork) StartProfilingPhase(name string, serviceIDs ...networks.ServiceID) (*ProfilingPhase, error) {
+	if network.options.ProfilesDirpath == "" {
+		return nil, stacktrace.NewError("Profiles aren't being captured; the network must be run with NetworkOptions.ProfilesDirpath")
+	}
+	if network.options.TestVolumeMountpoint == "" {
+		return nil, stacktrace.NewError("The test volume's mountpoint isn't known; the network must be run with NetworkOptions.TestVolumeMountpoint")
+	}
+	phase := &ProfilingPhase{
+		name:                 name,
+		testVolumeMountpoint: network.options.TestVolumeMountpoint,
+		destDirpath:          network.options.ProfilesDirpath,
+		nodes:                make(map[networks.ServiceID]profiledNode, len(serviceIDs)),
+	}
+
+	for _, serviceID := range serviceIDs {
+		ipAddr, err := network.getServiceIPAddr(serviceID)
+		if err != nil {
+			phase.stopProfilers()
+			return nil, stacktrace.Propagate(err, "Failed to get the IP address of service %v to profile it", serviceID)
+		}
+		client, err := network.GetAvalancheClient(serviceID)
+		if err != nil {
+			phase.stopProfilers()
+			return nil, stacktrace.Propagate(err, "Failed to get the client of service %v to profile it", serviceID)
+		}
+		adminClient := client.AdminAPI()
+		if success, err := adminClient.StartCPUProfiler(); err != nil {
+			phase.stopProfilers()
+			return nil, stacktrace.Propagate(err, "Failed to start the CPU profiler of %v", serviceID)
+		} else if !success {
+			phase.stopProfilers()
+			return nil, stacktrace.NewError("Starting the CPU profiler of %v was unsuccessful", serviceID)
+		}
+		phase.nodes[serviceID] = profiledNode{ipAddr: ipAddr, client: adminClient}
+	}
+	logrus.Infof("Started profiling phase '%v' on %v nodes", name, len(serviceIDs))
+	return phase, nil
+}
+
+// End stops the CPU profilers of the phase's nodes, takes their memory and lock profiles, and copies all of their
+// profiles into the phase's directory (a subdirectory per node). Every node is handled even if some fail, and the
+// first error is returned.
+func (phase *ProfilingPhase) End() error {
+	var firstErr error
+	for serviceID, node := range phase.nodes {
+		if err := phase.endNode(serviceID, node); err != nil {
+			logrus.Warnf("Failed to capture the profiles of %v for phase '%v': %v", serviceID, phase.name, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	if firstErr != nil {
+		return stacktrace.Propagate(firstErr, "Failed to capture the profiles of phase '%v'", phase.name)
+	}
+	logrus.Infof("Captured the profiles of phase '%v'", phase.name)
+	return nil
+}
+
+// ================= Helper functions ===================
+
+// endNode takes the profiles of one of the phase's nodes and copies them out of the test volume
+func (phase *ProfilingPhase) endNode(serviceID networks.ServiceID, node profiledNode) error {
+	profilers := []struct {
+		name    string
+		profile func() (bool, error)
+	}{
+		{"CPU", node.client.StopCPUProfiler},
+		{"memory", node.client.MemoryProfile},
+		{"lock", node.client.LockProfile},
+	}
+	for _, profiler := range profilers {
+		if success, err := profiler.profile(); err != nil {
+			return stacktrace.Propagate(err, "Failed to take the %v profile of %v", profiler.name, serviceID)
+		} else if !success {
+			return stacktrace.NewError("Taking the %v profile of %v was unsuccessful", profiler.name, serviceID)
+		}
+	}
+
+	srcDirpath := avalancheService.GetProfilesDirpath(phase.testVolumeMountpoint, node.ipAddr)
+	destDirpath := filepath.Join(phase.destDirpath, phase.name, string(serviceID))
+	if err := os.MkdirAll(destDirpath, profilesDirPerms); err != nil {
+		return stacktrace.Propagate(err, "Could not create profiles directory %v", destDirpath)
+	}
+	profileFilenames := []string{
+		avalancheService.CPUProfileFilename,
+		avalancheService.MemoryProfileFilename,
+		avalancheService.LockProfileFilename,
+	}
+	for _, filename := range profileFilenames {
+		if err := copyFile(filepath.Join(srcDirpath, filename), filepath.Join(destDirpath, filename)); err != nil {
+			return stacktrace.Propagate(err, "Failed to copy the profiles of %v", serviceID)
+		}
+	}
+	return nil
+}
+
+// stopProfilers stops the CPU profilers that were started for a phase that couldn't be started on all of its nodes
+func (phase *ProfilingPhase) stopProfilers() {
+	for serviceID, node := range phase.nodes {
+		if _, err := node.client.StopCPUProfiler(); err != nil {
+			logrus.Warnf("Failed to stop the CPU profiler of %v: %v", serviceID, err)
+		}
+	}
+}
+
+func copyFile(srcFilepath string, destFilepath string) error {
+	srcFile, err := os.Open(srcFilepath)
+	if err != nil {
+		return stacktrace.Propagate(err, "Could not open file %v", srcFilepath)
+	}
+	defer srcFile.Close()
+	destFile, err := os.Create(destFilepath)
+	if err != nil {
+		return stacktrace.Propagate(err, "Could not create file %v", destFilepath)
+	}
+	defer destFile.Close()
+	if _, err := io.Copy(destFile, srcFile); err != nil {
+		return stacktrace.Propagate(err, "Could not copy %v to %v", srcFilepath, destFilepath)
+	}
+	return nil
+}
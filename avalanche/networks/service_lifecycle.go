@@ -0,0 +1,191 @@
+package networks
+
+import (
+	"time"
+
+	avalancheService "github.com/ava-labs/avalanche-testing/avalanche/services"
+	"github.com/kurtosis-tech/kurtosis/commons/networks"
+	"github.com/kurtosis-tech/kurtosis/commons/services"
+	"github.com/palantir/stacktrace"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// How often to check whether a started or restarted service is up
+	serviceStartPollInterval = 1 * time.Second
+
+	// Where avalanchego keeps its database (unless configured otherwise) and logs inside a node's container
+	defaultNodeDataDirpath = "/root/.avalanchego"
+)
+
+// NOTE: Stopping a service stops its container without removing it, so the node keeps everything it had on disk - the
+// staking cert & key that its AvalancheCertProvider generated, and its database - and comes back with the same node ID and
+// IP when it's started again. This is unlike RemoveService, which throws the node away for good.
+
+// StopService gracefully stops the node with the given service ID, so that it can later be brought back with StartService
+func (network TestAvalancheNetwork) StopService(serviceID networks.ServiceID) error {
+	containerID, err := network.getServiceContainerID(serviceID)
+	if err != nil {
+		return stacktrace.Propagate(err, "An error occurred getting the container of service %v", serviceID)
+	}
+	logrus.Debugf("Stopping service %v...", serviceID)
+	if err := network.containerManager.stopContainer(containerID, containerStopTimeout); err != nil {
+		return stacktrace.Propagate(err, "An error occurred stopping service %v", serviceID)
+	}
+	return nil
+}
+
+// KillService stops the node with the given service ID with SIGKILL, simulating a crash. The node can later be brought
+// back with StartService.
+func (network TestAvalancheNetwork) KillService(serviceID networks.ServiceID) error {
+	containerID, err := network.getServiceContainerID(serviceID)
+	if err != nil {
+		return stacktrace.Propagate(err, "An error occurred getting the container of service %v", serviceID)
+	}
+	logrus.Debugf("Killing service %v...", serviceID)
+	if err := network.containerManager.killContainer(containerID); err != nil {
+		return stacktrace.Propagate(err, "An error occurred killing service %v", serviceID)
+	}
+	return nil
+}
+
+// StartService starts a node that was stopped with StopService or KillService back up, restores any traffic rules that
+// applied to it, and blocks until the node is available again
+func (network TestAvalancheNetwork) StartService(serviceID networks.ServiceID) error {
+	containerID, err := network.getServiceContainerID(serviceID)
+	if err != nil {
+		return stacktrace.Propagate(err, "An error occurred getting the container of service %v", serviceID)
+	}
+	logrus.Debugf("Starting service %v...", serviceID)
+	if err := network.containerManager.startContainer(containerID); err != nil {
+		return stacktrace.Propagate(err, "An error occurred starting service %v", serviceID)
+	}
+	if err := network.restoreTrafficRules(serviceID); err != nil {
+		return stacktrace.Propagate(err, "An error occurred restoring the traffic rules of service %v", serviceID)
+	}
+	if err := network.WaitForServiceUp(serviceID); err != nil {
+		return stacktrace.Propagate(err, "An error occurred waiting for service %v to come back up", serviceID)
+	}
+	return nil
+}
+
+// RestartService gracefully stops the node with the given service ID and starts it back up, blocking until it's available
+func (network TestAvalancheNetwork) RestartService(serviceID networks.ServiceID) error {
+	if err := network.StopService(serviceID); err != nil {
+		return stacktrace.Propagate(err, "An error occurred stopping service %v for the restart", serviceID)
+	}
+	if err := network.StartService(serviceID); err != nil {
+		return stacktrace.Propagate(err, "An error occurred starting service %v for the restart", serviceID)
+	}
+	return nil
+}
+
+// UpgradeService replaces the container of the node with the given service ID with one running the given image,
+// keeping the node's start command, staking cert & key, IP and database, and blocks until the node is available again.
+// The node therefore comes back with the same node ID, like with RestartService.
+// NOTE: Kurtosis doesn't know about the new container, so services that were upgraded need to be removed with
+// 	RemoveService before the test ends.
+// Args:
+// 	serviceID: The ID of the service to upgrade
+// 	imageName: The Docker image to run the node from now on
+func (network TestAvalancheNetwork) UpgradeService(serviceID networks.ServiceID, imageName string) error {
+	containerID, err := network.getServiceContainerID(serviceID)
+	if err != nil {
+		return stacktrace.Propagate(err, "An error occurred getting the container of service %v", serviceID)
+	}
+	dataDirpath := network.getServiceDataDirpath(serviceID)
+	logrus.Debugf("Upgrading service %v to image %v...", serviceID, imageName)
+	if _, err := network.containerManager.replaceContainer(containerID, imageName, dataDirpath, containerStopTimeout); err != nil {
+		return stacktrace.Propagate(err, "An error occurred replacing the container of service %v", serviceID)
+	}
+	if err := network.restoreTrafficRules(serviceID); err != nil {
+		return stacktrace.Propagate(err, "An error occurred restoring the traffic rules of service %v", serviceID)
+	}
+	if err := network.WaitForServiceUp(serviceID); err != nil {
+		return stacktrace.Propagate(err, "An error occurred waiting for service %v to come back up on image %v", serviceID, imageName)
+	}
+	return nil
+}
+
+// WaitForServiceUp blocks until the node with the given service ID meets the readiness criteria of its configuration,
+// e.g. after it was added with AddService. Unlike the availability checker that AddService returns, the error says which
+// criterion the node is still failing if it isn't available within the configuration's availability timeout.
+func (network TestAvalancheNetwork) WaitForServiceUp(serviceID networks.ServiceID) error {
+	node, err := network.svcNetwork.GetService(serviceID)
+	if err != nil {
+		return stacktrace.Propagate(err, "An error occurred retrieving service node with ID %v", serviceID)
+	}
+	ipAddr, err := network.getServiceIPAddr(serviceID)
+	if err != nil {
+		return stacktrace.Propagate(err, "An error occurred getting the IP of service %v", serviceID)
+	}
+
+	network.servicesMutex.Lock()
+	configID, found := network.serviceConfigIDs[serviceID]
+	network.servicesMutex.Unlock()
+	if !found {
+		return stacktrace.NewError("Couldn't find the configuration that service %v was started with", serviceID)
+	}
+	availabilityCheckerCore, found := network.availabilityCheckerCores[configID]
+	if !found {
+		return stacktrace.NewError("Couldn't find the availability checker of configuration %v", configID)
+	}
+
+	// The service's timeout should count from now, not from when it was first started
+	availabilityCheckerCore.ResetService(ipAddr)
+	deadline := time.Now().Add(availabilityCheckerCore.GetTimeout())
+	if err := awaitServiceUp(availabilityCheckerCore, node.Service, ipAddr, deadline); err != nil {
+		return stacktrace.Propagate(err, "Service %v didn't come up", serviceID)
+	}
+	return nil
+}
+
+// ================= Helper functions ===================
+// awaitServiceUp polls an availability checker core until the service with the given IP meets its readiness criteria,
+// returning the criterion that the service is still failing if it doesn't by [deadline]
+func awaitServiceUp(
+	availabilityCheckerCore *avalancheService.AvalancheServiceAvailabilityCheckerCore,
+	service services.Service,
+	ipAddr string,
+	deadline time.Time) error {
+	for time.Now().Before(deadline) {
+		if availabilityCheckerCore.IsServiceUp(service, nil) {
+			return nil
+		}
+		time.Sleep(serviceStartPollInterval)
+	}
+	if lastFailure := availabilityCheckerCore.GetLastFailure(ipAddr); lastFailure != nil {
+		return stacktrace.Propagate(lastFailure, "Service at %v isn't available by %v", ipAddr, deadline)
+	}
+	return stacktrace.NewError("Service at %v isn't available by %v", ipAddr, deadline)
+}
+
+// getServiceDataDirpath returns the directory that holds the database of the given service's node
+func (network TestAvalancheNetwork) getServiceDataDirpath(serviceID networks.ServiceID) string {
+	network.servicesMutex.Lock()
+	configID, found := network.serviceConfigIDs[serviceID]
+	network.servicesMutex.Unlock()
+	if !found {
+		return defaultNodeDataDirpath
+	}
+
+	network.nodeConfigsMutex.RLock()
+	defer network.nodeConfigsMutex.RUnlock()
+	// Boot node configurations have no entry, and always use the default
+	if nodeConfig, found := network.serviceNodeConfigs[configID]; found && nodeConfig.DBDir != "" {
+		return nodeConfig.DBDir
+	}
+	return defaultNodeDataDirpath
+}
+
+func (network TestAvalancheNetwork) getServiceContainerID(serviceID networks.ServiceID) (string, error) {
+	ipAddr, err := network.getServiceIPAddr(serviceID)
+	if err != nil {
+		return "", stacktrace.Propagate(err, "An error occurred getting the IP of service %v", serviceID)
+	}
+	containerID, err := network.containerManager.getContainerID(ipAddr)
+	if err != nil {
+		return "", stacktrace.Propagate(err, "An error occurred getting the container with IP %v", ipAddr)
+	}
+	return containerID, nil
+}
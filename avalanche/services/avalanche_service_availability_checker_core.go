@@ -2,65 +2,122 @@ package services
 
 import (
 	"fmt"
+	"sync"
 	"time"
 
-	"github.com/ava-labs/avalanche-testing/avalanche_client/apis/info"
+	"github.com/ava-labs/avalanche-testing/avalanche_client/apis"
 	"github.com/ava-labs/avalanche-testing/utils/constants"
 	"github.com/kurtosis-tech/kurtosis/commons/services"
+	"github.com/palantir/stacktrace"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultAvailabilityTimeout is how long an Avalanche service gets to meet its readiness criteria by default
+	DefaultAvailabilityTimeout = 90 * time.Second
 )
 
 // NewAvalancheServiceAvailabilityChecker returns a new services.ServiceAvailabilityCheckerCore to
 // check if an AvalancheService is ready
-func NewAvalancheServiceAvailabilityChecker(timeout time.Duration) services.ServiceAvailabilityCheckerCore {
+// Args:
+// 	timeout: How long a service gets to meet the readiness criteria
+// 	criteria: The criteria a service must meet to be considered available, checked in order; if none are given,
+// 		DefaultReadinessCriteria are used
+func NewAvalancheServiceAvailabilityChecker(timeout time.Duration, criteria ...ReadinessCriterion) *AvalancheServiceAvailabilityCheckerCore {
+	if len(criteria) == 0 {
+		criteria = DefaultReadinessCriteria()
+	}
 	return &AvalancheServiceAvailabilityCheckerCore{
-		timeout: timeout,
+		timeout:       timeout,
+		criteria:      criteria,
+		mutex:         &sync.Mutex{},
+		serviceStates: make(map[string]*serviceReadinessState),
 	}
 }
 
 // AvalancheServiceAvailabilityCheckerCore implements services.ServiceAvailabilityCheckerCore
 // that defines the criteria for an Avalanche service being available
+// NOTE: Kurtosis shares one core between all the services started from the same configuration, so the readiness state
+// 	is tracked per service.
 type AvalancheServiceAvailabilityCheckerCore struct {
-	timeout                                                    time.Duration
-	bootstrappedPChain, bootstrappedCChain, bootstrappedXChain bool
+	timeout  time.Duration
+	criteria []ReadinessCriterion
+
+	mutex *sync.Mutex
+
+	// Mapping of service IP -> readiness state of that service
+	serviceStates map[string]*serviceReadinessState
+}
+
+// serviceReadinessState tracks how a single service is doing against the readiness criteria
+type serviceReadinessState struct {
+	firstCheckTime time.Time
+
+	// The error of the criterion that failed on the most recent check, or nil if the service is available
+	lastFailure error
+
+	// Whether we've already reported that the service missed its timeout, so it only gets reported once
+	timeoutReported bool
 }
 
 // IsServiceUp implements services.ServiceAvailabilityCheckerCore#IsServiceUp
-// and returns true when the Avalanche healthcheck reports that the node is available
-func (g AvalancheServiceAvailabilityCheckerCore) IsServiceUp(toCheck services.Service, dependencies []services.Service) bool {
+// and returns true when the service meets all the readiness criteria
+func (g *AvalancheServiceAvailabilityCheckerCore) IsServiceUp(toCheck services.Service, dependencies []services.Service) bool {
 	// NOTE: we don't check the dependencies intentionally, because we don't need to - an Avalanche service won't report itself
 	//  as up until its bootstrappers are up
 
 	castedService := toCheck.(AvalancheService)
 	jsonRPCSocket := castedService.GetJSONRPCSocket()
-	uri := fmt.Sprintf("http://%s:%d", jsonRPCSocket.GetIpAddr(), jsonRPCSocket.GetPort().Int())
-	client := info.NewClient(uri, constants.DefaultRequestTimeout)
+	ipAddr := jsonRPCSocket.GetIpAddr()
+	uri := fmt.Sprintf("http://%s:%d", ipAddr, jsonRPCSocket.GetPort().Int())
+	client := apis.NewClient(uri, constants.DefaultRequestTimeout)
 
-	if !g.bootstrappedPChain {
-		if bootstrapped, err := client.IsBootstrapped("P"); err != nil || !bootstrapped {
-			return false
-		}
-		g.bootstrappedPChain = true
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	state, found := g.serviceStates[ipAddr]
+	if !found {
+		state = &serviceReadinessState{firstCheckTime: time.Now()}
+		g.serviceStates[ipAddr] = state
 	}
 
-	if !g.bootstrappedCChain {
-		if bootstrapped, err := client.IsBootstrapped("C"); err != nil || !bootstrapped {
+	for _, criterion := range g.criteria {
+		if err := criterion.Check(client); err != nil {
+			state.lastFailure = stacktrace.Propagate(err, "Readiness criterion '%v' isn't met", criterion.GetName())
+			logrus.Tracef("Service at %v isn't available yet: %v", ipAddr, state.lastFailure)
+			// Kurtosis' availability checkers only return that the service timed out, so the reason is logged for their
+			//  callers; the network's setup and TestAvalancheNetwork.WaitForServiceUp return it in their errors instead
+			if !state.timeoutReported && time.Since(state.firstCheckTime) >= g.timeout {
+				logrus.Errorf("Service at %v still isn't available after %v: %v", ipAddr, g.timeout, state.lastFailure)
+				state.timeoutReported = true
+			}
 			return false
 		}
-		g.bootstrappedCChain = true
 	}
-
-	if !g.bootstrappedXChain {
-		if bootstrapped, err := client.IsBootstrapped("X"); err != nil || !bootstrapped {
-			return false
-		}
-		g.bootstrappedXChain = true
-	}
-
-	time.Sleep(5 * time.Second)
+	state.lastFailure = nil
 	return true
 }
 
 // GetTimeout implements services.AvailabilityCheckerCore
-func (g AvalancheServiceAvailabilityCheckerCore) GetTimeout() time.Duration {
-	return 90 * time.Second
+func (g *AvalancheServiceAvailabilityCheckerCore) GetTimeout() time.Duration {
+	return g.timeout
+}
+
+// GetLastFailure returns the error of the readiness criterion that the service with the given IP failed on its most
+// recent check, or nil if the service was available or hasn't been checked
+func (g *AvalancheServiceAvailabilityCheckerCore) GetLastFailure(ipAddr string) error {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	state, found := g.serviceStates[ipAddr]
+	if !found {
+		return nil
+	}
+	return state.lastFailure
+}
+
+// ResetService forgets the readiness state of the service with the given IP, so that its timeout starts counting from
+// the next check (e.g. after the service is restarted)
+func (g *AvalancheServiceAvailabilityCheckerCore) ResetService(ipAddr string) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	delete(g.serviceStates, ipAddr)
 }
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ava-labs/avalanche-testing/avalanche_client/apis"
+	"github.com/palantir/stacktrace"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeCriterion passes once it has been checked a given number of times
+type fakeCriterion struct {
+	name          string
+	checksToPass  int
+	numChecksDone int
+}
+
+func (criterion *fakeCriterion) GetName() string {
+	return criterion.name
+}
+
+func (criterion *fakeCriterion) Check(client *apis.Client) error {
+	criterion.numChecksDone++
+	if criterion.numChecksDone < criterion.checksToPass {
+		return stacktrace.NewError("Only checked %v times", criterion.numChecksDone)
+	}
+	return nil
+}
+
+func TestAvailabilityCheckerReportsFailingCriterion(t *testing.T) {
+	first := &fakeCriterion{name: "first", checksToPass: 2}
+	second := &fakeCriterion{name: "second", checksToPass: 2}
+	checker := NewAvalancheServiceAvailabilityChecker(time.Minute, first, second)
+	service := AvalancheService{ipAddr: testPublicIP.String(), jsonRPCPort: "9650/tcp"}
+
+	assert.False(t, checker.IsServiceUp(service, nil))
+	assert.Contains(t, checker.GetLastFailure(testPublicIP.String()).Error(), "first")
+	assert.Equal(t, 0, second.numChecksDone, "Criteria after the failing one shouldn't be checked")
+
+	assert.False(t, checker.IsServiceUp(service, nil))
+	assert.Contains(t, checker.GetLastFailure(testPublicIP.String()).Error(), "second")
+
+	assert.True(t, checker.IsServiceUp(service, nil))
+	assert.NoError(t, checker.GetLastFailure(testPublicIP.String()))
+}
+
+func TestAvailabilityCheckerUsesGivenTimeout(t *testing.T) {
+	timeout := 17 * time.Second
+	checker := NewAvalancheServiceAvailabilityChecker(timeout)
+	assert.Equal(t, timeout, checker.GetTimeout())
+	assert.Equal(t, len(DefaultReadinessCriteria()), len(checker.criteria))
+}
@@ -5,7 +5,6 @@ import (
 	"net"
 	"os"
 	"strings"
-	"time"
 
 	"github.com/ava-labs/avalanche-testing/avalanche/services/certs"
 	"github.com/docker/go-connections/nat"
@@ -20,9 +19,14 @@ const (
 
 	stakingTLSCertFileID = "staking-tls-cert"
 	stakingTLSKeyFileID  = "staking-tls-key"
+	genesisFileID        = "genesis"
+	nodeConfigFileID     = "node-config"
 
 	testVolumeMountpoint = "/shared"
 	avalancheBinary      = "/avalanchego/build/avalanchego"
+
+	// The flag that sets the directory the node creates its IPC sockets in
+	ipcsPathFlag = "ipcs-path"
 )
 
 // AvalancheLogLevel specifies the log level for an Avalanche client
@@ -36,11 +40,9 @@ const (
 
 // AvalancheServiceInitializerCore implements Kurtosis' services.ServiceInitializerCore used to initialize an Avalanche service
 type AvalancheServiceInitializerCore struct {
-	// Snow protocol sample size
-	snowSampleSize int
-
-	// Snow protocol quorum size
-	snowQuorumSize int
+	// The configuration of the node, which may be shared with and updated by the network so that services started
+	//  later get the updated configuration
+	nodeConfig *NodeConfig
 
 	// Whether the node should be started with staking enabled
 	stakingEnabled bool
@@ -48,47 +50,42 @@ type AvalancheServiceInitializerCore struct {
 	// The fixed transaction fee for the network
 	txFee uint64
 
-	// The initial timeout for the network
-	networkInitialTimeout time.Duration
+	// The ID of the network that the custom genesis file is for
+	networkID uint32
 
-	// TODO Switch these to be named properties of this struct, so that we're being explicit about what arguments
-	//  are consumed
-	// A set of CLI args that will be passed as-is to the Avalanche service
-	additionalCLIArgs map[string]string
+	// The contents of the custom genesis file the node should start with, or empty to use the hardcoded local genesis
+	genesisFileContents []byte
 
 	// The node IDs of the nodes this node should bootstrap from
 	bootstrapperNodeIDs []string
 
 	// Cert provider that should be used when initializing the Avalanche service
 	certProvider certs.AvalancheCertProvider
-
-	// Log level that the Avalanche service should start with
-	logLevel AvalancheLogLevel
 }
 
 // NewAvalancheServiceInitializerCore creates a new Avalanche service initializer core with the following parameters:
 // Args:
-// 		snowSampleSize: Sample size for Snow consensus protocol
-// 		snowQuroumSize: Quorum size for Snow consensus protocol
+// 		nodeConfig: The configuration of the node, which must have passed validation
+// 		txFee: The fixed transaction fee of the network
 // 		stakingEnabled: Whether this node will use staking
-// 		cliArgs: A mapping of cli_arg -> cli_arg_value that will be passed as-is to the Avalanche node
+// 		networkID: The ID of the network that the custom genesis file is for, which is ignored if there's no custom
+// 			genesis file
+// 		genesisFileContents: The contents of a custom genesis file for the node to use, or empty to use avalanchego's
+// 			hardcoded local network genesis
 // 		bootstrapperNodeIDs: The node IDs of the bootstrapper nodes that this node will connect to. While this *seems* unintuitive
 // 			why this would be required, it's because Avalanche doesn't actually use certs. So, to prevent against man-in-the-middle attacks,
 // 			the user is required to manually specify the node IDs of the nodese it's connecting to.
 // 		certProvider: Provides the certs used by the Avalanche services generated by this core
-// 		logLevel: The loglevel that the Avalanche node should output at.
 // Returns:
 // 		An intializer core for creating Avalanche nodes with the specified parameers.
 func NewAvalancheServiceInitializerCore(
-	snowSampleSize int,
-	snowQuorumSize int,
+	nodeConfig *NodeConfig,
 	txFee uint64,
 	stakingEnabled bool,
-	networkInitialTimeout time.Duration,
-	additionalCLIArgs map[string]string,
+	networkID uint32,
+	genesisFileContents []byte,
 	bootstrapperNodeIDs []string,
-	certProvider certs.AvalancheCertProvider,
-	logLevel AvalancheLogLevel) *AvalancheServiceInitializerCore {
+	certProvider certs.AvalancheCertProvider) *AvalancheServiceInitializerCore {
 	// Defensive copy
 	bootstrapperIDsCopy := make([]string, 0, len(bootstrapperNodeIDs))
 	for _, nodeID := range bootstrapperNodeIDs {
@@ -96,15 +93,13 @@ func NewAvalancheServiceInitializerCore(
 	}
 
 	return &AvalancheServiceInitializerCore{
-		snowSampleSize:        snowSampleSize,
-		snowQuorumSize:        snowQuorumSize,
-		txFee:                 txFee,
-		stakingEnabled:        stakingEnabled,
-		networkInitialTimeout: networkInitialTimeout,
-		additionalCLIArgs:     additionalCLIArgs,
-		bootstrapperNodeIDs:   bootstrapperIDsCopy,
-		certProvider:          certProvider,
-		logLevel:              logLevel,
+		nodeConfig:          nodeConfig,
+		txFee:               txFee,
+		stakingEnabled:      stakingEnabled,
+		networkID:           networkID,
+		genesisFileContents: genesisFileContents,
+		bootstrapperNodeIDs: bootstrapperIDsCopy,
+		certProvider:        certProvider,
 	}
 }
 
@@ -118,28 +113,49 @@ func (core AvalancheServiceInitializerCore) GetUsedPorts() map[nat.Port]bool {
 
 // GetFilesToMount implements services.ServiceInitializerCore to declare the files used by the node
 func (core AvalancheServiceInitializerCore) GetFilesToMount() map[string]bool {
+	result := make(map[string]bool)
 	if core.stakingEnabled {
-		return map[string]bool{
-			stakingTLSCertFileID: true,
-			stakingTLSKeyFileID:  true,
-		}
+		result[stakingTLSCertFileID] = true
+		result[stakingTLSKeyFileID] = true
 	}
-	return make(map[string]bool)
+	if len(core.genesisFileContents) > 0 {
+		result[genesisFileID] = true
+	}
+	if core.nodeConfig.UseConfigFile {
+		result[nodeConfigFileID] = true
+	}
+	return result
 }
 
 // InitializeMountedFiles implementats services.ServiceInitializerCore to initialize the file needed by the node
 func (core AvalancheServiceInitializerCore) InitializeMountedFiles(osFiles map[string]*os.File, dependencies []services.Service) error {
-	certFilePointer := osFiles[stakingTLSCertFileID]
-	keyFilePointer := osFiles[stakingTLSKeyFileID]
-	certPEM, keyPEM, err := core.certProvider.GetCertAndKey()
-	if err != nil {
-		return stacktrace.Propagate(err, "Could not get cert & key when initializing service")
+	if core.stakingEnabled {
+		certFilePointer := osFiles[stakingTLSCertFileID]
+		keyFilePointer := osFiles[stakingTLSKeyFileID]
+		certPEM, keyPEM, err := core.certProvider.GetCertAndKey()
+		if err != nil {
+			return stacktrace.Propagate(err, "Could not get cert & key when initializing service")
+		}
+		if _, err := certFilePointer.Write(certPEM.Bytes()); err != nil {
+			return err
+		}
+		if _, err := keyFilePointer.Write(keyPEM.Bytes()); err != nil {
+			return err
+		}
 	}
-	if _, err := certFilePointer.Write(certPEM.Bytes()); err != nil {
-		return err
+	if len(core.genesisFileContents) > 0 {
+		if _, err := osFiles[genesisFileID].Write(core.genesisFileContents); err != nil {
+			return stacktrace.Propagate(err, "Could not write the genesis file when initializing service")
+		}
 	}
-	if _, err := keyFilePointer.Write(keyPEM.Bytes()); err != nil {
-		return err
+	if core.nodeConfig.UseConfigFile {
+		configFileContents, err := core.nodeConfig.ToConfigFile()
+		if err != nil {
+			return stacktrace.Propagate(err, "Could not render the node config file when initializing service")
+		}
+		if _, err := osFiles[nodeConfigFileID].Write(configFileContents); err != nil {
+			return stacktrace.Propagate(err, "Could not write the node config file when initializing service")
+		}
 	}
 	return nil
 }
@@ -157,19 +173,38 @@ func (core AvalancheServiceInitializerCore) GetStartCommand(mountedFileFilepaths
 	}
 
 	publicIPFlag := fmt.Sprintf("--public-ip=%s", publicIPAddr.String())
+	// avalanchego refuses to override the genesis of a standard network, so a custom genesis needs its custom network ID
+	networkIDFlag := "--network-id=local"
+	if len(core.genesisFileContents) > 0 {
+		networkIDFlag = fmt.Sprintf("--network-id=%d", core.networkID)
+	}
 	commandList := []string{
 		avalancheBinary,
 		publicIPFlag,
-		"--network-id=local",
+		networkIDFlag,
 		fmt.Sprintf("--http-port=%d", httpPort.Int()),
 		"--http-host=", // Leave empty to make API openly accessible
 		fmt.Sprintf("--staking-port=%d", stakingPort.Int()),
-		fmt.Sprintf("--log-level=%s", core.logLevel),
-		fmt.Sprintf("--snow-sample-size=%d", core.snowSampleSize),
-		fmt.Sprintf("--snow-quorum-size=%d", core.snowQuorumSize),
 		fmt.Sprintf("--staking-enabled=%v", core.stakingEnabled),
 		fmt.Sprintf("--tx-fee=%d", core.txFee),
-		fmt.Sprintf("--network-initial-timeout=%d", int64(core.networkInitialTimeout)),
+	}
+
+	if core.nodeConfig.UseConfigFile {
+		configFilepath, found := mountedFileFilepaths[nodeConfigFileID]
+		if !found {
+			return nil, stacktrace.NewError("Could not find file key '%v' in the mounted filepaths map; this is likely a code bug", nodeConfigFileID)
+		}
+		commandList = append(commandList, fmt.Sprintf("--config-file=%s", configFilepath))
+	} else {
+		commandList = append(commandList, core.nodeConfig.ToFlags()...)
+	}
+
+	if len(core.genesisFileContents) > 0 {
+		genesisFilepath, found := mountedFileFilepaths[genesisFileID]
+		if !found {
+			return nil, stacktrace.NewError("Could not find file key '%v' in the mounted filepaths map; this is likely a code bug", genesisFileID)
+		}
+		commandList = append(commandList, fmt.Sprintf("--genesis=%s", genesisFilepath))
 	}
 
 	if core.stakingEnabled {
@@ -206,16 +241,41 @@ func (core AvalancheServiceInitializerCore) GetStartCommand(mountedFileFilepaths
 		commandList = append(commandList, "--bootstrap-ips="+joinedSockets)
 	}
 
-	// Append additional CLI arguments
-	// These are added as is with no additional checking
-	for param, argument := range core.additionalCLIArgs {
-		commandList = append(commandList, fmt.Sprintf("--%s=%s", param, argument))
+	// Commands for a shell to run before starting the node, e.g. to create the directories on the test volume that
+	//  the node writes to
+	setupCommands := []string{}
+	if core.nodeConfig.ShareIPCs {
+		ipcsDirpath := GetIPCsDirpath(testVolumeMountpoint, publicIPAddr.String())
+		commandList = append(commandList, fmt.Sprintf("--%s=%s", ipcsPathFlag, ipcsDirpath))
+		setupCommands = append(setupCommands, "mkdir -p "+shellQuote(ipcsDirpath))
+	}
+	// The admin API writes profiles to the node's working directory, so the node has to be started from the test
+	//  volume for its profiles to be copied out
+	if core.nodeConfig.CaptureProfiles {
+		profilesDirpath := GetProfilesDirpath(testVolumeMountpoint, publicIPAddr.String())
+		setupCommands = append(setupCommands, "mkdir -p "+shellQuote(profilesDirpath), "cd "+shellQuote(profilesDirpath))
+	}
+	if len(setupCommands) > 0 {
+		quotedCommandList := make([]string, len(commandList))
+		for i, arg := range commandList {
+			quotedCommandList[i] = shellQuote(arg)
+		}
+		commandList = []string{
+			"/bin/sh",
+			"-c",
+			fmt.Sprintf("%s && exec %s", strings.Join(setupCommands, " && "), strings.Join(quotedCommandList, " ")),
+		}
 	}
 
 	logrus.Debugf("Command list: %+v", commandList)
 	return commandList, nil
 }
 
+// shellQuote quotes an argument so that a shell passes it on as it is
+func shellQuote(arg string) string {
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
 // GetServiceFromIp implements services.ServiceInitializerCore function to take the IP address of the Docker container that Kurtosis
 // launches the Avalanche node inside and wrap it with our AvalancheService implementation of NodeService
 func (core AvalancheServiceInitializerCore) GetServiceFromIp(ipAddr string) services.Service {
@@ -14,17 +14,24 @@ import (
 
 var testPublicIP = net.ParseIP("172.17.0.2")
 
+const testNetworkID uint32 = 4242
+
+var testNodeConfig = NodeConfig{
+	LogLevel:              INFO,
+	SnowSampleSize:        1,
+	SnowQuorumSize:        1,
+	NetworkInitialTimeout: 2 * time.Second,
+}
+
 func TestNoDepsStartCommand(t *testing.T) {
 	initializerCore := NewAvalancheServiceInitializerCore(
-		1,
-		1,
+		&testNodeConfig,
 		0,
 		false,
-		2*time.Second,
-		make(map[string]string),
+		0,
+		nil,
 		[]string{},
 		certs.NewStaticAvalancheCertProvider(bytes.Buffer{}, bytes.Buffer{}),
-		INFO,
 	)
 
 	expected := []string{
@@ -34,11 +41,11 @@ func TestNoDepsStartCommand(t *testing.T) {
 		"--http-port=9650",
 		"--http-host=",
 		"--staking-port=9651",
+		"--staking-enabled=false",
+		"--tx-fee=0",
 		"--log-level=info",
 		"--snow-sample-size=1",
 		"--snow-quorum-size=1",
-		"--staking-enabled=false",
-		"--tx-fee=0",
 		fmt.Sprintf("--network-initial-timeout=%d", int64(2*time.Second)),
 	}
 	actual, err := initializerCore.GetStartCommand(make(map[string]string), testPublicIP, make([]services.Service, 0))
@@ -54,15 +61,13 @@ func TestWithDepsStartCommand(t *testing.T) {
 		testNodeID,
 	}
 	initializerCore := NewAvalancheServiceInitializerCore(
-		1,
-		1,
+		&testNodeConfig,
 		0,
 		false,
-		2*time.Second,
-		make(map[string]string),
+		0,
+		nil,
 		bootstrapperNodeIDs,
 		certs.NewStaticAvalancheCertProvider(bytes.Buffer{}, bytes.Buffer{}),
-		INFO,
 	)
 
 	expected := []string{
@@ -72,11 +77,11 @@ func TestWithDepsStartCommand(t *testing.T) {
 		"--http-port=9650",
 		"--http-host=",
 		"--staking-port=9651",
+		"--staking-enabled=false",
+		"--tx-fee=0",
 		"--log-level=info",
 		"--snow-sample-size=1",
 		"--snow-quorum-size=1",
-		"--staking-enabled=false",
-		"--tx-fee=0",
 		fmt.Sprintf("--network-initial-timeout=%d", int64(2*time.Second)),
 		fmt.Sprintf("--bootstrap-ips=%v:9651", testDependencyIP),
 	}
@@ -93,3 +98,141 @@ func TestWithDepsStartCommand(t *testing.T) {
 	assert.NoError(t, err, "An error occurred getting the start command")
 	assert.Equal(t, expected, actual)
 }
+
+func TestGenesisFileStartCommand(t *testing.T) {
+	testGenesisFilepath := "/shared/genesis.json"
+	initializerCore := NewAvalancheServiceInitializerCore(
+		&testNodeConfig,
+		0,
+		false,
+		testNetworkID,
+		[]byte("{}"),
+		[]string{},
+		certs.NewStaticAvalancheCertProvider(bytes.Buffer{}, bytes.Buffer{}),
+	)
+
+	assert.Equal(t, map[string]bool{genesisFileID: true}, initializerCore.GetFilesToMount())
+
+	expected := []string{
+		avalancheBinary,
+		"--public-ip=" + testPublicIP.String(),
+		fmt.Sprintf("--network-id=%d", testNetworkID),
+		"--http-port=9650",
+		"--http-host=",
+		"--staking-port=9651",
+		"--staking-enabled=false",
+		"--tx-fee=0",
+		"--log-level=info",
+		"--snow-sample-size=1",
+		"--snow-quorum-size=1",
+		fmt.Sprintf("--network-initial-timeout=%d", int64(2*time.Second)),
+		"--genesis=" + testGenesisFilepath,
+	}
+	mountedFileFilepaths := map[string]string{
+		genesisFileID: testGenesisFilepath,
+	}
+	actual, err := initializerCore.GetStartCommand(mountedFileFilepaths, testPublicIP, make([]services.Service, 0))
+	assert.NoError(t, err, "An error occurred getting the start command")
+	assert.Equal(t, expected, actual)
+}
+
+func TestConfigFileStartCommand(t *testing.T) {
+	testConfigFilepath := "/shared/node-config.json"
+	nodeConfig := testNodeConfig
+	nodeConfig.UseConfigFile = true
+	initializerCore := NewAvalancheServiceInitializerCore(
+		&nodeConfig,
+		0,
+		false,
+		0,
+		nil,
+		[]string{},
+		certs.NewStaticAvalancheCertProvider(bytes.Buffer{}, bytes.Buffer{}),
+	)
+
+	assert.Equal(t, map[string]bool{nodeConfigFileID: true}, initializerCore.GetFilesToMount())
+
+	expected := []string{
+		avalancheBinary,
+		"--public-ip=" + testPublicIP.String(),
+		"--network-id=local",
+		"--http-port=9650",
+		"--http-host=",
+		"--staking-port=9651",
+		"--staking-enabled=false",
+		"--tx-fee=0",
+		"--config-file=" + testConfigFilepath,
+	}
+	mountedFileFilepaths := map[string]string{
+		nodeConfigFileID: testConfigFilepath,
+	}
+	actual, err := initializerCore.GetStartCommand(mountedFileFilepaths, testPublicIP, make([]services.Service, 0))
+	assert.NoError(t, err, "An error occurred getting the start command")
+	assert.Equal(t, expected, actual)
+}
+
+func TestCaptureProfilesStartCommand(t *testing.T) {
+	nodeConfig := testNodeConfig
+	nodeConfig.CaptureProfiles = true
+	nodeConfig.ExtraFlags = map[string]string{"plugin-dir": "/it's here"}
+	initializerCore := NewAvalancheServiceInitializerCore(
+		&nodeConfig,
+		0,
+		false,
+		0,
+		nil,
+		[]string{},
+		certs.NewStaticAvalancheCertProvider(bytes.Buffer{}, bytes.Buffer{}),
+	)
+
+	profilesDirpath := "/shared/profiles/" + testPublicIP.String()
+	expected := []string{
+		"/bin/sh",
+		"-c",
+		fmt.Sprintf(
+			"mkdir -p '%s' && cd '%s' && exec '%s' '--public-ip=%s' '--network-id=local' '--http-port=9650' '--http-host=' "+
+				"'--staking-port=9651' '--staking-enabled=false' '--tx-fee=0' '--log-level=info' '--snow-sample-size=1' "+
+				"'--snow-quorum-size=1' '--network-initial-timeout=%d' '--plugin-dir=/it'\\''s here'",
+			profilesDirpath,
+			profilesDirpath,
+			avalancheBinary,
+			testPublicIP.String(),
+			int64(2*time.Second)),
+	}
+	actual, err := initializerCore.GetStartCommand(make(map[string]string), testPublicIP, make([]services.Service, 0))
+	assert.NoError(t, err, "An error occurred getting the start command")
+	assert.Equal(t, expected, actual)
+}
+
+func TestShareIPCsStartCommand(t *testing.T) {
+	nodeConfig := testNodeConfig
+	nodeConfig.ShareIPCs = true
+	nodeConfig.IPCsAPIEnabled = Bool(true)
+	initializerCore := NewAvalancheServiceInitializerCore(
+		&nodeConfig,
+		0,
+		false,
+		0,
+		nil,
+		[]string{},
+		certs.NewStaticAvalancheCertProvider(bytes.Buffer{}, bytes.Buffer{}),
+	)
+
+	ipcsDirpath := "/shared/ipcs/" + testPublicIP.String()
+	expected := []string{
+		"/bin/sh",
+		"-c",
+		fmt.Sprintf(
+			"mkdir -p '%s' && exec '%s' '--public-ip=%s' '--network-id=local' '--http-port=9650' '--http-host=' "+
+				"'--staking-port=9651' '--staking-enabled=false' '--tx-fee=0' '--log-level=info' '--snow-sample-size=1' "+
+				"'--snow-quorum-size=1' '--network-initial-timeout=%d' '--api-ipcs-enabled=true' '--ipcs-path=%s'",
+			ipcsDirpath,
+			avalancheBinary,
+			testPublicIP.String(),
+			int64(2*time.Second),
+			ipcsDirpath),
+	}
+	actual, err := initializerCore.GetStartCommand(make(map[string]string), testPublicIP, make([]services.Service, 0))
+	assert.NoError(t, err, "An error occurred getting the start command")
+	assert.Equal(t, expected, actual)
+}
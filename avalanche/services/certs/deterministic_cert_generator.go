@@ -0,0 +1,396 @@
+package certs
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/palantir/stacktrace"
+)
+
+// KeyType is the kind of private key that a staking cert is generated for
+type KeyType string
+
+const (
+	// RSAKeyType generates 4096-bit RSA keys, like avalanchego's own staking keys
+	RSAKeyType KeyType = "rsa"
+
+	// ECDSAKeyType generates P-256 ECDSA keys, which are much cheaper to generate but only work with node images whose
+	//  staking accepts non-RSA keys
+	ECDSAKeyType KeyType = "ecdsa"
+)
+
+const (
+	ecPrivateKeyPreamble = "EC PRIVATE KEY"
+
+	rsaKeyBits     = 4096
+	rsaKeyExponent = 65537
+
+	// The number of Miller-Rabin rounds used when searching for RSA primes
+	primalityTestRounds = 20
+)
+
+var (
+	// Derived certs must be byte-for-byte identical on every run, because node IDs are derived from the whole cert, so
+	//  their validity period can't depend on the current time
+	deterministicCertNotBefore = time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+	deterministicCertNotAfter  = deterministicCertNotBefore.AddDate(100, 0, 0)
+)
+
+// ParseKeyType returns the KeyType with the given name
+func ParseKeyType(keyTypeStr string) (KeyType, error) {
+	switch keyType := KeyType(keyTypeStr); keyType {
+	case RSAKeyType, ECDSAKeyType:
+		return keyType, nil
+	default:
+		return "", stacktrace.NewError("Unknown key type %v; must be %v or %v", keyTypeStr, RSAKeyType, ECDSAKeyType)
+	}
+}
+
+// DeterministicCertGenerator derives staking certs & keys from a seed and an identity (e.g. a service ID), so that
+// nodes get the same node IDs on every run with the same seed, and a failed run can be replayed with the same nodes.
+// Generated identities are cached in memory and, optionally, on disk, because deriving RSA keys is slow.
+type DeterministicCertGenerator struct {
+	seed    []byte
+	keyType KeyType
+
+	// The directory that generated identities are cached in across runs, or nil to only cache them in memory
+	diskCache *identityCache
+
+	// Mapping of identity -> generated cert & key, guarded by mutex
+	memoryCache map[string]cachedIdentity
+	mutex       *sync.Mutex
+}
+
+// NewDeterministicCertGenerator creates a generator that derives certs & keys from the given seed
+// Args:
+// 	seed: The seed the certs & keys are derived from; the same seed and identity always give the same cert & key
+// 	keyType: The type of private key to generate
+// 	cacheDirpath: The directory to cache generated identities in across runs, or empty to only cache them in memory
+func NewDeterministicCertGenerator(seed string, keyType KeyType, cacheDirpath string) (*DeterministicCertGenerator, error) {
+	if _, err := ParseKeyType(string(keyType)); err != nil {
+		return nil, stacktrace.Propagate(err, "Can't derive certs with key type %v", keyType)
+	}
+	var diskCache *identityCache
+	if cacheDirpath != "" {
+		cache, err := newIdentityCache(cacheDirpath)
+		if err != nil {
+			return nil, stacktrace.Propagate(err, "Failed to create the identity cache")
+		}
+		diskCache = cache
+	}
+	return &DeterministicCertGenerator{
+		seed:        []byte(seed),
+		keyType:     keyType,
+		diskCache:   diskCache,
+		memoryCache: make(map[string]cachedIdentity),
+		mutex:       &sync.Mutex{},
+	}, nil
+}
+
+// GetCertAndKey returns the cert & private key derived for the given identity
+// Returns:
+// 	certPemBytes: The bytes of the derived cert
+// 	keyPemBytes: The bytes of the private key derived with the cert
+func (generator *DeterministicCertGenerator) GetCertAndKey(identity string) (certPemBytes bytes.Buffer, keyPemBytes bytes.Buffer, err error) {
+	generator.mutex.Lock()
+	cached, found := generator.memoryCache[identity]
+	generator.mutex.Unlock()
+	if found {
+		return *bytes.NewBuffer(cached.certPEM), *bytes.NewBuffer(cached.keyPEM), nil
+	}
+	cacheKey := generator.cacheKey(identity)
+	if generator.diskCache != nil {
+		if cached, found := generator.diskCache.load(cacheKey); found {
+			generator.mutex.Lock()
+			generator.memoryCache[identity] = cached
+			generator.mutex.Unlock()
+			return *bytes.NewBuffer(cached.certPEM), *bytes.NewBuffer(cached.keyPEM), nil
+		}
+	}
+
+	// The derivation is slow, so it's done without holding the mutex to let other identities be derived in parallel;
+	//  if the same identity is derived twice at once, both derive the same cert & key anyway
+	certPEM, keyPEM, err := generator.generate(identity)
+	if err != nil {
+		return bytes.Buffer{}, bytes.Buffer{}, stacktrace.Propagate(err, "Failed to generate the cert & key of identity %v", identity)
+	}
+	generated := cachedIdentity{certPEM: certPEM, keyPEM: keyPEM}
+	generator.mutex.Lock()
+	generator.memoryCache[identity] = generated
+	generator.mutex.Unlock()
+	if generator.diskCache != nil {
+		if err := generator.diskCache.store(cacheKey, generated); err != nil {
+			return bytes.Buffer{}, bytes.Buffer{}, stacktrace.Propagate(err, "Failed to cache the cert & key of identity %v", identity)
+		}
+	}
+	return *bytes.NewBuffer(certPEM), *bytes.NewBuffer(keyPEM), nil
+}
+
+// DeriveInt64 derives a value from the seed and the given label, for other parts of a network (like its network ID) that
+// should be the same on every run with the same seed. The value doesn't depend on the key type.
+func (generator *DeterministicCertGenerator) DeriveInt64(label string) int64 {
+	mac := hmac.New(sha256.New, generator.seed)
+	mac.Write([]byte("value/" + label))
+	return int64(binary.BigEndian.Uint64(mac.Sum(nil)))
+}
+
+// cacheKey returns the name an identity is cached under, which covers everything the identity's cert depends on (the
+// seed, the key type and the identity itself)
+func (generator *DeterministicCertGenerator) cacheKey(identity string) string {
+	mac := hmac.New(sha256.New, generator.seed)
+	mac.Write([]byte(string(generator.keyType) + "/" + identity))
+	return string(generator.keyType) + "-" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (generator *DeterministicCertGenerator) generate(identity string) ([]byte, []byte, error) {
+	stream := newDeterministicStream(generator.seed, string(generator.keyType)+"/identity/"+identity)
+
+	var signer crypto.Signer
+	var keyBlock *pem.Block
+	switch generator.keyType {
+	case RSAKeyType:
+		privateKey, err := deriveRSAKey(stream)
+		if err != nil {
+			return nil, nil, stacktrace.Propagate(err, "Failed to derive RSA key")
+		}
+		// PKCS #1 v1.5 signatures are deterministic, so the RSA key can sign the cert itself
+		signer = privateKey
+		keyBlock = &pem.Block{Type: privateKeyPreamble, Bytes: x509.MarshalPKCS1PrivateKey(privateKey)}
+	case ECDSAKeyType:
+		privateKey, err := deriveECDSAKey(stream)
+		if err != nil {
+			return nil, nil, stacktrace.Propagate(err, "Failed to derive ECDSA key")
+		}
+		signer = deterministicECDSASigner{privateKey: privateKey}
+		keyBytes, err := x509.MarshalECPrivateKey(privateKey)
+		if err != nil {
+			return nil, nil, stacktrace.Propagate(err, "Failed to serialize ECDSA key")
+		}
+		keyBlock = &pem.Block{Type: ecPrivateKeyPreamble, Bytes: keyBytes}
+	default:
+		return nil, nil, stacktrace.NewError("Unknown key type %v", generator.keyType)
+	}
+
+	serialNumberBytes := make([]byte, 8)
+	if _, err := io.ReadFull(stream, serialNumberBytes); err != nil {
+		return nil, nil, stacktrace.Propagate(err, "Failed to derive serial number")
+	}
+	serialNumber := int64(binary.BigEndian.Uint64(serialNumberBytes) >> 1)
+	serviceCert := getServiceCert(serialNumber)
+	serviceCert.NotBefore = deterministicCertNotBefore
+	serviceCert.NotAfter = deterministicCertNotAfter
+	issuerCert := rootCert
+	issuerCert.NotBefore = deterministicCertNotBefore
+	issuerCert.NotAfter = deterministicCertNotAfter
+
+	// The randomness source isn't used by either of the signers
+	certBytes, err := x509.CreateCertificate(rand.Reader, serviceCert, &issuerCert, signer.Public(), signer)
+	if err != nil {
+		return nil, nil, stacktrace.Propagate(err, "Failed to sign service cert with cert authority.")
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: certificatePreamble, Bytes: certBytes}), pem.EncodeToMemory(keyBlock), nil
+}
+
+// ================= Key derivation ===================
+
+// deriveRSAKey derives an RSA key from the stream
+// NOTE: rsa.GenerateKey can't be used for this, because it deliberately consumes a random amount of its randomness
+// 	source so that callers can't depend on its output being deterministic
+func deriveRSAKey(stream io.Reader) (*rsa.PrivateKey, error) {
+	exponent := big.NewInt(rsaKeyExponent)
+	one := big.NewInt(1)
+	for {
+		p, err := derivePrime(stream, rsaKeyBits/2)
+		if err != nil {
+			return nil, err
+		}
+		q, err := derivePrime(stream, rsaKeyBits/2)
+		if err != nil {
+			return nil, err
+		}
+		if p.Cmp(q) == 0 {
+			continue
+		}
+		totient := new(big.Int).Mul(new(big.Int).Sub(p, one), new(big.Int).Sub(q, one))
+		d := new(big.Int).ModInverse(exponent, totient)
+		if d == nil {
+			// The exponent isn't coprime with the totient, so these primes can't be used
+			continue
+		}
+		privateKey := &rsa.PrivateKey{
+			PublicKey: rsa.PublicKey{
+				N: new(big.Int).Mul(p, q),
+				E: rsaKeyExponent,
+			},
+			D:      d,
+			Primes: []*big.Int{p, q},
+		}
+		privateKey.Precompute()
+		if err := privateKey.Validate(); err != nil {
+			return nil, stacktrace.Propagate(err, "Derived an invalid RSA key")
+		}
+		return privateKey, nil
+	}
+}
+
+// derivePrime derives a prime of exactly [bits] bits from the stream, whose top two bits are set so that the product
+// of two of them has exactly twice as many bits
+func derivePrime(stream io.Reader, bits int) (*big.Int, error) {
+	candidateBytes := make([]byte, (bits+7)/8)
+	candidate := new(big.Int)
+	for {
+		if _, err := io.ReadFull(stream, candidateBytes); err != nil {
+			return nil, stacktrace.Propagate(err, "Failed to derive prime candidate")
+		}
+		// Drop any bits beyond [bits], then set the top two and the bottom one
+		candidate.SetBytes(candidateBytes)
+		candidate.Rsh(candidate, uint(len(candidateBytes)*8-bits))
+		candidate.SetBit(candidate, bits-1, 1)
+		candidate.SetBit(candidate, bits-2, 1)
+		candidate.SetBit(candidate, 0, 1)
+		if candidate.ProbablyPrime(primalityTestRounds) {
+			return candidate, nil
+		}
+	}
+}
+
+// deriveECDSAKey derives a P-256 key from the stream
+func deriveECDSAKey(stream io.Reader) (*ecdsa.PrivateKey, error) {
+	curve := elliptic.P256()
+	order := curve.Params().N
+	// Read 64 bits more than the order's size so that reducing modulo the order is practically unbiased
+	scalarBytes := make([]byte, (order.BitLen()+7)/8+8)
+	if _, err := io.ReadFull(stream, scalarBytes); err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to derive private scalar")
+	}
+	// Map into [1, order - 1]
+	orderMinusOne := new(big.Int).Sub(order, big.NewInt(1))
+	d := new(big.Int).SetBytes(scalarBytes)
+	d.Mod(d, orderMinusOne)
+	d.Add(d, big.NewInt(1))
+
+	privateKey := &ecdsa.PrivateKey{D: d}
+	privateKey.PublicKey.Curve = curve
+	privateKey.PublicKey.X, privateKey.PublicKey.Y = curve.ScalarBaseMult(d.Bytes())
+	return privateKey, nil
+}
+
+// deterministicECDSASigner signs with an ECDSA key using nonces derived from the key and the digest being signed,
+// because ecdsa.Sign's signatures (and so the certs it signs, and their node IDs) differ on every call
+type deterministicECDSASigner struct {
+	privateKey *ecdsa.PrivateKey
+}
+
+// Public implements crypto.Signer
+func (signer deterministicECDSASigner) Public() crypto.PublicKey {
+	return &signer.privateKey.PublicKey
+}
+
+// Sign implements crypto.Signer, ignoring the randomness source
+func (signer deterministicECDSASigner) Sign(_ io.Reader, digest []byte, _ crypto.SignerOpts) ([]byte, error) {
+	params := signer.privateKey.Curve.Params()
+	order := params.N
+	e := hashToInt(digest, order)
+
+	nonceKey := signer.privateKey.D.Bytes()
+	for counter := uint64(0); ; counter++ {
+		nonceStream := newDeterministicStream(nonceKey, string(digest)+string(uint64ToBytes(counter)))
+		nonceBytes := make([]byte, (order.BitLen()+7)/8+8)
+		if _, err := io.ReadFull(nonceStream, nonceBytes); err != nil {
+			return nil, stacktrace.Propagate(err, "Failed to derive nonce")
+		}
+		k := new(big.Int).SetBytes(nonceBytes)
+		k.Mod(k, order)
+		if k.Sign() == 0 {
+			continue
+		}
+
+		// r = (k * G).x mod n, s = k^-1 * (e + r * d) mod n
+		x, _ := signer.privateKey.Curve.ScalarBaseMult(k.Bytes())
+		r := new(big.Int).Mod(x, order)
+		if r.Sign() == 0 {
+			continue
+		}
+		s := new(big.Int).Mul(r, signer.privateKey.D)
+		s.Add(s, e)
+		s.Mul(s, new(big.Int).ModInverse(k, order))
+		s.Mod(s, order)
+		if s.Sign() == 0 {
+			continue
+		}
+		return asn1.Marshal(struct {
+			R, S *big.Int
+		}{r, s})
+	}
+}
+
+// hashToInt converts a digest to an integer the way ECDSA does, keeping only as many of its leftmost bits as the
+// curve's order has
+func hashToInt(digest []byte, order *big.Int) *big.Int {
+	orderBytes := (order.BitLen() + 7) / 8
+	if len(digest) > orderBytes {
+		digest = digest[:orderBytes]
+	}
+	result := new(big.Int).SetBytes(digest)
+	if excess := len(digest)*8 - order.BitLen(); excess > 0 {
+		result.Rsh(result, uint(excess))
+	}
+	return result
+}
+
+// ================= Deterministic stream ===================
+
+// deterministicStream is an endless stream of bytes derived from a key and a label, made of the blocks
+// HMAC-SHA256(key, label || counter) for counter = 0, 1, ...
+type deterministicStream struct {
+	key     []byte
+	label   []byte
+	counter uint64
+	buffer  []byte
+}
+
+func newDeterministicStream(key []byte, label string) *deterministicStream {
+	return &deterministicStream{
+		key:   key,
+		label: []byte(label),
+	}
+}
+
+// Read implements io.Reader, and always fills the whole of [p]
+func (stream *deterministicStream) Read(p []byte) (int, error) {
+	numRead := 0
+	for numRead < len(p) {
+		if len(stream.buffer) == 0 {
+			mac := hmac.New(sha256.New, stream.key)
+			mac.Write(stream.label)
+			mac.Write(uint64ToBytes(stream.counter))
+			stream.buffer = mac.Sum(nil)
+			stream.counter++
+		}
+		copied := copy(p[numRead:], stream.buffer)
+		stream.buffer = stream.buffer[copied:]
+		numRead += copied
+	}
+	return numRead, nil
+}
+
+func uint64ToBytes(value uint64) []byte {
+	result := make([]byte, 8)
+	binary.BigEndian.PutUint64(result, value)
+	return result
+}
@@ -0,0 +1,162 @@
+package certs
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeterministicCertsAreReproducible(t *testing.T) {
+	for _, keyType := range []KeyType{RSAKeyType, ECDSAKeyType} {
+		generator, err := NewDeterministicCertGenerator("seed", keyType, "")
+		assert.NoError(t, err)
+		otherGenerator, err := NewDeterministicCertGenerator("seed", keyType, "")
+		assert.NoError(t, err)
+
+		certPEM, keyPEM, err := generator.GetCertAndKey("node-0")
+		assert.NoError(t, err)
+		otherCertPEM, otherKeyPEM, err := otherGenerator.GetCertAndKey("node-0")
+		assert.NoError(t, err)
+		assert.Equal(t, certPEM.Bytes(), otherCertPEM.Bytes(), keyType)
+		assert.Equal(t, keyPEM.Bytes(), otherKeyPEM.Bytes(), keyType)
+
+		// The cert must be usable as a staking cert
+		_, err = tls.X509KeyPair(certPEM.Bytes(), keyPEM.Bytes())
+		assert.NoError(t, err, keyType)
+	}
+}
+
+func TestDeterministicCertsDependOnSeedAndIdentity(t *testing.T) {
+	generator, err := NewDeterministicCertGenerator("seed", ECDSAKeyType, "")
+	assert.NoError(t, err)
+	otherSeedGenerator, err := NewDeterministicCertGenerator("other-seed", ECDSAKeyType, "")
+	assert.NoError(t, err)
+
+	getNodeID := func(generator *DeterministicCertGenerator, identity string) string {
+		certPEM, _, err := generator.GetCertAndKey(identity)
+		assert.NoError(t, err)
+		nodeID, err := GetNodeIDFromCert(certPEM.Bytes())
+		assert.NoError(t, err)
+		return nodeID
+	}
+	nodeID := getNodeID(generator, "node-0")
+	assert.Equal(t, nodeID, getNodeID(generator, "node-0"))
+	assert.NotEqual(t, nodeID, getNodeID(generator, "node-1"))
+	assert.NotEqual(t, nodeID, getNodeID(otherSeedGenerator, "node-0"))
+}
+
+func TestDerivedValuesDependOnSeedAndLabel(t *testing.T) {
+	generator, err := NewDeterministicCertGenerator("seed", ECDSAKeyType, "")
+	assert.NoError(t, err)
+	otherKeyTypeGenerator, err := NewDeterministicCertGenerator("seed", RSAKeyType, "")
+	assert.NoError(t, err)
+	otherSeedGenerator, err := NewDeterministicCertGenerator("other-seed", ECDSAKeyType, "")
+	assert.NoError(t, err)
+
+	value := generator.DeriveInt64("label")
+	assert.Equal(t, value, generator.DeriveInt64("label"))
+	assert.Equal(t, value, otherKeyTypeGenerator.DeriveInt64("label"))
+	assert.NotEqual(t, value, generator.DeriveInt64("other-label"))
+	assert.NotEqual(t, value, otherSeedGenerator.DeriveInt64("label"))
+}
+
+func TestConcurrentDerivations(t *testing.T) {
+	generator, err := NewDeterministicCertGenerator("seed", ECDSAKeyType, "")
+	assert.NoError(t, err)
+	expectedCertPEM, _, err := generator.GetCertAndKey("node-0")
+	assert.NoError(t, err)
+
+	// Fresh generators derive identities concurrently, including the same identity more than once at a time
+	concurrentGenerator, err := NewDeterministicCertGenerator("seed", ECDSAKeyType, "")
+	assert.NoError(t, err)
+	certPEMs := make([][]byte, 8)
+	wg := &sync.WaitGroup{}
+	for i := range certPEMs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			certPEM, _, err := concurrentGenerator.GetCertAndKey(fmt.Sprintf("node-%v", i%2))
+			assert.NoError(t, err)
+			certPEMs[i] = certPEM.Bytes()
+		}(i)
+	}
+	wg.Wait()
+	for i := 0; i < len(certPEMs); i += 2 {
+		assert.Equal(t, expectedCertPEM.Bytes(), certPEMs[i])
+		assert.NotEqual(t, expectedCertPEM.Bytes(), certPEMs[i+1])
+	}
+}
+
+func TestIdentityCache(t *testing.T) {
+	cacheDirpath, err := ioutil.TempDir("", "identity-cache-test")
+	assert.NoError(t, err)
+	defer os.RemoveAll(cacheDirpath)
+
+	generator, err := NewDeterministicCertGenerator("seed", ECDSAKeyType, cacheDirpath)
+	assert.NoError(t, err)
+	certPEM, keyPEM, err := generator.GetCertAndKey("node-0")
+	assert.NoError(t, err)
+	cacheFiles, err := ioutil.ReadDir(cacheDirpath)
+	assert.NoError(t, err)
+	assert.Len(t, cacheFiles, 1)
+
+	// Swap another identity into node-0's cache file, which a new generator should then read rather than derive node-0
+	otherCertPEM, otherKeyPEM, err := generator.GetCertAndKey("node-1")
+	assert.NoError(t, err)
+	cacheFilepath := generator.diskCache.getFilepath(generator.cacheKey("node-0"))
+	assert.NoError(t, ioutil.WriteFile(cacheFilepath, append(otherCertPEM.Bytes(), otherKeyPEM.Bytes()...), 0600))
+	cachingGenerator, err := NewDeterministicCertGenerator("seed", ECDSAKeyType, cacheDirpath)
+	assert.NoError(t, err)
+	cachedCertPEM, cachedKeyPEM, err := cachingGenerator.GetCertAndKey("node-0")
+	assert.NoError(t, err)
+	assert.Equal(t, otherCertPEM.Bytes(), cachedCertPEM.Bytes())
+	assert.Equal(t, otherKeyPEM.Bytes(), cachedKeyPEM.Bytes())
+
+	// Generators with another seed don't read the identities cached for this one
+	otherSeedGenerator, err := NewDeterministicCertGenerator("other-seed", ECDSAKeyType, cacheDirpath)
+	assert.NoError(t, err)
+	otherSeedCertPEM, _, err := otherSeedGenerator.GetCertAndKey("node-0")
+	assert.NoError(t, err)
+	assert.NotEqual(t, otherCertPEM.Bytes(), otherSeedCertPEM.Bytes())
+
+	// Malformed cache files get regenerated
+	assert.NoError(t, ioutil.WriteFile(cacheFilepath, append(keyPEM.Bytes(), certPEM.Bytes()...), 0600))
+	regeneratingGenerator, err := NewDeterministicCertGenerator("seed", ECDSAKeyType, cacheDirpath)
+	assert.NoError(t, err)
+	regeneratedCertPEM, regeneratedKeyPEM, err := regeneratingGenerator.GetCertAndKey("node-0")
+	assert.NoError(t, err)
+	assert.Equal(t, certPEM.Bytes(), regeneratedCertPEM.Bytes())
+	assert.Equal(t, keyPEM.Bytes(), regeneratedKeyPEM.Bytes())
+}
+
+func TestDeterministicCertProvider(t *testing.T) {
+	generator, err := NewDeterministicCertGenerator("seed", ECDSAKeyType, "")
+	assert.NoError(t, err)
+
+	varyingProvider := NewDeterministicAvalancheCertProvider(generator, "config", true)
+	varyingProvider.SetNextIdentity("node-0")
+	firstCertPEM, _, err := varyingProvider.GetCertAndKey()
+	assert.NoError(t, err)
+	secondCertPEM, _, err := varyingProvider.GetCertAndKey()
+	assert.NoError(t, err)
+	assert.NotEqual(t, firstCertPEM.Bytes(), secondCertPEM.Bytes())
+	expectedCertPEM, _, err := generator.GetCertAndKey("node-0")
+	assert.NoError(t, err)
+	assert.Equal(t, expectedCertPEM.Bytes(), firstCertPEM.Bytes())
+	expectedCertPEM, _, err = generator.GetCertAndKey("config-1")
+	assert.NoError(t, err)
+	assert.Equal(t, expectedCertPEM.Bytes(), secondCertPEM.Bytes())
+
+	fixedProvider := NewDeterministicAvalancheCertProvider(generator, "config", false)
+	fixedProvider.SetNextIdentity("node-1")
+	firstCertPEM, _, err = fixedProvider.GetCertAndKey()
+	assert.NoError(t, err)
+	secondCertPEM, _, err = fixedProvider.GetCertAndKey()
+	assert.NoError(t, err)
+	assert.Equal(t, firstCertPEM.Bytes(), secondCertPEM.Bytes())
+}
@@ -0,0 +1,75 @@
+package certs
+
+import (
+	"bytes"
+	"strconv"
+	"sync"
+
+	"github.com/palantir/stacktrace"
+)
+
+// DeterministicAvalancheCertProvider implements AvalancheCertProvider by deriving the certs of the services started with
+// one configuration from a DeterministicCertGenerator, using the services' IDs as their identities
+type DeterministicAvalancheCertProvider struct {
+	generator *DeterministicCertGenerator
+
+	// The identity of the certs when they don't vary, and the prefix of the identities of certs that aren't for a known
+	//  service
+	identityPrefix string
+
+	varyCerts bool
+
+	// The identity of the next cert to provide, and the number of certs provided so far, guarded by mutex
+	nextIdentity     string
+	numCertsProvided int
+	mutex            *sync.Mutex
+}
+
+// NewDeterministicAvalancheCertProvider creates a new cert provider that derives its certs from the given generator
+// Args:
+// 	generator: The generator to derive the certs from
+// 	identityPrefix: A name for the services the certs are for, usually their configuration ID
+// 	varyCerts: True to derive a different cert for each service, or false to yield the same cert each time
+func NewDeterministicAvalancheCertProvider(generator *DeterministicCertGenerator, identityPrefix string, varyCerts bool) *DeterministicAvalancheCertProvider {
+	return &DeterministicAvalancheCertProvider{
+		generator:      generator,
+		identityPrefix: identityPrefix,
+		varyCerts:      varyCerts,
+		mutex:          &sync.Mutex{},
+	}
+}
+
+// SetNextIdentity sets the identity that the next cert is derived for, which should be the ID of the service that's
+// about to be started
+// NOTE: The caller must make sure no other service is started with the provider until the cert has been provided.
+func (d *DeterministicAvalancheCertProvider) SetNextIdentity(identity string) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.nextIdentity = identity
+}
+
+// GetCertAndKey implements AvalancheCertProvider function that yields the cert and private key derived for the next
+// identity; if no identity was set, the cert is derived from how many certs the provider has given out so far
+// Returns:
+// 	certPemBytes: The bytes of the derived cert
+// 	keyPemBytes: The bytes of the private key that was derived alongside the cert
+func (d *DeterministicAvalancheCertProvider) GetCertAndKey() (certPemBytes bytes.Buffer, keyPemBytes bytes.Buffer, err error) {
+	d.mutex.Lock()
+	identity := d.identityPrefix
+	if d.varyCerts {
+		if d.nextIdentity != "" {
+			identity = d.nextIdentity
+		} else {
+			identity = d.identityPrefix + "-" + strconv.Itoa(d.numCertsProvided)
+		}
+	}
+	d.nextIdentity = ""
+	d.numCertsProvided++
+	d.mutex.Unlock()
+
+	certPEM, keyPEM, err := d.generator.GetCertAndKey(identity)
+	if err != nil {
+		return bytes.Buffer{}, bytes.Buffer{}, stacktrace.Propagate(err, "Failed to derive the cert of identity %v", identity)
+	}
+	return certPEM, keyPEM, nil
+}
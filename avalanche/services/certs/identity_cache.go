@@ -0,0 +1,85 @@
+package certs
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/palantir/stacktrace"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	identityCacheFileExtension = ".pem"
+)
+
+// cachedIdentity is a generated cert & key, PEM-encoded
+type cachedIdentity struct {
+	certPEM []byte
+	keyPEM  []byte
+}
+
+// identityCache stores generated identities in a directory, one file per identity holding the cert's PEM block
+// followed by the key's
+type identityCache struct {
+	dirpath string
+}
+
+func newIdentityCache(dirpath string) (*identityCache, error) {
+	if err := os.MkdirAll(dirpath, 0755); err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to create identity cache directory %v", dirpath)
+	}
+	return &identityCache{dirpath: dirpath}, nil
+}
+
+// load returns the identity cached under the given key, if there's a valid one
+func (cache *identityCache) load(key string) (cachedIdentity, bool) {
+	cacheFilepath := cache.getFilepath(key)
+	contents, err := ioutil.ReadFile(cacheFilepath)
+	if os.IsNotExist(err) {
+		return cachedIdentity{}, false
+	}
+	if err != nil {
+		logrus.Warnf("Ignoring identity cache file %v that couldn't be read: %v", cacheFilepath, err)
+		return cachedIdentity{}, false
+	}
+
+	certBlock, rest := pem.Decode(contents)
+	keyBlock, _ := pem.Decode(rest)
+	if certBlock == nil || certBlock.Type != certificatePreamble || keyBlock == nil {
+		logrus.Warnf("Ignoring malformed identity cache file %v", cacheFilepath)
+		return cachedIdentity{}, false
+	}
+	return cachedIdentity{
+		certPEM: pem.EncodeToMemory(certBlock),
+		keyPEM:  pem.EncodeToMemory(keyBlock),
+	}, true
+}
+
+// store caches the identity under the given key, replacing the cache file atomically so that concurrent runs sharing
+// the cache never see a partially-written one
+func (cache *identityCache) store(key string, identity cachedIdentity) error {
+	tempFile, err := ioutil.TempFile(cache.dirpath, key+"-*.tmp")
+	if err != nil {
+		return stacktrace.Propagate(err, "Failed to create temporary identity cache file")
+	}
+	defer os.Remove(tempFile.Name())
+
+	contents := append(append([]byte{}, identity.certPEM...), identity.keyPEM...)
+	if _, err := tempFile.Write(contents); err != nil {
+		tempFile.Close()
+		return stacktrace.Propagate(err, "Failed to write temporary identity cache file %v", tempFile.Name())
+	}
+	if err := tempFile.Close(); err != nil {
+		return stacktrace.Propagate(err, "Failed to close temporary identity cache file %v", tempFile.Name())
+	}
+	if err := os.Rename(tempFile.Name(), cache.getFilepath(key)); err != nil {
+		return stacktrace.Propagate(err, "Failed to move the identity cache file into place")
+	}
+	return nil
+}
+
+func (cache *identityCache) getFilepath(key string) string {
+	return filepath.Join(cache.dirpath, key+identityCacheFileExtension)
+}
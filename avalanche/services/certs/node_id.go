@@ -0,0 +1,38 @@
+package certs
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/hashing"
+	"github.com/palantir/stacktrace"
+)
+
+const (
+	// Prefix that avalanchego puts in front of the string form of a node ID
+	nodeIDPrefix = "NodeID-"
+)
+
+// GetNodeIDFromCert computes the node ID that an Avalanche node started with the given staking cert will have
+// Args:
+// 	certPemBytes: The PEM-encoded staking cert of the node
+// Returns:
+// 	The node ID in the "NodeID-..." form that avalanchego APIs and CLI flags use
+func GetNodeIDFromCert(certPemBytes []byte) (string, error) {
+	block, _ := pem.Decode(certPemBytes)
+	if block == nil || block.Type != certificatePreamble {
+		return "", stacktrace.NewError("Could not decode a PEM-encoded certificate from the given bytes")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return "", stacktrace.Propagate(err, "Failed to parse the staking cert")
+	}
+
+	// avalanchego derives the node ID from a hash of the raw cert bytes, NOT just the public key
+	shortID, err := ids.ToShortID(hashing.PubkeyBytesToAddress(cert.Raw))
+	if err != nil {
+		return "", stacktrace.Propagate(err, "Failed to convert the cert hash to a node ID")
+	}
+	return nodeIDPrefix + shortID.String(), nil
+}
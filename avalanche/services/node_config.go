@@ -0,0 +1,373 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/palantir/stacktrace"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// Defaults used for the NodeConfig fields that every node needs, which match the small networks the tests run
+	DefaultSnowSampleSize        = 2
+	DefaultSnowQuorumSize        = 2
+	DefaultNetworkInitialTimeout = 2 * time.Second
+)
+
+// ByzantineBehavior is a behavior that the byzantine Avalanche image can be started with
+type ByzantineBehavior string
+
+const (
+	// Not byzantine; the flag isn't passed at all, so that the node can run normal Avalanche images
+	NoByzantineBehavior ByzantineBehavior = ""
+
+	// Sends chits that weren't requested
+	ChitSpammerBehavior ByzantineBehavior = "chit-spammer"
+
+	// Issues conflicting transactions in the same vertex
+	ConflictingTxsVertexBehavior ByzantineBehavior = "conflicting-txs-vertex"
+)
+
+var knownByzantineBehaviors = map[ByzantineBehavior]bool{
+	NoByzantineBehavior:          true,
+	ChitSpammerBehavior:          true,
+	ConflictingTxsVertexBehavior: true,
+}
+
+var knownLogLevels = map[AvalancheLogLevel]bool{
+	VERBOSE: true,
+	DEBUG:   true,
+	INFO:    true,
+}
+
+// IsKnownLogLevel returns true if [level] is one of the log levels that nodes can be started with
+func IsKnownLogLevel(level AvalancheLogLevel) bool {
+	return knownLogLevels[level]
+}
+
+// The flags that the initializer core sets itself to wire the node into the test network, which can't be overridden
+var reservedFlags = map[string]bool{
+	"public-ip":             true,
+	"network-id":            true,
+	"http-host":             true,
+	"http-port":             true,
+	"staking-port":          true,
+	"staking-enabled":       true,
+	"staking-tls-cert-file": true,
+	"staking-tls-key-file":  true,
+	"tx-fee":                true,
+	"genesis":               true,
+	"bootstrap-ids":         true,
+	"bootstrap-ips":         true,
+	"config-file":           true,
+}
+
+// NodeConfig is the avalanchego configuration of the nodes started with one service configuration. Optional fields
+// left at their zero values aren't rendered, so the node uses its own defaults for them.
+type NodeConfig struct {
+	// Log level that the node should start with
+	LogLevel AvalancheLogLevel
+
+	// ================= Consensus =================
+	// Snow protocol sample size
+	SnowSampleSize int
+
+	// Snow protocol quorum size
+	SnowQuorumSize int
+
+	// Optional: Consecutive successful polls needed to accept a virtuous (resp. rogue) transaction
+	SnowVirtuousCommitThreshold int
+	SnowRogueCommitThreshold    int
+
+	// Optional: Minimum number of polls that are kept outstanding
+	SnowConcurrentRepolls int
+
+	// Optional: Number of operations per vertex, and number of parents per vertex, of the Avalanche DAG
+	SnowAvalancheBatchSize  int
+	SnowAvalancheNumParents int
+
+	// ================= Timeouts =================
+	// Initial timeout of network requests
+	NetworkInitialTimeout time.Duration
+
+	// Optional: Bounds the network request timeout adapts between
+	NetworkMinimumTimeout time.Duration
+	NetworkMaximumTimeout time.Duration
+
+	// ================= APIs =================
+	// Optional: Whether each API is enabled, or nil to leave it at the node's default
+	AdminAPIEnabled    *bool
+	IPCsAPIEnabled     *bool
+	KeystoreAPIEnabled *bool
+	MetricsAPIEnabled  *bool
+	HealthAPIEnabled   *bool
+	InfoAPIEnabled     *bool
+
+	// ================= Database =================
+	// Optional: False to keep the node's database in memory, or nil to leave it at the node's default
+	DBEnabled *bool
+
+	// Optional: The directory of the node's database
+	DBDir string
+
+	// ================= Profiling =================
+	// True to start the node from its own directory on the test volume, which is where the admin API writes the
+	//  node's profiles, so that the test can copy them out. Needs the admin API and a node image with /bin/sh.
+	CaptureProfiles bool
+
+	// ================= IPCs =================
+	// True to have the node create the IPC sockets that it publishes blockchains on in its own directory on the test
+	//  volume, so that the test can subscribe to them. Needs the IPCs API, which nodes disable by default, and a node
+	//  image with /bin/sh.
+	ShareIPCs bool
+
+	// ================= Byzantine =================
+	// The byzantine behavior of the node, which must be started from the byzantine image for anything but
+	//  NoByzantineBehavior
+	ByzantineBehavior ByzantineBehavior
+
+	// ================= Subnets =================
+	// Optional: The IDs of the subnets the node validates besides the primary network
+	WhitelistedSubnets []string
+
+	// ================= Staking =================
+	// Optional: Shortest period that validators and delegators can stake for, which tests lower to see stakers
+	//  finish and get rewarded. Needs an avalanchego version that has the --min-stake-duration flag, which v0.8.3
+	//  doesn't.
+	MinStakeDuration time.Duration
+
+	// Escape hatch for flags that aren't typed above, mapping flag name (without the leading dashes) -> value. Flags
+	//  here win over the typed fields with the same flag, which gets logged as a warning.
+	ExtraFlags map[string]string
+
+	// True to hand the node its configuration in a JSON config file rather than as flags, which needs an avalanchego
+	//  version that supports --config-file
+	UseConfigFile bool
+}
+
+// Bool is a helper for setting the optional bool fields of a NodeConfig
+func Bool(value bool) *bool {
+	return &value
+}
+
+// WithDefaults returns a copy of the config with the defaults filled in for the fields every node needs
+func (config NodeConfig) WithDefaults() NodeConfig {
+	if config.LogLevel == "" {
+		config.LogLevel = INFO
+	}
+	if config.SnowSampleSize == 0 {
+		config.SnowSampleSize = DefaultSnowSampleSize
+	}
+	if config.SnowQuorumSize == 0 {
+		config.SnowQuorumSize = DefaultSnowQuorumSize
+	}
+	if config.NetworkInitialTimeout == 0 {
+		config.NetworkInitialTimeout = DefaultNetworkInitialTimeout
+	}
+	return config.clone()
+}
+
+// Validate checks that the config is one avalanchego would start with
+func (config NodeConfig) Validate() error {
+	if !IsKnownLogLevel(config.LogLevel) {
+		return stacktrace.NewError("Unknown log level '%v'", config.LogLevel)
+	}
+	if config.SnowSampleSize < 1 {
+		return stacktrace.NewError("Snow sample size must be at least 1 but was %v", config.SnowSampleSize)
+	}
+	if config.SnowQuorumSize <= config.SnowSampleSize/2 || config.SnowQuorumSize > config.SnowSampleSize {
+		return stacktrace.NewError(
+			"Snow quorum size must be more than half the sample size and at most the sample size, but was %v with sample size %v",
+			config.SnowQuorumSize,
+			config.SnowSampleSize)
+	}
+	if config.SnowVirtuousCommitThreshold < 0 || config.SnowRogueCommitThreshold < 0 {
+		return stacktrace.NewError("Snow commit thresholds can't be negative")
+	}
+	if config.SnowVirtuousCommitThreshold > 0 && config.SnowRogueCommitThreshold > 0 &&
+		config.SnowRogueCommitThreshold < config.SnowVirtuousCommitThreshold {
+		return stacktrace.NewError(
+			"Snow rogue commit threshold %v can't be lower than the virtuous commit threshold %v",
+			config.SnowRogueCommitThreshold,
+			config.SnowVirtuousCommitThreshold)
+	}
+	if config.SnowConcurrentRepolls < 0 || config.SnowAvalancheBatchSize < 0 || config.SnowAvalancheNumParents < 0 {
+		return stacktrace.NewError("Snow repolls, batch size and number of parents can't be negative")
+	}
+
+	if config.NetworkInitialTimeout <= 0 {
+		return stacktrace.NewError("Network initial timeout must be positive but was %v", config.NetworkInitialTimeout)
+	}
+	if config.NetworkMinimumTimeout < 0 || config.NetworkMaximumTimeout < 0 {
+		return stacktrace.NewError("Network timeout bounds can't be negative")
+	}
+	if config.NetworkMinimumTimeout > 0 && config.NetworkMinimumTimeout > config.NetworkInitialTimeout {
+		return stacktrace.NewError(
+			"Network minimum timeout %v is higher than the initial timeout %v",
+			config.NetworkMinimumTimeout,
+			config.NetworkInitialTimeout)
+	}
+	if config.NetworkMaximumTimeout > 0 && config.NetworkMaximumTimeout < config.NetworkInitialTimeout {
+		return stacktrace.NewError(
+			"Network maximum timeout %v is lower than the initial timeout %v",
+			config.NetworkMaximumTimeout,
+			config.NetworkInitialTimeout)
+	}
+
+	if config.CaptureProfiles && config.AdminAPIEnabled != nil && !*config.AdminAPIEnabled {
+		return stacktrace.NewError("Profiles can't be captured with the admin API disabled")
+	}
+
+	if config.ShareIPCs {
+		if config.IPCsAPIEnabled == nil || !*config.IPCsAPIEnabled {
+			return stacktrace.NewError("IPCs can't be shared without enabling the IPCs API")
+		}
+		if _, found := config.ExtraFlags[ipcsPathFlag]; found {
+			return stacktrace.NewError("Flag '%v' is set by the test network when IPCs are shared", ipcsPathFlag)
+		}
+	}
+
+	if !knownByzantineBehaviors[config.ByzantineBehavior] {
+		return stacktrace.NewError("Unknown byzantine behavior '%v'", config.ByzantineBehavior)
+	}
+	for _, subnetID := range config.WhitelistedSubnets {
+		if subnetID == "" || strings.Contains(subnetID, ",") {
+			return stacktrace.NewError("Invalid whitelisted subnet ID '%v'", subnetID)
+		}
+	}
+	if config.MinStakeDuration < 0 {
+		return stacktrace.NewError("Minimum stake duration can't be negative but was %v", config.MinStakeDuration)
+	}
+
+	for name := range config.ExtraFlags {
+		if name == "" || strings.HasPrefix(name, "-") || strings.ContainsAny(name, "= ") {
+			return stacktrace.NewError("Invalid extra flag name '%v'; flag names are given without leading dashes", name)
+		}
+		if reservedFlags[name] {
+			return stacktrace.NewError("Flag '%v' is set by the test network and can't be passed as an extra flag", name)
+		}
+	}
+	return nil
+}
+
+// nodeFlag is a flag rendered from a NodeConfig; values keep their types so config files get typed JSON values
+type nodeFlag struct {
+	name  string
+	value interface{}
+}
+
+// getFlags renders the config to the flags it sets, the typed fields first in a fixed order and then the extra flags
+// sorted by name, logging a warning for each extra flag that overrides a typed one
+func (config NodeConfig) getFlags() []nodeFlag {
+	flags := []nodeFlag{
+		{"log-level", string(config.LogLevel)},
+		{"snow-sample-size", config.SnowSampleSize},
+		{"snow-quorum-size", config.SnowQuorumSize},
+		{"network-initial-timeout", int64(config.NetworkInitialTimeout)},
+	}
+	addInt := func(name string, value int) {
+		if value != 0 {
+			flags = append(flags, nodeFlag{name, value})
+		}
+	}
+	addDuration := func(name string, value time.Duration) {
+		if value != 0 {
+			flags = append(flags, nodeFlag{name, int64(value)})
+		}
+	}
+	addBool := func(name string, value *bool) {
+		if value != nil {
+			flags = append(flags, nodeFlag{name, *value})
+		}
+	}
+	addInt("snow-virtuous-commit-threshold", config.SnowVirtuousCommitThreshold)
+	addInt("snow-rogue-commit-threshold", config.SnowRogueCommitThreshold)
+	addInt("snow-concurrent-repolls", config.SnowConcurrentRepolls)
+	addInt("snow-avalanche-batch-size", config.SnowAvalancheBatchSize)
+	addInt("snow-avalanche-num-parents", config.SnowAvalancheNumParents)
+	addDuration("network-minimum-timeout", config.NetworkMinimumTimeout)
+	addDuration("network-maximum-timeout", config.NetworkMaximumTimeout)
+	addBool("api-admin-enabled", config.AdminAPIEnabled)
+	addBool("api-ipcs-enabled", config.IPCsAPIEnabled)
+	addBool("api-keystore-enabled", config.KeystoreAPIEnabled)
+	addBool("api-metrics-enabled", config.MetricsAPIEnabled)
+	addBool("api-health-enabled", config.HealthAPIEnabled)
+	addBool("api-info-enabled", config.InfoAPIEnabled)
+	addBool("db-enabled", config.DBEnabled)
+	if config.DBDir != "" {
+		flags = append(flags, nodeFlag{"db-dir", config.DBDir})
+	}
+	if config.ByzantineBehavior != NoByzantineBehavior {
+		flags = append(flags, nodeFlag{"byzantine-behavior", string(config.ByzantineBehavior)})
+	}
+	if len(config.WhitelistedSubnets) > 0 {
+		flags = append(flags, nodeFlag{"whitelisted-subnets", strings.Join(config.WhitelistedSubnets, ",")})
+	}
+	// Unlike the network timeouts, which the node takes as nanoseconds, the minimum stake duration is a Go duration
+	if config.MinStakeDuration != 0 {
+		flags = append(flags, nodeFlag{"min-stake-duration", config.MinStakeDuration.String()})
+	}
+
+	extraFlagNames := make([]string, 0, len(config.ExtraFlags))
+	for name := range config.ExtraFlags {
+		extraFlagNames = append(extraFlagNames, name)
+	}
+	sort.Strings(extraFlagNames)
+	for _, name := range extraFlagNames {
+		value := config.ExtraFlags[name]
+		overridden := false
+		for i, flag := range flags {
+			if flag.name == name {
+				logrus.Warnf("Extra flag --%v=%v overrides the typed node config value %v", name, value, flag.value)
+				flags[i].value = value
+				overridden = true
+				break
+			}
+		}
+		if !overridden {
+			flags = append(flags, nodeFlag{name, value})
+		}
+	}
+	return flags
+}
+
+// ToFlags renders the config to avalanchego command line flags
+func (config NodeConfig) ToFlags() []string {
+	result := []string{}
+	for _, flag := range config.getFlags() {
+		result = append(result, fmt.Sprintf("--%s=%v", flag.name, flag.value))
+	}
+	return result
+}
+
+// ToConfigFile renders the config to the contents of an avalanchego JSON config file
+func (config NodeConfig) ToConfigFile() ([]byte, error) {
+	contents := make(map[string]interface{})
+	for _, flag := range config.getFlags() {
+		contents[flag.name] = flag.value
+	}
+	bytes, err := json.MarshalIndent(contents, "", "  ")
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to serialize the node config file")
+	}
+	return bytes, nil
+}
+
+// clone returns a copy of the config that shares no slices or maps with it
+func (config NodeConfig) clone() NodeConfig {
+	if config.WhitelistedSubnets != nil {
+		config.WhitelistedSubnets = append([]string{}, config.WhitelistedSubnets...)
+	}
+	if config.ExtraFlags != nil {
+		extraFlags := make(map[string]string, len(config.ExtraFlags))
+		for name, value := range config.ExtraFlags {
+			extraFlags[name] = value
+		}
+		config.ExtraFlags = extraFlags
+	}
+	return config
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNodeConfigDefaults(t *testing.T) {
+	config := NodeConfig{}.WithDefaults()
+	assert.Equal(t, INFO, config.LogLevel)
+	assert.Equal(t, DefaultSnowSampleSize, config.SnowSampleSize)
+	assert.Equal(t, DefaultSnowQuorumSize, config.SnowQuorumSize)
+	assert.Equal(t, DefaultNetworkInitialTimeout, config.NetworkInitialTimeout)
+	assert.NoError(t, config.Validate())
+
+	config = NodeConfig{LogLevel: DEBUG, SnowSampleSize: 8, SnowQuorumSize: 6}.WithDefaults()
+	assert.Equal(t, DEBUG, config.LogLevel)
+	assert.Equal(t, 8, config.SnowSampleSize)
+	assert.Equal(t, 6, config.SnowQuorumSize)
+}
+
+func TestNodeConfigValidation(t *testing.T) {
+	invalidConfigs := map[string]NodeConfig{
+		"unknown log level":      {LogLevel: "loud"},
+		"quorum over sample":     {SnowSampleSize: 2, SnowQuorumSize: 3},
+		"quorum not majority":    {SnowSampleSize: 4, SnowQuorumSize: 2},
+		"rogue under virtuous":   {SnowVirtuousCommitThreshold: 10, SnowRogueCommitThreshold: 5},
+		"minimum over initial":   {NetworkMinimumTimeout: time.Minute},
+		"maximum under initial":  {NetworkMaximumTimeout: time.Millisecond},
+		"unknown byzantine":      {ByzantineBehavior: "sleepy"},
+		"empty subnet":           {WhitelistedSubnets: []string{""}},
+		"negative min stake":     {MinStakeDuration: -time.Minute},
+		"profiles without admin": {CaptureProfiles: true, AdminAPIEnabled: Bool(false)},
+		"ipcs without ipcs api":  {ShareIPCs: true},
+		"ipcs path extra flag":   {ShareIPCs: true, IPCsAPIEnabled: Bool(true), ExtraFlags: map[string]string{"ipcs-path": "/tmp"}},
+		"dashed extra flag":      {ExtraFlags: map[string]string{"--log-level": "debug"}},
+		"reserved extra flag":    {ExtraFlags: map[string]string{"http-port": "1234"}},
+		"extra flag with equals": {ExtraFlags: map[string]string{"a=b": "c"}},
+	}
+	for name, config := range invalidConfigs {
+		assert.Error(t, config.WithDefaults().Validate(), name)
+	}
+}
+
+func TestNodeConfigFlags(t *testing.T) {
+	config := NodeConfig{
+		NetworkMaximumTimeout: 10 * time.Second,
+		IPCsAPIEnabled:        Bool(true),
+		DBEnabled:             Bool(false),
+		ByzantineBehavior:     ChitSpammerBehavior,
+		WhitelistedSubnets:    []string{"subnetA", "subnetB"},
+		MinStakeDuration:      time.Minute,
+		ExtraFlags: map[string]string{
+			"snow-sample-size":   "3",
+			"assertions-enabled": "true",
+		},
+	}.WithDefaults()
+	assert.NoError(t, config.Validate())
+
+	expected := []string{
+		"--log-level=info",
+		"--snow-sample-size=3",
+		"--snow-quorum-size=2",
+		"--network-initial-timeout=2000000000",
+		"--network-maximum-timeout=10000000000",
+		"--api-ipcs-enabled=true",
+		"--db-enabled=false",
+		"--byzantine-behavior=chit-spammer",
+		"--whitelisted-subnets=subnetA,subnetB",
+		"--min-stake-duration=1m0s",
+		"--assertions-enabled=true",
+	}
+	assert.Equal(t, expected, config.ToFlags())
+
+	configFileContents, err := config.ToConfigFile()
+	assert.NoError(t, err)
+	parsed := make(map[string]interface{})
+	assert.NoError(t, json.Unmarshal(configFileContents, &parsed))
+	assert.Len(t, parsed, len(expected))
+	assert.Equal(t, "3", parsed["snow-sample-size"])
+	assert.Equal(t, float64(2), parsed["snow-quorum-size"])
+	assert.Equal(t, false, parsed["db-enabled"])
+	assert.Equal(t, "subnetA,subnetB", parsed["whitelisted-subnets"])
+	assert.Equal(t, "1m0s", parsed["min-stake-duration"])
+}
+
+func TestNodeConfigWithDefaultsCopies(t *testing.T) {
+	original := NodeConfig{
+		WhitelistedSubnets: []string{"subnetA"},
+		ExtraFlags:         map[string]string{"assertions-enabled": "true"},
+	}
+	config := original.WithDefaults()
+	config.WhitelistedSubnets[0] = "subnetB"
+	config.ExtraFlags["assertions-enabled"] = "false"
+	assert.Equal(t, "subnetA", original.WhitelistedSubnets[0])
+	assert.Equal(t, "true", original.ExtraFlags["assertions-enabled"])
+}
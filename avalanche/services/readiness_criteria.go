@@ -0,0 +1,145 @@
+package services
+
+import (
+	"github.com/ava-labs/avalanche-testing/avalanche_client/apis"
+	"github.com/ava-labs/avalanchego/utils/constants"
+	"github.com/palantir/stacktrace"
+)
+
+// The chains that every Avalanche node has, which must be bootstrapped for the node to be usable
+var defaultBootstrappedChains = []string{"P", "C", "X"}
+
+// ReadinessCriterion is a condition that an Avalanche node must meet before it's considered available
+type ReadinessCriterion interface {
+	// GetName returns a short description of the criterion, used when reporting that it's failing
+	GetName() string
+
+	// Check returns nil if the node behind the given client meets the criterion, or an error describing why it doesn't
+	Check(client *apis.Client) error
+}
+
+// DefaultReadinessCriteria returns the criteria that nodes are held to when none are specified: all the default chains
+// must be bootstrapped
+func DefaultReadinessCriteria() []ReadinessCriterion {
+	return []ReadinessCriterion{
+		NewChainsBootstrappedCriterion(defaultBootstrappedChains...),
+	}
+}
+
+// ================= Chains bootstrapped ===================
+// ChainsBootstrappedCriterion requires that the node has finished bootstrapping the given chains
+type ChainsBootstrappedCriterion struct {
+	chains []string
+}
+
+// NewChainsBootstrappedCriterion creates a criterion requiring that the chains with the given IDs or aliases are bootstrapped
+func NewChainsBootstrappedCriterion(chains ...string) *ChainsBootstrappedCriterion {
+	return &ChainsBootstrappedCriterion{chains: chains}
+}
+
+// GetName implements ReadinessCriterion
+func (criterion ChainsBootstrappedCriterion) GetName() string {
+	return "chains bootstrapped"
+}
+
+// Check implements ReadinessCriterion
+func (criterion ChainsBootstrappedCriterion) Check(client *apis.Client) error {
+	for _, chain := range criterion.chains {
+		bootstrapped, err := client.InfoAPI().IsBootstrapped(chain)
+		if err != nil {
+			return stacktrace.Propagate(err, "Failed to check whether chain %v is bootstrapped", chain)
+		}
+		if !bootstrapped {
+			return stacktrace.NewError("Chain %v isn't bootstrapped yet", chain)
+		}
+	}
+	return nil
+}
+
+// ================= Healthy ===================
+// HealthyCriterion requires that the node's health API reports it as live
+type HealthyCriterion struct{}
+
+// NewHealthyCriterion creates a criterion requiring that the node reports itself as healthy
+func NewHealthyCriterion() *HealthyCriterion {
+	return &HealthyCriterion{}
+}
+
+// GetName implements ReadinessCriterion
+func (criterion HealthyCriterion) GetName() string {
+	return "health liveness"
+}
+
+// Check implements ReadinessCriterion
+func (criterion HealthyCriterion) Check(client *apis.Client) error {
+	liveness, err := client.HealthAPI().GetLiveness()
+	if err != nil {
+		return stacktrace.Propagate(err, "Failed to get the node's liveness")
+	}
+	if !liveness.Healthy {
+		return stacktrace.NewError("Node reports that it isn't healthy; failing checks: %v", liveness.GetFailingChecks())
+	}
+	return nil
+}
+
+// ================= Minimum peers ===================
+// MinPeersCriterion requires that the node is connected to at least a given number of peers
+type MinPeersCriterion struct {
+	minPeers int
+}
+
+// NewMinPeersCriterion creates a criterion requiring that the node has at least minPeers peers
+func NewMinPeersCriterion(minPeers int) *MinPeersCriterion {
+	return &MinPeersCriterion{minPeers: minPeers}
+}
+
+// GetName implements ReadinessCriterion
+func (criterion MinPeersCriterion) GetName() string {
+	return "minimum peers"
+}
+
+// Check implements ReadinessCriterion
+func (criterion MinPeersCriterion) Check(client *apis.Client) error {
+	peers, err := client.InfoAPI().Peers()
+	if err != nil {
+		return stacktrace.Propagate(err, "Failed to get the node's peers")
+	}
+	if len(peers) < criterion.minPeers {
+		return stacktrace.NewError("Node has %v peers, but at least %v are required", len(peers), criterion.minPeers)
+	}
+	return nil
+}
+
+// ================= Validator ===================
+// ValidatorCriterion requires that the node is in the current validator set of the primary network
+type ValidatorCriterion struct{}
+
+// NewValidatorCriterion creates a criterion requiring that the node is a current primary network validator
+func NewValidatorCriterion() *ValidatorCriterion {
+	return &ValidatorCriterion{}
+}
+
+// GetName implements ReadinessCriterion
+func (criterion ValidatorCriterion) GetName() string {
+	return "validator set inclusion"
+}
+
+// Check implements ReadinessCriterion
+func (criterion ValidatorCriterion) Check(client *apis.Client) error {
+	nodeID, err := client.InfoAPI().GetNodeID()
+	if err != nil {
+		return stacktrace.Propagate(err, "Failed to get the node's ID")
+	}
+	validators, _, err := client.PChainAPI().GetCurrentValidators(constants.PrimaryNetworkID)
+	if err != nil {
+		return stacktrace.Propagate(err, "Failed to get the current validators")
+	}
+	for _, validator := range validators {
+		// The validators come back as generic JSON objects
+		validatorMap, ok := validator.(map[string]interface{})
+		if ok && validatorMap["nodeID"] == nodeID {
+			return nil
+		}
+	}
+	return stacktrace.NewError("Node %v isn't in the current validator set", nodeID)
+}
@@ -0,0 +1,33 @@
+package services
+
+import (
+	"path"
+)
+
+const (
+	// The files that the admin API writes the node's profiles to, in the node's working directory
+	CPUProfileFilename    = "cpu.profile"
+	MemoryProfileFilename = "mem.profile"
+	LockProfileFilename   = "lock.profile"
+
+	// The directory on the test volume that nodes started with NodeConfig.CaptureProfiles run from, with one
+	// subdirectory per node
+	profilesDirname = "profiles"
+
+	// The directory on the test volume that nodes started with NodeConfig.ShareIPCs create their IPC sockets in, with
+	// one subdirectory per node
+	ipcsDirname = "ipcs"
+)
+
+// GetProfilesDirpath returns the directory that the node with the given IP writes its profiles to when it's started
+// with NodeConfig.CaptureProfiles, given where the test volume is mounted (which differs between the nodes and the
+// controller)
+func GetProfilesDirpath(testVolumeMountpoint string, ipAddr string) string {
+	return path.Join(testVolumeMountpoint, profilesDirname, ipAddr)
+}
+
+// GetIPCsDirpath returns the directory that the node with the given IP creates its IPC sockets in when it's started
+// with NodeConfig.ShareIPCs, given where the test volume is mounted
+func GetIPCsDirpath(testVolumeMountpoint string, ipAddr string) string {
+	return path.Join(testVolumeMountpoint, ipcsDirname, ipAddr)
+}
@@ -1,6 +1,7 @@
 package admin
 
 import (
+	"context"
 	"time"
 
 	"github.com/ava-labs/avalanche-testing/avalanche_client/utils"
@@ -15,8 +16,20 @@ type Client struct {
 
 // NewClient returns a new Info API Client
 func NewClient(uri string, requestTimeout time.Duration) *Client {
+	return NewClientWithTransport(uri, utils.NewTransport(utils.DefaultTransportOptions(requestTimeout)))
+}
+
+// NewClientWithTransport returns a client for the Admin API endpoint that sends its requests through the given transport
+func NewClientWithTransport(uri string, transport *utils.Transport) *Client {
 	return &Client{
-		requester: utils.NewEndpointRequester(uri, "/ext/admin", "admin", requestTimeout),
+		requester: utils.NewEndpointRequesterWithTransport(uri, "/ext/admin", "admin", transport),
+	}
+}
+
+// WithContext returns a copy of the client whose requests are made with the given context
+func (c *Client) WithContext(ctx context.Context) *Client {
+	return &Client{
+		requester: utils.WithContext(ctx, c.requester),
 	}
 }
 
@@ -53,7 +66,7 @@ func (c *Client) MemoryProfile() (bool, error) {
 // LockProfile ...
 func (c *Client) LockProfile() (bool, error) {
 	res := &api.SuccessResponse{}
-	err := c.requester.SendRequest("memoryProfile", struct{}{}, res)
+	err := c.requester.SendRequest("lockProfile", struct{}{}, res)
 	if err != nil {
 		return false, err
 	}
@@ -1,6 +1,7 @@
 package admin
 
 import (
+	"context"
 	"testing"
 
 	"github.com/ava-labs/avalanche-testing/avalanche_client/apis/test"
@@ -22,6 +23,10 @@ func NewMockClient(response interface{}, err error) utils.EndpointRequester {
 }
 
 func (mc *mockClient) SendRequest(method string, params interface{}, reply interface{}) error {
+	return mc.SendRequestWithContext(context.Background(), method, params, reply)
+}
+
+func (mc *mockClient) SendRequestWithContext(ctx context.Context, method string, params interface{}, reply interface{}) error {
 	if mc.err != nil {
 		return mc.err
 	}
@@ -4,6 +4,7 @@
 package avm
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -23,8 +24,21 @@ type Client struct {
 
 // Returns a Client for interacting with the X chain endpoint
 func NewClient(uri, chain string, requestTimeout time.Duration) *Client {
+	return NewClientWithTransport(uri, chain, utils.NewTransport(utils.DefaultTransportOptions(requestTimeout)))
+}
+
+// NewClientWithTransport returns a Client for interacting with the X chain endpoint that sends its requests through the
+// given transport
+func NewClientWithTransport(uri, chain string, transport *utils.Transport) *Client {
+	return &Client{
+		requester: utils.NewEndpointRequesterWithTransport(uri, fmt.Sprintf("/ext/bc/%s", chain), "avm", transport),
+	}
+}
+
+// WithContext returns a copy of the client whose requests are made with the given context
+func (c *Client) WithContext(ctx context.Context) *Client {
 	return &Client{
-		requester: utils.NewEndpointRequester(uri, fmt.Sprintf("/ext/bc/%s", chain), "avm", requestTimeout),
+		requester: utils.WithContext(ctx, c.requester),
 	}
 }
 
@@ -1,41 +1,70 @@
 package apis
 
 import (
+	"context"
 	"time"
 
 	"github.com/ava-labs/avalanche-testing/avalanche_client/apis/admin"
 	"github.com/ava-labs/avalanche-testing/avalanche_client/apis/avm"
+	"github.com/ava-labs/avalanche-testing/avalanche_client/apis/evm"
 	"github.com/ava-labs/avalanche-testing/avalanche_client/apis/health"
 	"github.com/ava-labs/avalanche-testing/avalanche_client/apis/info"
 	"github.com/ava-labs/avalanche-testing/avalanche_client/apis/ipcs"
 	"github.com/ava-labs/avalanche-testing/avalanche_client/apis/keystore"
+	"github.com/ava-labs/avalanche-testing/avalanche_client/apis/metrics"
 	"github.com/ava-labs/avalanche-testing/avalanche_client/apis/platform"
+	"github.com/ava-labs/avalanche-testing/avalanche_client/utils"
 )
 
 const (
 	XChain = "X"
+	CChain = "C"
 )
 
 type Client struct {
 	admin    *admin.Client
 	xChain   *avm.Client
+	cChain   *evm.Client
 	health   *health.Client
 	info     *info.Client
 	ipcs     *ipcs.Client
 	keystore *keystore.Client
+	metrics  *metrics.Client
 	platform *platform.Client
 }
 
 // Returns a Client for interacting with the P Chain endpoint
 func NewClient(uri string, requestTimeout time.Duration) *Client {
+	return NewClientWithTransport(uri, utils.NewTransport(utils.DefaultTransportOptions(requestTimeout)))
+}
+
+// NewClientWithTransport returns a Client whose API clients all send their requests through the given transport
+func NewClientWithTransport(uri string, transport *utils.Transport) *Client {
+	return &Client{
+		admin:    admin.NewClientWithTransport(uri, transport),
+		xChain:   avm.NewClientWithTransport(uri, XChain, transport),
+		cChain:   evm.NewClientWithTransport(uri, CChain, transport),
+		health:   health.NewClientWithTransport(uri, transport),
+		info:     info.NewClientWithTransport(uri, transport),
+		ipcs:     ipcs.NewClientWithTransport(uri, transport),
+		keystore: keystore.NewClientWithTransport(uri, transport),
+		metrics:  metrics.NewClientWithTransport(uri, transport),
+		platform: platform.NewClientWithTransport(uri, transport),
+	}
+}
+
+// WithContext returns a copy of the Client whose API clients make their requests with the given context
+func (c *Client) WithContext(ctx context.Context) *Client {
 	return &Client{
-		admin:    admin.NewClient(uri, requestTimeout),
-		xChain:   avm.NewClient(uri, XChain, requestTimeout),
-		health:   health.NewClient(uri, requestTimeout),
-		info:     info.NewClient(uri, requestTimeout),
-		ipcs:     ipcs.NewClient(uri, requestTimeout),
-		keystore: keystore.NewClient(uri, requestTimeout),
-		platform: platform.NewClient(uri, requestTimeout),
+		admin:    c.admin.WithContext(ctx),
+		xChain:   c.xChain.WithContext(ctx),
+		cChain:   c.cChain.WithContext(ctx),
+		health:   c.health.WithContext(ctx),
+		info:     c.info.WithContext(ctx),
+		ipcs:     c.ipcs.WithContext(ctx),
+		keystore: c.keystore.WithContext(ctx),
+		metrics:  c.metrics.WithContext(ctx),
+		platform: c.platform.WithContext(ctx),
 	}
 }
 
@@ -47,6 +76,10 @@ func (c *Client) XChainAPI() *avm.Client {
 	return c.xChain
 }
 
+func (c *Client) CChainAPI() *evm.Client {
+	return c.cChain
+}
+
 func (c *Client) InfoAPI() *info.Client {
 	return c.info
 }
@@ -66,3 +99,8 @@ func (c *Client) KeystoreAPI() *keystore.Client {
 func (c *Client) AdminAPI() *admin.Client {
 	return c.admin
 }
+
+// MetricsAPI returns the client of the node's Prometheus metrics endpoint, which the network's metrics scraper reads
+func (c *Client) MetricsAPI() *metrics.Client {
+	return c.metrics
+}
@@ -0,0 +1,162 @@
+package evm
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ava-labs/avalanche-testing/avalanche_client/utils"
+	"github.com/ava-labs/avalanchego/api"
+	"github.com/ava-labs/avalanchego/ids"
+	cjson "github.com/ava-labs/avalanchego/utils/json"
+	rpc "github.com/gorilla/rpc/v2/json2"
+)
+
+const (
+	// The block tag that makes Ethereum JSON-RPC calls operate on the most recently accepted block
+	latestBlock = "latest"
+)
+
+// Client for the C Chain, covering both its Ethereum JSON-RPC endpoint and its Avalanche-specific avax endpoint
+type Client struct {
+	// Requester for the Ethereum JSON-RPC methods, which aren't prefixed with a service name
+	ethRequester utils.EndpointRequester
+	// Requester for the avax.* methods that move AVAX between the C Chain and the other chains
+	avaxRequester utils.EndpointRequester
+}
+
+// NewClient returns a Client for interacting with the C Chain [chain] (usually "C")
+func NewClient(uri, chain string, requestTimeout time.Duration) *Client {
+	return NewClientWithTransport(uri, chain, utils.NewTransport(utils.DefaultTransportOptions(requestTimeout)))
+}
+
+// NewClientWithTransport returns a Client for the C Chain [chain] that sends its requests through the given transport
+func NewClientWithTransport(uri, chain string, transport *utils.Transport) *Client {
+	return &Client{
+		ethRequester:  utils.NewEndpointRequesterWithTransport(uri, fmt.Sprintf("/ext/bc/%s/rpc", chain), "", transport),
+		avaxRequester: utils.NewEndpointRequesterWithTransport(uri, fmt.Sprintf("/ext/bc/%s/avax", chain), "avax", transport),
+	}
+}
+
+// WithContext returns a copy of the client whose requests are made with the given context
+func (c *Client) WithContext(ctx context.Context) *Client {
+	return &Client{
+		ethRequester:  utils.WithContext(ctx, c.ethRequester),
+		avaxRequester: utils.WithContext(ctx, c.avaxRequester),
+	}
+}
+
+// ============= Ethereum JSON-RPC ===================
+
+// BlockNumber returns the number of the most recently accepted block
+func (c *Client) BlockNumber() (uint64, error) {
+	var res HexUint64
+	err := c.ethRequester.SendRequest("eth_blockNumber", []interface{}{}, &res)
+	return uint64(res), err
+}
+
+// ChainID returns the EIP-155 chain ID that transactions sent to the chain must be signed with
+func (c *Client) ChainID() (*big.Int, error) {
+	var res HexBig
+	if err := c.ethRequester.SendRequest("eth_chainId", []interface{}{}, &res); err != nil {
+		return nil, err
+	}
+	return res.Int(), nil
+}
+
+// GetBalance returns the balance, in wei, of the "0x"-prefixed hex address [address] as of the latest block
+func (c *Client) GetBalance(address string) (*big.Int, error) {
+	var res HexBig
+	if err := c.ethRequester.SendRequest("eth_getBalance", []interface{}{address, latestBlock}, &res); err != nil {
+		return nil, err
+	}
+	return res.Int(), nil
+}
+
+// GetTransactionCount returns the nonce of the next transaction from [address] as of the latest block
+func (c *Client) GetTransactionCount(address string) (uint64, error) {
+	var res HexUint64
+	err := c.ethRequester.SendRequest("eth_getTransactionCount", []interface{}{address, latestBlock}, &res)
+	return uint64(res), err
+}
+
+// SendRawTransaction issues an RLP-encoded, signed Ethereum transaction and returns its "0x"-prefixed hash
+func (c *Client) SendRawTransaction(txBytes []byte) (string, error) {
+	var res string
+	err := c.ethRequester.SendRequest("eth_sendRawTransaction", []interface{}{"0x" + hex.EncodeToString(txBytes)}, &res)
+	return res, err
+}
+
+// GetTransactionReceipt returns the receipt of the transaction with hash [txHash], or nil if the transaction hasn't
+// been accepted yet
+func (c *Client) GetTransactionReceipt(txHash string) (*TransactionReceipt, error) {
+	res := &TransactionReceipt{}
+	err := c.ethRequester.SendRequest("eth_getTransactionReceipt", []interface{}{txHash}, res)
+	if errors.Is(err, rpc.ErrNullResult) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// ============= Avax API ===================
+
+// ExportAVAX exports [amount] nAVAX from the C Chain address of [user] to the X Chain address [to]
+func (c *Client) ExportAVAX(user api.UserPass, amount uint64, to string) (ids.ID, error) {
+	res := &api.JsonTxID{}
+	err := c.avaxRequester.SendRequest("exportAVAX", &ExportAVAXArgs{
+		UserPass: user,
+		Amount:   cjson.Uint64(amount),
+		To:       to,
+	}, res)
+	if err != nil {
+		return ids.Empty, err
+	}
+	return res.TxID, nil
+}
+
+// ImportAVAX imports the AVAX that was exported from [sourceChain] to [user]'s C Chain address, crediting it to the
+// "0x"-prefixed hex address [to]
+func (c *Client) ImportAVAX(user api.UserPass, to, sourceChain string) (ids.ID, error) {
+	res := &api.JsonTxID{}
+	err := c.avaxRequester.SendRequest("importAVAX", &ImportAVAXArgs{
+		UserPass:    user,
+		To:          to,
+		SourceChain: sourceChain,
+	}, res)
+	if err != nil {
+		return ids.Empty, err
+	}
+	return res.TxID, nil
+}
+
+// ImportKey adds the "PrivateKey-..." formatted [privateKey] to [user]'s C Chain keys and returns its hex address
+func (c *Client) ImportKey(user api.UserPass, privateKey string) (string, error) {
+	res := &api.JsonAddress{}
+	err := c.avaxRequester.SendRequest("importKey", &ImportKeyArgs{
+		UserPass:   user,
+		PrivateKey: privateKey,
+	}, res)
+	if err != nil {
+		return "", err
+	}
+	return res.Address, nil
+}
+
+// ExportKey returns the "PrivateKey-..." formatted private key of [user]'s C Chain address [address]
+func (c *Client) ExportKey(user api.UserPass, address string) (string, error) {
+	res := &ExportKeyReply{}
+	err := c.avaxRequester.SendRequest("exportKey", &ExportKeyArgs{
+		UserPass: user,
+		Address:  address,
+	}, res)
+	if err != nil {
+		return "", err
+	}
+	return res.PrivateKey, nil
+}
@@ -0,0 +1,107 @@
+package evm
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testRequest struct {
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+// newTestClient returns a Client pointed at a server that checks every request goes to the C Chain's Ethereum
+// JSON-RPC endpoint and answers it with the given result
+func newTestClient(t *testing.T, result string, check func(request testRequest)) (*Client, func()) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/ext/bc/C/rpc", r.URL.Path)
+		request := testRequest{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		check(request)
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","result":%s,"id":1}`, result)
+	}))
+	return NewClient(server.URL, "C", time.Second), server.Close
+}
+
+func TestBlockNumber(t *testing.T) {
+	client, closeServer := newTestClient(t, `"0x1b4"`, func(request testRequest) {
+		assert.Equal(t, "eth_blockNumber", request.Method)
+	})
+	defer closeServer()
+
+	blockNumber, err := client.BlockNumber()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(436), blockNumber)
+}
+
+func TestGetBalance(t *testing.T) {
+	address := "0x8db97c7cece249c2b98bdc0226cc4c2a57bf52fc"
+	client, closeServer := newTestClient(t, `"0x21e19e0c9bab2400000"`, func(request testRequest) {
+		assert.Equal(t, "eth_getBalance", request.Method)
+		assert.Equal(t, []json.RawMessage{json.RawMessage(`"` + address + `"`), json.RawMessage(`"latest"`)}, request.Params)
+	})
+	defer closeServer()
+
+	balance, err := client.GetBalance(address)
+	assert.NoError(t, err)
+	expected, _ := new(big.Int).SetString("10000000000000000000000", 10)
+	assert.Equal(t, 0, expected.Cmp(balance))
+}
+
+func TestSendRawTransaction(t *testing.T) {
+	txHash := "0xe670ec64341771606e55d6b4ca35a1a6b75ee3d5145a99d05921026d1527331"
+	client, closeServer := newTestClient(t, `"`+txHash+`"`, func(request testRequest) {
+		assert.Equal(t, "eth_sendRawTransaction", request.Method)
+		assert.Equal(t, []json.RawMessage{json.RawMessage(`"0xdeadbeef"`)}, request.Params)
+	})
+	defer closeServer()
+
+	result, err := client.SendRawTransaction([]byte{0xde, 0xad, 0xbe, 0xef})
+	assert.NoError(t, err)
+	assert.Equal(t, txHash, result)
+}
+
+func TestGetTransactionReceipt(t *testing.T) {
+	receiptJSON := `{
+		"transactionHash": "0xabc",
+		"blockHash": "0xdef",
+		"blockNumber": "0x10",
+		"gasUsed": "0x5208",
+		"contractAddress": "0x5fbdb2315678afecb367f032d93f642f64180aa3",
+		"status": "0x1"
+	}`
+	client, closeServer := newTestClient(t, receiptJSON, func(request testRequest) {
+		assert.Equal(t, "eth_getTransactionReceipt", request.Method)
+	})
+	defer closeServer()
+
+	receipt, err := client.GetTransactionReceipt("0xabc")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(16), uint64(receipt.BlockNumber))
+	assert.Equal(t, uint64(21000), uint64(receipt.GasUsed))
+	assert.Equal(t, "0x5fbdb2315678afecb367f032d93f642f64180aa3", receipt.ContractAddress)
+	assert.True(t, receipt.Succeeded())
+}
+
+func TestGetTransactionReceiptOfPendingTx(t *testing.T) {
+	client, closeServer := newTestClient(t, `null`, func(request testRequest) {})
+	defer closeServer()
+
+	receipt, err := client.GetTransactionReceipt("0xabc")
+	assert.NoError(t, err)
+	assert.Nil(t, receipt)
+}
+
+func TestInvalidHexQuantity(t *testing.T) {
+	var value HexUint64
+	assert.Error(t, json.Unmarshal([]byte(`"10"`), &value))
+	assert.Error(t, json.Unmarshal([]byte(`"0x"`), &value))
+	assert.Error(t, json.Unmarshal([]byte(`16`), &value))
+}
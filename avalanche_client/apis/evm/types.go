@@ -0,0 +1,123 @@
+package evm
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ava-labs/avalanchego/api"
+	cjson "github.com/ava-labs/avalanchego/utils/json"
+)
+
+const (
+	// The status of a transaction receipt whose transaction executed successfully
+	receiptStatusSuccessful = 1
+)
+
+// HexUint64 is a uint64 that's encoded as a "0x"-prefixed hex string, as Ethereum JSON-RPC quantities are
+type HexUint64 uint64
+
+// UnmarshalJSON implements json.Unmarshaler
+func (h *HexUint64) UnmarshalJSON(bytes []byte) error {
+	digits, err := unmarshalHexQuantity(bytes)
+	if err != nil {
+		return err
+	}
+	value, err := strconv.ParseUint(digits, 16, 64)
+	if err != nil {
+		return fmt.Errorf("invalid hex quantity '%s': %w", digits, err)
+	}
+	*h = HexUint64(value)
+	return nil
+}
+
+// HexBig is an arbitrarily large integer that's encoded as a "0x"-prefixed hex string, like balances in wei
+type HexBig big.Int
+
+// UnmarshalJSON implements json.Unmarshaler
+func (h *HexBig) UnmarshalJSON(bytes []byte) error {
+	digits, err := unmarshalHexQuantity(bytes)
+	if err != nil {
+		return err
+	}
+	value, ok := new(big.Int).SetString(digits, 16)
+	if !ok {
+		return fmt.Errorf("invalid hex quantity '%s'", digits)
+	}
+	*h = HexBig(*value)
+	return nil
+}
+
+// Int returns the value as a big.Int
+func (h *HexBig) Int() *big.Int {
+	return (*big.Int)(h)
+}
+
+// unmarshalHexQuantity returns the hex digits of a JSON string holding a "0x"-prefixed hex quantity
+func unmarshalHexQuantity(bytes []byte) (string, error) {
+	var str string
+	if err := json.Unmarshal(bytes, &str); err != nil {
+		return "", fmt.Errorf("hex quantity must be a string: %w", err)
+	}
+	if !strings.HasPrefix(str, "0x") || len(str) == 2 {
+		return "", fmt.Errorf("hex quantity '%s' must be a nonempty hex string with a 0x prefix", str)
+	}
+	return str[2:], nil
+}
+
+// TransactionReceipt is the part of an Ethereum transaction receipt that tests need
+type TransactionReceipt struct {
+	TransactionHash string    `json:"transactionHash"`
+	BlockHash       string    `json:"blockHash"`
+	BlockNumber     HexUint64 `json:"blockNumber"`
+	GasUsed         HexUint64 `json:"gasUsed"`
+	// The address of the contract created by the transaction, or empty if it didn't create one
+	ContractAddress string    `json:"contractAddress"`
+	Status          HexUint64 `json:"status"`
+}
+
+// Succeeded returns true if the transaction executed successfully, rather than being reverted
+func (receipt *TransactionReceipt) Succeeded() bool {
+	return receipt.Status == receiptStatusSuccessful
+}
+
+// ExportAVAXArgs are the arguments to avax.exportAVAX
+type ExportAVAXArgs struct {
+	api.UserPass
+
+	// Amount of nAVAX to export
+	Amount cjson.Uint64 `json:"amount"`
+
+	// X Chain address, in the "X-..." form, that the AVAX is exported to
+	To string `json:"to"`
+}
+
+// ImportAVAXArgs are the arguments to avax.importAVAX
+type ImportAVAXArgs struct {
+	api.UserPass
+
+	// Chain the AVAX was exported from
+	SourceChain string `json:"sourceChain"`
+
+	// Hex address that the AVAX is credited to
+	To string `json:"to"`
+}
+
+// ImportKeyArgs are the arguments to avax.importKey
+type ImportKeyArgs struct {
+	api.UserPass
+	PrivateKey string `json:"privateKey"`
+}
+
+// ExportKeyArgs are the arguments to avax.exportKey
+type ExportKeyArgs struct {
+	api.UserPass
+	Address string `json:"address"`
+}
+
+// ExportKeyReply is the reply of avax.exportKey
+type ExportKeyReply struct {
+	PrivateKey string `json:"privateKey"`
+}
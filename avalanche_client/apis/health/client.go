@@ -1,10 +1,11 @@
 package health
 
 import (
+	"context"
+	"fmt"
 	"time"
 
 	"github.com/ava-labs/avalanche-testing/avalanche_client/utils"
-	"github.com/ava-labs/avalanchego/api/health"
 )
 
 // Client for Avalanche Health API Endpoint
@@ -14,32 +15,109 @@ type Client struct {
 
 // NewClient returns a client to interact with Health API endpoint
 func NewClient(uri string, requestTimeout time.Duration) *Client {
+	return NewClientWithTransport(uri, utils.NewTransport(utils.DefaultTransportOptions(requestTimeout)))
+}
+
+// NewClientWithTransport returns a client for the Health API endpoint that sends its requests through the given transport
+func NewClientWithTransport(uri string, transport *utils.Transport) *Client {
 	return &Client{
-		requester: utils.NewEndpointRequester(uri, "/ext/health", "health", requestTimeout),
+		requester: utils.NewEndpointRequesterWithTransport(uri, "/ext/health", "health", transport),
+	}
+}
+
+// WithContext returns a copy of the client whose requests are made with the given context
+func (c *Client) WithContext(ctx context.Context) *Client {
+	return &Client{
+		requester: utils.WithContext(ctx, c.requester),
+	}
+}
+
+// CheckError is the error that a health check failed with
+type CheckError struct {
+	Message string      `json:"message,omitempty"`
+	Cause   *CheckError `json:"cause,omitempty"`
+}
+
+// Error implements the error interface
+func (err *CheckError) Error() string {
+	if err.Cause == nil {
+		return err.Message
+	}
+	return fmt.Sprintf("%v: %v", err.Message, err.Cause.Error())
+}
+
+// CheckResult is the outcome of the last run of one of a node's health checks
+// NOTE: avalanchego's own reply type can't be used, because the error of a failing check doesn't unmarshal into an
+// 	error interface
+type CheckResult struct {
+	// Details of the outcome, in a format that depends on the check
+	Message interface{} `json:"message,omitempty"`
+
+	// The error the check failed with, or nil if it passed
+	Error *CheckError `json:"error,omitempty"`
+
+	// When the check was last run, and how long it took
+	Timestamp time.Time     `json:"timestamp"`
+	Duration  time.Duration `json:"duration"`
+
+	// The number of runs in a row that the check has failed, and when the first of them was
+	ContiguousFailures int64      `json:"contiguousFailures"`
+	TimeOfFirstFailure *time.Time `json:"timeOfFirstFailure"`
+}
+
+// IsFailing returns true if the last run of the check failed
+func (result CheckResult) IsFailing() bool {
+	return result.Error != nil || result.ContiguousFailures > 0
+}
+
+// LivenessReply is the full reply of the getLiveness endpoint, with the result of each of the node's named checks
+type LivenessReply struct {
+	Checks  map[string]CheckResult `json:"checks"`
+	Healthy bool                   `json:"healthy"`
+}
+
+// GetFailingChecks returns a mapping of the names of the checks whose last run failed -> why they failed
+func (reply LivenessReply) GetFailingChecks() map[string]string {
+	result := make(map[string]string)
+	for name, check := range reply.Checks {
+		if !check.IsFailing() {
+			continue
+		}
+		reason := fmt.Sprintf("failed %v times in a row", check.ContiguousFailures)
+		if check.Error != nil {
+			reason = fmt.Sprintf("%v (%v)", check.Error.Error(), reason)
+		}
+		result[name] = reason
 	}
+	return result
 }
 
-// GetLiveness returns a health check on the Avalanche node
-func (c *Client) GetLiveness() (*health.GetLivenessReply, error) {
-	res := &health.GetLivenessReply{}
+// GetLiveness returns the result of each of the Avalanche node's health checks, and whether it's healthy overall
+func (c *Client) GetLiveness() (*LivenessReply, error) {
+	res := &LivenessReply{}
 	err := c.requester.SendRequest("getLiveness", struct{}{}, res)
 	return res, err
 }
 
 // AwaitHealthy queries the GetLiveness endpoint [checks] times, with a pause of [interval]
-// in between checks and returns early if GetLiveness returns healthy
+// in between checks and returns early if GetLiveness returns healthy. If the node never
+// reports itself as healthy, the returned error says which checks were failing on the last
+// query, or why the last query failed.
 func (c *Client) AwaitHealthy(checks int, interval time.Duration) (bool, error) {
+	var lastErr error
 	for i := 0; i < checks; i++ {
 		time.Sleep(interval)
 		res, err := c.GetLiveness()
 		if err != nil {
+			lastErr = err
 			continue
 		}
 
 		if res.Healthy {
 			return true, nil
 		}
+		lastErr = fmt.Errorf("node isn't healthy; failing checks: %v", res.GetFailingChecks())
 	}
 
-	return false, nil
+	return false, lastErr
 }
@@ -0,0 +1,96 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ava-labs/avalanche-testing/avalanche_client/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockClient struct {
+	response string
+	err      error
+}
+
+// NewMockClient returns a mock client that decodes the given JSON as the result of every request
+func NewMockClient(response string, err error) utils.EndpointRequester {
+	return &mockClient{
+		response: response,
+		err:      err,
+	}
+}
+
+func (mc *mockClient) SendRequest(method string, params interface{}, reply interface{}) error {
+	return mc.SendRequestWithContext(context.Background(), method, params, reply)
+}
+
+func (mc *mockClient) SendRequestWithContext(ctx context.Context, method string, params interface{}, reply interface{}) error {
+	if mc.err != nil {
+		return mc.err
+	}
+	return json.Unmarshal([]byte(mc.response), reply)
+}
+
+func TestGetLivenessDecodesFailingChecks(t *testing.T) {
+	mockClient := Client{requester: NewMockClient(`{
+		"checks": {
+			"chains.default.bootstrapped": {
+				"message": ["X"],
+				"error": {"message": "chains not bootstrapped"},
+				"timestamp": "2020-09-01T10:00:00Z",
+				"duration": 1000,
+				"contiguousFailures": 3,
+				"timeOfFirstFailure": "2020-09-01T09:59:30Z"
+			},
+			"network.validators.heartbeat": {
+				"message": {"heartbeat": 1598954400},
+				"timestamp": "2020-09-01T10:00:00Z",
+				"duration": 500,
+				"contiguousFailures": 0,
+				"timeOfFirstFailure": null
+			}
+		},
+		"healthy": false
+	}`, nil)}
+
+	reply, err := mockClient.GetLiveness()
+	assert.NoError(t, err)
+	assert.False(t, reply.Healthy)
+	assert.Len(t, reply.Checks, 2)
+
+	bootstrapped := reply.Checks["chains.default.bootstrapped"]
+	assert.True(t, bootstrapped.IsFailing())
+	assert.Equal(t, int64(3), bootstrapped.ContiguousFailures)
+	assert.Equal(t, time.Date(2020, 9, 1, 10, 0, 0, 0, time.UTC), bootstrapped.Timestamp)
+	assert.Equal(t, time.Date(2020, 9, 1, 9, 59, 30, 0, time.UTC), *bootstrapped.TimeOfFirstFailure)
+	assert.False(t, reply.Checks["network.validators.heartbeat"].IsFailing())
+	assert.Equal(t, map[string]string{
+		"chains.default.bootstrapped": "chains not bootstrapped (failed 3 times in a row)",
+	}, reply.GetFailingChecks())
+}
+
+func TestAwaitHealthyReportsLastFailure(t *testing.T) {
+	mockClient := Client{requester: NewMockClient(`{"checks": {}, "healthy": true}`, nil)}
+	healthy, err := mockClient.AwaitHealthy(2, 0)
+	assert.NoError(t, err)
+	assert.True(t, healthy)
+
+	requestErr := errors.New("connection refused")
+	mockClient = Client{requester: NewMockClient("", requestErr)}
+	healthy, err = mockClient.AwaitHealthy(2, 0)
+	assert.Equal(t, requestErr, err)
+	assert.False(t, healthy)
+
+	mockClient = Client{requester: NewMockClient(`{
+		"checks": {"network": {"error": {"message": "no peers"}, "contiguousFailures": 1}},
+		"healthy": false
+	}`, nil)}
+	healthy, err = mockClient.AwaitHealthy(2, 0)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "no peers")
+	assert.False(t, healthy)
+}
@@ -1,11 +1,12 @@
 package info
 
 import (
+	"context"
 	"time"
 
 	"github.com/ava-labs/avalanche-testing/avalanche_client/utils"
 	"github.com/ava-labs/avalanchego/api/info"
-	"github.com/ava-labs/avalanchego/network"
+	cjson "github.com/ava-labs/avalanchego/utils/json"
 )
 
 // Client is an Info API Client
@@ -15,8 +16,20 @@ type Client struct {
 
 // NewClient returns a new Info API Client
 func NewClient(uri string, requestTimeout time.Duration) *Client {
+	return NewClientWithTransport(uri, utils.NewTransport(utils.DefaultTransportOptions(requestTimeout)))
+}
+
+// NewClientWithTransport returns a client for the Info API endpoint that sends its requests through the given transport
+func NewClientWithTransport(uri string, transport *utils.Transport) *Client {
 	return &Client{
-		requester: utils.NewEndpointRequester(uri, "/ext/info", "info", requestTimeout),
+		requester: utils.NewEndpointRequesterWithTransport(uri, "/ext/info", "info", transport),
+	}
+}
+
+// WithContext returns a copy of the client whose requests are made with the given context
+func (c *Client) WithContext(ctx context.Context) *Client {
+	return &Client{
+		requester: utils.WithContext(ctx, c.requester),
 	}
 }
 
@@ -48,14 +61,34 @@ func (c *Client) GetBlockchainID() (string, error) {
 	return res.BlockchainID, err
 }
 
-// Peers ...
-func (c *Client) Peers() ([]network.PeerID, error) {
-	res := &info.PeersReply{}
+// Peer is a node that the node is connected to
+type Peer struct {
+	// The IP the connection is with, and the IP the peer says it can be reached at
+	IP       string `json:"ip"`
+	PublicIP string `json:"publicIP"`
+
+	ID      string `json:"id"`
+	Version string `json:"version"`
+
+	// When the node last sent a message to and received a message from the peer
+	LastSent     time.Time `json:"lastSent"`
+	LastReceived time.Time `json:"lastReceived"`
+}
+
+// PeersReply is the reply of the peers endpoint
+type PeersReply struct {
+	Peers []Peer `json:"peers"`
+}
+
+// Peers returns the nodes that the node is connected to
+func (c *Client) Peers() ([]Peer, error) {
+	res := &PeersReply{}
 	err := c.requester.SendRequest("peers", struct{}{}, res)
 	return res.Peers, err
 }
 
-// IsBootstrapped ...
+// IsBootstrapped returns whether the chain with the given ID or alias (e.g. "X", "P" or the ID of a subnet's
+// blockchain) is done bootstrapping
 func (c *Client) IsBootstrapped(chain string) (bool, error) {
 	res := &info.IsBootstrappedResponse{}
 	err := c.requester.SendRequest("isBootstrapped", &info.IsBootstrappedArgs{
@@ -63,3 +96,47 @@ func (c *Client) IsBootstrapped(chain string) (bool, error) {
 	}, res)
 	return res.IsBootstrapped, err
 }
+
+// GetNodeVersionReply is the reply of the getNodeVersion endpoint
+type GetNodeVersionReply struct {
+	Version string `json:"version"`
+}
+
+// GetNodeVersion returns the version the node runs (e.g. "avalanche/0.8.3")
+func (c *Client) GetNodeVersion() (string, error) {
+	res := &GetNodeVersionReply{}
+	err := c.requester.SendRequest("getNodeVersion", struct{}{}, res)
+	return res.Version, err
+}
+
+// GetTxFeeReply is the reply of the getTxFee endpoint
+type GetTxFeeReply struct {
+	TxFee         cjson.Uint64 `json:"txFee"`
+	CreationTxFee cjson.Uint64 `json:"creationTxFee"`
+}
+
+// GetTxFee returns the fee of a transaction, and of a transaction that creates something (e.g. an asset or a
+// subnet) on nodes that charge those differently
+// NOTE: Nodes older than the getTxFee endpoint return a method not found error
+func (c *Client) GetTxFee() (*GetTxFeeReply, error) {
+	res := &GetTxFeeReply{}
+	err := c.requester.SendRequest("getTxFee", struct{}{}, res)
+	return res, err
+}
+
+// UptimeReply is the reply of the uptime endpoint, as percentages from 0 to 100
+type UptimeReply struct {
+	// The share of stake that considers the node up for long enough to be rewarded
+	RewardingStakePercentage float64 `json:"rewardingStakePercentage,string"`
+
+	// The node's uptime as seen by the other validators, weighted by their stake
+	WeightedAveragePercentage float64 `json:"weightedAveragePercentage,string"`
+}
+
+// Uptime returns how long the node has been up as seen by the other validators, which only validators can query
+// NOTE: Nodes older than the uptime endpoint return a method not found error
+func (c *Client) Uptime() (*UptimeReply, error) {
+	res := &UptimeReply{}
+	err := c.requester.SendRequest("uptime", struct{}{}, res)
+	return res, err
+}
@@ -0,0 +1,81 @@
+package info
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testRequest struct {
+	Method string `json:"method"`
+}
+
+// newTestClient returns a Client pointed at a server that checks every request calls the given method of the Info API
+// and answers it with the given result
+func newTestClient(t *testing.T, method string, result string) (*Client, func()) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/ext/info", r.URL.Path)
+		request := testRequest{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		assert.Equal(t, "info."+method, request.Method)
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","result":%s,"id":1}`, result)
+	}))
+	return NewClient(server.URL, time.Second), server.Close
+}
+
+func TestPeers(t *testing.T) {
+	client, closeServer := newTestClient(t, "peers", `{"peers":[{
+		"ip": "172.17.0.3:9651",
+		"publicIP": "172.17.0.3:9651",
+		"id": "NodeID-7Xhw2mDxuDS44j42TCB6U5579esbSt3Lg",
+		"version": "avalanche/0.8.3",
+		"lastSent": "2020-09-01T10:00:00Z",
+		"lastReceived": "2020-09-01T10:00:01Z"
+	}]}`)
+	defer closeServer()
+
+	peers, err := client.Peers()
+	assert.NoError(t, err)
+	assert.Equal(t, []Peer{{
+		IP:           "172.17.0.3:9651",
+		PublicIP:     "172.17.0.3:9651",
+		ID:           "NodeID-7Xhw2mDxuDS44j42TCB6U5579esbSt3Lg",
+		Version:      "avalanche/0.8.3",
+		LastSent:     time.Date(2020, 9, 1, 10, 0, 0, 0, time.UTC),
+		LastReceived: time.Date(2020, 9, 1, 10, 0, 1, 0, time.UTC),
+	}}, peers)
+}
+
+func TestGetNodeVersion(t *testing.T) {
+	client, closeServer := newTestClient(t, "getNodeVersion", `{"version":"avalanche/0.8.3"}`)
+	defer closeServer()
+
+	version, err := client.GetNodeVersion()
+	assert.NoError(t, err)
+	assert.Equal(t, "avalanche/0.8.3", version)
+}
+
+func TestGetTxFee(t *testing.T) {
+	client, closeServer := newTestClient(t, "getTxFee", `{"txFee":"1000000","creationTxFee":"10000000"}`)
+	defer closeServer()
+
+	fees, err := client.GetTxFee()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1000000), uint64(fees.TxFee))
+	assert.Equal(t, uint64(10000000), uint64(fees.CreationTxFee))
+}
+
+func TestUptime(t *testing.T) {
+	client, closeServer := newTestClient(t, "uptime", `{"rewardingStakePercentage":"100.0000","weightedAveragePercentage":"99.5000"}`)
+	defer closeServer()
+
+	uptime, err := client.Uptime()
+	assert.NoError(t, err)
+	assert.Equal(t, 100.0, uptime.RewardingStakePercentage)
+	assert.Equal(t, 99.5, uptime.WeightedAveragePercentage)
+}
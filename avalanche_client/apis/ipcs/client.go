@@ -1,6 +1,7 @@
 package ipcs
 
 import (
+	"context"
 	"time"
 
 	"github.com/ava-labs/avalanche-testing/avalanche_client/utils"
@@ -15,8 +16,20 @@ type Client struct {
 
 // NewClient returns a Client for interacting with the IPCS endpoint
 func NewClient(uri string, requestTimeout time.Duration) *Client {
+	return NewClientWithTransport(uri, utils.NewTransport(utils.DefaultTransportOptions(requestTimeout)))
+}
+
+// NewClientWithTransport returns a client for the IPCS API endpoint that sends its requests through the given transport
+func NewClientWithTransport(uri string, transport *utils.Transport) *Client {
+	return &Client{
+		requester: utils.NewEndpointRequesterWithTransport(uri, "/ext/ipcs", "ipcs", transport),
+	}
+}
+
+// WithContext returns a copy of the client whose requests are made with the given context
+func (c *Client) WithContext(ctx context.Context) *Client {
 	return &Client{
-		requester: utils.NewEndpointRequester(uri, "/ext/ipcs", "ipcs", requestTimeout),
+		requester: utils.WithContext(ctx, c.requester),
 	}
 }
 
@@ -4,6 +4,7 @@
 package keystore
 
 import (
+	"context"
 	"time"
 
 	"github.com/ava-labs/avalanche-testing/avalanche_client/utils"
@@ -18,8 +19,20 @@ type Client struct {
 }
 
 func NewClient(uri string, requestTimeout time.Duration) *Client {
+	return NewClientWithTransport(uri, utils.NewTransport(utils.DefaultTransportOptions(requestTimeout)))
+}
+
+// NewClientWithTransport returns a client for the Keystore API endpoint that sends its requests through the given transport
+func NewClientWithTransport(uri string, transport *utils.Transport) *Client {
+	return &Client{
+		requester: utils.NewEndpointRequesterWithTransport(uri, "/ext/keystore", "keystore", transport),
+	}
+}
+
+// WithContext returns a copy of the client whose requests are made with the given context
+func (c *Client) WithContext(ctx context.Context) *Client {
 	return &Client{
-		requester: utils.NewEndpointRequester(uri, "/ext/keystore", "keystore", requestTimeout),
+		requester: utils.WithContext(ctx, c.requester),
 	}
 }
 
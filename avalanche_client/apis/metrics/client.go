@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ava-labs/avalanche-testing/avalanche_client/utils"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// Client for the Prometheus metrics endpoint of an Avalanche node, which is plain HTTP rather than JSON-RPC
+type Client struct {
+	url       string
+	transport *utils.Transport
+	ctx       context.Context
+}
+
+// NewClient returns a client to scrape the metrics endpoint
+func NewClient(uri string, requestTimeout time.Duration) *Client {
+	return NewClientWithTransport(uri, utils.NewTransport(utils.DefaultTransportOptions(requestTimeout)))
+}
+
+// NewClientWithTransport returns a client for the metrics endpoint that sends its requests through the given transport
+func NewClientWithTransport(uri string, transport *utils.Transport) *Client {
+	return &Client{
+		url:       uri + "/ext/metrics",
+		transport: transport,
+		ctx:       context.Background(),
+	}
+}
+
+// WithContext returns a copy of the client whose requests are made with the given context
+func (c *Client) WithContext(ctx context.Context) *Client {
+	return &Client{
+		url:       c.url,
+		transport: c.transport,
+		ctx:       ctx,
+	}
+}
+
+// GetMetrics returns the node's current metrics, by metric family name
+func (c *Client) GetMetrics() (map[string]*dto.MetricFamily, error) {
+	body, err := c.transport.Get(c.ctx, c.url)
+	if err != nil {
+		return nil, err
+	}
+	parser := expfmt.TextParser{}
+	families, err := parser.TextToMetricFamilies(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("problem parsing the metrics from %s: %w", c.url, err)
+	}
+	return families, nil
+}
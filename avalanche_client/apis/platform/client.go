@@ -4,6 +4,7 @@
 package platform
 
 import (
+	"context"
 	"time"
 
 	"github.com/ava-labs/avalanchego/api"
@@ -22,8 +23,20 @@ type Client struct {
 
 // NewClient returns a Client for interacting with the P Chain endpoint
 func NewClient(uri string, requestTimeout time.Duration) *Client {
+	return NewClientWithTransport(uri, utils.NewTransport(utils.DefaultTransportOptions(requestTimeout)))
+}
+
+// NewClientWithTransport returns a client for the Platform API endpoint that sends its requests through the given transport
+func NewClientWithTransport(uri string, transport *utils.Transport) *Client {
+	return &Client{
+		requester: utils.NewEndpointRequesterWithTransport(uri, "/ext/P", "platform", transport),
+	}
+}
+
+// WithContext returns a copy of the client whose requests are made with the given context
+func (c *Client) WithContext(ctx context.Context) *Client {
 	return &Client{
-		requester: utils.NewEndpointRequester(uri, "/ext/P", "platform", requestTimeout),
+		requester: utils.WithContext(ctx, c.requester),
 	}
 }
 
@@ -262,6 +275,18 @@ func (c *Client) GetBlockchains() ([]platformvm.APIBlockchain, error) {
 	return res.Blockchains, err
 }
 
+// IssueTx issues the signed transaction [txBytes] to the P Chain and returns its ID
+func (c *Client) IssueTx(txBytes []byte) (ids.ID, error) {
+	res := &platformvm.IssueTxResponse{}
+	err := c.requester.SendRequest("issueTx", &platformvm.IssueTxArgs{
+		Tx: formatting.CB58{Bytes: txBytes},
+	}, res)
+	if err != nil {
+		return ids.Empty, err
+	}
+	return res.TxID, nil
+}
+
 // GetTx returns the byte representation of the transaction corresponding to [txID]
 func (c *Client) GetTx(txID ids.ID) ([]byte, error) {
 	res := &platformvm.GetTxResponse{}
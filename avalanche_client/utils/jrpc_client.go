@@ -4,74 +4,57 @@
 package utils
 
 import (
-	"bytes"
+	"context"
 	"fmt"
-	"net/http"
 	"strings"
 	"time"
-
-	"github.com/sirupsen/logrus"
-
-	rpc "github.com/gorilla/rpc/v2/json2"
 )
 
 // ============= RPC Requester ===================
-const (
-	defaultMinID = 1
-	defaultMaxID = 1000000
-)
 
 // AvalancheRPCRequester ...
 type AvalancheRPCRequester interface {
 	SendJSONRPCRequest(endpoint string, method string, params interface{}, reply interface{}) error
+	SendJSONRPCRequestWithContext(ctx context.Context, endpoint string, method string, params interface{}, reply interface{}) error
 }
 
 type jsonRPCRequester struct {
-	uri    string
-	client http.Client
+	uri       string
+	transport *Transport
 }
 
 // NewAvalancheRPCRequester ...
 func NewAvalancheRPCRequester(uri string, requestTimeout time.Duration) AvalancheRPCRequester {
+	return NewAvalancheRPCRequesterWithTransport(uri, NewTransport(DefaultTransportOptions(requestTimeout)))
+}
+
+// NewAvalancheRPCRequesterWithTransport creates a requester that sends its requests through the given transport
+func NewAvalancheRPCRequesterWithTransport(uri string, transport *Transport) AvalancheRPCRequester {
 	return &jsonRPCRequester{
-		uri: uri,
-		client: http.Client{
-			Timeout: requestTimeout,
-		},
+		uri:       uri,
+		transport: transport,
 	}
 }
 
 // SendJSONRPCRequest ...
 func (requester jsonRPCRequester) SendJSONRPCRequest(endpoint string, method string, params interface{}, reply interface{}) error {
+	return requester.SendJSONRPCRequestWithContext(context.Background(), endpoint, method, params, reply)
+}
+
+// SendJSONRPCRequestWithContext sends a request that gets cancelled (including any retries) when the context is done
+func (requester jsonRPCRequester) SendJSONRPCRequestWithContext(ctx context.Context, endpoint string, method string, params interface{}, reply interface{}) error {
 	// Golang has a nasty & subtle behaviour where duplicated '//' in the URL is treated as GET, even if it's POST
 	// https://stackoverflow.com/questions/23463601/why-golang-treats-my-post-request-as-a-get-one
 	endpoint = strings.TrimLeft(endpoint, "/")
 
-	requestBodyBytes, err := rpc.EncodeClientRequest(method, params)
-	if err != nil {
-		return fmt.Errorf("problem marshaling request to endpoint '%v' with method '%v' and params '%v': %w", endpoint, method, params, err)
-	}
-
 	url := fmt.Sprintf("%v/%v", requester.uri, endpoint)
-	logrus.Tracef("Sending request to %s:\n%s\n", url, requestBodyBytes)
-	resp, err := requester.client.Post(url, "application/json", bytes.NewBuffer(requestBodyBytes))
-	if err != nil {
-		return fmt.Errorf("problem while making JSON RPC POST request to %s: %s", url, err)
-	}
-	defer resp.Body.Close()
-	statusCode := resp.StatusCode
-
-	// Return an error for any non successful status code
-	if statusCode < 200 || statusCode > 299 {
-		return fmt.Errorf("received status code '%v'", statusCode)
-	}
-
-	return rpc.DecodeClientResponse(resp.Body, reply)
+	return requester.transport.Call(ctx, url, method, params, reply)
 }
 
 // EndpointRequester ...
 type EndpointRequester interface {
 	SendRequest(method string, params interface{}, reply interface{}) error
+	SendRequestWithContext(ctx context.Context, method string, params interface{}, reply interface{}) error
 }
 
 type avalancheEndpointRequester struct {
@@ -81,18 +64,55 @@ type avalancheEndpointRequester struct {
 
 // NewEndpointRequester ...
 func NewEndpointRequester(uri, endpoint, base string, requestTimeout time.Duration) EndpointRequester {
+	return NewEndpointRequesterWithTransport(uri, endpoint, base, NewTransport(DefaultTransportOptions(requestTimeout)))
+}
+
+// NewEndpointRequesterWithTransport creates a requester for an API endpoint that sends its requests through the given transport
+func NewEndpointRequesterWithTransport(uri, endpoint, base string, transport *Transport) EndpointRequester {
 	return &avalancheEndpointRequester{
-		requester: NewAvalancheRPCRequester(uri, requestTimeout),
+		requester: NewAvalancheRPCRequesterWithTransport(uri, transport),
 		endpoint:  endpoint,
 		base:      base,
 	}
 }
 
 func (e *avalancheEndpointRequester) SendRequest(method string, params interface{}, reply interface{}) error {
-	return e.requester.SendJSONRPCRequest(
+	return e.SendRequestWithContext(context.Background(), method, params, reply)
+}
+
+func (e *avalancheEndpointRequester) SendRequestWithContext(ctx context.Context, method string, params interface{}, reply interface{}) error {
+	// Endpoints without a base (e.g. the C Chain's Ethereum JSON-RPC endpoint) take the bare method name
+	if e.base != "" {
+		method = fmt.Sprintf("%s.%s", e.base, method)
+	}
+	return e.requester.SendJSONRPCRequestWithContext(
+		ctx,
 		e.endpoint,
-		fmt.Sprintf("%s.%s", e.base, method),
+		method,
 		params,
 		reply,
 	)
 }
+
+// contextEndpointRequester sends every request with a fixed context
+type contextEndpointRequester struct {
+	ctx       context.Context
+	requester EndpointRequester
+}
+
+// WithContext returns a requester whose SendRequest calls are made with the given context, so that API clients can
+// support cancellation and deadlines without every method needing a context parameter
+func WithContext(ctx context.Context, requester EndpointRequester) EndpointRequester {
+	return &contextEndpointRequester{
+		ctx:       ctx,
+		requester: requester,
+	}
+}
+
+func (c *contextEndpointRequester) SendRequest(method string, params interface{}, reply interface{}) error {
+	return c.requester.SendRequestWithContext(c.ctx, method, params, reply)
+}
+
+func (c *contextEndpointRequester) SendRequestWithContext(ctx context.Context, method string, params interface{}, reply interface{}) error {
+	return c.requester.SendRequestWithContext(ctx, method, params, reply)
+}
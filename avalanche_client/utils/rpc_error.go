@@ -0,0 +1,97 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package utils
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode"
+)
+
+// RPCError is returned when a node answers a JSON-RPC request with a non-2xx status code or a JSON-RPC error, or a plain
+// HTTP request with a non-2xx status code, and keeps everything the node sent back so callers can tell failures apart
+type RPCError struct {
+	// The URL & JSON-RPC method that the request was sent to, or the HTTP method for plain HTTP requests
+	URL    string
+	Method string
+
+	// The HTTP status code of the response
+	StatusCode int
+
+	// The raw body of the response
+	Body string
+
+	// The JSON-RPC error code and message, if the response contained a JSON-RPC error (Code is 0 otherwise)
+	Code    int
+	Message string
+}
+
+// Error implements the error interface
+func (e *RPCError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf(
+			"request to %v with method '%v' failed with status code '%v' and JSON-RPC error %v: %v",
+			e.URL,
+			e.Method,
+			e.StatusCode,
+			e.Code,
+			e.Message)
+	}
+	return fmt.Sprintf(
+		"request to %v with method '%v' failed with status code '%v'; body: %v",
+		e.URL,
+		e.Method,
+		e.StatusCode,
+		e.Body)
+}
+
+// IsRetryable returns true if the error came from the server failing rather than from the request being bad, and the
+// request was read-only, in which case sending the same request again may succeed. A request that changes state (e.g.
+// issuing a transaction) isn't retryable because the server may have applied it before failing.
+func (e *RPCError) IsRetryable() bool {
+	return e.StatusCode >= http.StatusInternalServerError && IsReadOnlyMethod(e.Method)
+}
+
+// The leading words of the camel case names of the JSON-RPC methods (after the API's "base." prefix) that only read state
+var readOnlyMethodPrefixes = []string{"get", "is", "list", "sample", "validates", "validatedBy", "peers", "uptime"}
+
+// The Ethereum JSON-RPC methods of the C Chain that only read state, which have no base
+var readOnlyEthMethodPrefixes = []string{
+	"eth_get",
+	"eth_blockNumber",
+	"eth_call",
+	"eth_chainId",
+	"eth_estimateGas",
+	"eth_gasPrice",
+	"net_version",
+	"web3_clientVersion",
+}
+
+// IsReadOnlyMethod returns true if the JSON-RPC method (e.g. "avm.getBalance") or HTTP method is known to only read
+// state, so that sending it twice has the same effect as sending it once
+func IsReadOnlyMethod(method string) bool {
+	if method == http.MethodGet {
+		return true
+	}
+	baseAndName := strings.SplitN(method, ".", 2)
+	if len(baseAndName) < 2 {
+		return hasAnyWordPrefix(method, readOnlyEthMethodPrefixes)
+	}
+	return hasAnyWordPrefix(baseAndName[1], readOnlyMethodPrefixes)
+}
+
+// hasAnyWordPrefix returns true if the camel case string starts with any of the given prefixes followed by the end of
+// the string or the next word, so that e.g. "is" matches "isBootstrapped" but not "issueTx"
+func hasAnyWordPrefix(str string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if !strings.HasPrefix(str, prefix) {
+			continue
+		}
+		if rest := str[len(prefix):]; rest == "" || unicode.IsUpper(rune(rest[0])) {
+			return true
+		}
+	}
+	return false
+}
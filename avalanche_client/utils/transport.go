@@ -0,0 +1,233 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package utils
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"syscall"
+	"time"
+
+	rpc "github.com/gorilla/rpc/v2/json2"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultMaxRetries     = 3
+	defaultInitialBackoff = 100 * time.Millisecond
+	defaultMaxBackoff     = 2 * time.Second
+)
+
+// RPCCall is a single JSON-RPC call going through a Transport
+type RPCCall struct {
+	URL    string
+	Method string
+	Params interface{}
+
+	// The attempt number of this call, starting at 0 and going up every time the call gets retried
+	Attempt int
+}
+
+// CallFunc sends a JSON-RPC call and decodes the result into reply
+type CallFunc func(ctx context.Context, call RPCCall, reply interface{}) error
+
+// Middleware wraps every attempt at a JSON-RPC call made through a Transport, e.g. to log calls, record metrics or
+// inject faults. Middleware runs inside the retry loop, so errors it returns are retried like any other.
+type Middleware func(next CallFunc) CallFunc
+
+// TransportOptions configures a Transport
+type TransportOptions struct {
+	// The timeout of each individual HTTP request; a deadline on the context of a call applies on top of this
+	RequestTimeout time.Duration
+
+	// How many times a call that failed with a retryable error gets retried: connection refused, which means the node
+	//  never saw the call, or a 5xx for a method that only reads state (see IsReadOnlyMethod)
+	MaxRetries int
+
+	// The delay before the first retry, which doubles with each retry up to MaxBackoff
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+
+	// Middleware to wrap calls in, with the first element being the outermost
+	Middleware []Middleware
+}
+
+// DefaultTransportOptions returns the options used by clients that aren't given a Transport
+func DefaultTransportOptions(requestTimeout time.Duration) TransportOptions {
+	return TransportOptions{
+		RequestTimeout: requestTimeout,
+		MaxRetries:     defaultMaxRetries,
+		InitialBackoff: defaultInitialBackoff,
+		MaxBackoff:     defaultMaxBackoff,
+	}
+}
+
+// Transport sends JSON-RPC calls over HTTP, retrying on transient failures. A single Transport can be shared between
+// all the clients talking to a node.
+type Transport struct {
+	client         http.Client
+	maxRetries     int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	call           CallFunc
+}
+
+// NewTransport creates a Transport with the given options
+func NewTransport(options TransportOptions) *Transport {
+	transport := &Transport{
+		client: http.Client{
+			Timeout: options.RequestTimeout,
+		},
+		maxRetries:     options.MaxRetries,
+		initialBackoff: options.InitialBackoff,
+		maxBackoff:     options.MaxBackoff,
+	}
+	call := transport.send
+	for i := len(options.Middleware) - 1; i >= 0; i-- {
+		call = options.Middleware[i](call)
+	}
+	transport.call = call
+	return transport
+}
+
+// Call sends a JSON-RPC call to the given URL, retrying with backoff while it fails with a retryable error and the
+// context isn't done
+func (transport *Transport) Call(ctx context.Context, url string, method string, params interface{}, reply interface{}) error {
+	return transport.retry(ctx, url, method, func(attempt int) error {
+		call := RPCCall{
+			URL:     url,
+			Method:  method,
+			Params:  params,
+			Attempt: attempt,
+		}
+		return transport.call(ctx, call, reply)
+	})
+}
+
+// Get fetches the body of a plain HTTP endpoint (e.g. a node's Prometheus metrics), retrying like Call does
+// NOTE: The transport's middleware only wraps JSON-RPC calls, so it doesn't see these requests
+func (transport *Transport) Get(ctx context.Context, url string) ([]byte, error) {
+	var body []byte
+	err := transport.retry(ctx, url, http.MethodGet, func(int) error {
+		var err error
+		body, err = transport.get(ctx, url)
+		return err
+	})
+	return body, err
+}
+
+// retry makes attempts at a request until one succeeds, fails with an error that isn't retryable, or the retries or
+// context run out
+func (transport *Transport) retry(ctx context.Context, url string, method string, attempt func(attempt int) error) error {
+	backoff := transport.initialBackoff
+	for attemptNum := 0; ; attemptNum++ {
+		err := attempt(attemptNum)
+		if err == nil || attemptNum >= transport.maxRetries || !isRetryable(err) {
+			return err
+		}
+
+		logrus.Debugf("Retrying call to %v with method '%v' in %v after error: %v", url, method, backoff, err)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("gave up retrying call to %s with method '%s' (last error: %v): %w", url, method, err, ctx.Err())
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > transport.maxBackoff {
+			backoff = transport.maxBackoff
+		}
+	}
+}
+
+// send makes a single attempt at a call, with no retrying
+func (transport *Transport) send(ctx context.Context, call RPCCall, reply interface{}) error {
+	requestBodyBytes, err := rpc.EncodeClientRequest(call.Method, call.Params)
+	if err != nil {
+		return fmt.Errorf("problem marshaling request to '%v' with method '%v' and params '%v': %w", call.URL, call.Method, call.Params, err)
+	}
+
+	logrus.Tracef("Sending request to %s:\n%s\n", call.URL, requestBodyBytes)
+	request, err := http.NewRequest(http.MethodPost, call.URL, bytes.NewBuffer(requestBodyBytes))
+	if err != nil {
+		return fmt.Errorf("problem creating JSON RPC POST request to %s: %w", call.URL, err)
+	}
+	request = request.WithContext(ctx)
+	request.Header.Set("Content-Type", "application/json")
+
+	resp, err := transport.client.Do(request)
+	if err != nil {
+		return fmt.Errorf("problem while making JSON RPC POST request to %s: %w", call.URL, err)
+	}
+	defer resp.Body.Close()
+
+	responseBodyBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("problem reading the response body from %s: %w", call.URL, err)
+	}
+
+	rpcErr := &RPCError{
+		URL:        call.URL,
+		Method:     call.Method,
+		StatusCode: resp.StatusCode,
+		Body:       string(responseBodyBytes),
+	}
+	decodeErr := rpc.DecodeClientResponse(bytes.NewReader(responseBodyBytes), reply)
+
+	// Return an error for any non successful status code, keeping the JSON-RPC error if the body had one
+	var jsonRPCErr *rpc.Error
+	if errors.As(decodeErr, &jsonRPCErr) {
+		rpcErr.Code = int(jsonRPCErr.Code)
+		rpcErr.Message = jsonRPCErr.Message
+		return rpcErr
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return rpcErr
+	}
+	if decodeErr != nil {
+		return fmt.Errorf("problem decoding the response from %s: %w", call.URL, decodeErr)
+	}
+	return nil
+}
+
+// get makes a single attempt at a plain GET request, with no retrying
+func (transport *Transport) get(ctx context.Context, url string) ([]byte, error) {
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("problem creating GET request to %s: %w", url, err)
+	}
+	resp, err := transport.client.Do(request.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("problem while making GET request to %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	responseBodyBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("problem reading the response body from %s: %w", url, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, &RPCError{
+			URL:        url,
+			Method:     http.MethodGet,
+			StatusCode: resp.StatusCode,
+			Body:       string(responseBodyBytes),
+		}
+	}
+	return responseBodyBytes, nil
+}
+
+// isRetryable returns true if the error is one that sending the same call again might not hit, and sending it again
+// can't apply the call twice
+func isRetryable(err error) bool {
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) {
+		return rpcErr.IsRetryable()
+	}
+	// The node isn't listening (yet), e.g. because it's still starting up or restarting
+	return errors.Is(err, syscall.ECONNREFUSED)
+}
@@ -0,0 +1,185 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testMethod = "test.getValue"
+
+	// A method that changes state, which must not be retried after the server failed
+	testWriteMethod = "test.issueTx"
+	testResult      = `{"jsonrpc":"2.0","result":{"value":"hello"},"id":1}`
+)
+
+type testReply struct {
+	Value string `json:"value"`
+}
+
+func newTestTransport(middleware ...Middleware) *Transport {
+	return NewTransport(TransportOptions{
+		RequestTimeout: time.Second,
+		MaxRetries:     2,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     time.Millisecond,
+		Middleware:     middleware,
+	})
+}
+
+func TestTransportRetriesServerErrors(t *testing.T) {
+	var numRequests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&numRequests, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, testResult)
+	}))
+	defer server.Close()
+
+	reply := &testReply{}
+	err := newTestTransport().Call(context.Background(), server.URL, testMethod, struct{}{}, reply)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", reply.Value)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&numRequests))
+}
+
+func TestTransportDoesntRetryServerErrorsOfWriteMethods(t *testing.T) {
+	var numRequests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&numRequests, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	err := newTestTransport().Call(context.Background(), server.URL, testWriteMethod, struct{}{}, &testReply{})
+	var rpcErr *RPCError
+	assert.True(t, errors.As(err, &rpcErr), "Expected an RPCError but got: %v", err)
+	assert.Equal(t, http.StatusServiceUnavailable, rpcErr.StatusCode)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&numRequests), "A call the server may have applied shouldn't be retried")
+}
+
+func TestIsReadOnlyMethod(t *testing.T) {
+	readOnlyMethods := []string{
+		"avm.getBalance",
+		"platform.getCurrentValidators",
+		"info.isBootstrapped",
+		"info.peers",
+		"keystore.listUsers",
+		"eth_getBalance",
+		"eth_blockNumber",
+		http.MethodGet,
+	}
+	for _, method := range readOnlyMethods {
+		assert.True(t, IsReadOnlyMethod(method), "Method %v should be read-only", method)
+	}
+	writeMethods := []string{
+		"avm.issueTx",
+		"avm.send",
+		"keystore.createUser",
+		"platform.importAVAX",
+		"platform.addValidator",
+		"admin.startCPUProfiler",
+		"eth_sendRawTransaction",
+	}
+	for _, method := range writeMethods {
+		assert.False(t, IsReadOnlyMethod(method), "Method %v shouldn't be read-only", method)
+	}
+}
+
+func TestTransportGetRetriesServerErrors(t *testing.T) {
+	var numRequests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&numRequests, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		assert.Equal(t, http.MethodGet, r.Method)
+		fmt.Fprint(w, "plain text")
+	}))
+	defer server.Close()
+
+	body, err := newTestTransport().Get(context.Background(), server.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, "plain text", string(body))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&numRequests))
+}
+
+func TestTransportKeepsErrorDetails(t *testing.T) {
+	var numRequests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&numRequests, 1)
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"jsonrpc":"2.0","error":{"code":-32000,"message":"bad things"},"id":1}`)
+	}))
+	defer server.Close()
+
+	err := newTestTransport().Call(context.Background(), server.URL, testMethod, struct{}{}, &testReply{})
+	var rpcErr *RPCError
+	assert.True(t, errors.As(err, &rpcErr), "Expected an RPCError but got: %v", err)
+	assert.Equal(t, http.StatusBadRequest, rpcErr.StatusCode)
+	assert.Equal(t, -32000, rpcErr.Code)
+	assert.Equal(t, "bad things", rpcErr.Message)
+	assert.Contains(t, rpcErr.Body, "bad things")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&numRequests), "Client errors shouldn't be retried")
+}
+
+func TestTransportStopsRetryingWhenContextIsDone(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	transport := NewTransport(TransportOptions{
+		RequestTimeout: time.Second,
+		MaxRetries:     100,
+		InitialBackoff: 50 * time.Millisecond,
+		MaxBackoff:     50 * time.Millisecond,
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := transport.Call(ctx, server.URL, testMethod, struct{}{}, &testReply{})
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), "Expected the context's error but got: %v", err)
+}
+
+func TestTransportMiddleware(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, testResult)
+	}))
+	defer server.Close()
+
+	calls := []string{}
+	recordCalls := func(next CallFunc) CallFunc {
+		return func(ctx context.Context, call RPCCall, reply interface{}) error {
+			calls = append(calls, fmt.Sprintf("%v#%v", call.Method, call.Attempt))
+			return next(ctx, call, reply)
+		}
+	}
+	// Fails the first attempt of every call, like a flaky network would
+	injectFault := func(next CallFunc) CallFunc {
+		return func(ctx context.Context, call RPCCall, reply interface{}) error {
+			if call.Attempt == 0 {
+				return &RPCError{URL: call.URL, Method: call.Method, StatusCode: http.StatusBadGateway}
+			}
+			return next(ctx, call, reply)
+		}
+	}
+
+	reply := &testReply{}
+	err := newTestTransport(recordCalls, injectFault).Call(context.Background(), server.URL, testMethod, struct{}{}, reply)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", reply.Value)
+	assert.Equal(t, []string{testMethod + "#0", testMethod + "#1"}, calls)
+}
@@ -4,15 +4,23 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/ava-labs/avalanche-testing/avalanche/logging"
+	avalancheNetwork "github.com/ava-labs/avalanche-testing/avalanche/networks"
+	"github.com/ava-labs/avalanche-testing/avalanche/services/certs"
 	testsuite "github.com/ava-labs/avalanche-testing/testsuite/kurtosis"
+	"github.com/ava-labs/avalanche-testing/testsuite/report"
+	"github.com/ava-labs/avalanche-testing/testsuite/scenario"
 	"github.com/kurtosis-tech/kurtosis/controller"
 	"github.com/sirupsen/logrus"
 )
 
 /*
 A CLI entrypoint that will be packaged inside a Docker image to form the test controller used for orchestrating test execution
+
 	for tests in the Avalanche E2E test suite.
 */
 func main() {
@@ -52,6 +60,24 @@ func main() {
 		"The name of a pre-built avalanche-byzantine image, either on the local Docker engine or in Docker Hub",
 	)
 
+	upgradeOldImageNameArg := flag.String(
+		"upgrade-old-image-name",
+		"",
+		"The Avalanche image that the rolling upgrade test starts its network on, either on the local Docker engine or in Docker Hub",
+	)
+
+	upgradeNewImageNameArg := flag.String(
+		"upgrade-new-image-name",
+		"",
+		"The Avalanche image that the rolling upgrade test upgrades its nodes to, either on the local Docker engine or in Docker Hub",
+	)
+
+	stakingRewardsImageNameArg := flag.String(
+		"staking-rewards-image-name",
+		"",
+		"The Avalanche image that the staking rewards test runs on, either on the local Docker engine or in Docker Hub",
+	)
+
 	dockerNetworkArg := flag.String(
 		"docker-network",
 		"",
@@ -76,6 +102,36 @@ func main() {
 		"IP address of the gateway address on the Docker network that the test controller is running in",
 	)
 
+	scenariosDirpathArg := flag.String(
+		"scenarios-dir",
+		"",
+		"If set, the directory of YAML/JSON scenario files to register as tests",
+	)
+
+	certSeedArg := flag.String(
+		"cert-seed",
+		"",
+		"If set, the seed that the staking certs of non-boot nodes are derived from, so that they get the same node IDs on every run",
+	)
+
+	certKeyTypeArg := flag.String(
+		"cert-key-type",
+		string(certs.RSAKeyType),
+		fmt.Sprintf("The type of key that certs derived from --cert-seed have (%v or %v)", certs.RSAKeyType, certs.ECDSAKeyType),
+	)
+
+	certCacheDirpathArg := flag.String(
+		"cert-cache-dir",
+		"",
+		"If set, the directory to cache the certs derived from --cert-seed in across runs, keyed by seed, key type and service ID",
+	)
+
+	metricsScrapeIntervalArg := flag.Duration(
+		"metrics-scrape-interval",
+		0,
+		"If positive, how often to scrape the metrics of every node in the test network, which are written to the test's artifacts",
+	)
+
 	logLevelArg := flag.String(
 		"log-level",
 		"info",
@@ -101,9 +157,46 @@ func main() {
 		*avalancheImageNameArg)
 
 	logrus.Debugf("Byzantine image name: %s", *byzantineImageNameArg)
+	logrus.Debugf("Upgrade image names: %s -> %s", *upgradeOldImageNameArg, *upgradeNewImageNameArg)
+	captures := avalancheNetwork.NewCaptureCollector()
+	networkOptions := avalancheNetwork.NetworkOptions{
+		MetricsScrapeInterval: *metricsScrapeIntervalArg,
+		TestVolumeMountpoint:  *testVolumeMountpointArg,
+		ProfilesDirpath:       filepath.Join(report.GetControllerArtifactsDirpath(*testVolumeMountpointArg), report.ProfilesDirname),
+		Captures:              captures,
+		CertCacheDirpath:      *certCacheDirpathArg,
+	}
+	if *certSeedArg != "" {
+		certKeyType, err := certs.ParseKeyType(*certKeyTypeArg)
+		if err != nil {
+			logrus.Fatalf("Invalid cert key type: %v", err)
+			os.Exit(1)
+		}
+		certGenerator, err := certs.NewDeterministicCertGenerator(*certSeedArg, certKeyType, networkOptions.CertCacheDirpath)
+		if err != nil {
+			logrus.Fatalf("Failed to create the cert generator: %v", err)
+			os.Exit(1)
+		}
+		networkOptions.CertGenerator = certGenerator
+		logrus.Infof("Deriving %v node certs from seed '%v'", certKeyType, *certSeedArg)
+	}
+	var scenarios []*scenario.Scenario
+	if *scenariosDirpathArg != "" {
+		loadedScenarios, err := scenario.LoadDir(*scenariosDirpathArg)
+		if err != nil {
+			logrus.Fatalf("Failed to load the scenarios in %v: %v", *scenariosDirpathArg, err)
+			os.Exit(1)
+		}
+		scenarios = loadedScenarios
+	}
 	testSuite := testsuite.AvalancheTestSuite{
-		ByzantineImageName: *byzantineImageNameArg,
-		NormalImageName:    *avalancheImageNameArg,
+		ByzantineImageName:      *byzantineImageNameArg,
+		NormalImageName:         *avalancheImageNameArg,
+		UpgradeOldImageName:     *upgradeOldImageNameArg,
+		UpgradeNewImageName:     *upgradeNewImageNameArg,
+		StakingRewardsImageName: *stakingRewardsImageNameArg,
+		Scenarios:               scenarios,
+		NetworkOptions:          networkOptions,
 	}
 	controller := controller.NewTestController(
 		*testVolumeArg,
@@ -116,7 +209,44 @@ func main() {
 		*testNameArg)
 
 	logrus.Infof("Running test '%v'...", *testNameArg)
+	startTime := time.Now()
 	setupErr, testErr := controller.RunTest()
+
+	// Leave the outcome, the captured metrics, profiles and health transitions and (on failure) the service logs on the
+	//  test volume for the initializer's report
+	artifactsDirpath := report.GetControllerArtifactsDirpath(*testVolumeMountpointArg)
+	result := report.ControllerResult{
+		Status:   report.Passed,
+		Duration: time.Since(startTime),
+	}
+	if setupErr != nil {
+		result.Status = report.SetupError
+		result.Failure = fmt.Sprintf("%+v", setupErr)
+	} else if testErr != nil {
+		result.Status = report.Failed
+		result.Failure = fmt.Sprintf("%+v", testErr)
+	}
+	if err := report.WriteControllerResult(artifactsDirpath, result); err != nil {
+		logrus.Warnf("Couldn't write the test result for the report: %v", err)
+	}
+	for i, metricsScraper := range captures.GetMetricsScrapers() {
+		metricsScraper.Stop()
+		if err := metricsScraper.WriteFile(filepath.Join(artifactsDirpath, getCaptureFilename(report.MetricsFilename, i))); err != nil {
+			logrus.Warnf("Couldn't write the captured metrics for the report: %v", err)
+		}
+	}
+	for i, healthMonitor := range captures.GetHealthMonitors() {
+		healthMonitor.Stop()
+		if err := healthMonitor.WriteFile(filepath.Join(artifactsDirpath, getCaptureFilename(report.HealthFilename, i))); err != nil {
+			logrus.Warnf("Couldn't write the health transitions for the report: %v", err)
+		}
+	}
+	if result.Status != report.Passed {
+		if err := report.CollectServiceLogs(*dockerNetworkArg, *testControllerIPArg, artifactsDirpath); err != nil {
+			logrus.Warnf("Couldn't collect the service logs for the report: %v", err)
+		}
+	}
+
 	if setupErr != nil {
 		logrus.Errorf("Test %v encountered an error during setup (test did not run):", *testNameArg)
 		fmt.Fprintln(logrus.StandardLogger().Out, setupErr)
@@ -129,3 +259,13 @@ func main() {
 	}
 	logrus.Infof("Test %v succeeded", *testNameArg)
 }
+
+// getCaptureFilename returns the file that what the [index]th network of the test captured is written to, which only
+// gets the network's index added to it when the test ran more than one network
+func getCaptureFilename(filename string, index int) string {
+	if index == 0 {
+		return filename
+	}
+	extension := filepath.Ext(filename)
+	return fmt.Sprintf("%v-%v%v", strings.TrimSuffix(filename, extension), index, extension)
+}
@@ -4,10 +4,15 @@ go 1.13
 
 require (
 	github.com/ava-labs/avalanchego v0.8.3
+	github.com/docker/docker v17.12.0-ce-rc1.0.20200514193020-5da88705cccc+incompatible
 	github.com/docker/go-connections v0.4.0
 	github.com/gorilla/rpc v1.2.0
 	github.com/kurtosis-tech/kurtosis v0.0.0-20200810120239-94d43a13679e
 	github.com/palantir/stacktrace v0.0.0-20161112013806-78658fd2d177
+	github.com/prometheus/client_model v0.2.0
+	github.com/prometheus/common v0.10.0
 	github.com/sirupsen/logrus v1.6.0
 	github.com/stretchr/testify v1.6.1
+	go.nanomsg.org/mangos/v3 v3.0.1
+	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c
 )
@@ -6,18 +6,29 @@ import (
 	"os"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/ava-labs/avalanche-testing/avalanche/logging"
+	"github.com/ava-labs/avalanche-testing/avalanche/services/certs"
 	testsuite "github.com/ava-labs/avalanche-testing/testsuite/kurtosis"
+	"github.com/ava-labs/avalanche-testing/testsuite/report"
+	"github.com/ava-labs/avalanche-testing/testsuite/scenario"
 	"github.com/kurtosis-tech/kurtosis/initializer"
 	"github.com/sirupsen/logrus"
 )
 
 const (
-	testNameArgSeparator     = ","
-	avalancheImageNameEnvVar = "AVALANCHE_IMAGE_NAME"
-	byzantineImageNameEnvVar = "BYZANTINE_IMAGE_NAME"
-	defaultParallelism       = 4
+	testNameArgSeparator         = ","
+	avalancheImageNameEnvVar     = "AVALANCHE_IMAGE_NAME"
+	byzantineImageNameEnvVar     = "BYZANTINE_IMAGE_NAME"
+	upgradeOldImageEnvVar        = "UPGRADE_OLD_IMAGE_NAME"
+	upgradeNewImageEnvVar        = "UPGRADE_NEW_IMAGE_NAME"
+	stakingRewardsImageEnvVar    = "STAKING_REWARDS_IMAGE_NAME"
+	certSeedEnvVar               = "CERT_SEED"
+	certKeyTypeEnvVar            = "CERT_KEY_TYPE"
+	metricsScrapeIntervalEnvVar  = "METRICS_SCRAPE_INTERVAL"
+	defaultParallelism           = 4
+	defaultMetricsScrapeInterval = 10 * time.Second
 
 	// The number of bits to make each test network, which dictates the max number of services a test can spin up
 	// Here we choose 8 bits = 256 max services per test
@@ -53,6 +64,24 @@ func main() {
 		"The name of a pre-built avalanche-byzantine image, on the local Docker engine",
 	)
 
+	upgradeOldImageNameArg := flag.String(
+		"upgrade-old-image-name",
+		"",
+		"If set along with --upgrade-new-image-name, the Avalanche image that the rolling upgrade test starts its network on",
+	)
+
+	upgradeNewImageNameArg := flag.String(
+		"upgrade-new-image-name",
+		"",
+		"If set along with --upgrade-old-image-name, the Avalanche image that the rolling upgrade test upgrades its nodes to",
+	)
+
+	stakingRewardsImageNameArg := flag.String(
+		"staking-rewards-image-name",
+		"",
+		"If set, the Avalanche image that the staking rewards test runs on, which must support --min-stake-duration (avalanchego v0.8.3 doesn't)",
+	)
+
 	testControllerImageNameArg := flag.String(
 		"test-controller-image-name",
 		"",
@@ -77,6 +106,36 @@ func main() {
 		fmt.Sprintf("Log level to use for the initializer (%v)", logging.GetAcceptableStrings()),
 	)
 
+	reportDirpathArg := flag.String(
+		"report",
+		"",
+		"If set, the directory to write JSON & JUnit XML reports of the test results to, along with the logs of failed tests",
+	)
+
+	scenariosDirpathArg := flag.String(
+		"scenarios-dir",
+		"",
+		"If set, the directory of YAML/JSON scenario files to register as tests; the controller image must contain the same scenarios",
+	)
+
+	certSeedArg := flag.String(
+		"cert-seed",
+		"",
+		"If set, the seed that the staking certs of non-boot nodes are derived from, so that they get the same node IDs on every run (default: random certs)",
+	)
+
+	certKeyTypeArg := flag.String(
+		"cert-key-type",
+		string(certs.RSAKeyType),
+		fmt.Sprintf("The type of key that certs derived from --cert-seed have (%v or %v); only use %v with node images that accept it", certs.RSAKeyType, certs.ECDSAKeyType, certs.ECDSAKeyType),
+	)
+
+	metricsScrapeIntervalArg := flag.Duration(
+		"metrics-scrape-interval",
+		defaultMetricsScrapeInterval,
+		"How often to scrape the metrics of every node in a test network, which are written to the test's artifacts alongside the --report (0 to not scrape them)",
+	)
+
 	parallelismArg := flag.Uint(
 		"parallelism",
 		defaultParallelism,
@@ -86,9 +145,26 @@ func main() {
 	flag.Parse()
 
 	logrus.Info("Welcome to the Avalanche E2E test suite, powered by the Kurtosis framework")
+	var scenarios []*scenario.Scenario
+	if *scenariosDirpathArg != "" {
+		loadedScenarios, err := scenario.LoadDir(*scenariosDirpathArg)
+		if err != nil {
+			logrus.Fatalf("Failed to load the scenarios in %v: %v", *scenariosDirpathArg, err)
+			os.Exit(1)
+		}
+		scenarios = loadedScenarios
+	}
+	if (*upgradeOldImageNameArg == "") != (*upgradeNewImageNameArg == "") {
+		logrus.Fatalf("--upgrade-old-image-name and --upgrade-new-image-name must be set together")
+		os.Exit(1)
+	}
 	testSuite := testsuite.AvalancheTestSuite{
-		ByzantineImageName: *byzantineImageNameArg,
-		NormalImageName:    *avalancheImageNameArg,
+		ByzantineImageName:      *byzantineImageNameArg,
+		NormalImageName:         *avalancheImageNameArg,
+		UpgradeOldImageName:     *upgradeOldImageNameArg,
+		UpgradeNewImageName:     *upgradeNewImageNameArg,
+		StakingRewardsImageName: *stakingRewardsImageNameArg,
+		Scenarios:               scenarios,
 	}
 	if *doListArg {
 		testNames := []string{}
@@ -121,6 +197,12 @@ func main() {
 		os.Exit(1)
 	}
 
+	// As with the log level, this is validated here so the user doesn't have to wait for a controller to find out
+	if _, err := certs.ParseKeyType(*certKeyTypeArg); err != nil {
+		logrus.Fatalf("Invalid cert key type: %v", err)
+		os.Exit(1)
+	}
+
 	testNamesArgStr := strings.TrimSpace(*testNamesArg)
 	testNames := map[string]bool{}
 	if len(testNamesArgStr) > 0 {
@@ -130,18 +212,57 @@ func main() {
 		}
 	}
 
-	testSuiteRunner := initializer.NewTestSuiteRunner(
-		testSuite,
-		*testControllerImageNameArg,
-		*controllerLogLevelArg,
-		map[string]string{
-			avalancheImageNameEnvVar: *avalancheImageNameArg,
-			byzantineImageNameEnvVar: *byzantineImageNameArg,
-		},
-		networkWidthBits)
+	newTestSuiteRunner := func() *initializer.TestSuiteRunner {
+		return initializer.NewTestSuiteRunner(
+			testSuite,
+			*testControllerImageNameArg,
+			*controllerLogLevelArg,
+			map[string]string{
+				avalancheImageNameEnvVar:    *avalancheImageNameArg,
+				byzantineImageNameEnvVar:    *byzantineImageNameArg,
+				upgradeOldImageEnvVar:       *upgradeOldImageNameArg,
+				upgradeNewImageEnvVar:       *upgradeNewImageNameArg,
+				stakingRewardsImageEnvVar:   *stakingRewardsImageNameArg,
+				certSeedEnvVar:              *certSeedArg,
+				certKeyTypeEnvVar:           *certKeyTypeArg,
+				metricsScrapeIntervalEnvVar: metricsScrapeIntervalArg.String(),
+			},
+			networkWidthBits)
+	}
+
+	if *reportDirpathArg != "" {
+		reportTestNames := []string{}
+		for name := range testSuite.GetTests() {
+			if len(testNames) == 0 || testNames[name] {
+				reportTestNames = append(reportTestNames, name)
+			}
+		}
+		sort.Strings(reportTestNames)
+		reportingRunner := report.NewReportingRunner(
+			newTestSuiteRunner(),
+			*testControllerImageNameArg,
+			*avalancheImageNameArg,
+			*byzantineImageNameArg,
+			*upgradeOldImageNameArg,
+			*upgradeNewImageNameArg,
+			*stakingRewardsImageNameArg,
+			*reportDirpathArg)
+		testReport, err := reportingRunner.RunTests(reportTestNames, *parallelismArg)
+		if err != nil {
+			logrus.Error("An error occurred running the tests:")
+			logrus.Error(err)
+			os.Exit(1)
+		}
+		logrus.Infof("Wrote the test report to %v", *reportDirpathArg)
+		if testReport.AllPassed() {
+			os.Exit(0)
+		} else {
+			os.Exit(1)
+		}
+	}
 
 	// Create the container based on the configurations, but don't start it yet.
-	allTestsSucceeded, error := testSuiteRunner.RunTests(testNames, *parallelismArg)
+	allTestsSucceeded, error := newTestSuiteRunner().RunTests(testNames, *parallelismArg)
 	if error != nil {
 		logrus.Error("An error occurred running the tests:")
 		logrus.Error(error)
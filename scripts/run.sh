@@ -6,4 +6,4 @@ AVALANCHE_IMAGE_DEFAULT="avaplatform/avalanchego:latest"
 CONTROLLER_IMAGE="avaplatform/avalanche-testing_controller:latest"
 root_dirpath="$(dirname "${script_dirpath}")"
 
-"${root_dirpath}/build/avalanche-testing" "--avalanche-image-name=${AVALANCHE_IMAGE_DEFAULT}" "--test-controller-image-name=${CONTROLLER_IMAGE}" ${*:-}
+"${root_dirpath}/build/avalanche-testing" "--avalanche-image-name=${AVALANCHE_IMAGE_DEFAULT}" "--test-controller-image-name=${CONTROLLER_IMAGE}" "--scenarios-dir=${root_dirpath}/scenarios" ${*:-}
@@ -0,0 +1,30 @@
+package helpers
+
+import (
+	"time"
+
+	"github.com/palantir/stacktrace"
+	"github.com/sirupsen/logrus"
+)
+
+// AwaitCondition polls a check until it passes, for network state (like peer lists) that takes a while to settle
+// Args:
+// 	timeout: How long to keep polling for
+// 	pollInterval: How long to wait between checks
+// 	check: The check to poll, which returns nil once the condition is met
+// Returns:
+// 	nil if the check passed within the timeout, or the last error the check returned otherwise
+func AwaitCondition(timeout time.Duration, pollInterval time.Duration, check func() error) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		err := check()
+		if err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return stacktrace.Propagate(err, "Condition still wasn't met after %v", timeout)
+		}
+		logrus.Debugf("Condition not met yet: %v", err)
+		time.Sleep(pollInterval)
+	}
+}
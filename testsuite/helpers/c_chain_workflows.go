@@ -0,0 +1,170 @@
+package helpers
+
+import (
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ava-labs/avalanche-testing/avalanche_client/apis/evm"
+	"github.com/ava-labs/avalanche-testing/avalanche_client/utils/constants"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/palantir/stacktrace"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// The C Chain denominates AVAX in wei like Ethereum does, with 1 nAVAX worth 1 gwei
+	weiPerNanoAvax = 1000000000
+
+	// How often to poll the C Chain while waiting for atomic transactions and receipts
+	cChainPollInterval = time.Second
+
+	xChainAddressPrefix = "X-"
+	cChainAddressPrefix = "C-"
+)
+
+// The messages of the errors the X Chain's importAVAX returns when there are no atomic UTXOs to import yet
+var nothingToImportErrorMessages = []string{"no spendable funds", "no import inputs"}
+
+// NanoAvaxToWei converts an amount of nAVAX to the number of wei it's worth on the C Chain
+func NanoAvaxToWei(nanoAvax uint64) *big.Int {
+	return new(big.Int).Mul(new(big.Int).SetUint64(nanoAvax), big.NewInt(weiPerNanoAvax))
+}
+
+// ImportXChainKeyToCChain adds the private key of the runner user's X Chain address [xChainAddress] to the user's
+// C Chain keys, so that the user can move AVAX between the two chains with that key
+// Returns:
+// 	The "0x"-prefixed hex address of the key on the C Chain
+func (runner RPCWorkFlowRunner) ImportXChainKeyToCChain(xChainAddress string) (string, error) {
+	client := runner.client
+	privateKey, err := client.XChainAPI().ExportKey(runner.userPass, xChainAddress)
+	if err != nil {
+		return "", stacktrace.Propagate(err, "Failed to export the key of X Chain address %s", xChainAddress)
+	}
+	ethAddress, err := client.CChainAPI().ImportKey(runner.userPass, privateKey)
+	if err != nil {
+		return "", stacktrace.Propagate(err, "Failed to import the key of X Chain address %s to the C Chain", xChainAddress)
+	}
+	return ethAddress, nil
+}
+
+// TransferAvaXChainToCChain exports [amount] nAVAX from the X Chain to the C Chain key of X Chain address
+// [xChainAddress], then imports it to the C Chain hex address [ethAddress], and blocks until the import has been
+// credited
+// NOTE: the key of [xChainAddress] must already be in the user's C Chain keys (see ImportXChainKeyToCChain)
+func (runner RPCWorkFlowRunner) TransferAvaXChainToCChain(xChainAddress, ethAddress string, amount uint64) error {
+	client := runner.client
+	cChainAddress, err := toCChainAtomicAddress(xChainAddress)
+	if err != nil {
+		return stacktrace.Propagate(err, "Failed to get the C Chain atomic address of X Chain address %s", xChainAddress)
+	}
+	startingBalance, err := client.CChainAPI().GetBalance(ethAddress)
+	if err != nil {
+		return stacktrace.Propagate(err, "Failed to get the C Chain balance of %s", ethAddress)
+	}
+
+	exportTxID, err := client.XChainAPI().ExportAVAX(runner.userPass, amount, cChainAddress)
+	if err != nil {
+		return stacktrace.Propagate(err, "Failed to export AVAX to C Chain address %s", cChainAddress)
+	}
+	if err := runner.waitForXchainTransactionAcceptance(exportTxID); err != nil {
+		return stacktrace.Propagate(err, "Failed to accept ExportTx: %s", exportTxID)
+	}
+
+	importTxID, err := client.CChainAPI().ImportAVAX(runner.userPass, ethAddress, constants.XChainID.String())
+	if err != nil {
+		return stacktrace.Propagate(err, "Failed to import AVAX to C Chain address %s", ethAddress)
+	}
+	logrus.Debugf("Issued C Chain ImportTx %s; waiting for %s to be credited...", importTxID, ethAddress)
+
+	// The C Chain has no status endpoint for atomic transactions, so watch for the balance to go up instead
+	err = AwaitCondition(runner.networkAcceptanceTimeout, cChainPollInterval, func() error {
+		balance, err := client.CChainAPI().GetBalance(ethAddress)
+		if err != nil {
+			return stacktrace.Propagate(err, "Failed to get the C Chain balance of %s", ethAddress)
+		}
+		if balance.Cmp(startingBalance) <= 0 {
+			return stacktrace.NewError("C Chain balance of %s is still %v wei", ethAddress, balance)
+		}
+		return nil
+	})
+	if err != nil {
+		return stacktrace.Propagate(err, "ImportTx %s wasn't credited to %s", importTxID, ethAddress)
+	}
+	return nil
+}
+
+// TransferAvaCChainToXChain exports [amount] nAVAX from the user's C Chain funds to X Chain address [xChainAddress],
+// then imports it on the X Chain, and blocks until the import has been accepted
+func (runner RPCWorkFlowRunner) TransferAvaCChainToXChain(xChainAddress string, amount uint64) error {
+	client := runner.client
+	exportTxID, err := client.CChainAPI().ExportAVAX(runner.userPass, amount, xChainAddress)
+	if err != nil {
+		return stacktrace.Propagate(err, "Failed to export AVAX to X Chain address %s", xChainAddress)
+	}
+	logrus.Debugf("Issued C Chain ExportTx %s; waiting for it to reach the X Chain...", exportTxID)
+
+	// The exported UTXOs only become importable once the C Chain has accepted the export, which we can't query for
+	//  directly, so keep trying the import while there's nothing to import yet
+	deadline := time.Now().Add(runner.networkAcceptanceTimeout)
+	var importTxID ids.ID
+	for {
+		importTxID, err = client.XChainAPI().ImportAVAX(runner.userPass, xChainAddress, constants.CChainID.String())
+		if err == nil {
+			break
+		}
+		if !isNothingToImportError(err) {
+			return stacktrace.Propagate(err, "Failed to import AVAX to X Chain address %s", xChainAddress)
+		}
+		if time.Now().After(deadline) {
+			return stacktrace.Propagate(err, "ExportTx %s never became importable on the X Chain", exportTxID)
+		}
+		logrus.Debugf("ExportTx %s isn't importable on the X Chain yet: %v", exportTxID, err)
+		time.Sleep(cChainPollInterval)
+	}
+	if err := runner.waitForXchainTransactionAcceptance(importTxID); err != nil {
+		return stacktrace.Propagate(err, "Failed to accept ImportTx: %s", importTxID)
+	}
+	return nil
+}
+
+// AwaitCChainTxReceipt blocks until the C Chain transaction with hash [txHash] has been accepted, and returns its
+// receipt
+func (runner RPCWorkFlowRunner) AwaitCChainTxReceipt(txHash string) (*evm.TransactionReceipt, error) {
+	var receipt *evm.TransactionReceipt
+	err := AwaitCondition(runner.networkAcceptanceTimeout, cChainPollInterval, func() error {
+		var err error
+		receipt, err = runner.client.CChainAPI().GetTransactionReceipt(txHash)
+		if err != nil {
+			return stacktrace.Propagate(err, "Failed to get the receipt of transaction %s", txHash)
+		}
+		if receipt == nil {
+			return stacktrace.NewError("Transaction %s hasn't been accepted yet", txHash)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Timed out waiting for transaction %s to be accepted on the CChain.", txHash)
+	}
+	return receipt, nil
+}
+
+// isNothingToImportError returns true if [err] is the error the X Chain returns from importAVAX when none of the
+// exported UTXOs have reached it yet, rather than a failure that trying again won't fix
+func isNothingToImportError(err error) bool {
+	for _, message := range nothingToImportErrorMessages {
+		if strings.Contains(err.Error(), message) {
+			return true
+		}
+	}
+	return false
+}
+
+// toCChainAtomicAddress returns the "C-..." address that AVAX must be exported to from the X Chain for the owner of
+// X Chain address [xChainAddress] to import it on the C Chain; both chains use the same address bytes
+func toCChainAtomicAddress(xChainAddress string) (string, error) {
+	if !strings.HasPrefix(xChainAddress, xChainAddressPrefix) {
+		return "", stacktrace.NewError("X Chain address %s is missing the %s prefix", xChainAddress, xChainAddressPrefix)
+	}
+	return cChainAddressPrefix + strings.TrimPrefix(xChainAddress, xChainAddressPrefix), nil
+}
@@ -0,0 +1,54 @@
+package helpers
+
+import (
+	avalancheNetwork "github.com/ava-labs/avalanche-testing/avalanche/networks"
+	"github.com/ava-labs/avalanche-testing/avalanche_client/apis"
+	testingConstants "github.com/ava-labs/avalanche-testing/avalanche_client/utils/constants"
+	"github.com/ava-labs/avalanche-testing/testsuite/txbuilder"
+	"github.com/ava-labs/avalanchego/utils/constants"
+	"github.com/ava-labs/avalanchego/utils/crypto"
+	"github.com/ava-labs/avalanchego/vms/components/avax"
+	"github.com/ava-labs/avalanchego/vms/secp256k1fx"
+	"github.com/palantir/stacktrace"
+)
+
+const (
+	maxGenesisUTXOs = 100
+)
+
+// GetGenesisKeyAndUTXO returns the key of the genesis funded address, and an AVAX UTXO that it alone owns, as seen by
+// the node of the given client, for building transactions (e.g. conflicting ones) with the txbuilder
+func GetGenesisKeyAndUTXO(client *apis.Client) (*crypto.PrivateKeySECP256K1R, *avax.UTXO, error) {
+	genesisKey, err := txbuilder.ParsePrivateKey(avalancheNetwork.DefaultLocalNetGenesisConfig.FundedAddresses.PrivateKey)
+	if err != nil {
+		return nil, nil, stacktrace.Propagate(err, "Failed to parse the genesis private key")
+	}
+	builder, err := txbuilder.NewBuilder(constants.LocalID, genesisKey)
+	if err != nil {
+		return nil, nil, stacktrace.Propagate(err, "Failed to create the transaction builder")
+	}
+	genesisAddress, err := builder.XChainAddress(genesisKey.PublicKey().Address())
+	if err != nil {
+		return nil, nil, stacktrace.Propagate(err, "Failed to format the genesis address")
+	}
+
+	utxoReply, err := client.XChainAPI().GetUTXOs([]string{genesisAddress}, maxGenesisUTXOs, "", "")
+	if err != nil {
+		return nil, nil, stacktrace.Propagate(err, "Failed to get the UTXOs of the genesis address %s", genesisAddress)
+	}
+	utxosBytes := make([][]byte, len(utxoReply.UTXOs))
+	for i, formattedUTXO := range utxoReply.UTXOs {
+		utxosBytes[i] = formattedUTXO.Bytes
+	}
+	utxos, err := txbuilder.ParseUTXOs(builder.XChainCodec(), utxosBytes)
+	if err != nil {
+		return nil, nil, stacktrace.Propagate(err, "Failed to parse the UTXOs of the genesis address")
+	}
+	for _, utxo := range utxos {
+		out, ok := utxo.Out.(*secp256k1fx.TransferOutput)
+		if ok && utxo.AssetID().Equals(testingConstants.AvaxAssetID) && out.Locktime == 0 && len(out.Addrs) == 1 {
+			return genesisKey, utxo, nil
+		}
+	}
+	return nil, nil, stacktrace.NewError("The genesis address %s has no unlocked AVAX UTXO of its own", genesisAddress)
+}
@@ -0,0 +1,53 @@
+package helpers
+
+import (
+	avalancheNetwork "github.com/ava-labs/avalanche-testing/avalanche/networks"
+	"github.com/ava-labs/avalanche-testing/avalanche_client/apis"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/kurtosis-tech/kurtosis/commons/networks"
+	"github.com/palantir/stacktrace"
+)
+
+// GetNodeIDsAndClients returns the node IDs and Avalanche clients of the network's services with the given IDs
+func GetNodeIDsAndClients(
+	network avalancheNetwork.TestAvalancheNetwork,
+	serviceIDs map[networks.ServiceID]bool,
+) (map[networks.ServiceID]string, map[networks.ServiceID]*apis.Client, error) {
+	nodeIDs := make(map[networks.ServiceID]string)
+	clients := make(map[networks.ServiceID]*apis.Client)
+	for serviceID := range serviceIDs {
+		client, err := network.GetAvalancheClient(serviceID)
+		if err != nil {
+			return nil, nil, stacktrace.Propagate(err, "An error occurred getting the Avalanche client for service with ID %v", serviceID)
+		}
+		clients[serviceID] = client
+		nodeID, err := client.InfoAPI().GetNodeID()
+		if err != nil {
+			return nil, nil, stacktrace.Propagate(err, "An error occurred getting the Avalanche node ID for service with ID %v", serviceID)
+		}
+		nodeIDs[serviceID] = nodeID
+	}
+	return nodeIDs, clients, nil
+}
+
+// GetValidatorNodeIDs returns the node IDs of the current validators of subnet [subnetID] (ids.Empty for the primary
+// network), as reported by the node behind [client]
+func GetValidatorNodeIDs(client *apis.Client, subnetID ids.ID) (map[string]bool, error) {
+	validators, _, err := client.PChainAPI().GetCurrentValidators(subnetID)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to get the current validators of subnet %s", subnetID)
+	}
+	nodeIDs := make(map[string]bool)
+	for _, validator := range validators {
+		validatorMap, ok := validator.(map[string]interface{})
+		if !ok {
+			return nil, stacktrace.NewError("Unexpected validator format: %v", validator)
+		}
+		nodeID, ok := validatorMap["nodeID"].(string)
+		if !ok {
+			return nil, stacktrace.NewError("Validator %v has no node ID", validator)
+		}
+		nodeIDs[nodeID] = true
+	}
+	return nodeIDs, nil
+}
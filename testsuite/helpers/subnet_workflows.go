@@ -0,0 +1,142 @@
+package helpers
+
+import (
+	"time"
+
+	"github.com/ava-labs/avalanche-testing/avalanche_client/apis"
+	"github.com/ava-labs/avalanchego/ids"
+	cjson "github.com/ava-labs/avalanchego/utils/json"
+	"github.com/ava-labs/avalanchego/vms/platformvm"
+	"github.com/palantir/stacktrace"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultSubnetValidationPeriod is how long subnet validators validate for, which must fit inside the
+	// validators' primary network validation period
+	DefaultSubnetValidationPeriod = 24 * time.Hour
+
+	// How often to poll a blockchain's status while waiting for it to change
+	blockchainStatusPollInterval = time.Second
+)
+
+// CreateSubnet issues a transaction to create a subnet controlled by [threshold] of the P Chain addresses
+// [controlKeys], and blocks until it's committed
+// NOTE: the runner's user must hold the control keys to add validators & blockchains to the subnet afterwards
+// Returns:
+// 	The ID of the new subnet
+func (runner RPCWorkFlowRunner) CreateSubnet(controlKeys []string, threshold uint32) (ids.ID, error) {
+	subnetID, err := runner.client.PChainAPI().CreateSubnet(runner.userPass, platformvm.APISubnet{
+		ControlKeys: controlKeys,
+		Threshold:   cjson.Uint32(threshold),
+	})
+	if err != nil {
+		return ids.Empty, stacktrace.Propagate(err, "Failed to create subnet")
+	}
+	if err := runner.waitForPChainTransactionAcceptance(subnetID); err != nil {
+		return ids.Empty, stacktrace.Propagate(err, "Failed to accept CreateSubnet tx: %s", subnetID)
+	}
+	return subnetID, nil
+}
+
+// AddSubnetValidators adds each node in [nodeIDs] as a validator of subnet [subnetID] with the given weight, and
+// blocks until the transactions are committed and the validation periods begin
+// NOTE: the nodes must already be validating the primary network for at least DefaultSubnetValidationPeriod
+func (runner RPCWorkFlowRunner) AddSubnetValidators(subnetID ids.ID, nodeIDs []string, weight uint64) error {
+	client := runner.client
+	var lastValidationStartTime time.Time
+	// Each tx spends the change of the one before it, so it's only issued once the one before it has been committed
+	for _, nodeID := range nodeIDs {
+		lastValidationStartTime = time.Now().Add(DefaultStakingDelay)
+		txID, err := client.PChainAPI().AddSubnetValidator(
+			runner.userPass,
+			"",
+			nodeID,
+			weight,
+			uint64(lastValidationStartTime.Unix()),
+			uint64(lastValidationStartTime.Add(DefaultSubnetValidationPeriod).Unix()),
+			subnetID.String(),
+		)
+		if err != nil {
+			return stacktrace.Propagate(err, "Failed to add %s as a validator of subnet %s", nodeID, subnetID)
+		}
+		if err := runner.waitForPChainTransactionAcceptance(txID); err != nil {
+			return stacktrace.Propagate(err, "Failed to accept AddSubnetValidator tx: %s", txID)
+		}
+	}
+
+	time.Sleep(time.Until(lastValidationStartTime) + stakingPeriodSynchronyDelay)
+	return nil
+}
+
+// CreateBlockchain issues a transaction to create a blockchain running VM [vmID] with the given genesis data on subnet
+// [subnetID], and blocks until it's committed
+// Returns:
+// 	The ID of the new blockchain
+func (runner RPCWorkFlowRunner) CreateBlockchain(subnetID ids.ID, vmID string, fxIDs []string, name string, genesisData []byte) (ids.ID, error) {
+	blockchainID, err := runner.client.PChainAPI().CreateBlockchain(runner.userPass, subnetID, vmID, fxIDs, name, genesisData)
+	if err != nil {
+		return ids.Empty, stacktrace.Propagate(err, "Failed to create blockchain %s on subnet %s", name, subnetID)
+	}
+	if err := runner.waitForPChainTransactionAcceptance(blockchainID); err != nil {
+		return ids.Empty, stacktrace.Propagate(err, "Failed to accept CreateBlockchain tx: %s", blockchainID)
+	}
+	return blockchainID, nil
+}
+
+// AwaitBlockchainStatus blocks until the runner's node reports [expectedStatus] for blockchain [blockchainID]
+func (runner RPCWorkFlowRunner) AwaitBlockchainStatus(blockchainID ids.ID, expectedStatus platformvm.Status) error {
+	return AwaitCondition(runner.networkAcceptanceTimeout, blockchainStatusPollInterval, func() error {
+		status, err := runner.client.PChainAPI().GetBlockchainStatus(blockchainID.String())
+		if err != nil {
+			return stacktrace.Propagate(err, "Failed to get the status of blockchain %s", blockchainID)
+		}
+		if status != expectedStatus {
+			return stacktrace.NewError("Blockchain %s has status %s rather than %s", blockchainID, status, expectedStatus)
+		}
+		return nil
+	})
+}
+
+// VerifySubnetMembership verifies that the node behind [client] sees blockchain [blockchainID] as validated by subnet
+// [subnetID], and sees exactly [validatorNodeIDs] as the subnet's current validators
+func VerifySubnetMembership(client *apis.Client, subnetID ids.ID, blockchainID ids.ID, validatorNodeIDs []string) error {
+	pChainAPI := client.PChainAPI()
+	validatingSubnetID, err := pChainAPI.ValidatedBy(blockchainID)
+	if err != nil {
+		return stacktrace.Propagate(err, "Failed to get the subnet validating blockchain %s", blockchainID)
+	}
+	if !validatingSubnetID.Equals(subnetID) {
+		return stacktrace.NewError("Blockchain %s is validated by subnet %s rather than %s", blockchainID, validatingSubnetID, subnetID)
+	}
+
+	blockchainIDs, err := pChainAPI.Validates(subnetID)
+	if err != nil {
+		return stacktrace.Propagate(err, "Failed to get the blockchains validated by subnet %s", subnetID)
+	}
+	foundBlockchain := false
+	for _, validatedBlockchainID := range blockchainIDs {
+		if validatedBlockchainID.Equals(blockchainID) {
+			foundBlockchain = true
+			break
+		}
+	}
+	if !foundBlockchain {
+		return stacktrace.NewError("Subnet %s doesn't validate blockchain %s; it validates %v", subnetID, blockchainID, blockchainIDs)
+	}
+
+	actualNodeIDs, err := GetValidatorNodeIDs(client, subnetID)
+	if err != nil {
+		return stacktrace.Propagate(err, "Failed to get the current validators of subnet %s", subnetID)
+	}
+	if len(actualNodeIDs) != len(validatorNodeIDs) {
+		return stacktrace.NewError("Subnet %s has validators %v, but expected %v", subnetID, actualNodeIDs, validatorNodeIDs)
+	}
+	for _, nodeID := range validatorNodeIDs {
+		if !actualNodeIDs[nodeID] {
+			return stacktrace.NewError("Node %s isn't a current validator of subnet %s; validators are %v", nodeID, subnetID, actualNodeIDs)
+		}
+	}
+	logrus.Debugf("Verified that subnet %s validates blockchain %s with validators %v", subnetID, blockchainID, validatorNodeIDs)
+	return nil
+}
@@ -1,22 +1,54 @@
 package kurtosis
 
 import (
+	"fmt"
 	"time"
 
+	avalancheNetwork "github.com/ava-labs/avalanche-testing/avalanche/networks"
+	"github.com/ava-labs/avalanche-testing/testsuite/loadgen"
+	"github.com/ava-labs/avalanche-testing/testsuite/scenario"
 	"github.com/ava-labs/avalanche-testing/testsuite/tests/bombard"
+	"github.com/ava-labs/avalanche-testing/testsuite/tests/byzantine"
+	"github.com/ava-labs/avalanche-testing/testsuite/tests/cchain"
 	"github.com/ava-labs/avalanche-testing/testsuite/tests/conflictvtx"
 	"github.com/ava-labs/avalanche-testing/testsuite/tests/connected"
+	"github.com/ava-labs/avalanche-testing/testsuite/tests/crosschain"
 	"github.com/ava-labs/avalanche-testing/testsuite/tests/duplicate"
+	"github.com/ava-labs/avalanche-testing/testsuite/tests/load"
+	"github.com/ava-labs/avalanche-testing/testsuite/tests/partition"
+	"github.com/ava-labs/avalanche-testing/testsuite/tests/restart"
+	"github.com/ava-labs/avalanche-testing/testsuite/tests/rewards"
 	"github.com/ava-labs/avalanche-testing/testsuite/tests/spamchits"
+	"github.com/ava-labs/avalanche-testing/testsuite/tests/subnet"
+	"github.com/ava-labs/avalanche-testing/testsuite/tests/upgrade"
 	"github.com/ava-labs/avalanche-testing/testsuite/tests/workflow"
 	"github.com/ava-labs/avalanche-testing/testsuite/verifier"
+	"github.com/ava-labs/avalanchego/utils/units"
+	"github.com/ava-labs/avalanchego/vms/timestampvm"
+	"github.com/kurtosis-tech/kurtosis/commons/networks"
 	"github.com/kurtosis-tech/kurtosis/commons/testsuite"
+	"github.com/palantir/stacktrace"
+	"github.com/sirupsen/logrus"
 )
 
 // AvalancheTestSuite implements the Kurtosis TestSuite interface
 type AvalancheTestSuite struct {
 	ByzantineImageName string
 	NormalImageName    string
+
+	// The images that the rolling upgrade test upgrades a network from and to
+	UpgradeOldImageName string
+	UpgradeNewImageName string
+
+	// The image that the staking rewards test runs on, which must support the --min-stake-duration flag that the test
+	// shortens the staking periods with
+	StakingRewardsImageName string
+
+	// Tests defined in scenario files, which are registered under their scenario names
+	Scenarios []*scenario.Scenario
+
+	// The settings of the environment that the tests' networks run in
+	NetworkOptions avalancheNetwork.NetworkOptions
 }
 
 // GetTests implements the Kurtosis TestSuite interface
@@ -31,13 +63,55 @@ func (a AvalancheTestSuite) GetTests() map[string]testsuite.Test {
 		result["conflictingTxsVertexTest"] = conflictvtx.StakingNetworkConflictingTxsVertexTest{
 			ByzantineImageName: a.ByzantineImageName,
 			NormalImageName:    a.NormalImageName,
+			TxFee:              1000000,
+		}
+		for _, behavior := range byzantine.Catalog {
+			result[fmt.Sprintf("stakingNetworkByzantineTest_%v", behavior.Name)] = byzantine.StakingNetworkByzantineTest{
+				ByzantineImageName: a.ByzantineImageName,
+				NormalImageName:    a.NormalImageName,
+				Behavior:           behavior,
+				NumByzantineNodes:  4,
+				NumHonestNodes:     2,
+				TxFee:              1000000,
+				SafetyVerifier:     verifier.ConsensusSafetyVerifier{HeightTolerance: 2},
+			}
+		}
+	}
+	if a.UpgradeOldImageName != "" && a.UpgradeNewImageName != "" {
+		result["stakingNetworkRollingUpgradeTest"] = upgrade.StakingNetworkRollingUpgradeTest{
+			OldImageName:     a.UpgradeOldImageName,
+			NewImageName:     a.UpgradeNewImageName,
+			LoadConfig:       loadgen.NewConfig(20, 30*time.Second, 3*time.Minute, 30*time.Second, 40, 1000000),
+			MinAcceptedRatio: 0.5,
+			Verifier:         verifier.NetworkStateVerifier{},
+		}
+	}
+	if a.StakingRewardsImageName != "" {
+		result["stakingNetworkRewardsTest"] = rewards.StakingNetworkRewardsTest{
+			ImageName:         a.StakingRewardsImageName,
+			MinStakeDuration:  time.Minute,
+			StakingPeriod:     3 * time.Minute,
+			DelegationPeriod:  time.Minute,
+			ValidatorStake:    2 * units.KiloAvax,
+			DelegatorStake:    2 * units.KiloAvax,
+			DelegationFeeRate: 2,
+			RewardTolerance:   0.01,
+			TxFee:             1000000,
 		}
 	}
 	result["stakingNetworkBombardXChainTest"] = bombard.StakingNetworkBombardTest{
-		ImageName:         a.NormalImageName,
-		NumTxs:            1000,
-		TxFee:             1000000,
-		AcceptanceTimeout: 10 * time.Second,
+		ImageName:             a.NormalImageName,
+		NumTxs:                1000,
+		TxFee:                 1000000,
+		AcceptanceTimeout:     10 * time.Second,
+		ProfileNodes:          true,
+		AwaitAcceptanceEvents: true,
+	}
+	result["stakingNetworkSustainedLoadTest"] = load.StakingNetworkSustainedLoadTest{
+		ImageName:        a.NormalImageName,
+		LoadConfig:       loadgen.NewConfig(50, 30*time.Second, 2*time.Minute, 30*time.Second, 50, 1000000),
+		MinAcceptedRatio: 0.95,
+		MaxP99Latency:    10 * time.Second,
 	}
 	result["stakingNetworkFullyConnectedTest"] = connected.StakingNetworkFullyConnectedTest{
 		ImageName: a.NormalImageName,
@@ -47,9 +121,75 @@ func (a AvalancheTestSuite) GetTests() map[string]testsuite.Test {
 		ImageName: a.NormalImageName,
 		Verifier:  verifier.NetworkStateVerifier{},
 	}
+	result["stakingNetworkPartitionTest"] = partition.StakingNetworkPartitionTest{
+		ImageName: a.NormalImageName,
+		Verifier:  verifier.NetworkStateVerifier{},
+	}
+	result["stakingNetworkNodeRestartTest"] = restart.NodeRestartTest{
+		ImageName: a.NormalImageName,
+		Verifier:  verifier.NetworkStateVerifier{},
+	}
+	result["stakingNetworkCChainWorkflowTest"] = cchain.StakingNetworkCChainWorkflowTest{
+		ImageName: a.NormalImageName,
+	}
+	result["stakingNetworkAtomicStressTest"] = crosschain.StakingNetworkAtomicStressTest{
+		ImageName:       a.NormalImageName,
+		NumNodes:        2,
+		NumUsers:        6,
+		NumCycles:       5,
+		UserFunds:       100 * units.Avax,
+		TransferAmount:  10 * units.Avax,
+		TxFee:           1000000,
+		NumRestarts:     2,
+		RestartInterval: 45 * time.Second,
+	}
+	result["stakingNetworkSubnetLifecycleTest"] = subnet.StakingNetworkSubnetLifecycleTest{
+		ImageName:           a.NormalImageName,
+		NumSubnetValidators: 3,
+		VMID:                timestampvm.ID.String(),
+		GenesisData:         []byte("e2e subnet genesis"),
+	}
 	result["StakingNetworkRPCWorkflowTest"] = workflow.StakingNetworkRPCWorkflowTest{
 		ImageName: a.NormalImageName,
 	}
+	result["stakingNetworkGeneratedGenesisRPCWorkflowTest"] = workflow.StakingNetworkRPCWorkflowTest{
+		ImageName:           a.NormalImageName,
+		NumGeneratedStakers: 7,
+		CertGenerator:       a.NetworkOptions.CertGenerator,
+	}
+
+	for _, testScenario := range a.Scenarios {
+		if _, found := result[testScenario.Name]; found {
+			logrus.Warnf("Skipping scenario %v because a test with the same name already exists", testScenario.Name)
+			continue
+		}
+		result[testScenario.Name] = scenario.Test{
+			Scenario:         testScenario,
+			DefaultImageName: a.NormalImageName,
+		}
+	}
 
+	for name, test := range result {
+		result[name] = networkOptionsTest{Test: test, networkOptions: a.NetworkOptions}
+	}
 	return result
 }
+
+// networkOptionsTest wraps a test to give the network it loads the settings of the environment that it's run in
+type networkOptionsTest struct {
+	testsuite.Test
+
+	networkOptions avalancheNetwork.NetworkOptions
+}
+
+// GetNetworkLoader implements the Kurtosis Test interface
+func (test networkOptionsTest) GetNetworkLoader() (networks.NetworkLoader, error) {
+	loader, err := test.Test.GetNetworkLoader()
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to get the network loader of the wrapped test")
+	}
+	if avalancheLoader, ok := loader.(*avalancheNetwork.TestAvalancheNetworkLoader); ok {
+		avalancheLoader.UseOptions(test.networkOptions)
+	}
+	return loader, nil
+}
@@ -0,0 +1,128 @@
+package loadgen
+
+import (
+	"math"
+	"time"
+
+	"github.com/palantir/stacktrace"
+)
+
+const (
+	// DefaultPollInterval is how often the generator polls the status of an issued transaction by default, which
+	// is also the resolution of the reported latencies
+	DefaultPollInterval = 100 * time.Millisecond
+
+	// DefaultAcceptanceTimeout is how long an issued transaction gets to be accepted by default before it's counted
+	// as timed out
+	DefaultAcceptanceTimeout = 30 * time.Second
+
+	// How many times the transactions a chain is expected to issue it gets funded for, so that a chain that's
+	// picked more often than average doesn't run dry
+	chainFundingMultiplier = 2
+)
+
+// Config describes the load that a Generator puts on the X Chain
+// The generator's rate climbs linearly from 0 to TargetTPS over RampUpDuration, holds at TargetTPS for SteadyDuration,
+// and then falls linearly back to 0 over RampDownDuration.
+type Config struct {
+	// The number of transactions per second to issue at the peak of the load
+	TargetTPS float64
+
+	RampUpDuration   time.Duration
+	SteadyDuration   time.Duration
+	RampDownDuration time.Duration
+
+	// The number of independent UTXO chains, each owned by its own address, to spread the transactions over
+	// NOTE: the transactions of a single chain are issued one at a time, so this needs to be at least TargetTPS
+	// 	multiplied by the time it takes to issue a transaction
+	NumChains int
+
+	// The transaction fee of the network, which every transaction pays
+	TxFee uint64
+
+	// How often to poll the status of an issued transaction
+	PollInterval time.Duration
+
+	// How long an issued transaction gets to be accepted before it's counted as timed out
+	AcceptanceTimeout time.Duration
+}
+
+// NewConfig returns a Config with the default poll interval and acceptance timeout
+// Args:
+// 	targetTPS: The number of transactions per second to issue at the peak of the load
+// 	rampUpDuration: How long it takes to climb from 0 to targetTPS
+// 	steadyDuration: How long targetTPS is held for
+// 	rampDownDuration: How long it takes to fall from targetTPS back to 0
+// 	numChains: The number of independent UTXO chains to spread the transactions over
+// 	txFee: The transaction fee of the network
+func NewConfig(targetTPS float64, rampUpDuration, steadyDuration, rampDownDuration time.Duration, numChains int, txFee uint64) Config {
+	return Config{
+		TargetTPS:         targetTPS,
+		RampUpDuration:    rampUpDuration,
+		SteadyDuration:    steadyDuration,
+		RampDownDuration:  rampDownDuration,
+		NumChains:         numChains,
+		TxFee:             txFee,
+		PollInterval:      DefaultPollInterval,
+		AcceptanceTimeout: DefaultAcceptanceTimeout,
+	}
+}
+
+func (config Config) validate() error {
+	if config.TargetTPS <= 0 {
+		return stacktrace.NewError("Target TPS must be positive, but was %v", config.TargetTPS)
+	}
+	if config.RampUpDuration < 0 || config.SteadyDuration < 0 || config.RampDownDuration < 0 {
+		return stacktrace.NewError("Load phase durations can't be negative")
+	}
+	if config.TotalDuration() == 0 {
+		return stacktrace.NewError("At least one load phase must have a nonzero duration")
+	}
+	if config.NumChains <= 0 {
+		return stacktrace.NewError("Number of UTXO chains must be positive, but was %v", config.NumChains)
+	}
+	if config.TxFee == 0 {
+		return stacktrace.NewError("Transaction fee must be nonzero so that every transaction spends a different amount")
+	}
+	if config.PollInterval <= 0 {
+		return stacktrace.NewError("Poll interval must be positive, but was %v", config.PollInterval)
+	}
+	return nil
+}
+
+// TotalDuration returns how long the generator issues transactions for
+func (config Config) TotalDuration() time.Duration {
+	return config.RampUpDuration + config.SteadyDuration + config.RampDownDuration
+}
+
+// ExpectedNumTxs returns the number of transactions the generator schedules over its whole run, which is the area
+// under the rate curve
+func (config Config) ExpectedNumTxs() uint64 {
+	rampSeconds := (config.RampUpDuration + config.RampDownDuration).Seconds() / 2
+	return uint64(math.Ceil(config.TargetTPS * (rampSeconds + config.SteadyDuration.Seconds())))
+}
+
+// rateAt returns the number of transactions per second the generator should be issuing after [elapsed] time
+func (config Config) rateAt(elapsed time.Duration) float64 {
+	if elapsed < 0 {
+		return 0
+	}
+	if elapsed < config.RampUpDuration {
+		return config.TargetTPS * float64(elapsed) / float64(config.RampUpDuration)
+	}
+	elapsed -= config.RampUpDuration
+	if elapsed < config.SteadyDuration {
+		return config.TargetTPS
+	}
+	elapsed -= config.SteadyDuration
+	if elapsed < config.RampDownDuration {
+		return config.TargetTPS * float64(config.RampDownDuration-elapsed) / float64(config.RampDownDuration)
+	}
+	return 0
+}
+
+// txsPerChain returns how many transactions each UTXO chain gets funded for
+func (config Config) txsPerChain() uint64 {
+	numChains := uint64(config.NumChains)
+	return chainFundingMultiplier * ((config.ExpectedNumTxs() + numChains - 1) / numChains)
+}
@@ -0,0 +1,44 @@
+package loadgen
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateAt(t *testing.T) {
+	config := NewConfig(100, 10*time.Second, 20*time.Second, 5*time.Second, 10, 1000)
+
+	assert.Equal(t, 0.0, config.rateAt(0))
+	assert.Equal(t, 50.0, config.rateAt(5*time.Second))
+	assert.Equal(t, 100.0, config.rateAt(10*time.Second))
+	assert.Equal(t, 100.0, config.rateAt(29*time.Second))
+	assert.Equal(t, 60.0, config.rateAt(32*time.Second))
+	assert.Equal(t, 0.0, config.rateAt(35*time.Second))
+	assert.Equal(t, 0.0, config.rateAt(time.Minute))
+}
+
+func TestRateAtWithoutRamps(t *testing.T) {
+	config := NewConfig(40, 0, 10*time.Second, 0, 10, 1000)
+
+	assert.Equal(t, 40.0, config.rateAt(0))
+	assert.Equal(t, 40.0, config.rateAt(9*time.Second))
+	assert.Equal(t, 0.0, config.rateAt(10*time.Second))
+}
+
+func TestExpectedNumTxs(t *testing.T) {
+	config := NewConfig(100, 10*time.Second, 20*time.Second, 5*time.Second, 10, 1000)
+
+	// 500 during the ramp up, 2000 while steady and 250 during the ramp down
+	assert.Equal(t, uint64(2750), config.ExpectedNumTxs())
+	assert.Equal(t, uint64(550), config.txsPerChain())
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, NewConfig(100, time.Second, time.Second, time.Second, 10, 1000).validate())
+	assert.Error(t, NewConfig(0, time.Second, time.Second, time.Second, 10, 1000).validate())
+	assert.Error(t, NewConfig(100, 0, 0, 0, 10, 1000).validate())
+	assert.Error(t, NewConfig(100, time.Second, time.Second, time.Second, 0, 1000).validate())
+	assert.Error(t, NewConfig(100, time.Second, time.Second, time.Second, 10, 0).validate())
+}
@@ -0,0 +1,196 @@
+package loadgen
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ava-labs/avalanche-testing/avalanche_client/apis"
+	"github.com/ava-labs/avalanche-testing/testsuite/helpers"
+	"github.com/ava-labs/avalanche-testing/testsuite/txbuilder"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/snow/choices"
+	"github.com/ava-labs/avalanchego/utils/codec"
+	"github.com/palantir/stacktrace"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// How often the scheduler releases the transactions that have come due
+	schedulerTickInterval = 10 * time.Millisecond
+
+	// How often the generator logs its progress
+	progressLogInterval = 10 * time.Second
+)
+
+// Generator puts sustained load on the X Chain by issuing simple AVAX transfers at a scheduled rate, spread over many
+// independent UTXO chains, and measures how long each transaction takes to be accepted
+type Generator struct {
+	config Config
+	codec  codec.Codec
+	chains []*utxoChain
+}
+
+// NewGenerator creates a Generator and funds its UTXO chains
+// Args:
+// 	funder: Workflow runner whose keystore user holds enough AVAX to fund every UTXO chain
+// 	clients: Clients of the nodes to issue transactions to, which the UTXO chains are spread over evenly
+// 	config: The load to generate
+func NewGenerator(funder *helpers.RPCWorkFlowRunner, clients []*apis.Client, config Config) (*Generator, error) {
+	if err := config.validate(); err != nil {
+		return nil, stacktrace.Propagate(err, "Invalid load generator config")
+	}
+	if len(clients) == 0 {
+		return nil, stacktrace.NewError("At least one client is needed to issue transactions to")
+	}
+	codec, err := txbuilder.NewXChainCodec()
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to initialize codec")
+	}
+	logrus.Infof("Funding %v UTXO chains for %v transactions...", config.NumChains, config.ExpectedNumTxs())
+	chains, err := createUTXOChains(funder, clients, config, codec)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to create the UTXO chains")
+	}
+	return &Generator{
+		config: config,
+		codec:  codec,
+		chains: chains,
+	}, nil
+}
+
+// Run issues transactions following the configured rate curve, then waits for every issued transaction to be
+// accepted, rejected or timed out, and returns the results
+// NOTE: a Generator's UTXO chains are used up by a run, so Run should only be called once
+func (generator *Generator) Run() Results {
+	recorder := newResultsRecorder()
+	trackersWaitGroup := &sync.WaitGroup{}
+
+	// Each UTXO chain is served by its own worker so that a chain's transactions are issued in order; the scheduler
+	//  hands out issue requests to whichever worker is free
+	issueRequests := make(chan struct{}, len(generator.chains))
+	workersWaitGroup := &sync.WaitGroup{}
+	for _, chain := range generator.chains {
+		workersWaitGroup.Add(1)
+		go func(chain *utxoChain) {
+			defer workersWaitGroup.Done()
+			for range issueRequests {
+				generator.issueNextTx(chain, recorder, trackersWaitGroup)
+			}
+		}(chain)
+	}
+
+	logrus.Infof("Generating load peaking at %v TPS for %v...", generator.config.TargetTPS, generator.config.TotalDuration())
+	startTime := time.Now()
+	generator.schedule(startTime, issueRequests, recorder)
+	close(issueRequests)
+	workersWaitGroup.Wait()
+	duration := time.Since(startTime)
+
+	logrus.Infof("Finished issuing transactions after %v; waiting for the outstanding transactions to be decided...", duration)
+	trackersWaitGroup.Wait()
+	return recorder.finish(duration)
+}
+
+// schedule releases issue requests at the configured rate until the end of the last load phase, counting the
+// requests that no worker was free to take as missed
+func (generator *Generator) schedule(startTime time.Time, issueRequests chan struct{}, recorder *resultsRecorder) {
+	ticker := time.NewTicker(schedulerTickInterval)
+	defer ticker.Stop()
+
+	totalDuration := generator.config.TotalDuration()
+	lastTickTime := startTime
+	lastProgressLogTime := startTime
+	dueTxs := 0.0
+	for tickTime := range ticker.C {
+		elapsed := tickTime.Sub(startTime)
+		if elapsed >= totalDuration {
+			return
+		}
+		dueTxs += generator.config.rateAt(elapsed) * tickTime.Sub(lastTickTime).Seconds()
+		lastTickTime = tickTime
+		for ; dueTxs >= 1; dueTxs-- {
+			select {
+			case issueRequests <- struct{}{}:
+				recorder.record(func(results *Results) { results.Scheduled++ })
+			default:
+				recorder.record(func(results *Results) {
+					results.Scheduled++
+					results.Missed++
+				})
+			}
+		}
+		if tickTime.Sub(lastProgressLogTime) >= progressLogInterval {
+			recorder.record(func(results *Results) {
+				logrus.Infof(
+					"Load generator progress after %v: %v issued, %v accepted, %v missed, %v issue errors",
+					elapsed,
+					results.Issued,
+					results.Accepted,
+					results.Missed,
+					results.IssueErrors)
+			})
+			lastProgressLogTime = tickTime
+		}
+	}
+}
+
+// issueNextTx issues the next transaction of [chain] and starts tracking its acceptance
+func (generator *Generator) issueNextTx(chain *utxoChain, recorder *resultsRecorder, trackersWaitGroup *sync.WaitGroup) {
+	txFee := generator.config.TxFee
+	if !chain.hasFunds(txFee) {
+		logrus.Debugf("UTXO chain of address %v ran out of funds", chain.address)
+		recorder.record(func(results *Results) { results.Missed++ })
+		return
+	}
+	tx, err := chain.nextTx(txFee, generator.codec)
+	if err != nil {
+		logrus.Debugf("Failed to build transaction for address %v: %v", chain.address, err)
+		recorder.record(func(results *Results) { results.IssueErrors++ })
+		return
+	}
+
+	issueTime := time.Now()
+	txID, err := chain.client.XChainAPI().IssueTx(tx.Bytes())
+	if err != nil {
+		// The chain isn't advanced, so the same UTXO gets spent by the next attempt
+		logrus.Debugf("Failed to issue transaction %v: %v", tx.ID(), err)
+		recorder.record(func(results *Results) { results.IssueErrors++ })
+		return
+	}
+	chain.advance(tx, txFee)
+	recorder.record(func(results *Results) { results.Issued++ })
+
+	trackersWaitGroup.Add(1)
+	go func() {
+		defer trackersWaitGroup.Done()
+		generator.trackAcceptance(chain.client, txID, issueTime, recorder)
+	}()
+}
+
+// trackAcceptance polls the status of [txID] until it's decided or the acceptance timeout passes
+func (generator *Generator) trackAcceptance(client *apis.Client, txID ids.ID, issueTime time.Time, recorder *resultsRecorder) {
+	for time.Since(issueTime) < generator.config.AcceptanceTimeout {
+		time.Sleep(generator.config.PollInterval)
+		status, err := client.XChainAPI().GetTxStatus(txID)
+		if err != nil {
+			// The transport already retries transient failures, so keep polling until the timeout
+			logrus.Debugf("Failed to get the status of transaction %v: %v", txID, err)
+			continue
+		}
+		switch status {
+		case choices.Accepted:
+			latency := time.Since(issueTime)
+			recorder.record(func(results *Results) {
+				results.Accepted++
+				results.Latencies = append(results.Latencies, latency)
+			})
+			return
+		case choices.Rejected:
+			logrus.Warnf("Transaction %v was rejected", txID)
+			recorder.record(func(results *Results) { results.Rejected++ })
+			return
+		}
+	}
+	logrus.Warnf("Transaction %v wasn't decided within %v", txID, generator.config.AcceptanceTimeout)
+	recorder.record(func(results *Results) { results.TimedOut++ })
+}
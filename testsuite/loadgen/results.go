@@ -0,0 +1,104 @@
+package loadgen
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Results describes how the network held up under the load of a Generator run
+type Results struct {
+	// Number of transactions the scheduler called for
+	Scheduled int
+	// Number of scheduled transactions that were skipped because every UTXO chain was still busy issuing
+	Missed int
+	// Number of transactions that failed to be issued
+	IssueErrors int
+	// Number of transactions successfully issued to a node
+	Issued int
+
+	Accepted int
+	Rejected int
+	// Number of issued transactions that were neither accepted nor rejected within the acceptance timeout
+	TimedOut int
+
+	// How long the generator issued transactions for
+	Duration time.Duration
+
+	// Time from issuance to observed acceptance of every accepted transaction, sorted ascending
+	Latencies []time.Duration
+}
+
+// AcceptedTPS returns the average number of accepted transactions per second over the run
+func (results Results) AcceptedTPS() float64 {
+	if results.Duration <= 0 {
+		return 0
+	}
+	return float64(results.Accepted) / results.Duration.Seconds()
+}
+
+// LatencyPercentile returns the issue-to-acceptance latency that [percentile] percent of the accepted transactions
+// were at or below, using the nearest-rank method, or 0 if no transactions were accepted
+func (results Results) LatencyPercentile(percentile float64) time.Duration {
+	numLatencies := len(results.Latencies)
+	if numLatencies == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(percentile / 100 * float64(numLatencies)))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > numLatencies {
+		rank = numLatencies
+	}
+	return results.Latencies[rank-1]
+}
+
+// String returns a one-line summary of the results, for logging
+func (results Results) String() string {
+	return fmt.Sprintf(
+		"scheduled=%d missed=%d issueErrors=%d issued=%d accepted=%d rejected=%d timedOut=%d duration=%v acceptedTPS=%.2f p50=%v p90=%v p99=%v",
+		results.Scheduled,
+		results.Missed,
+		results.IssueErrors,
+		results.Issued,
+		results.Accepted,
+		results.Rejected,
+		results.TimedOut,
+		results.Duration,
+		results.AcceptedTPS(),
+		results.LatencyPercentile(50),
+		results.LatencyPercentile(90),
+		results.LatencyPercentile(99),
+	)
+}
+
+// resultsRecorder accumulates Results from the generator's goroutines
+type resultsRecorder struct {
+	mutex   *sync.Mutex
+	results Results
+}
+
+func newResultsRecorder() *resultsRecorder {
+	return &resultsRecorder{mutex: &sync.Mutex{}}
+}
+
+// record applies [update] to the results while holding the lock
+func (recorder *resultsRecorder) record(update func(results *Results)) {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	update(&recorder.results)
+}
+
+// finish returns the accumulated results with the latencies sorted
+func (recorder *resultsRecorder) finish(duration time.Duration) Results {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	results := recorder.results
+	results.Duration = duration
+	results.Latencies = append([]time.Duration{}, recorder.results.Latencies...)
+	sort.Slice(results.Latencies, func(i, j int) bool { return results.Latencies[i] < results.Latencies[j] })
+	return results
+}
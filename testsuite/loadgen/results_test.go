@@ -0,0 +1,35 @@
+package loadgen
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLatencyPercentile(t *testing.T) {
+	recorder := newResultsRecorder()
+	// Record the latencies 100ms...1s out of order
+	for _, i := range []int{7, 3, 10, 1, 5, 9, 2, 8, 4, 6} {
+		latency := time.Duration(i) * 100 * time.Millisecond
+		recorder.record(func(results *Results) {
+			results.Accepted++
+			results.Latencies = append(results.Latencies, latency)
+		})
+	}
+	results := recorder.finish(5 * time.Second)
+
+	assert.Equal(t, 100*time.Millisecond, results.LatencyPercentile(0))
+	assert.Equal(t, 500*time.Millisecond, results.LatencyPercentile(50))
+	assert.Equal(t, 900*time.Millisecond, results.LatencyPercentile(90))
+	assert.Equal(t, time.Second, results.LatencyPercentile(99))
+	assert.Equal(t, time.Second, results.LatencyPercentile(100))
+	assert.Equal(t, 2.0, results.AcceptedTPS())
+}
+
+func TestLatencyPercentileWithoutAcceptedTxs(t *testing.T) {
+	results := newResultsRecorder().finish(time.Second)
+
+	assert.Equal(t, time.Duration(0), results.LatencyPercentile(50))
+	assert.Equal(t, 0.0, results.AcceptedTPS())
+}
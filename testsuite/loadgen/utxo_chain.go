@@ -0,0 +1,123 @@
+package loadgen
+
+import (
+	"github.com/ava-labs/avalanche-testing/avalanche_client/apis"
+	testingConstants "github.com/ava-labs/avalanche-testing/avalanche_client/utils/constants"
+	"github.com/ava-labs/avalanche-testing/testsuite/helpers"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/codec"
+	"github.com/ava-labs/avalanchego/utils/constants"
+	"github.com/ava-labs/avalanchego/utils/crypto"
+	"github.com/ava-labs/avalanchego/utils/formatting"
+	"github.com/ava-labs/avalanchego/vms/avm"
+	"github.com/ava-labs/avalanchego/vms/components/avax"
+	"github.com/ava-labs/avalanchego/vms/secp256k1fx"
+	"github.com/palantir/stacktrace"
+)
+
+const (
+	xChainAlias = "X"
+)
+
+// utxoChain is a single AVAX UTXO that's repeatedly spent back to the address that owns it, minus the fee, so that
+// every transaction in the chain can be built locally without waiting for the previous one to be accepted
+// NOTE: a chain is only ever touched by one goroutine at a time
+type utxoChain struct {
+	// The client the chain's transactions are issued to; a chain sticks to one node so that the node has always seen
+	// the transaction that created the UTXO being spent
+	client *apis.Client
+
+	privateKey *crypto.PrivateKeySECP256K1R
+	address    ids.ShortID
+	utxo       *avax.UTXO
+	balance    uint64
+}
+
+// createUTXOChains generates a new key for every chain, funds its address from [funder]'s keystore user, and fetches
+// the resulting UTXO; chain i issues its transactions to clients[i % len(clients)]
+func createUTXOChains(funder *helpers.RPCWorkFlowRunner, clients []*apis.Client, config Config, codec codec.Codec) ([]*utxoChain, error) {
+	seedAmount := (config.txsPerChain() + 1) * config.TxFee
+	factory := crypto.FactorySECP256K1R{}
+	chains := make([]*utxoChain, config.NumChains)
+	addresses := make([]string, config.NumChains)
+	for i := range chains {
+		privateKeyIntf, err := factory.NewPrivateKey()
+		if err != nil {
+			return nil, stacktrace.Propagate(err, "Failed to generate the private key of UTXO chain %v", i)
+		}
+		privateKey := privateKeyIntf.(*crypto.PrivateKeySECP256K1R)
+		address := privateKey.PublicKey().Address()
+		formattedAddress, err := formatting.FormatAddress(xChainAlias, constants.GetHRP(constants.LocalID), address.Bytes())
+		if err != nil {
+			return nil, stacktrace.Propagate(err, "Failed to format the address of UTXO chain %v", i)
+		}
+		chains[i] = &utxoChain{
+			client:     clients[i%len(clients)],
+			privateKey: privateKey,
+			address:    address,
+			balance:    seedAmount,
+		}
+		addresses[i] = formattedAddress
+	}
+
+	if err := funder.FundXChainAddresses(addresses, seedAmount); err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to fund the UTXO chain addresses")
+	}
+
+	for i, chain := range chains {
+		utxoReply, err := chain.client.XChainAPI().GetUTXOs([]string{addresses[i]}, 1, "", "")
+		if err != nil {
+			return nil, stacktrace.Propagate(err, "Failed to get the UTXOs of address %v", addresses[i])
+		}
+		if len(utxoReply.UTXOs) != 1 {
+			return nil, stacktrace.NewError("Expected 1 UTXO for freshly funded address %v but found %v", addresses[i], len(utxoReply.UTXOs))
+		}
+		utxo := &avax.UTXO{}
+		if err := codec.Unmarshal(utxoReply.UTXOs[0].Bytes, utxo); err != nil {
+			return nil, stacktrace.Propagate(err, "Failed to unmarshal the UTXO of address %v", addresses[i])
+		}
+		chain.utxo = utxo
+	}
+	return chains, nil
+}
+
+// hasFunds returns true if the chain can pay for another transaction
+func (chain *utxoChain) hasFunds(txFee uint64) bool {
+	return chain.balance > txFee
+}
+
+// nextTx builds and signs the transaction spending the chain's current UTXO, without advancing the chain
+func (chain *utxoChain) nextTx(txFee uint64, codec codec.Codec) (*avm.Tx, error) {
+	tx := &avm.Tx{UnsignedTx: &avm.BaseTx{BaseTx: avax.BaseTx{
+		NetworkID:    constants.LocalID,
+		BlockchainID: testingConstants.XChainID,
+		Outs: []*avax.TransferableOutput{{
+			Asset: avax.Asset{ID: testingConstants.AvaxAssetID},
+			Out: &secp256k1fx.TransferOutput{
+				Amt: chain.balance - txFee,
+				OutputOwners: secp256k1fx.OutputOwners{
+					Threshold: 1,
+					Addrs:     []ids.ShortID{chain.address},
+				},
+			},
+		}},
+		Ins: []*avax.TransferableInput{{
+			UTXOID: chain.utxo.UTXOID,
+			Asset:  avax.Asset{ID: testingConstants.AvaxAssetID},
+			In: &secp256k1fx.TransferInput{
+				Amt:   chain.balance,
+				Input: secp256k1fx.Input{SigIndices: []uint32{0}},
+			},
+		}},
+	}}}
+	if err := tx.SignSECP256K1Fx(codec, [][]*crypto.PrivateKeySECP256K1R{{chain.privateKey}}); err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to sign transaction")
+	}
+	return tx, nil
+}
+
+// advance moves the chain onto the UTXO created by [tx], once [tx] has been issued
+func (chain *utxoChain) advance(tx *avm.Tx, txFee uint64) {
+	chain.utxo = tx.UTXOs()[0]
+	chain.balance -= txFee
+}
@@ -0,0 +1,210 @@
+package report
+
+import (
+	"archive/tar"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/palantir/stacktrace"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// The directory inside a test's artifacts that service container logs get written to
+	serviceLogsDirname = "service-logs"
+
+	// Env vars that Kurtosis sets on the controller container, which tell us which test it ran and where its test volume is
+	testNameEnvVar             = "TEST_NAME"
+	testVolumeMountpointEnvVar = "TEST_VOLUME_MOUNTPOINT"
+)
+
+// CollectServiceLogs writes the logs of every service container on the given Docker network, besides the controller
+// itself, into the artifacts directory. It's meant to be called by the controller after a test fails.
+// Args:
+// 	dockerNetwork: The ID of the Docker network the test ran in
+// 	controllerIPAddr: The IP of the controller container, which gets skipped
+// 	artifactsDirpath: The controller's artifacts directory
+func CollectServiceLogs(dockerNetwork string, controllerIPAddr string, artifactsDirpath string) error {
+	ctx := context.Background()
+	dockerClient, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return stacktrace.Propagate(err, "Could not create a Docker client")
+	}
+
+	containers, err := dockerClient.ContainerList(ctx, types.ContainerListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("network", dockerNetwork)),
+	})
+	if err != nil {
+		return stacktrace.Propagate(err, "Failed to list the containers on network %v", dockerNetwork)
+	}
+
+	logsDirpath := filepath.Join(artifactsDirpath, serviceLogsDirname)
+	if err := os.MkdirAll(logsDirpath, reportDirPerms); err != nil {
+		return stacktrace.Propagate(err, "Could not create service logs directory %v", logsDirpath)
+	}
+	for _, containerSummary := range containers {
+		if isContainerWithIP(containerSummary, controllerIPAddr) {
+			continue
+		}
+		containerName := containerSummary.ID
+		if len(containerSummary.Names) > 0 {
+			containerName = strings.TrimPrefix(containerSummary.Names[0], "/")
+		}
+		logFilepath := filepath.Join(logsDirpath, containerName+".log")
+		if err := writeContainerLogs(dockerClient, containerSummary.ID, logFilepath); err != nil {
+			// One missing log shouldn't stop us from collecting the rest
+			logrus.Warnf("Couldn't collect the logs of container %v: %v", containerName, err)
+		}
+	}
+	return nil
+}
+
+// copyTestArtifacts finds the controller container that ran the given test and copies the artifacts it left on the
+// test volume into the destination directory
+// Args:
+// 	controllerImage: The image of the test controllers
+// 	testName: The test whose controller we're looking for
+// 	startedAfter: When the test was started, so that controllers left behind by earlier runs get skipped
+// 	destDirpath: The directory to copy the artifacts into
+func copyTestArtifacts(dockerClient *client.Client, controllerImage string, testName string, startedAfter time.Time, destDirpath string) error {
+	ctx := context.Background()
+	containers, err := dockerClient.ContainerList(ctx, types.ContainerListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("ancestor", controllerImage)),
+	})
+	if err != nil {
+		return stacktrace.Propagate(err, "Failed to list the containers of controller image %v", controllerImage)
+	}
+	// Newest first, so we get the controller of this run if there are several for the same test
+	sort.Slice(containers, func(i, j int) bool {
+		return containers[i].Created > containers[j].Created
+	})
+
+	for _, containerSummary := range containers {
+		if time.Unix(containerSummary.Created, 0).Before(startedAfter.Truncate(time.Second)) {
+			break
+		}
+		containerInfo, err := dockerClient.ContainerInspect(ctx, containerSummary.ID)
+		if err != nil {
+			return stacktrace.Propagate(err, "Failed to inspect container %v", containerSummary.ID)
+		}
+		env := parseEnv(containerInfo.Config.Env)
+		if env[testNameEnvVar] != testName {
+			continue
+		}
+		artifactsDirpath := GetControllerArtifactsDirpath(env[testVolumeMountpointEnvVar])
+		if err := copyDirFromContainer(dockerClient, containerSummary.ID, artifactsDirpath, destDirpath); err != nil {
+			return stacktrace.Propagate(err, "An error occurred copying the artifacts out of controller container %v", containerSummary.ID)
+		}
+		return nil
+	}
+	return stacktrace.NewError("Couldn't find the controller container that ran test %v", testName)
+}
+
+// ================= Helper functions ===================
+func isContainerWithIP(containerSummary types.Container, ipAddr string) bool {
+	if containerSummary.NetworkSettings == nil {
+		return false
+	}
+	for _, endpoint := range containerSummary.NetworkSettings.Networks {
+		if endpoint != nil && endpoint.IPAddress == ipAddr {
+			return true
+		}
+	}
+	return false
+}
+
+func writeContainerLogs(dockerClient *client.Client, containerID string, logFilepath string) error {
+	logsReader, err := dockerClient.ContainerLogs(context.Background(), containerID, types.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Timestamps: true,
+	})
+	if err != nil {
+		return stacktrace.Propagate(err, "Failed to get the logs of container %v", containerID)
+	}
+	defer logsReader.Close()
+
+	logFile, err := os.Create(logFilepath)
+	if err != nil {
+		return stacktrace.Propagate(err, "Failed to create log file %v", logFilepath)
+	}
+	defer logFile.Close()
+	if _, err := stdcopy.StdCopy(logFile, logFile, logsReader); err != nil {
+		return stacktrace.Propagate(err, "Failed to write the logs of container %v", containerID)
+	}
+	return nil
+}
+
+func parseEnv(envList []string) map[string]string {
+	result := make(map[string]string)
+	for _, envVar := range envList {
+		keyAndValue := strings.SplitN(envVar, "=", 2)
+		if len(keyAndValue) == 2 {
+			result[keyAndValue[0]] = keyAndValue[1]
+		}
+	}
+	return result
+}
+
+// copyDirFromContainer copies the contents of a directory in a (possibly stopped) container into a local directory
+func copyDirFromContainer(dockerClient *client.Client, containerID string, srcDirpath string, destDirpath string) error {
+	tarReader, _, err := dockerClient.CopyFromContainer(context.Background(), containerID, srcDirpath)
+	if err != nil {
+		return stacktrace.Propagate(err, "Failed to copy %v out of container %v", srcDirpath, containerID)
+	}
+	defer tarReader.Close()
+
+	// The archive's entries are all inside a top-level directory named after the source directory, which we strip
+	srcDirname := filepath.Base(srcDirpath)
+	archive := tar.NewReader(tarReader)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return stacktrace.Propagate(err, "An error occurred reading the archive of %v", srcDirpath)
+		}
+		relativePath := strings.TrimPrefix(strings.TrimPrefix(header.Name, srcDirname), "/")
+		if relativePath == "" || strings.Contains(relativePath, "..") {
+			continue
+		}
+		destPath := filepath.Join(destDirpath, relativePath)
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(destPath, reportDirPerms); err != nil {
+				return stacktrace.Propagate(err, "Could not create directory %v", destPath)
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(destPath), reportDirPerms); err != nil {
+				return stacktrace.Propagate(err, "Could not create directory %v", filepath.Dir(destPath))
+			}
+			if err := writeFileFromReader(destPath, archive); err != nil {
+				return stacktrace.Propagate(err, "Could not extract %v", destPath)
+			}
+		}
+	}
+}
+
+func writeFileFromReader(destPath string, reader io.Reader) error {
+	destFile, err := os.Create(destPath)
+	if err != nil {
+		return stacktrace.Propagate(err, "Could not create file %v", destPath)
+	}
+	defer destFile.Close()
+	if _, err := io.Copy(destFile, reader); err != nil {
+		return stacktrace.Propagate(err, "Could not write file %v", destPath)
+	}
+	return nil
+}
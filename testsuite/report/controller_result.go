@@ -0,0 +1,75 @@
+package report
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/palantir/stacktrace"
+)
+
+const (
+	// ControllerArtifactsDirname is the directory on the test volume that the controller puts a test's artifacts in, which
+	// the initializer copies out of the controller container after the test
+	ControllerArtifactsDirname = "artifacts"
+
+	// The file in the controller's artifacts directory that the outcome of the test gets written to
+	controllerResultFilename = "result.json"
+
+	// MetricsFilename is the file in the controller's artifacts directory that the metrics scraped from the test
+	// network's nodes get written to
+	MetricsFilename = "metrics.json"
+
+	// HealthFilename is the file in the controller's artifacts directory that the health transitions of the test
+	// network's nodes get written to
+	HealthFilename = "health.json"
+
+	// ProfilesDirname is the directory in the controller's artifacts directory that the profiles of the test's profiling
+	// phases get copied to, with a subdirectory per phase
+	ProfilesDirname = "profiles"
+)
+
+// ControllerResult is the outcome of a test, as seen from inside the controller that ran it
+type ControllerResult struct {
+	Status   TestStatus    `json:"status"`
+	Duration time.Duration `json:"durationNanos"`
+	Failure  string        `json:"failure,omitempty"`
+}
+
+// GetControllerArtifactsDirpath returns the directory the controller puts its artifacts in, given where the test volume
+// is mounted
+func GetControllerArtifactsDirpath(testVolumeMountpoint string) string {
+	return filepath.Join(testVolumeMountpoint, ControllerArtifactsDirname)
+}
+
+// WriteControllerResult writes the result of a test into the controller's artifacts directory
+func WriteControllerResult(artifactsDirpath string, result ControllerResult) error {
+	if err := os.MkdirAll(artifactsDirpath, reportDirPerms); err != nil {
+		return stacktrace.Propagate(err, "Could not create artifacts directory %v", artifactsDirpath)
+	}
+	resultBytes, err := json.Marshal(result)
+	if err != nil {
+		return stacktrace.Propagate(err, "Could not serialize the controller result")
+	}
+	resultFilepath := filepath.Join(artifactsDirpath, controllerResultFilename)
+	if err := ioutil.WriteFile(resultFilepath, resultBytes, reportFilePerms); err != nil {
+		return stacktrace.Propagate(err, "Could not write the controller result to %v", resultFilepath)
+	}
+	return nil
+}
+
+// readControllerResult reads a result written by WriteControllerResult out of a copied artifacts directory
+func readControllerResult(artifactsDirpath string) (*ControllerResult, error) {
+	resultFilepath := filepath.Join(artifactsDirpath, controllerResultFilename)
+	resultBytes, err := ioutil.ReadFile(resultFilepath)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Could not read the controller result from %v", resultFilepath)
+	}
+	result := &ControllerResult{}
+	if err := json.Unmarshal(resultBytes, result); err != nil {
+		return nil, stacktrace.Propagate(err, "Could not deserialize the controller result")
+	}
+	return result, nil
+}
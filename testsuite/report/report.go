@@ -0,0 +1,220 @@
+package report
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/palantir/stacktrace"
+)
+
+const (
+	// JSONReportFilename is the name of the JSON report inside the report directory
+	JSONReportFilename = "report.json"
+
+	// JUnitReportFilename is the name of the JUnit XML report inside the report directory
+	JUnitReportFilename = "junit.xml"
+
+	// ArtifactsDirname is the name of the directory inside the report directory that gets a subdirectory of artifacts
+	// per test
+	ArtifactsDirname = "artifacts"
+
+	junitSuiteName = "avalanche-testing"
+
+	reportFilePerms = 0644
+	reportDirPerms  = 0755
+)
+
+// TestStatus is the outcome of a single test
+type TestStatus string
+
+const (
+	// Passed means the test ran and succeeded
+	Passed TestStatus = "passed"
+
+	// Failed means the test ran and failed
+	Failed TestStatus = "failed"
+
+	// SetupError means the test's network couldn't be set up, so the test never ran
+	SetupError TestStatus = "setup_error"
+
+	// Error means the test couldn't be run at all (e.g. the controller couldn't be started)
+	Error TestStatus = "error"
+)
+
+// TestResult is the report entry for a single test
+type TestResult struct {
+	Name     string        `json:"name"`
+	Status   TestStatus    `json:"status"`
+	Duration time.Duration `json:"durationNanos"`
+
+	// The stacktrace of the error that failed the test, if it didn't pass
+	Failure string `json:"failure,omitempty"`
+
+	// Paths, relative to the report directory, of the files (like service logs) collected for the test
+	Artifacts []string `json:"artifacts,omitempty"`
+}
+
+// Report is the machine-readable summary of a test suite run
+type Report struct {
+	StartTime time.Time     `json:"startTime"`
+	Duration  time.Duration `json:"durationNanos"`
+
+	// The names of the node images the tests were run with
+	AvalancheImageName      string `json:"avalancheImageName"`
+	ByzantineImageName      string `json:"byzantineImageName,omitempty"`
+	UpgradeOldImageName     string `json:"upgradeOldImageName,omitempty"`
+	UpgradeNewImageName     string `json:"upgradeNewImageName,omitempty"`
+	StakingRewardsImageName string `json:"stakingRewardsImageName,omitempty"`
+
+	Tests []TestResult `json:"tests"`
+}
+
+// AllPassed returns true if every test in the report passed
+func (report Report) AllPassed() bool {
+	for _, test := range report.Tests {
+		if test.Status != Passed {
+			return false
+		}
+	}
+	return true
+}
+
+// WriteReports writes the JSON and JUnit XML versions of the report into the given directory
+func WriteReports(report Report, reportDirpath string) error {
+	if err := os.MkdirAll(reportDirpath, reportDirPerms); err != nil {
+		return stacktrace.Propagate(err, "Could not create report directory %v", reportDirpath)
+	}
+	if err := WriteJSON(report, filepath.Join(reportDirpath, JSONReportFilename)); err != nil {
+		return stacktrace.Propagate(err, "An error occurred writing the JSON report")
+	}
+	if err := WriteJUnit(report, filepath.Join(reportDirpath, JUnitReportFilename)); err != nil {
+		return stacktrace.Propagate(err, "An error occurred writing the JUnit report")
+	}
+	return nil
+}
+
+// WriteJSON writes the report as JSON to the given file
+func WriteJSON(report Report, filepath string) error {
+	reportBytes, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return stacktrace.Propagate(err, "Could not serialize the report to JSON")
+	}
+	if err := ioutil.WriteFile(filepath, reportBytes, reportFilePerms); err != nil {
+		return stacktrace.Propagate(err, "Could not write the JSON report to %v", filepath)
+	}
+	return nil
+}
+
+// WriteJUnit writes the report as JUnit XML to the given file
+func WriteJUnit(report Report, filepath string) error {
+	reportBytes, err := xml.MarshalIndent(toJUnit(report), "", "  ")
+	if err != nil {
+		return stacktrace.Propagate(err, "Could not serialize the report to JUnit XML")
+	}
+	reportBytes = append([]byte(xml.Header), reportBytes...)
+	if err := ioutil.WriteFile(filepath, reportBytes, reportFilePerms); err != nil {
+		return stacktrace.Propagate(err, "Could not write the JUnit report to %v", filepath)
+	}
+	return nil
+}
+
+// ================= JUnit XML format ===================
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Errors     int             `xml:"errors,attr"`
+	Time       string          `xml:"time,attr"`
+	Timestamp  string          `xml:"timestamp,attr"`
+	Properties []junitProperty `xml:"properties>property"`
+	TestCases  []junitTestCase `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitProblem `xml:"failure,omitempty"`
+	Error     *junitProblem `xml:"error,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitProblem struct {
+	Message  string `xml:"message,attr"`
+	Type     string `xml:"type,attr"`
+	Contents string `xml:",chardata"`
+}
+
+func toJUnit(report Report) junitTestSuites {
+	suite := junitTestSuite{
+		Name:      junitSuiteName,
+		Tests:     len(report.Tests),
+		Time:      formatSeconds(report.Duration),
+		Timestamp: report.StartTime.UTC().Format(time.RFC3339),
+		Properties: []junitProperty{
+			{Name: "avalancheImageName", Value: report.AvalancheImageName},
+			{Name: "byzantineImageName", Value: report.ByzantineImageName},
+			{Name: "upgradeOldImageName", Value: report.UpgradeOldImageName},
+			{Name: "upgradeNewImageName", Value: report.UpgradeNewImageName},
+			{Name: "stakingRewardsImageName", Value: report.StakingRewardsImageName},
+		},
+	}
+	for _, test := range report.Tests {
+		testCase := junitTestCase{
+			Name:      test.Name,
+			ClassName: junitSuiteName,
+			Time:      formatSeconds(test.Duration),
+		}
+		problem := &junitProblem{
+			Message:  fmt.Sprintf("Test %v finished with status %v", test.Name, test.Status),
+			Type:     string(test.Status),
+			Contents: test.Failure,
+		}
+		switch test.Status {
+		case Passed:
+		case Failed:
+			testCase.Failure = problem
+			suite.Failures++
+		default:
+			testCase.Error = problem
+			suite.Errors++
+		}
+		// Attachment lines in the format understood by the JUnit attachments plugin & most CI dashboards
+		for _, artifact := range test.Artifacts {
+			testCase.SystemOut += fmt.Sprintf("[[ATTACHMENT|%v]]\n", artifact)
+		}
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+	return junitTestSuites{
+		Name:     junitSuiteName,
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Errors:   suite.Errors,
+		Time:     suite.Time,
+		Suites:   []junitTestSuite{suite},
+	}
+}
+
+func formatSeconds(duration time.Duration) string {
+	return fmt.Sprintf("%.3f", duration.Seconds())
+}
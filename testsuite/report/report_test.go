@@ -0,0 +1,57 @@
+package report
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteReports(t *testing.T) {
+	reportDirpath, err := ioutil.TempDir("", "report-test")
+	assert.NoError(t, err)
+	defer os.RemoveAll(reportDirpath)
+
+	report := Report{
+		StartTime:           time.Unix(1600000000, 0),
+		Duration:            90 * time.Second,
+		AvalancheImageName:  "avaplatform/avalanchego:dev",
+		UpgradeOldImageName: "avaplatform/avalanchego:v0.8.2",
+		UpgradeNewImageName: "avaplatform/avalanchego:v0.8.3",
+		Tests: []TestResult{
+			{Name: "passingTest", Status: Passed, Duration: 30 * time.Second},
+			{Name: "failingTest", Status: Failed, Duration: 40 * time.Second, Failure: "boom", Artifacts: []string{"artifacts/failingTest/service-logs/node.log"}},
+			{Name: "brokenTest", Status: SetupError, Duration: 20 * time.Second, Failure: "no network"},
+		},
+	}
+	assert.False(t, report.AllPassed())
+	assert.NoError(t, WriteReports(report, reportDirpath))
+
+	jsonBytes, err := ioutil.ReadFile(filepath.Join(reportDirpath, JSONReportFilename))
+	assert.NoError(t, err)
+	parsedReport := Report{}
+	assert.NoError(t, json.Unmarshal(jsonBytes, &parsedReport))
+	assert.Equal(t, report.Tests, parsedReport.Tests)
+	assert.Equal(t, report.AvalancheImageName, parsedReport.AvalancheImageName)
+	assert.Equal(t, report.UpgradeOldImageName, parsedReport.UpgradeOldImageName)
+	assert.Equal(t, report.UpgradeNewImageName, parsedReport.UpgradeNewImageName)
+
+	junitBytes, err := ioutil.ReadFile(filepath.Join(reportDirpath, JUnitReportFilename))
+	assert.NoError(t, err)
+	parsedJUnit := junitTestSuites{}
+	assert.NoError(t, xml.Unmarshal(junitBytes, &parsedJUnit))
+	assert.Equal(t, 3, parsedJUnit.Tests)
+	assert.Equal(t, 1, parsedJUnit.Failures)
+	assert.Equal(t, 1, parsedJUnit.Errors)
+
+	testCases := parsedJUnit.Suites[0].TestCases
+	assert.Nil(t, testCases[0].Failure)
+	assert.Equal(t, "boom", testCases[1].Failure.Contents)
+	assert.Contains(t, testCases[1].SystemOut, "[[ATTACHMENT|artifacts/failingTest/service-logs/node.log]]")
+	assert.Equal(t, "no network", testCases[2].Error.Contents)
+}
@@ -0,0 +1,176 @@
+package report
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/docker/docker/client"
+	"github.com/palantir/stacktrace"
+	"github.com/sirupsen/logrus"
+)
+
+// TestRunner is the part of Kurtosis' TestSuiteRunner that's needed to run tests for a report
+type TestRunner interface {
+	RunTests(testNamesToRun map[string]bool, testParallelism uint) (bool, error)
+}
+
+// ReportingRunner runs tests with a TestRunner and writes their results as a report. The TestRunner only says whether
+// all the tests passed, so the result of each test is read from what its controller left in its artifacts.
+type ReportingRunner struct {
+	testRunner TestRunner
+
+	controllerImageName     string
+	avalancheImageName      string
+	byzantineImageName      string
+	upgradeOldImageName     string
+	upgradeNewImageName     string
+	stakingRewardsImageName string
+
+	// Where the report files and test artifacts get written
+	reportDirpath string
+}
+
+// NewReportingRunner creates a new ReportingRunner
+// Args:
+// 	testRunner: A Kurtosis TestSuiteRunner configured with the controller image and env vars to use
+// 	controllerImageName: The image of the test controller, used to find the controller of each test to get its artifacts
+// 	avalancheImageName: The normal node image the tests run with, for the report
+// 	byzantineImageName: The byzantine node image the tests run with, for the report
+// 	upgradeOldImageName: The image the rolling upgrade test starts its nodes on, if set, for the report
+// 	upgradeNewImageName: The image the rolling upgrade test upgrades its nodes to, if set, for the report
+// 	stakingRewardsImageName: The image the staking rewards test runs with, if set, for the report
+// 	reportDirpath: The directory the report files and test artifacts will be written to
+func NewReportingRunner(
+	testRunner TestRunner,
+	controllerImageName string,
+	avalancheImageName string,
+	byzantineImageName string,
+	upgradeOldImageName string,
+	upgradeNewImageName string,
+	stakingRewardsImageName string,
+	reportDirpath string) *ReportingRunner {
+	return &ReportingRunner{
+		testRunner:              testRunner,
+		controllerImageName:     controllerImageName,
+		avalancheImageName:      avalancheImageName,
+		byzantineImageName:      byzantineImageName,
+		upgradeOldImageName:     upgradeOldImageName,
+		upgradeNewImageName:     upgradeNewImageName,
+		stakingRewardsImageName: stakingRewardsImageName,
+		reportDirpath:           reportDirpath,
+	}
+}
+
+// RunTests runs the given tests, at most [parallelism] at a time, and writes the report once they're all done
+// Returns:
+// 	The report, which is also written to the report directory
+func (runner ReportingRunner) RunTests(testNames []string, parallelism uint) (*Report, error) {
+	dockerClient, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Could not create a Docker client")
+	}
+
+	report := &Report{
+		StartTime:               time.Now(),
+		AvalancheImageName:      runner.avalancheImageName,
+		ByzantineImageName:      runner.byzantineImageName,
+		UpgradeOldImageName:     runner.upgradeOldImageName,
+		UpgradeNewImageName:     runner.upgradeNewImageName,
+		StakingRewardsImageName: runner.stakingRewardsImageName,
+	}
+
+	// A single runner has to run all the tests, since separate runners would allocate the same Docker subnets and test
+	//  volumes and collide
+	testNamesToRun := make(map[string]bool, len(testNames))
+	for _, testName := range testNames {
+		testNamesToRun[testName] = true
+	}
+	allPassed, runErr := runner.testRunner.RunTests(testNamesToRun, parallelism)
+	report.Duration = time.Since(report.StartTime)
+
+	for _, testName := range testNames {
+		report.Tests = append(report.Tests, runner.getTestResult(dockerClient, testName, report.StartTime, allPassed, runErr))
+	}
+	sort.Slice(report.Tests, func(i, j int) bool {
+		return report.Tests[i].Name < report.Tests[j].Name
+	})
+
+	if err := WriteReports(*report, runner.reportDirpath); err != nil {
+		return nil, stacktrace.Propagate(err, "An error occurred writing the reports to %v", runner.reportDirpath)
+	}
+	return report, nil
+}
+
+// getTestResult copies the artifacts of a test that was run out of its controller, and gets its result from them
+// Args:
+// 	startTime: When the tests were started, so that controllers left behind by earlier runs get skipped
+// 	allPassed: Whether the test runner reported that all the tests passed
+// 	runErr: The error the test runner returned, if any
+func (runner ReportingRunner) getTestResult(
+	dockerClient *client.Client,
+	testName string,
+	startTime time.Time,
+	allPassed bool,
+	runErr error) TestResult {
+	// Without the controller's result, all we know is how the run as a whole went
+	result := TestResult{Name: testName}
+	switch {
+	case runErr != nil:
+		result.Status = Error
+		result.Failure = fmt.Sprintf("%+v", runErr)
+	case allPassed:
+		result.Status = Passed
+	default:
+		result.Status = Error
+		result.Failure = "Some tests failed, and the controller of this test didn't leave a result"
+	}
+
+	testArtifactsDirpath := filepath.Join(runner.reportDirpath, ArtifactsDirname, testName)
+	if err := copyTestArtifacts(dockerClient, runner.controllerImageName, testName, startTime, testArtifactsDirpath); err != nil {
+		logrus.Warnf("Couldn't get the artifacts of test %v: %v", testName, err)
+		return result
+	}
+
+	// The controller knows whether the test failed in setup or in the test itself, and how long it took
+	if controllerResult, err := readControllerResult(testArtifactsDirpath); err != nil {
+		logrus.Warnf("Couldn't read the controller's result for test %v: %v", testName, err)
+	} else {
+		result.Status = controllerResult.Status
+		result.Duration = controllerResult.Duration
+		result.Failure = controllerResult.Failure
+	}
+
+	artifacts, err := listArtifacts(testArtifactsDirpath)
+	if err != nil {
+		logrus.Warnf("Couldn't list the artifacts of test %v: %v", testName, err)
+	}
+	result.Artifacts = artifacts
+	return result
+}
+
+// listArtifacts returns the paths, relative to the report directory, of the artifact files of a test
+func listArtifacts(testArtifactsDirpath string) ([]string, error) {
+	reportDirpath := filepath.Dir(filepath.Dir(testArtifactsDirpath))
+	artifacts := []string{}
+	err := filepath.Walk(testArtifactsDirpath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || info.Name() == controllerResultFilename {
+			return nil
+		}
+		relativePath, err := filepath.Rel(reportDirpath, path)
+		if err != nil {
+			return err
+		}
+		artifacts = append(artifacts, relativePath)
+		return nil
+	})
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "An error occurred walking artifacts directory %v", testArtifactsDirpath)
+	}
+	return artifacts, nil
+}
@@ -0,0 +1,432 @@
+package scenario
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	avalancheNetwork "github.com/ava-labs/avalanche-testing/avalanche/networks"
+	avalancheService "github.com/ava-labs/avalanche-testing/avalanche/services"
+	"github.com/palantir/stacktrace"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	defaultExecutionTimeout = 5 * time.Minute
+	defaultSetupBuffer      = 3 * time.Minute
+	defaultLogLevel         = "debug"
+	defaultSnowQuorumSize   = 2
+	defaultSnowSampleSize   = 2
+	defaultInitialTimeout   = 2 * time.Second
+
+	// Boot nodes are started by the network loader itself, and their service & configuration IDs use these prefixes
+	bootNodeServiceIDPrefix = "boot-node-"
+	bootNodeConfigIDPrefix  = "boot-node-config-"
+)
+
+// The step actions a scenario can use
+const (
+	// Sends [amount] nAVAX from the genesis funds to [account]'s X Chain address
+	FundAction = "fund"
+	// Moves [amount] nAVAX of [account]'s funds to the P Chain and stakes it to make [node] a primary network validator
+	StakeAction = "stake"
+	// Moves [amount] nAVAX of [account]'s funds to the P Chain and delegates it to the validator [validator]
+	DelegateAction = "delegate"
+	// Sends [amount] nAVAX from [account]'s X Chain address to [to]'s X Chain address
+	SendAction = "send"
+	// Starts node [node] with node configuration [config]
+	AddNodeAction = "add_node"
+	// Stops and removes node [node]
+	RemoveNodeAction = "remove_node"
+	// Checks that [account]'s balance on [chain] is exactly [balance] nAVAX
+	AssertBalanceAction = "assert_balance"
+	// Checks that [node]'s peers are exactly the nodes [peers], or include them if [atLeast] is set
+	AssertPeersAction = "assert_peers"
+)
+
+// The chains that balances can be asserted on
+const (
+	XChain = "X"
+	PChain = "P"
+)
+
+// Scenario is a test defined declaratively in a YAML or JSON file rather than in Go: the network it runs on and the
+// ordered steps that make up the test
+type Scenario struct {
+	// The name the test is registered under, which defaults to the file's name without its extension
+	Name string `yaml:"name"`
+
+	// How long the steps may take to run, and how long the network may take to set up
+	ExecutionTimeout time.Duration `yaml:"executionTimeout"`
+	SetupBuffer      time.Duration `yaml:"setupBuffer"`
+
+	// The settings of the network & its boot nodes
+	Network NetworkConfig `yaml:"network"`
+
+	// Mapping of configuration ID -> the settings of the nodes started with that configuration
+	NodeConfigs map[string]NodeConfig `yaml:"nodeConfigs"`
+
+	// Mapping of service ID -> configuration ID of the nodes that are started along with the boot nodes
+	InitialNodes map[string]string `yaml:"initialNodes"`
+
+	// The steps of the test, which are run in order
+	Steps []Step `yaml:"steps"`
+}
+
+// NetworkConfig holds the network-wide settings of a scenario
+type NetworkConfig struct {
+	// The image the boot nodes run; empty means the suite's Avalanche image
+	Image string `yaml:"image"`
+
+	LogLevel       string        `yaml:"logLevel"`
+	SnowQuorumSize int           `yaml:"snowQuorumSize"`
+	SnowSampleSize int           `yaml:"snowSampleSize"`
+	TxFee          uint64        `yaml:"txFee"`
+	InitialTimeout time.Duration `yaml:"initialTimeout"`
+}
+
+// NodeConfig holds the settings of the nodes started with one configuration; unset fields default to the network's
+type NodeConfig struct {
+	Image          string `yaml:"image"`
+	LogLevel       string `yaml:"logLevel"`
+	SnowQuorumSize int    `yaml:"snowQuorumSize"`
+	SnowSampleSize int    `yaml:"snowSampleSize"`
+
+	// Whether the nodes get their own certs (and so node IDs); only set this to false to test duplicate node IDs
+	VaryCerts *bool `yaml:"varyCerts"`
+
+	// Extra flags the nodes are started with, without the leading dashes, for settings not covered above
+	CLIArgs map[string]string `yaml:"cliArgs"`
+}
+
+// toNodeConfig converts the settings to the node config of the nodes started with them
+func (nodeConfig NodeConfig) toNodeConfig(network NetworkConfig) avalancheService.NodeConfig {
+	extraFlags := make(map[string]string)
+	for param, argument := range nodeConfig.CLIArgs {
+		extraFlags[param] = argument
+	}
+	return avalancheService.NodeConfig{
+		LogLevel:              avalancheService.AvalancheLogLevel(nodeConfig.LogLevel),
+		SnowQuorumSize:        nodeConfig.SnowQuorumSize,
+		SnowSampleSize:        nodeConfig.SnowSampleSize,
+		NetworkInitialTimeout: network.InitialTimeout,
+		ExtraFlags:            extraFlags,
+	}
+}
+
+// Step is a single action of a scenario; which of the fields are used depends on the action
+// Accounts are keystore users that are created on the node of the first step that names them, and are used through
+// that node from then on.
+type Step struct {
+	Action string `yaml:"action"`
+
+	// The service ID of the node the step acts on or through
+	Node string `yaml:"node"`
+	// The node configuration to start a node with
+	Config string `yaml:"config"`
+
+	// The account the step acts on, and the account that receives a send
+	Account string `yaml:"account"`
+	To      string `yaml:"to"`
+
+	// The nAVAX amount to fund, stake, delegate or send
+	Amount uint64 `yaml:"amount"`
+	// The service ID of the validator to delegate to
+	Validator string `yaml:"validator"`
+
+	// The chain and nAVAX balance that an account's balance is asserted against
+	Chain   string `yaml:"chain"`
+	Balance uint64 `yaml:"balance"`
+
+	// The service IDs of the nodes that are asserted to be peers
+	Peers   []string `yaml:"peers"`
+	AtLeast bool     `yaml:"atLeast"`
+}
+
+// LoadDir loads every scenario file (.yaml, .yml or .json) in the given directory
+// Returns:
+// 	The scenarios sorted by name, none of which share a name
+func LoadDir(dirpath string) ([]*Scenario, error) {
+	fileInfos, err := ioutil.ReadDir(dirpath)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to read scenario directory %v", dirpath)
+	}
+	scenarios := []*Scenario{}
+	names := make(map[string]string)
+	for _, fileInfo := range fileInfos {
+		if fileInfo.IsDir() || !isScenarioFile(fileInfo.Name()) {
+			continue
+		}
+		scenarioFilepath := filepath.Join(dirpath, fileInfo.Name())
+		scenario, err := LoadFile(scenarioFilepath)
+		if err != nil {
+			return nil, stacktrace.Propagate(err, "Failed to load scenario file %v", scenarioFilepath)
+		}
+		if otherFilepath, found := names[scenario.Name]; found {
+			return nil, stacktrace.NewError("Scenarios %v and %v are both named %v", otherFilepath, scenarioFilepath, scenario.Name)
+		}
+		names[scenario.Name] = scenarioFilepath
+		scenarios = append(scenarios, scenario)
+	}
+	sort.Slice(scenarios, func(i, j int) bool {
+		return scenarios[i].Name < scenarios[j].Name
+	})
+	return scenarios, nil
+}
+
+// LoadFile loads and validates the scenario in the given YAML or JSON file
+func LoadFile(scenarioFilepath string) (*Scenario, error) {
+	data, err := ioutil.ReadFile(scenarioFilepath)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to read scenario file %v", scenarioFilepath)
+	}
+	filename := filepath.Base(scenarioFilepath)
+	scenario, err := Parse(data, strings.TrimSuffix(filename, filepath.Ext(filename)))
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Invalid scenario file %v", scenarioFilepath)
+	}
+	return scenario, nil
+}
+
+// Parse parses and validates a scenario from YAML or JSON (which is a subset of YAML), filling in the defaults of
+// any unset fields
+// Args:
+// 	data: The contents of the scenario file
+// 	defaultName: The name to give the scenario if it doesn't name itself
+func Parse(data []byte, defaultName string) (*Scenario, error) {
+	scenario := &Scenario{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	// Catch misspelled fields, which would otherwise silently be left unset
+	decoder.KnownFields(true)
+	if err := decoder.Decode(scenario); err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to parse scenario")
+	}
+	if scenario.Name == "" {
+		scenario.Name = defaultName
+	}
+	scenario.setDefaults()
+	if err := scenario.validate(); err != nil {
+		return nil, stacktrace.Propagate(err, "Invalid scenario %v", scenario.Name)
+	}
+	return scenario, nil
+}
+
+func (scenario *Scenario) setDefaults() {
+	if scenario.ExecutionTimeout == 0 {
+		scenario.ExecutionTimeout = defaultExecutionTimeout
+	}
+	if scenario.SetupBuffer == 0 {
+		scenario.SetupBuffer = defaultSetupBuffer
+	}
+
+	network := &scenario.Network
+	if network.LogLevel == "" {
+		network.LogLevel = defaultLogLevel
+	}
+	if network.SnowQuorumSize == 0 {
+		network.SnowQuorumSize = defaultSnowQuorumSize
+	}
+	if network.SnowSampleSize == 0 {
+		network.SnowSampleSize = defaultSnowSampleSize
+	}
+	if network.InitialTimeout == 0 {
+		network.InitialTimeout = defaultInitialTimeout
+	}
+
+	for configID, nodeConfig := range scenario.NodeConfigs {
+		if nodeConfig.Image == "" {
+			nodeConfig.Image = network.Image
+		}
+		if nodeConfig.LogLevel == "" {
+			nodeConfig.LogLevel = network.LogLevel
+		}
+		if nodeConfig.SnowQuorumSize == 0 {
+			nodeConfig.SnowQuorumSize = network.SnowQuorumSize
+		}
+		if nodeConfig.SnowSampleSize == 0 {
+			nodeConfig.SnowSampleSize = network.SnowSampleSize
+		}
+		if nodeConfig.VaryCerts == nil {
+			varyCerts := true
+			nodeConfig.VaryCerts = &varyCerts
+		}
+		if nodeConfig.CLIArgs == nil {
+			nodeConfig.CLIArgs = make(map[string]string)
+		}
+		scenario.NodeConfigs[configID] = nodeConfig
+	}
+}
+
+// validate checks the scenario's settings, and walks through its steps to check that every node and account they
+// refer to exists at that point of the scenario
+func (scenario *Scenario) validate() error {
+	if !avalancheService.IsKnownLogLevel(avalancheService.AvalancheLogLevel(scenario.Network.LogLevel)) {
+		return stacktrace.NewError("Network has unknown log level %v", scenario.Network.LogLevel)
+	}
+	for configID, nodeConfig := range scenario.NodeConfigs {
+		if strings.HasPrefix(configID, bootNodeConfigIDPrefix) {
+			return stacktrace.NewError("Node config %v uses the prefix reserved for boot node configs", configID)
+		}
+		if !avalancheService.IsKnownLogLevel(avalancheService.AvalancheLogLevel(nodeConfig.LogLevel)) {
+			return stacktrace.NewError("Node config %v has unknown log level %v", configID, nodeConfig.LogLevel)
+		}
+		if err := nodeConfig.toNodeConfig(scenario.Network).Validate(); err != nil {
+			return stacktrace.Propagate(err, "Node config %v is invalid", configID)
+		}
+	}
+
+	numBootNodes := len(avalancheNetwork.DefaultLocalNetGenesisConfig.Stakers)
+	runningNodes := make(map[string]bool)
+	for serviceID, configID := range scenario.InitialNodes {
+		if hasBootNodePrefix(serviceID) {
+			return stacktrace.NewError("Initial node %v uses the prefix reserved for boot nodes", serviceID)
+		}
+		if _, found := scenario.NodeConfigs[configID]; !found {
+			return stacktrace.NewError("Initial node %v has unknown node config %v", serviceID, configID)
+		}
+		runningNodes[serviceID] = true
+	}
+	isRunning := func(serviceID string) bool {
+		return runningNodes[serviceID] || isBootNode(serviceID, numBootNodes)
+	}
+
+	accounts := make(map[string]bool)
+	for i, step := range scenario.Steps {
+		if err := step.validate(scenario, isRunning, accounts); err != nil {
+			return stacktrace.Propagate(err, "Invalid step %v (%v)", i, step.Action)
+		}
+		switch step.Action {
+		case AddNodeAction:
+			runningNodes[step.Node] = true
+		case RemoveNodeAction:
+			delete(runningNodes, step.Node)
+		}
+		if step.Account != "" {
+			accounts[step.Account] = true
+		}
+	}
+	return nil
+}
+
+// validate checks that the step has the fields its action needs, given the nodes running and the accounts that have
+// been created by the time it runs
+func (step Step) validate(scenario *Scenario, isRunning func(string) bool, accounts map[string]bool) error {
+	requireRunningNode := func(serviceID string) error {
+		if serviceID == "" {
+			return stacktrace.NewError("No node given")
+		}
+		if !isRunning(serviceID) {
+			return stacktrace.NewError("Node %v isn't running at this point", serviceID)
+		}
+		return nil
+	}
+	// Accounts are created on the node named by the first step that uses them
+	requireAccount := func() error {
+		if step.Account == "" {
+			return stacktrace.NewError("No account given")
+		}
+		if !accounts[step.Account] {
+			return requireRunningNode(step.Node)
+		}
+		return nil
+	}
+	requireAmount := func() error {
+		if step.Amount == 0 {
+			return stacktrace.NewError("No amount given")
+		}
+		return nil
+	}
+
+	switch step.Action {
+	case FundAction, SendAction:
+		if err := requireAccount(); err != nil {
+			return err
+		}
+		if step.Action == SendAction && !accounts[step.To] {
+			return stacktrace.NewError("Account %v to send to hasn't been used by an earlier step", step.To)
+		}
+		return requireAmount()
+	case StakeAction:
+		if err := requireAccount(); err != nil {
+			return err
+		}
+		// The account stakes for the node it's used through
+		if err := requireRunningNode(step.Node); err != nil {
+			return err
+		}
+		return requireAmount()
+	case DelegateAction:
+		if err := requireAccount(); err != nil {
+			return err
+		}
+		if err := requireRunningNode(step.Validator); err != nil {
+			return stacktrace.Propagate(err, "Invalid validator")
+		}
+		return requireAmount()
+	case AddNodeAction:
+		if step.Node == "" {
+			return stacktrace.NewError("No node given")
+		}
+		if hasBootNodePrefix(step.Node) {
+			return stacktrace.NewError("Node %v uses the prefix reserved for boot nodes", step.Node)
+		}
+		if isRunning(step.Node) {
+			return stacktrace.NewError("Node %v is already running", step.Node)
+		}
+		if _, found := scenario.NodeConfigs[step.Config]; !found {
+			return stacktrace.NewError("Unknown node config %v", step.Config)
+		}
+		return nil
+	case RemoveNodeAction:
+		return requireRunningNode(step.Node)
+	case AssertBalanceAction:
+		if err := requireAccount(); err != nil {
+			return err
+		}
+		if step.Chain != XChain && step.Chain != PChain {
+			return stacktrace.NewError("Chain must be %v or %v, not %v", XChain, PChain, step.Chain)
+		}
+		return nil
+	case AssertPeersAction:
+		if err := requireRunningNode(step.Node); err != nil {
+			return err
+		}
+		for _, peer := range step.Peers {
+			if err := requireRunningNode(peer); err != nil {
+				return stacktrace.Propagate(err, "Invalid peer")
+			}
+		}
+		return nil
+	default:
+		return stacktrace.NewError("Unknown action %v", step.Action)
+	}
+}
+
+func isScenarioFile(filename string) bool {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".yaml", ".yml", ".json":
+		return true
+	default:
+		return false
+	}
+}
+
+// hasBootNodePrefix returns true if [serviceID] uses the prefix reserved for the IDs of boot nodes
+func hasBootNodePrefix(serviceID string) bool {
+	return strings.HasPrefix(serviceID, bootNodeServiceIDPrefix)
+}
+
+// isBootNode returns true if [serviceID] is the ID of one of the [numBootNodes] boot nodes that the network loader
+// starts, which are numbered from 0
+func isBootNode(serviceID string, numBootNodes int) bool {
+	if !hasBootNodePrefix(serviceID) {
+		return false
+	}
+	indexStr := strings.TrimPrefix(serviceID, bootNodeServiceIDPrefix)
+	index, err := strconv.Atoi(indexStr)
+	return err == nil && strconv.Itoa(index) == indexStr && index >= 0 && index < numBootNodes
+}
@@ -0,0 +1,141 @@
+package scenario
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	// The directory of the scenarios that ship with the suite, relative to this package
+	suiteScenariosDirpath = "../../scenarios"
+)
+
+func TestParseYAML(t *testing.T) {
+	data := []byte(`
+name: yamlScenario
+executionTimeout: 10m
+network:
+  txFee: 1000000
+nodeConfigs:
+  staker:
+    logLevel: info
+    cliArgs:
+      api-ipcs-enabled: "true"
+  duplicate:
+    varyCerts: false
+initialNodes:
+  node-0: staker
+steps:
+  - action: fund
+    node: node-0
+    account: alice
+    amount: 100
+  - action: add_node
+    node: node-1
+    config: duplicate
+  - action: assert_peers
+    node: node-1
+    peers: [node-0, boot-node-0]
+    atLeast: true
+`)
+	scenario, err := Parse(data, "default")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "yamlScenario", scenario.Name)
+	assert.Equal(t, 10*time.Minute, scenario.ExecutionTimeout)
+	assert.Equal(t, defaultSetupBuffer, scenario.SetupBuffer)
+	assert.Equal(t, uint64(1000000), scenario.Network.TxFee)
+	assert.Equal(t, defaultLogLevel, scenario.Network.LogLevel)
+	assert.Equal(t, defaultInitialTimeout, scenario.Network.InitialTimeout)
+
+	staker := scenario.NodeConfigs["staker"]
+	assert.Equal(t, "info", staker.LogLevel)
+	assert.Equal(t, defaultSnowQuorumSize, staker.SnowQuorumSize)
+	assert.True(t, *staker.VaryCerts)
+	assert.Equal(t, map[string]string{"api-ipcs-enabled": "true"}, staker.CLIArgs)
+	assert.False(t, *scenario.NodeConfigs["duplicate"].VaryCerts)
+
+	assert.Equal(t, map[string]string{"node-0": "staker"}, scenario.InitialNodes)
+	assert.Len(t, scenario.Steps, 3)
+	assert.Equal(t, Step{Action: FundAction, Node: "node-0", Account: "alice", Amount: 100}, scenario.Steps[0])
+	assert.Equal(t, []string{"node-0", "boot-node-0"}, scenario.Steps[2].Peers)
+	assert.True(t, scenario.Steps[2].AtLeast)
+}
+
+func TestParseJSON(t *testing.T) {
+	data := []byte(`{
+		"setupBuffer": "1m",
+		"steps": [
+			{"action": "fund", "node": "boot-node-0", "account": "alice", "amount": 100},
+			{"action": "fund", "node": "boot-node-1", "account": "bob", "amount": 100},
+			{"action": "send", "account": "alice", "to": "bob", "amount": 50},
+			{"action": "assert_balance", "account": "bob", "chain": "X", "balance": 150}
+		]
+	}`)
+	scenario, err := Parse(data, "jsonScenario")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "jsonScenario", scenario.Name)
+	assert.Equal(t, time.Minute, scenario.SetupBuffer)
+	assert.Equal(t, defaultExecutionTimeout, scenario.ExecutionTimeout)
+	assert.Len(t, scenario.Steps, 4)
+	assert.Equal(t, Step{Action: AssertBalanceAction, Account: "bob", Chain: XChain, Balance: 150}, scenario.Steps[3])
+}
+
+func TestParseInvalid(t *testing.T) {
+	invalidScenarios := map[string]string{
+		"unknown field":           "steps:\n  - action: fund\n    acount: alice\n",
+		"unknown action":          "steps:\n  - action: explode\n",
+		"unknown log level":       "network:\n  logLevel: loud\n",
+		"unknown node config":     "initialNodes:\n  node-0: missing\n",
+		"reserved service ID":     "nodeConfigs:\n  normal: {}\ninitialNodes:\n  boot-node-9: normal\n",
+		"reserved config ID":      "nodeConfigs:\n  boot-node-config-9: {}\n",
+		"reserved CLI arg":        "nodeConfigs:\n  normal:\n    cliArgs:\n      http-port: \"1234\"\n",
+		"invalid quorum size":     "nodeConfigs:\n  normal:\n    snowQuorumSize: 1\n    snowSampleSize: 4\n",
+		"account without node":    "steps:\n  - action: fund\n    account: alice\n    amount: 1\n",
+		"missing amount":          "steps:\n  - action: fund\n    node: boot-node-0\n    account: alice\n",
+		"send to unknown account": "steps:\n  - action: send\n    node: boot-node-0\n    account: alice\n    to: bob\n    amount: 1\n",
+		"unknown chain":           "steps:\n  - action: assert_balance\n    node: boot-node-0\n    account: alice\n    chain: C\n",
+		"node not yet added":      "steps:\n  - action: assert_peers\n    node: node-0\n",
+		"unknown boot node":       "steps:\n  - action: assert_peers\n    node: boot-node-9\n",
+		"padded boot node index":  "steps:\n  - action: assert_peers\n    node: boot-node-01\n",
+		"node added twice": "nodeConfigs:\n  normal: {}\ninitialNodes:\n  node-0: normal\n" +
+			"steps:\n  - action: add_node\n    node: node-0\n    config: normal\n",
+		"node used after removal": "nodeConfigs:\n  normal: {}\ninitialNodes:\n  node-0: normal\n" +
+			"steps:\n  - action: remove_node\n    node: node-0\n  - action: assert_peers\n    node: node-0\n",
+	}
+	for description, data := range invalidScenarios {
+		_, err := Parse([]byte(data), "invalid")
+		assert.Error(t, err, description)
+	}
+}
+
+func TestLoadDir(t *testing.T) {
+	dirpath, err := ioutil.TempDir("", "scenario-test")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dirpath)
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dirpath, "b_scenario.yml"), []byte("steps: []\n"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dirpath, "a_scenario.json"), []byte("{}"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dirpath, "README.md"), []byte("# Not a scenario"), 0644))
+	scenarios, err := LoadDir(dirpath)
+	assert.NoError(t, err)
+	assert.Len(t, scenarios, 2)
+	assert.Equal(t, "a_scenario", scenarios[0].Name)
+	assert.Equal(t, "b_scenario", scenarios[1].Name)
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dirpath, "c_scenario.yaml"), []byte("name: a_scenario\n"), 0644))
+	_, err = LoadDir(dirpath)
+	assert.Error(t, err)
+}
+
+func TestSuiteScenariosAreValid(t *testing.T) {
+	scenarios, err := LoadDir(suiteScenariosDirpath)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, scenarios)
+}
@@ -0,0 +1,286 @@
+package scenario
+
+import (
+	"sort"
+	"time"
+
+	avalancheNetwork "github.com/ava-labs/avalanche-testing/avalanche/networks"
+	"github.com/ava-labs/avalanche-testing/testsuite/helpers"
+	"github.com/ava-labs/avalanche-testing/testsuite/verifier"
+	"github.com/ava-labs/avalanchego/api"
+	"github.com/kurtosis-tech/kurtosis/commons/networks"
+	"github.com/palantir/stacktrace"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	genesisFunderUsername = "scenario_genesis_funder"
+	accountUsernamePrefix = "scenario_"
+	accountPassword       = "Scenar1oAcc0unt!"
+
+	// How often to check a node's peers while waiting for them to match an assertion
+	peersPollInterval = time.Second
+)
+
+// account is a keystore user that a scenario's steps act on, through the node it was created on
+type account struct {
+	runner        *helpers.RPCWorkFlowRunner
+	xChainAddress string
+	pChainAddress string
+}
+
+// scenarioRun holds the state of a scenario as its steps are run
+type scenarioRun struct {
+	network                  avalancheNetwork.TestAvalancheNetwork
+	txFee                    uint64
+	networkAcceptanceTimeout time.Duration
+
+	// The user holding the genesis funds, which is created by the first fund step
+	genesisFunder *helpers.RPCWorkFlowRunner
+
+	// Mapping of account name -> account, for the accounts the steps have used so far
+	accounts map[string]*account
+}
+
+func newScenarioRun(network avalancheNetwork.TestAvalancheNetwork, txFee uint64, networkAcceptanceTimeout time.Duration) *scenarioRun {
+	return &scenarioRun{
+		network:                  network,
+		txFee:                    txFee,
+		networkAcceptanceTimeout: networkAcceptanceTimeout,
+		accounts:                 make(map[string]*account),
+	}
+}
+
+// runStep runs the given step, blocking until its effects have been accepted by the network
+func (run *scenarioRun) runStep(step Step) error {
+	switch step.Action {
+	case FundAction:
+		return run.fund(step)
+	case StakeAction:
+		return run.stake(step)
+	case DelegateAction:
+		return run.delegate(step)
+	case SendAction:
+		return run.send(step)
+	case AddNodeAction:
+		return run.addNode(step)
+	case RemoveNodeAction:
+		return run.removeNode(step)
+	case AssertBalanceAction:
+		return run.assertBalance(step)
+	case AssertPeersAction:
+		return run.assertPeers(step)
+	default:
+		return stacktrace.NewError("Unknown action %v", step.Action)
+	}
+}
+
+func (run *scenarioRun) fund(step Step) error {
+	acct, err := run.getAccount(step)
+	if err != nil {
+		return stacktrace.Propagate(err, "Failed to get account %v on node %v", step.Account, step.Node)
+	}
+	if run.genesisFunder == nil {
+		funder, err := run.newGenesisFunder()
+		if err != nil {
+			return stacktrace.Propagate(err, "Failed to set up the genesis funder")
+		}
+		run.genesisFunder = funder
+	}
+	if err := run.genesisFunder.FundXChainAddresses([]string{acct.xChainAddress}, step.Amount); err != nil {
+		return stacktrace.Propagate(err, "Failed to fund account %v", step.Account)
+	}
+	logrus.Infof("Funded account %v with %v nAVAX.", step.Account, step.Amount)
+	return nil
+}
+
+func (run *scenarioRun) stake(step Step) error {
+	acct, err := run.getAccount(step)
+	if err != nil {
+		return stacktrace.Propagate(err, "Failed to get account %v on node %v", step.Account, step.Node)
+	}
+	nodeID, err := run.getNodeID(step.Node)
+	if err != nil {
+		return stacktrace.Propagate(err, "Failed to get the node ID of %v", step.Node)
+	}
+	// The import onto the P Chain costs a fee on top of the stake
+	if err := acct.runner.TransferAvaXChainToPChain(acct.pChainAddress, step.Amount+run.txFee); err != nil {
+		return stacktrace.Propagate(err, "Failed to move account %v's stake to the P Chain", step.Account)
+	}
+	if err := acct.runner.AddValidatorToPrimaryNetwork(nodeID, acct.pChainAddress, step.Amount); err != nil {
+		return stacktrace.Propagate(err, "Failed to add %v as a validator", step.Node)
+	}
+	logrus.Infof("Account %v staked %v nAVAX for %v (%v).", step.Account, step.Amount, step.Node, nodeID)
+	return nil
+}
+
+func (run *scenarioRun) delegate(step Step) error {
+	acct, err := run.getAccount(step)
+	if err != nil {
+		return stacktrace.Propagate(err, "Failed to get account %v on node %v", step.Account, step.Node)
+	}
+	validatorNodeID, err := run.getNodeID(step.Validator)
+	if err != nil {
+		return stacktrace.Propagate(err, "Failed to get the node ID of validator %v", step.Validator)
+	}
+	if err := acct.runner.TransferAvaXChainToPChain(acct.pChainAddress, step.Amount+run.txFee); err != nil {
+		return stacktrace.Propagate(err, "Failed to move account %v's stake to the P Chain", step.Account)
+	}
+	if err := acct.runner.AddDelegatorToPrimaryNetwork(validatorNodeID, acct.pChainAddress, step.Amount); err != nil {
+		return stacktrace.Propagate(err, "Failed to delegate to %v", step.Validator)
+	}
+	logrus.Infof("Account %v delegated %v nAVAX to %v (%v).", step.Account, step.Amount, step.Validator, validatorNodeID)
+	return nil
+}
+
+func (run *scenarioRun) send(step Step) error {
+	acct, err := run.getAccount(step)
+	if err != nil {
+		return stacktrace.Propagate(err, "Failed to get account %v on node %v", step.Account, step.Node)
+	}
+	to, found := run.accounts[step.To]
+	if !found {
+		return stacktrace.NewError("Unknown account %v", step.To)
+	}
+	txID, err := acct.runner.SendAVAX(to.xChainAddress, step.Amount)
+	if err != nil {
+		return stacktrace.Propagate(err, "Failed to send from account %v to account %v", step.Account, step.To)
+	}
+	if err := acct.runner.AwaitXChainTxs(txID); err != nil {
+		return stacktrace.Propagate(err, "Failed to accept send tx %v", txID)
+	}
+	logrus.Infof("Sent %v nAVAX from account %v to account %v.", step.Amount, step.Account, step.To)
+	return nil
+}
+
+func (run *scenarioRun) addNode(step Step) error {
+	if _, err := run.network.AddService(networks.ConfigurationID(step.Config), networks.ServiceID(step.Node)); err != nil {
+		return stacktrace.Propagate(err, "Failed to add node %v", step.Node)
+	}
+	if err := run.network.WaitForServiceUp(networks.ServiceID(step.Node)); err != nil {
+		return stacktrace.Propagate(err, "Failed to wait for startup of node %v", step.Node)
+	}
+	logrus.Infof("Added node %v with config %v.", step.Node, step.Config)
+	return nil
+}
+
+func (run *scenarioRun) removeNode(step Step) error {
+	if err := run.network.RemoveService(networks.ServiceID(step.Node)); err != nil {
+		return stacktrace.Propagate(err, "Failed to remove node %v", step.Node)
+	}
+	logrus.Infof("Removed node %v.", step.Node)
+	return nil
+}
+
+func (run *scenarioRun) assertBalance(step Step) error {
+	acct, err := run.getAccount(step)
+	if err != nil {
+		return stacktrace.Propagate(err, "Failed to get account %v on node %v", step.Account, step.Node)
+	}
+	switch step.Chain {
+	case XChain:
+		return acct.runner.VerifyXChainAVABalance(acct.xChainAddress, step.Balance)
+	case PChain:
+		return acct.runner.VerifyPChainBalance(acct.pChainAddress, step.Balance)
+	default:
+		return stacktrace.NewError("Unknown chain %v", step.Chain)
+	}
+}
+
+// assertPeers waits for the node's peers to match the expected ones, since nodes take a while to connect to each
+// other after they've started
+func (run *scenarioRun) assertPeers(step Step) error {
+	serviceID := networks.ServiceID(step.Node)
+	client, err := run.network.GetAvalancheClient(serviceID)
+	if err != nil {
+		return stacktrace.Propagate(err, "Failed to get the client of node %v", step.Node)
+	}
+	expectedNodeIDs := make(map[string]bool)
+	for _, peer := range step.Peers {
+		nodeID, err := run.getNodeID(peer)
+		if err != nil {
+			return stacktrace.Propagate(err, "Failed to get the node ID of peer %v", peer)
+		}
+		expectedNodeIDs[nodeID] = true
+	}
+
+	return helpers.AwaitCondition(run.networkAcceptanceTimeout, peersPollInterval, func() error {
+		if !step.AtLeast {
+			return verifier.NetworkStateVerifier{}.VerifyExpectedPeers(serviceID, client, expectedNodeIDs, len(expectedNodeIDs), false)
+		}
+		peers, err := client.InfoAPI().Peers()
+		if err != nil {
+			return stacktrace.Propagate(err, "Failed to get the peers of node %v", step.Node)
+		}
+		actualNodeIDs := make(map[string]bool)
+		for _, peer := range peers {
+			actualNodeIDs[peer.ID] = true
+		}
+		for nodeID := range expectedNodeIDs {
+			if !actualNodeIDs[nodeID] {
+				return stacktrace.NewError("Node %v isn't connected to %v; its peers are %v", step.Node, nodeID, actualNodeIDs)
+			}
+		}
+		return nil
+	})
+}
+
+// getAccount returns the step's account, creating it on the step's node if this is the first step to use it
+func (run *scenarioRun) getAccount(step Step) (*account, error) {
+	if acct, found := run.accounts[step.Account]; found {
+		return acct, nil
+	}
+	client, err := run.network.GetAvalancheClient(networks.ServiceID(step.Node))
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to get the client of node %v", step.Node)
+	}
+	runner := helpers.NewRPCWorkFlowRunner(
+		client,
+		api.UserPass{Username: accountUsernamePrefix + step.Account, Password: accountPassword},
+		run.networkAcceptanceTimeout)
+	xChainAddress, pChainAddress, err := runner.CreateDefaultAddresses()
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to create account %v on node %v", step.Account, step.Node)
+	}
+	acct := &account{
+		runner:        runner,
+		xChainAddress: xChainAddress,
+		pChainAddress: pChainAddress,
+	}
+	run.accounts[step.Account] = acct
+	logrus.Debugf("Created account %v on node %v with addresses %v and %v.", step.Account, step.Node, xChainAddress, pChainAddress)
+	return acct, nil
+}
+
+// newGenesisFunder imports the genesis funds into a user on one of the boot nodes
+func (run *scenarioRun) newGenesisFunder() (*helpers.RPCWorkFlowRunner, error) {
+	bootServiceIDs := []string{}
+	for serviceID := range run.network.GetAllBootServiceIDs() {
+		bootServiceIDs = append(bootServiceIDs, string(serviceID))
+	}
+	sort.Strings(bootServiceIDs)
+	client, err := run.network.GetAvalancheClient(networks.ServiceID(bootServiceIDs[0]))
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to get the client of boot node %v", bootServiceIDs[0])
+	}
+	funder := helpers.NewRPCWorkFlowRunner(
+		client,
+		api.UserPass{Username: genesisFunderUsername, Password: accountPassword},
+		run.networkAcceptanceTimeout)
+	if _, err := funder.ImportGenesisFunds(); err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to import the genesis funds")
+	}
+	return funder, nil
+}
+
+func (run *scenarioRun) getNodeID(serviceID string) (string, error) {
+	client, err := run.network.GetAvalancheClient(networks.ServiceID(serviceID))
+	if err != nil {
+		return "", stacktrace.Propagate(err, "Failed to get the client of node %v", serviceID)
+	}
+	nodeID, err := client.InfoAPI().GetNodeID()
+	if err != nil {
+		return "", stacktrace.Propagate(err, "Failed to get the node ID of %v", serviceID)
+	}
+	return nodeID, nil
+}
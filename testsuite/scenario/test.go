@@ -0,0 +1,84 @@
+package scenario
+
+import (
+	"time"
+
+	avalancheNetwork "github.com/ava-labs/avalanche-testing/avalanche/networks"
+	avalancheService "github.com/ava-labs/avalanche-testing/avalanche/services"
+	"github.com/kurtosis-tech/kurtosis/commons/networks"
+	"github.com/kurtosis-tech/kurtosis/commons/testsuite"
+	"github.com/palantir/stacktrace"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	networkAcceptanceTimeoutRatio = 0.3
+)
+
+// Test implements the Kurtosis Test interface for a scenario
+type Test struct {
+	Scenario *Scenario
+
+	// The image that the network's nodes run when the scenario doesn't name one
+	DefaultImageName string
+}
+
+// Run implements the Kurtosis Test interface
+func (test Test) Run(network networks.Network, context testsuite.TestContext) {
+	castedNetwork := network.(avalancheNetwork.TestAvalancheNetwork)
+	networkAcceptanceTimeout := time.Duration(networkAcceptanceTimeoutRatio * float64(test.GetExecutionTimeout().Nanoseconds()))
+	run := newScenarioRun(castedNetwork, test.Scenario.Network.TxFee, networkAcceptanceTimeout)
+	for i, step := range test.Scenario.Steps {
+		logrus.Infof("Running step %v (%v) of scenario %v...", i, step.Action, test.Scenario.Name)
+		if err := run.runStep(step); err != nil {
+			context.Fatal(stacktrace.Propagate(err, "Step %v (%v) of scenario %v failed.", i, step.Action, test.Scenario.Name))
+		}
+	}
+	logrus.Infof("Scenario %v completed successfully.", test.Scenario.Name)
+}
+
+// GetNetworkLoader implements the Kurtosis Test interface
+func (test Test) GetNetworkLoader() (networks.NetworkLoader, error) {
+	networkConfig := test.Scenario.Network
+	serviceConfigs := make(map[networks.ConfigurationID]avalancheNetwork.TestAvalancheNetworkServiceConfig)
+	for configID, nodeConfig := range test.Scenario.NodeConfigs {
+		serviceConfigs[networks.ConfigurationID(configID)] = *avalancheNetwork.NewTestAvalancheNetworkServiceConfig(
+			*nodeConfig.VaryCerts,
+			test.imageName(nodeConfig.Image),
+			nodeConfig.toNodeConfig(networkConfig),
+		)
+	}
+	desiredServices := make(map[networks.ServiceID]networks.ConfigurationID)
+	for serviceID, configID := range test.Scenario.InitialNodes {
+		desiredServices[networks.ServiceID(serviceID)] = networks.ConfigurationID(configID)
+	}
+	return avalancheNetwork.NewTestAvalancheNetworkLoader(
+		true,
+		test.imageName(networkConfig.Image),
+		avalancheService.AvalancheLogLevel(networkConfig.LogLevel),
+		networkConfig.SnowQuorumSize,
+		networkConfig.SnowSampleSize,
+		networkConfig.TxFee,
+		networkConfig.InitialTimeout,
+		avalancheNetwork.DefaultLocalNetGenesisConfig,
+		serviceConfigs,
+		desiredServices,
+	)
+}
+
+// GetExecutionTimeout implements the Kurtosis Test interface
+func (test Test) GetExecutionTimeout() time.Duration {
+	return test.Scenario.ExecutionTimeout
+}
+
+// GetSetupBuffer implements the Kurtosis Test interface
+func (test Test) GetSetupBuffer() time.Duration {
+	return test.Scenario.SetupBuffer
+}
+
+func (test Test) imageName(scenarioImageName string) string {
+	if scenarioImageName == "" {
+		return test.DefaultImageName
+	}
+	return scenarioImageName
+}
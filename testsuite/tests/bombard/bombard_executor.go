@@ -1,32 +1,45 @@
 package bombard
 
 import (
-	"fmt"
-	"math/rand"
-	"strings"
 	"sync"
 	"time"
 
+	avalancheNetwork "github.com/ava-labs/avalanche-testing/avalanche/networks"
 	"github.com/ava-labs/avalanche-testing/avalanche_client/apis"
-	"github.com/ava-labs/avalanche-testing/testsuite/helpers"
 	"github.com/ava-labs/avalanche-testing/testsuite/tester"
-	"github.com/ava-labs/avalanchego/api"
+	"github.com/ava-labs/avalanche-testing/testsuite/wallet"
 	"github.com/ava-labs/avalanchego/ids"
 	"github.com/ava-labs/avalanchego/utils/constants"
 	"github.com/ava-labs/avalanchego/utils/crypto"
-	"github.com/ava-labs/avalanchego/utils/formatting"
 	"github.com/ava-labs/avalanchego/vms/components/avax"
 	"github.com/palantir/stacktrace"
 	"github.com/sirupsen/logrus"
 )
 
+const (
+	// The name of the profiling phase that transactions are issued during
+	issueProfilingPhase = "bombard-issue"
+)
+
 // NewBombardExecutor returns a new bombard test bombardExecutor
-func NewBombardExecutor(clients []*apis.Client, numTxs, txFee uint64, acceptanceTimeout time.Duration) tester.AvalancheTester {
+// If [startProfiling] isn't nil, it's called to start profiling the issuing of the transactions, which is ended once
+// they've all been issued. Profiling only helps diagnose the test, so failing to start or end it is logged rather than
+// failing the test. If [awaitAccepted] isn't nil, it's used to wait for the transactions to be accepted instead
+// of polling their statuses.
+func NewBombardExecutor(
+	clients []*apis.Client,
+	numTxs,
+	txFee uint64,
+	acceptanceTimeout time.Duration,
+	startProfiling func(phase string) (*avalancheNetwork.ProfilingPhase, error),
+	awaitAccepted func(txIDs ...ids.ID) error) tester.AvalancheTester {
 	return &bombardExecutor{
 		normalClients:     clients,
 		numTxs:            numTxs,
 		acceptanceTimeout: acceptanceTimeout,
 		txFee:             txFee,
+		startProfiling:    startProfiling,
+		awaitAccepted:     awaitAccepted,
 	}
 }
 
@@ -35,115 +48,75 @@ type bombardExecutor struct {
 	acceptanceTimeout time.Duration
 	numTxs            uint64
 	txFee             uint64
-}
-
-func createRandomString() string {
-	return fmt.Sprintf("rand:%d", rand.Int())
+	startProfiling    func(phase string) (*avalancheNetwork.ProfilingPhase, error)
+	awaitAccepted     func(txIDs ...ids.ID) error
 }
 
 // ExecuteTest implements the AvalancheTester interface
 func (e *bombardExecutor) ExecuteTest() error {
 	genesisClient := e.normalClients[0]
-	secondaryClients := make([]*helpers.RPCWorkFlowRunner, len(e.normalClients)-1)
-	xChainAddrs := make([]string, len(e.normalClients)-1)
+	secondaryWallets := make([]*wallet.Wallet, len(e.normalClients)-1)
+	privateKeys := make([]*crypto.PrivateKeySECP256K1R, len(secondaryWallets))
+	xChainAddrs := make([]string, len(secondaryWallets))
 	for i, client := range e.normalClients[1:] {
-		secondaryClients[i] = helpers.NewRPCWorkFlowRunner(
-			client,
-			api.UserPass{Username: createRandomString(), Password: createRandomString()},
-			e.acceptanceTimeout,
-		)
-		xChainAddress, _, err := secondaryClients[i].CreateDefaultAddresses()
+		secondaryWallet, err := wallet.NewWallet(client, constants.LocalID, e.txFee, e.acceptanceTimeout)
+		if err != nil {
+			return stacktrace.Propagate(err, "Failed to create wallet for client: %d", i)
+		}
+		privateKey, err := secondaryWallet.NewKey()
+		if err != nil {
+			return stacktrace.Propagate(err, "Failed to create key for client: %d", i)
+		}
+		xChainAddress, err := secondaryWallet.XChainAddress(privateKey.PublicKey().Address())
 		if err != nil {
-			return stacktrace.Propagate(err, "Failed to create default addresses for client: %d", i)
+			return stacktrace.Propagate(err, "Failed to format X Chain address for client: %d", i)
 		}
+		secondaryWallets[i] = secondaryWallet
+		privateKeys[i] = privateKey
 		xChainAddrs[i] = xChainAddress
 	}
 
-	genesisUser := api.UserPass{Username: createRandomString(), Password: createRandomString()}
-	highLevelGenesisClient := helpers.NewRPCWorkFlowRunner(
-		genesisClient,
-		genesisUser,
-		e.acceptanceTimeout,
-	)
-
-	if _, err := highLevelGenesisClient.ImportGenesisFunds(); err != nil {
-		return stacktrace.Propagate(err, "Failed to fund genesis client.")
-	}
-	addrs, err := genesisClient.XChainAPI().ListAddresses(genesisUser)
+	genesisWallet, err := wallet.NewWallet(genesisClient, constants.LocalID, e.txFee, e.acceptanceTimeout)
 	if err != nil {
-		return stacktrace.Propagate(err, "Failed to get genesis client's addresses")
+		return stacktrace.Propagate(err, "Failed to create genesis wallet.")
 	}
-	if len(addrs) != 1 {
-		return stacktrace.NewError("Found unexecpted number of addresses for genesis client: %d", len(addrs))
+	genesisAddress, err := genesisWallet.ImportGenesisFunds()
+	if err != nil {
+		return stacktrace.Propagate(err, "Failed to fund genesis client.")
 	}
-	genesisAddress := addrs[0]
 	logrus.Infof("Imported genesis funds at address: %s", genesisAddress)
 
 	// Fund X Chain Addresses enough to issue [numTxs]
 	seedAmount := (e.numTxs + 1) * e.txFee
-	if err := highLevelGenesisClient.FundXChainAddresses(xChainAddrs, seedAmount); err != nil {
+	if err := genesisWallet.FundXChainAddresses(xChainAddrs, seedAmount); err != nil {
 		return stacktrace.Propagate(err, "Failed to fund X Chain Addresses for Clients")
 	}
 	logrus.Infof("Funded X Chain Addresses with seedAmount %v.", seedAmount)
 
-	codec, err := createXChainCodec()
-	if err != nil {
-		return stacktrace.Propagate(err, "Failed to initialize codec.")
-	}
-	utxoLists := make([][]*avax.UTXO, len(secondaryClients))
-	for i, client := range secondaryClients {
+	utxoLists := make([][]*avax.UTXO, len(secondaryWallets))
+	for i, secondaryWallet := range secondaryWallets {
 		// Each address should have [e.txFee] remaining after sending [numTxs] and paying the fixed fee each time
-		if err := client.VerifyXChainAVABalance(xChainAddrs[i], seedAmount); err != nil {
+		if err := secondaryWallet.VerifyXChainAVABalance(xChainAddrs[i], seedAmount); err != nil {
 			return stacktrace.Propagate(err, "Failed to verify X Chain Balane for Client: %d", i)
 		}
-		utxoReply, err := genesisClient.XChainAPI().GetUTXOs([]string{xChainAddrs[i]}, 10, "", "")
-		if err != nil {
-			return err
+		if err := secondaryWallet.RefreshUTXOs(); err != nil {
+			return stacktrace.Propagate(err, "Failed to get UTXOs for Client: %d", i)
 		}
-		formattedUTXOs := utxoReply.UTXOs
-		utxos := make([]*avax.UTXO, len(formattedUTXOs))
-		for i, formattedUTXO := range formattedUTXOs {
-			utxoBytes := formattedUTXO.Bytes
-			utxo := &avax.UTXO{}
-			err := codec.Unmarshal(utxoBytes, utxo)
-			if err != nil {
-				return stacktrace.Propagate(err, "Failed to unmarshal utxo bytes.")
-			}
-			utxos[i] = utxo
+		utxoLists[i] = secondaryWallet.XChainUTXOs()
+		logrus.Infof("Decoded %d UTXOs", len(utxoLists[i]))
+		if len(utxoLists[i]) == 0 {
+			return stacktrace.NewError("Found no UTXOs for Client: %d", i)
 		}
-		utxoLists[i] = utxos
-		logrus.Infof("Decoded %d UTXOs", len(utxos))
-
 	}
 	logrus.Infof("Verified X Chain Balances and retrieved UTXOs.")
 
 	// Create a string of consecutive transactions for each secondary client to send
-	privateKeys := make([]*crypto.PrivateKeySECP256K1R, len(secondaryClients))
-	txLists := make([][][]byte, len(secondaryClients))
-	txIDLists := make([][]ids.ID, len(secondaryClients))
-	for i, client := range e.normalClients[1:] {
+	txLists := make([][][]byte, len(secondaryWallets))
+	txIDLists := make([][]ids.ID, len(secondaryWallets))
+	for i, privateKey := range privateKeys {
 		utxo := utxoLists[i][0]
-		pkStr, err := client.XChainAPI().ExportKey(secondaryClients[i].User(), xChainAddrs[i])
-		if err != nil {
-			return stacktrace.Propagate(err, "Failed to export key.")
-		}
-
-		if !strings.HasPrefix(pkStr, constants.SecretKeyPrefix) {
-			return fmt.Errorf("private key missing %s prefix", constants.SecretKeyPrefix)
-		}
-		trimmedPrivateKey := strings.TrimPrefix(pkStr, constants.SecretKeyPrefix)
-		formattedPrivateKey := formatting.CB58{}
-		if err := formattedPrivateKey.FromString(trimmedPrivateKey); err != nil {
-			return fmt.Errorf("problem parsing private key: %w", err)
-		}
-
-		factory := crypto.FactorySECP256K1R{}
-		skIntf, err := factory.ToPrivateKey(formattedPrivateKey.Bytes)
-		sk := skIntf.(*crypto.PrivateKeySECP256K1R)
-		privateKeys[i] = sk
-
 		logrus.Infof("Creating string of %d transactions", e.numTxs)
-		txs, txIDs, err := CreateConsecutiveTransactions(utxo, e.numTxs, seedAmount, e.txFee, sk)
+		txs, txIDs, err := CreateConsecutiveTransactions(utxo, e.numTxs, seedAmount, e.txFee, privateKey)
 		if err != nil {
 			return stacktrace.Propagate(err, "Failed to create transaction list.")
 		}
@@ -152,26 +125,48 @@ func (e *bombardExecutor) ExecuteTest() error {
 	}
 
 	wg := sync.WaitGroup{}
-	issueTxsAsync := func(runner *helpers.RPCWorkFlowRunner, txList [][]byte) {
-		if err := runner.IssueTxList(txList); err != nil {
-			panic(err)
+	issueErrs := make(chan error, len(secondaryWallets))
+	issueTxsAsync := func(secondaryWallet *wallet.Wallet, txList [][]byte) {
+		defer wg.Done()
+		if err := secondaryWallet.IssueTxList(txList); err != nil {
+			issueErrs <- err
 		}
-		wg.Done()
 	}
 
+	var profilingPhase *avalancheNetwork.ProfilingPhase
+	if e.startProfiling != nil {
+		phase, err := e.startProfiling(issueProfilingPhase)
+		if err != nil {
+			logrus.Warnf("Failed to start profiling the issuing of transactions; issuing them without profiling: %v", err)
+		}
+		profilingPhase = phase
+	}
 	startTime := time.Now()
 	logrus.Infof("Beginning to issue transactions...")
-	for i, client := range secondaryClients {
+	for i, secondaryWallet := range secondaryWallets {
 		wg.Add(1)
-		issueTxsAsync(client, txLists[i])
+		go issueTxsAsync(secondaryWallet, txLists[i])
 	}
 	wg.Wait()
+	close(issueErrs)
+	if profilingPhase != nil {
+		if err := profilingPhase.End(); err != nil {
+			logrus.Warnf("Failed to capture the profiles of the issuing of transactions: %v", err)
+		}
+	}
+	if err, failed := <-issueErrs; failed {
+		return stacktrace.Propagate(err, "Failed to issue transaction list.")
+	}
 
 	duration := time.Since(startTime)
 	logrus.Infof("Finished issuing transaction lists in %v seconds.", duration.Seconds())
+	awaitAccepted := genesisWallet.AwaitXChainTxs
+	if e.awaitAccepted != nil {
+		awaitAccepted = e.awaitAccepted
+	}
 	for _, txIDs := range txIDLists {
-		if err := highLevelGenesisClient.AwaitXChainTxs(txIDs...); err != nil {
-			stacktrace.Propagate(err, "Failed to confirm transactions.")
+		if err := awaitAccepted(txIDs...); err != nil {
+			return stacktrace.Propagate(err, "Failed to confirm transactions.")
 		}
 	}
 
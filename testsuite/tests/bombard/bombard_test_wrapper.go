@@ -3,9 +3,11 @@ package bombard
 import (
 	"time"
 
+	"github.com/ava-labs/avalanche-testing/avalanche/ipc"
 	avalancheNetwork "github.com/ava-labs/avalanche-testing/avalanche/networks"
 	avalancheService "github.com/ava-labs/avalanche-testing/avalanche/services"
 	"github.com/ava-labs/avalanche-testing/avalanche_client/apis"
+	"github.com/ava-labs/avalanchego/ids"
 	"github.com/kurtosis-tech/kurtosis/commons/networks"
 	"github.com/kurtosis-tech/kurtosis/commons/testsuite"
 	"github.com/palantir/stacktrace"
@@ -18,6 +20,7 @@ const (
 	additionalNode2ServiceID                          = "additional-node-2"
 	seedAmount                                        = int64(50000000000000)
 	stakeAmount                                       = int64(30000000000000)
+	xChainAlias                                       = "X"
 )
 
 // StakingNetworkBombardTest funds individual clients with a starting UTXO for each
@@ -28,6 +31,13 @@ type StakingNetworkBombardTest struct {
 	NumTxs            uint64
 	TxFee             uint64
 	AcceptanceTimeout time.Duration
+
+	// True to CPU profile the boot nodes while the transactions are issued, and capture their profiles
+	ProfileNodes bool
+
+	// True to confirm the transactions from the acceptance events that the boot nodes publish over IPC, rather than by
+	//  polling their statuses
+	AwaitAcceptanceEvents bool
 }
 
 // Run implements the Kurtosis Test interface
@@ -35,7 +45,9 @@ func (test StakingNetworkBombardTest) Run(network networks.Network, context test
 	castedNetwork := network.(avalancheNetwork.TestAvalancheNetwork)
 	bootServiceIDs := castedNetwork.GetAllBootServiceIDs()
 	clients := make([]*apis.Client, 0, len(bootServiceIDs))
+	serviceIDs := make([]networks.ServiceID, 0, len(bootServiceIDs))
 	for serviceID := range bootServiceIDs {
+		serviceIDs = append(serviceIDs, serviceID)
 		avalancheClient, err := castedNetwork.GetAvalancheClient(serviceID)
 		if err != nil {
 			context.Fatal(stacktrace.Propagate(err, "Failed to get Avalanche Client for boot node with serviceID: %s.", serviceID))
@@ -44,7 +56,33 @@ func (test StakingNetworkBombardTest) Run(network networks.Network, context test
 	}
 
 	// Execute the bombard test to issue [NumTxs] to each node
-	executor := NewBombardExecutor(clients, test.NumTxs, test.TxFee, test.AcceptanceTimeout)
+	var startProfiling func(phase string) (*avalancheNetwork.ProfilingPhase, error)
+	if test.ProfileNodes {
+		startProfiling = func(phase string) (*avalancheNetwork.ProfilingPhase, error) {
+			return castedNetwork.StartProfilingPhase(phase, serviceIDs...)
+		}
+	}
+	var awaitAccepted func(txIDs ...ids.ID) error
+	if test.AwaitAcceptanceEvents {
+		subscribers := make([]*ipc.Subscriber, 0, len(serviceIDs))
+		for _, serviceID := range serviceIDs {
+			subscriber, err := castedNetwork.SubscribeToDecisions(serviceID, xChainAlias)
+			if err != nil {
+				context.Fatal(stacktrace.Propagate(err, "Failed to subscribe to the X Chain decisions of %s.", serviceID))
+			}
+			defer subscriber.Close()
+			subscribers = append(subscribers, subscriber)
+		}
+		awaitAccepted = func(txIDs ...ids.ID) error {
+			for i, subscriber := range subscribers {
+				if err := subscriber.AwaitAccepted(test.AcceptanceTimeout, txIDs...); err != nil {
+					return stacktrace.Propagate(err, "%s didn't accept the transactions.", serviceIDs[i])
+				}
+			}
+			return nil
+		}
+	}
+	executor := NewBombardExecutor(clients, test.NumTxs, test.TxFee, test.AcceptanceTimeout, startProfiling, awaitAccepted)
 	logrus.Infof("Executing bombard test...")
 	if err := executor.ExecuteTest(); err != nil {
 		context.Fatal(stacktrace.Propagate(err, "Bombard Test Failed."))
@@ -80,15 +118,16 @@ func (test StakingNetworkBombardTest) GetNetworkLoader() (networks.NetworkLoader
 	serviceConfigs := make(map[networks.ConfigurationID]avalancheNetwork.TestAvalancheNetworkServiceConfig)
 	serviceConfigs[normalNodeConfigID] = *avalancheNetwork.NewTestAvalancheNetworkServiceConfig(
 		true,
-		avalancheService.DEBUG,
 		test.ImageName,
-		2,
-		2,
-		2*time.Second,
-		make(map[string]string),
+		avalancheService.NodeConfig{
+			LogLevel:              avalancheService.DEBUG,
+			SnowQuorumSize:        2,
+			SnowSampleSize:        2,
+			NetworkInitialTimeout: 2 * time.Second,
+		},
 	)
 
-	return avalancheNetwork.NewTestAvalancheNetworkLoader(
+	loader, err := avalancheNetwork.NewTestAvalancheNetworkLoader(
 		true,
 		test.ImageName,
 		avalancheService.DEBUG,
@@ -96,9 +135,23 @@ func (test StakingNetworkBombardTest) GetNetworkLoader() (networks.NetworkLoader
 		2,
 		test.TxFee,
 		2*time.Second,
+		avalancheNetwork.DefaultLocalNetGenesisConfig,
 		serviceConfigs,
 		desiredServices,
 	)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to create the network loader")
+	}
+	if err := loader.UpdateBootNodeConfig(func(config *avalancheService.NodeConfig) {
+		config.CaptureProfiles = test.ProfileNodes
+		if test.AwaitAcceptanceEvents {
+			config.IPCsAPIEnabled = avalancheService.Bool(true)
+			config.ShareIPCs = true
+		}
+	}); err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to make the boot nodes capture profiles and share IPCs")
+	}
+	return loader, nil
 }
 
 // GetExecutionTimeout implements the Kurtosis Test interface
@@ -0,0 +1,95 @@
+package byzantine
+
+import (
+	avalancheService "github.com/ava-labs/avalanche-testing/avalanche/services"
+	"github.com/palantir/stacktrace"
+)
+
+// Behavior describes a byzantine behavior that the byzantine Avalanche image can be started with, and the network-level
+// invariants that honest nodes are expected to uphold while validators running it are staked next to them
+type Behavior struct {
+	// The behavior the byzantine nodes are started with
+	Name avalancheService.ByzantineBehavior
+
+	Description string
+
+	// The largest fraction of the primary network's stake that validators running this behavior can hold while the
+	//  honest nodes stay live and safe
+	MaxByzantineStakeRatio float64
+
+	// Whether the byzantine nodes are expected to still be validators once the test is over. This is false for behaviors
+	//  that honest nodes are allowed to bench or disconnect from.
+	ByzantineNodesRemainValidators bool
+
+	// Whether the behavior only acts on conflicting transactions, so that the test issues sets of them to the byzantine
+	//  nodes and checks that no honest node accepts more than one transaction of a set
+	NeedsConflictingTxs bool
+}
+
+// Catalog holds every byzantine behavior that the generic byzantine test is run against
+var Catalog = []Behavior{
+	{
+		Name:                           avalancheService.ChitSpammerBehavior,
+		Description:                    "Sends chits for vertices that it was never queried about to all of its peers",
+		MaxByzantineStakeRatio:         0.5,
+		ByzantineNodesRemainValidators: true,
+	},
+	{
+		Name:                           avalancheService.ConflictingTxsVertexBehavior,
+		Description:                    "Batches conflicting transactions into a single vertex and pushes it to its peers",
+		MaxByzantineStakeRatio:         0.5,
+		ByzantineNodesRemainValidators: true,
+		NeedsConflictingTxs:            true,
+	},
+}
+
+// GetBehavior returns the catalog entry of the byzantine behavior with the given name
+func GetBehavior(name avalancheService.ByzantineBehavior) (Behavior, error) {
+	for _, behavior := range Catalog {
+		if behavior.Name == name {
+			return behavior, nil
+		}
+	}
+	return Behavior{}, stacktrace.NewError("No byzantine behavior named '%v' is in the catalog", name)
+}
+
+// validate checks that the behavior can be started by a node, and that the stake of the byzantine validators stays
+// within what the honest ones are expected to tolerate
+// Args:
+// 	byzantineWeights: The stake of each validator that runs the behavior
+// 	honestWeights: The stake of each validator, including the boot nodes, that doesn't
+func (behavior Behavior) validate(byzantineWeights []uint64, honestWeights []uint64) error {
+	if behavior.Name == avalancheService.NoByzantineBehavior {
+		return stacktrace.NewError("A byzantine behavior needs a name")
+	}
+	nodeConfig := avalancheService.NodeConfig{ByzantineBehavior: behavior.Name}.WithDefaults()
+	if err := nodeConfig.Validate(); err != nil {
+		return stacktrace.Propagate(err, "Byzantine behavior '%v' can't be started by a node", behavior.Name)
+	}
+	byzantineWeight := sumWeights(byzantineWeights)
+	honestWeight := sumWeights(honestWeights)
+	if byzantineWeight == 0 {
+		return stacktrace.NewError("At least one byzantine validator with stake is needed, but %v were requested", len(byzantineWeights))
+	}
+	if honestWeight == 0 {
+		return stacktrace.NewError("At least one honest validator with stake is needed, but %v were requested", len(honestWeights))
+	}
+	byzantineRatio := float64(byzantineWeight) / float64(byzantineWeight+honestWeight)
+	if byzantineRatio > behavior.MaxByzantineStakeRatio {
+		return stacktrace.NewError(
+			"%v of the %v staked by validators is staked by ones running byzantine behavior '%v', which is more than the %v that honest validators tolerate",
+			byzantineWeight,
+			byzantineWeight+honestWeight,
+			behavior.Name,
+			behavior.MaxByzantineStakeRatio)
+	}
+	return nil
+}
+
+func sumWeights(weights []uint64) uint64 {
+	result := uint64(0)
+	for _, weight := range weights {
+		result += weight
+	}
+	return result
+}
@@ -0,0 +1,53 @@
+package byzantine
+
+import (
+	"testing"
+
+	avalancheService "github.com/ava-labs/avalanche-testing/avalanche/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCatalogBehaviorsAreValid(t *testing.T) {
+	seen := make(map[avalancheService.ByzantineBehavior]bool)
+	for _, behavior := range Catalog {
+		assert.False(t, seen[behavior.Name], "Behavior %v is in the catalog twice", behavior.Name)
+		seen[behavior.Name] = true
+		assert.NotEmpty(t, behavior.Description, "Behavior %v has no description", behavior.Name)
+		assert.NoError(t, behavior.validate(getWeights(1, 10), getWeights(5, 10)), string(behavior.Name))
+
+		found, err := GetBehavior(behavior.Name)
+		assert.NoError(t, err)
+		assert.Equal(t, behavior, found)
+	}
+
+	_, err := GetBehavior("sleepy")
+	assert.Error(t, err)
+}
+
+func TestBehaviorValidation(t *testing.T) {
+	behavior := Behavior{
+		Name:                   avalancheService.ChitSpammerBehavior,
+		MaxByzantineStakeRatio: 0.5,
+	}
+	assert.NoError(t, behavior.validate(getWeights(5, 10), getWeights(5, 10)))
+	assert.Error(t, behavior.validate(getWeights(6, 10), getWeights(5, 10)))
+	assert.Error(t, behavior.validate(nil, getWeights(5, 10)))
+	assert.Error(t, behavior.validate(getWeights(1, 10), nil))
+	assert.Error(t, behavior.validate(getWeights(1, 0), getWeights(5, 10)))
+
+	// It's the stake that counts rather than the number of validators
+	assert.NoError(t, behavior.validate(getWeights(4, 10), []uint64{40}))
+	assert.Error(t, behavior.validate(getWeights(1, 50), getWeights(4, 10)))
+
+	assert.Error(t, Behavior{MaxByzantineStakeRatio: 1}.validate(getWeights(1, 10), getWeights(5, 10)))
+	assert.Error(t, Behavior{Name: "sleepy", MaxByzantineStakeRatio: 1}.validate(getWeights(1, 10), getWeights(5, 10)))
+}
+
+// getWeights returns the weights of [numValidators] validators that each stake [weight]
+func getWeights(numValidators int, weight uint64) []uint64 {
+	result := make([]uint64, numValidators)
+	for i := range result {
+		result[i] = weight
+	}
+	return result
+}
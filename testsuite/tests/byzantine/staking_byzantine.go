@@ -0,0 +1,448 @@
+package byzantine
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	avalancheNetwork "github.com/ava-labs/avalanche-testing/avalanche/networks"
+	avalancheService "github.com/ava-labs/avalanche-testing/avalanche/services"
+	"github.com/ava-labs/avalanche-testing/avalanche_client/apis"
+	"github.com/ava-labs/avalanche-testing/testsuite/helpers"
+	"github.com/ava-labs/avalanche-testing/testsuite/txbuilder"
+	"github.com/ava-labs/avalanche-testing/testsuite/verifier"
+	"github.com/ava-labs/avalanchego/api"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/snow/choices"
+	"github.com/ava-labs/avalanchego/utils/constants"
+	"github.com/kurtosis-tech/kurtosis/commons/networks"
+	"github.com/kurtosis-tech/kurtosis/commons/testsuite"
+	"github.com/palantir/stacktrace"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	honestNodeConfigID networks.ConfigurationID = "honest-config"
+	byzantineConfigID  networks.ConfigurationID = "byzantine-config"
+
+	honestNodePrefix    = "honest-node-"
+	byzantineNodePrefix = "byzantine-node-"
+
+	byzantineUsername = "byzantine_avalanche"
+	byzantinePassword = "byzant1n3!"
+	stakerUsername    = "staker_avalanche"
+	stakerPassword    = "test34test!23"
+	witnessUsername   = "byzantine_test_witness"
+	witnessPassword   = "Byz4ntin3W1tness!"
+
+	seedAmount  = uint64(50000000000000)
+	stakeAmount = uint64(30000000000000)
+
+	// The amount sent between honest nodes to check that they stay live
+	witnessAmount = uint64(1000000000)
+
+	// The number of conflicting spends in each set issued to a byzantine node, for behaviors that need them
+	numConflictingSpends = 2
+	conflictAssetName    = "Byzantine Conflict Asset"
+
+	networkStatePollInterval = 5 * time.Second
+
+	networkAcceptanceTimeoutRatio = 0.3
+)
+
+// StakingNetworkByzantineTest stakes several nodes running a byzantine behavior from the catalog next to several honest
+// ones, and checks that the honest nodes stay live and safe: a transfer between honest nodes is accepted by all of them,
+// none of them rejects it, none of them accepts two of the conflicting transactions issued to the byzantine nodes (for
+// behaviors that act on them), and they all agree on the balances and the validator set afterwards
+type StakingNetworkByzantineTest struct {
+	ByzantineImageName string
+	NormalImageName    string
+
+	// The behavior the byzantine nodes run, and the invariants the honest nodes uphold against it
+	Behavior Behavior
+
+	// The number of byzantine nodes, and of honest nodes in addition to the boot nodes, that are staked
+	NumByzantineNodes int
+	NumHonestNodes    int
+
+	// The network's transaction fee, which conflicting transactions are built for
+	TxFee uint64
+
+	SafetyVerifier verifier.ConsensusSafetyVerifier
+}
+
+// Run implements the Kurtosis Test interface
+func (test StakingNetworkByzantineTest) Run(network networks.Network, context testsuite.TestContext) {
+	castedNetwork := network.(avalancheNetwork.TestAvalancheNetwork)
+	networkAcceptanceTimeout := time.Duration(networkAcceptanceTimeoutRatio * float64(test.GetExecutionTimeout().Nanoseconds()))
+
+	byzantineNodeIDs, byzantineClients, err := helpers.GetNodeIDsAndClients(castedNetwork, toServiceIDSet(getServiceIDs(byzantineNodePrefix, test.NumByzantineNodes)))
+	if err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Failed to get the node IDs and clients of the byzantine nodes"))
+	}
+	honestServiceIDs := getServiceIDs(honestNodePrefix, test.NumHonestNodes)
+	honestNodeIDs, honestClients, err := helpers.GetNodeIDsAndClients(castedNetwork, toServiceIDSet(honestServiceIDs))
+	if err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Failed to get the node IDs and clients of the honest nodes"))
+	}
+	bootServiceIDs := []networks.ServiceID{}
+	for serviceID := range castedNetwork.GetAllBootServiceIDs() {
+		bootServiceIDs = append(bootServiceIDs, serviceID)
+	}
+	bootNodeIDs, bootClients, err := helpers.GetNodeIDsAndClients(castedNetwork, castedNetwork.GetAllBootServiceIDs())
+	if err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Failed to get the node IDs and clients of the boot nodes"))
+	}
+
+	// ================= STAKE THE BYZANTINE AND HONEST NODES ===================
+	logrus.Infof("Adding %v nodes running byzantine behavior '%v' as stakers...", test.NumByzantineNodes, test.Behavior.Name)
+	for _, serviceID := range getServiceIDs(byzantineNodePrefix, test.NumByzantineNodes) {
+		byzantineRunner := helpers.NewRPCWorkFlowRunner(
+			byzantineClients[serviceID],
+			api.UserPass{Username: byzantineUsername, Password: byzantinePassword},
+			networkAcceptanceTimeout)
+		if _, err := byzantineRunner.ImportGenesisFundsAndStartValidating(seedAmount, stakeAmount); err != nil {
+			context.Fatal(stacktrace.Propagate(err, "Failed to add byzantine node %v as a validator.", serviceID))
+		}
+	}
+	logrus.Infof("Adding %v honest nodes as stakers...", test.NumHonestNodes)
+	for _, serviceID := range honestServiceIDs {
+		honestRunner := helpers.NewRPCWorkFlowRunner(
+			honestClients[serviceID],
+			api.UserPass{Username: stakerUsername, Password: stakerPassword},
+			networkAcceptanceTimeout)
+		if _, err := honestRunner.ImportGenesisFundsAndStartValidating(seedAmount, stakeAmount); err != nil {
+			context.Fatal(stacktrace.Propagate(err, "Failed to add honest node %v as a validator.", serviceID))
+		}
+	}
+
+	// The boot nodes are honest too
+	for serviceID, nodeID := range bootNodeIDs {
+		honestNodeIDs[serviceID] = nodeID
+		honestClients[serviceID] = bootClients[serviceID]
+	}
+	allValidatorNodeIDs := make(map[string]bool)
+	for _, nodeID := range honestNodeIDs {
+		allValidatorNodeIDs[nodeID] = true
+	}
+	for _, nodeID := range byzantineNodeIDs {
+		allValidatorNodeIDs[nodeID] = true
+	}
+	logrus.Infof("Waiting for every honest node to see all %v validators...", len(allValidatorNodeIDs))
+	if err := helpers.AwaitCondition(networkAcceptanceTimeout, networkStatePollInterval, func() error {
+		return verifyValidators(honestClients, allValidatorNodeIDs, true)
+	}); err != nil {
+		context.Fatal(stacktrace.Propagate(err, "The honest nodes never agreed on the validator set with the byzantine nodes staked."))
+	}
+	// Poll so that the honest nodes added since the network was wrapped have a status from before the check starts
+	castedNetwork.GetHealthMonitor().Poll()
+	byzantineStakedTime := time.Now()
+
+	// ========================== ISSUE CONFLICTING TXS ==========================
+	conflictSets := []*issuedConflictSet{}
+	if test.Behavior.NeedsConflictingTxs {
+		for _, serviceID := range getServiceIDs(byzantineNodePrefix, test.NumByzantineNodes) {
+			logrus.Infof("Issuing %v conflicting transactions to byzantine node %v...", numConflictingSpends, serviceID)
+			conflictSet, err := test.issueConflictSet(honestClients[honestServiceIDs[0]], byzantineClients[serviceID], serviceID)
+			if err != nil {
+				context.Fatal(stacktrace.Propagate(err, "Failed to issue conflicting transactions to byzantine node %v.", serviceID))
+			}
+			conflictSets = append(conflictSets, conflictSet)
+		}
+	}
+
+	// ============================== CHECK LIVENESS =============================
+	senderServiceID := honestServiceIDs[0]
+	sender := helpers.NewRPCWorkFlowRunner(
+		honestClients[senderServiceID],
+		api.UserPass{Username: stakerUsername, Password: stakerPassword},
+		networkAcceptanceTimeout)
+	witness := helpers.NewRPCWorkFlowRunner(
+		honestClients[honestServiceIDs[len(honestServiceIDs)-1]],
+		api.UserPass{Username: witnessUsername, Password: witnessPassword},
+		networkAcceptanceTimeout)
+	witnessAddress, _, err := witness.CreateDefaultAddresses()
+	if err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Failed to create the witness addresses."))
+	}
+	logrus.Infof("Sending %v nAVAX from honest node %v to the witness address %v...", witnessAmount, senderServiceID, witnessAddress)
+	txID, err := sender.SendAVAX(witnessAddress, witnessAmount)
+	if err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Failed to send AVAX to the witness address."))
+	}
+	if err := sender.AwaitXChainTxs(txID); err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Honest nodes stopped accepting transactions with byzantine behavior '%v' staked.", test.Behavior.Name))
+	}
+
+	// =============================== CHECK SAFETY ==============================
+	logrus.Infof("Checking that every honest node accepted transaction %s...", txID)
+	var safetyViolation error
+	if err := helpers.AwaitCondition(networkAcceptanceTimeout, networkStatePollInterval, func() error {
+		safetyViolation = verifyTxAccepted(honestClients, txID, witnessAddress)
+		if _, ok := safetyViolation.(rejectedTxError); ok {
+			// A rejection is final, so there's no point in polling any longer
+			return nil
+		}
+		return safetyViolation
+	}); err != nil {
+		context.Fatal(stacktrace.Propagate(err, "The honest nodes never all accepted transaction %s.", txID))
+	}
+	if safetyViolation != nil {
+		context.Fatal(stacktrace.Propagate(safetyViolation, "Honest nodes disagree on transaction %s with byzantine behavior '%v' staked.", txID, test.Behavior.Name))
+	}
+
+	// Honest validators must never be dropped, while byzantine ones may be unless the behavior says otherwise
+	expectedValidatorNodeIDs := allValidatorNodeIDs
+	if !test.Behavior.ByzantineNodesRemainValidators {
+		expectedValidatorNodeIDs = make(map[string]bool)
+		for _, nodeID := range honestNodeIDs {
+			expectedValidatorNodeIDs[nodeID] = true
+		}
+	}
+	err = verifyValidators(honestClients, expectedValidatorNodeIDs, test.Behavior.ByzantineNodesRemainValidators)
+	context.AssertTrue(err == nil, stacktrace.Propagate(err, "Honest nodes disagree on the validator set with byzantine behavior '%v' staked.", test.Behavior.Name))
+
+	if err := verifyConflictsResolved(honestClients, conflictSets); err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Honest nodes accepted conflicting transactions with byzantine behavior '%v' staked.", test.Behavior.Name))
+	}
+
+	tracked := verifier.TrackedState{
+		XChainTxIDs:     []ids.ID{txID},
+		XChainAddresses: []string{witnessAddress},
+	}
+	for _, conflictSet := range conflictSets {
+		tracked.XChainTxIDs = append(tracked.XChainTxIDs, conflictSet.createAssetTxID)
+		tracked.XChainTxIDs = append(tracked.XChainTxIDs, conflictSet.spendTxIDs...)
+	}
+	if err := test.SafetyVerifier.VerifyConsensusSafety(honestClients, tracked); err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Honest nodes disagree on the accepted state with byzantine behavior '%v' staked.", test.Behavior.Name))
+	}
+
+	honestAndBootServiceIDs := append(append([]networks.ServiceID{}, honestServiceIDs...), bootServiceIDs...)
+	err = castedNetwork.GetHealthMonitor().AssertStayedHealthy(byzantineStakedTime, honestAndBootServiceIDs...)
+	context.AssertTrue(err == nil, stacktrace.Propagate(err, "An honest node went unhealthy with byzantine behavior '%v' staked.", test.Behavior.Name))
+}
+
+// GetNetworkLoader implements the Kurtosis Test interface
+func (test StakingNetworkByzantineTest) GetNetworkLoader() (networks.NetworkLoader, error) {
+	byzantineWeights := make([]uint64, 0, test.NumByzantineNodes)
+	for i := 0; i < test.NumByzantineNodes; i++ {
+		byzantineWeights = append(byzantineWeights, stakeAmount)
+	}
+	honestWeights := make([]uint64, 0, test.NumHonestNodes+len(avalancheNetwork.DefaultLocalNetGenesisConfig.Stakers))
+	for i := 0; i < test.NumHonestNodes; i++ {
+		honestWeights = append(honestWeights, stakeAmount)
+	}
+	for range avalancheNetwork.DefaultLocalNetGenesisConfig.Stakers {
+		honestWeights = append(honestWeights, avalancheNetwork.DefaultLocalNetGenesisConfig.GetStakerWeight())
+	}
+	if err := test.Behavior.validate(byzantineWeights, honestWeights); err != nil {
+		return nil, stacktrace.Propagate(err, "Invalid byzantine test configuration")
+	}
+	if test.NumHonestNodes < 1 {
+		return nil, stacktrace.NewError("At least one honest node besides the boot nodes is needed to send transactions from")
+	}
+
+	serviceConfigs := map[networks.ConfigurationID]avalancheNetwork.TestAvalancheNetworkServiceConfig{
+		honestNodeConfigID: *avalancheNetwork.NewTestAvalancheNetworkServiceConfig(
+			true,
+			test.NormalImageName,
+			avalancheService.NodeConfig{
+				LogLevel:              avalancheService.DEBUG,
+				SnowQuorumSize:        2,
+				SnowSampleSize:        2,
+				NetworkInitialTimeout: 2 * time.Second,
+			},
+		),
+		byzantineConfigID: *avalancheNetwork.NewTestAvalancheNetworkServiceConfig(
+			true,
+			test.ByzantineImageName,
+			avalancheService.NodeConfig{
+				LogLevel:              avalancheService.DEBUG,
+				SnowQuorumSize:        2,
+				SnowSampleSize:        2,
+				NetworkInitialTimeout: 2 * time.Second,
+				ByzantineBehavior:     test.Behavior.Name,
+			},
+		),
+	}
+
+	desiredServices := make(map[networks.ServiceID]networks.ConfigurationID)
+	for _, serviceID := range getServiceIDs(byzantineNodePrefix, test.NumByzantineNodes) {
+		desiredServices[serviceID] = byzantineConfigID
+	}
+	for _, serviceID := range getServiceIDs(honestNodePrefix, test.NumHonestNodes) {
+		desiredServices[serviceID] = honestNodeConfigID
+	}
+	logrus.Debugf("Byzantine Image Name: %s", test.ByzantineImageName)
+	logrus.Debugf("Normal Image Name: %s", test.NormalImageName)
+
+	return avalancheNetwork.NewTestAvalancheNetworkLoader(
+		true,
+		test.NormalImageName,
+		avalancheService.DEBUG,
+		2,
+		2,
+		test.TxFee,
+		2*time.Second,
+		avalancheNetwork.DefaultLocalNetGenesisConfig,
+		serviceConfigs,
+		desiredServices,
+	)
+}
+
+// GetExecutionTimeout implements the Kurtosis Test interface
+func (test StakingNetworkByzantineTest) GetExecutionTimeout() time.Duration {
+	// Each staked node has to wait out the staking delay
+	return 3*time.Minute + time.Duration(test.NumByzantineNodes+test.NumHonestNodes)*time.Minute
+}
+
+// GetSetupBuffer implements the Kurtosis Test interface
+func (test StakingNetworkByzantineTest) GetSetupBuffer() time.Duration {
+	return 3 * time.Minute
+}
+
+// ================= Helper functions ===================
+
+// issuedConflictSet is the IDs of a set of conflicting transactions that were issued to a byzantine node
+type issuedConflictSet struct {
+	createAssetTxID ids.ID
+
+	// The spends that all conflict with each other, of which honest nodes may accept at most one
+	spendTxIDs []ids.ID
+}
+
+// issueConflictSet builds a set of conflicting transactions from a genesis UTXO as [utxoClient]'s node sees it, and
+// issues all of them to the byzantine node with the given service ID
+func (test StakingNetworkByzantineTest) issueConflictSet(
+	utxoClient *apis.Client,
+	byzantineClient *apis.Client,
+	serviceID networks.ServiceID) (*issuedConflictSet, error) {
+	genesisKey, genesisUTXO, err := helpers.GetGenesisKeyAndUTXO(utxoClient)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to get a genesis UTXO to build the conflicting transactions from")
+	}
+	// Every byzantine node gets its own asset name, so that the sets' transactions have different IDs
+	conflictSet, err := txbuilder.NewConflictSet(
+		constants.LocalID,
+		genesisKey,
+		genesisUTXO,
+		fmt.Sprintf("%v %v", conflictAssetName, serviceID),
+		numConflictingSpends,
+		test.TxFee)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to build the conflicting transactions")
+	}
+
+	byzantineXChainAPI := byzantineClient.XChainAPI()
+	createAssetTxID, err := byzantineXChainAPI.IssueTx(conflictSet.CreateAssetTx.Bytes())
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to issue the create asset transaction")
+	}
+	result := &issuedConflictSet{createAssetTxID: createAssetTxID}
+	for i, spend := range conflictSet.Spends {
+		spendTxID, err := byzantineXChainAPI.IssueTx(spend.Bytes())
+		if err != nil {
+			return nil, stacktrace.Propagate(err, "Failed to issue conflicting spend %v", i)
+		}
+		result.spendTxIDs = append(result.spendTxIDs, spendTxID)
+	}
+	logrus.Infof("Issued create asset transaction %s and conflicting spends %v to byzantine node %v", createAssetTxID, result.spendTxIDs, serviceID)
+	return result, nil
+}
+
+// verifyConflictsResolved verifies that no honest node accepted more than one of the spends of any conflict set
+func verifyConflictsResolved(clients map[networks.ServiceID]*apis.Client, conflictSets []*issuedConflictSet) error {
+	for serviceID, client := range clients {
+		for _, conflictSet := range conflictSets {
+			acceptedTxIDs := []ids.ID{}
+			for _, spendTxID := range conflictSet.spendTxIDs {
+				status, err := client.XChainAPI().GetTxStatus(spendTxID)
+				if err != nil {
+					return stacktrace.Propagate(err, "Failed to get the status of conflicting transaction %s from service %v", spendTxID, serviceID)
+				}
+				if status == choices.Accepted {
+					acceptedTxIDs = append(acceptedTxIDs, spendTxID)
+				}
+			}
+			if len(acceptedTxIDs) > 1 {
+				return stacktrace.NewError("Service %v accepted conflicting transactions %v", serviceID, acceptedTxIDs)
+			}
+		}
+	}
+	return nil
+}
+
+// rejectedTxError is returned when an honest node rejected a transaction that other honest nodes accepted
+type rejectedTxError struct {
+	error
+}
+
+// verifyTxAccepted verifies that every given node has accepted the transaction with the given ID, and reports the
+// witness amount as the balance of the witness address
+func verifyTxAccepted(clients map[networks.ServiceID]*apis.Client, txID ids.ID, witnessAddress string) error {
+	for serviceID, client := range clients {
+		status, err := client.XChainAPI().GetTxStatus(txID)
+		if err != nil {
+			return stacktrace.Propagate(err, "Failed to get the status of transaction %s from service %v", txID, serviceID)
+		}
+		if status == choices.Rejected {
+			return rejectedTxError{stacktrace.NewError("Service %v rejected transaction %s", serviceID, txID)}
+		}
+		if status != choices.Accepted {
+			return stacktrace.NewError("Transaction %s has status %s on service %v", txID, status, serviceID)
+		}
+		balance, err := client.XChainAPI().GetBalance(witnessAddress, helpers.AvaxAssetID)
+		if err != nil {
+			return stacktrace.Propagate(err, "Failed to get the witness balance from service %v", serviceID)
+		}
+		if uint64(balance.Balance) != witnessAmount {
+			return stacktrace.NewError("Service %v reports a witness balance of %v instead of %v", serviceID, balance.Balance, witnessAmount)
+		}
+	}
+	return nil
+}
+
+// verifyValidators verifies that every given node reports the given node IDs as current validators
+// Args:
+// 	clients: The clients of the nodes to check
+// 	expectedValidatorNodeIDs: The node IDs that must be current validators
+// 	exact: Whether the expected node IDs must be the only current validators
+func verifyValidators(
+	clients map[networks.ServiceID]*apis.Client,
+	expectedValidatorNodeIDs map[string]bool,
+	exact bool) error {
+	for serviceID, client := range clients {
+		validatorNodeIDs, err := helpers.GetValidatorNodeIDs(client, ids.Empty)
+		if err != nil {
+			return stacktrace.Propagate(err, "Failed to get the validators from service %v", serviceID)
+		}
+		if exact && len(validatorNodeIDs) != len(expectedValidatorNodeIDs) {
+			return stacktrace.NewError("Service %v reports validators %v instead of %v", serviceID, validatorNodeIDs, expectedValidatorNodeIDs)
+		}
+		for nodeID := range expectedValidatorNodeIDs {
+			if !validatorNodeIDs[nodeID] {
+				return stacktrace.NewError("Service %v reports validators %v instead of %v", serviceID, validatorNodeIDs, expectedValidatorNodeIDs)
+			}
+		}
+	}
+	return nil
+}
+
+// toServiceIDSet returns the set of the given service IDs
+func toServiceIDSet(serviceIDs []networks.ServiceID) map[networks.ServiceID]bool {
+	serviceIDSet := make(map[networks.ServiceID]bool, len(serviceIDs))
+	for _, serviceID := range serviceIDs {
+		serviceIDSet[serviceID] = true
+	}
+	return serviceIDSet
+}
+
+// getServiceIDs returns the IDs of the given number of services with the given prefix, in the order they're staked in
+func getServiceIDs(prefix string, numServices int) []networks.ServiceID {
+	serviceIDs := make([]networks.ServiceID, 0, numServices)
+	for i := 0; i < numServices; i++ {
+		serviceIDs = append(serviceIDs, networks.ServiceID(prefix+strconv.Itoa(i)))
+	}
+	return serviceIDs
+}
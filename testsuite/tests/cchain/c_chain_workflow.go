@@ -0,0 +1,124 @@
+package cchain
+
+import (
+	"math/big"
+	"time"
+
+	avalancheNetwork "github.com/ava-labs/avalanche-testing/avalanche/networks"
+	avalancheService "github.com/ava-labs/avalanche-testing/avalanche/services"
+	"github.com/ava-labs/avalanche-testing/avalanche_client/apis"
+	"github.com/ava-labs/avalanche-testing/testsuite/helpers"
+	"github.com/ava-labs/avalanchego/api"
+	"github.com/ava-labs/avalanchego/utils/units"
+	"github.com/kurtosis-tech/kurtosis/commons/networks"
+	"github.com/kurtosis-tech/kurtosis/commons/testsuite"
+	"github.com/palantir/stacktrace"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	userUsername = "c_chain_user"
+	userPassword = "CCha1nUs3r!"
+
+	transferAmount       = 10 * units.KiloAvax
+	returnTransferAmount = 4 * units.KiloAvax
+
+	networkAcceptanceTimeoutRatio = 0.3
+)
+
+// StakingNetworkCChainWorkflowTest moves AVAX from the X Chain to the C Chain and back, checking the balances on both
+// chains through the C Chain's Ethereum JSON-RPC endpoint and the X Chain API
+type StakingNetworkCChainWorkflowTest struct {
+	ImageName string
+}
+
+// Run implements the Kurtosis Test interface
+func (test StakingNetworkCChainWorkflowTest) Run(network networks.Network, context testsuite.TestContext) {
+	castedNetwork := network.(avalancheNetwork.TestAvalancheNetwork)
+	networkAcceptanceTimeout := time.Duration(networkAcceptanceTimeoutRatio * float64(test.GetExecutionTimeout().Nanoseconds()))
+	var client *apis.Client
+	for serviceID := range castedNetwork.GetAllBootServiceIDs() {
+		bootClient, err := castedNetwork.GetAvalancheClient(serviceID)
+		if err != nil {
+			context.Fatal(stacktrace.Propagate(err, "Failed to get Avalanche Client for boot node with serviceID: %s.", serviceID))
+		}
+		client = bootClient
+		break
+	}
+	user := api.UserPass{Username: userUsername, Password: userPassword}
+	runner := helpers.NewRPCWorkFlowRunner(client, user, networkAcceptanceTimeout)
+
+	// ====================================== X CHAIN -> C CHAIN ===============================
+	genesisAddress, err := runner.ImportGenesisFunds()
+	if err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Failed to import genesis funds."))
+	}
+	ethAddress, err := runner.ImportXChainKeyToCChain(genesisAddress)
+	if err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Failed to import the genesis key to the C Chain."))
+	}
+	startingCChainBalance, err := client.CChainAPI().GetBalance(ethAddress)
+	if err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Failed to get the C Chain balance of %s.", ethAddress))
+	}
+	logrus.Infof("Transferring %v nAVAX from %s to %s on the C Chain...", transferAmount, genesisAddress, ethAddress)
+	if err := runner.TransferAvaXChainToCChain(genesisAddress, ethAddress, transferAmount); err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Failed to transfer AVAX from the X Chain to the C Chain."))
+	}
+
+	cChainBalance, err := client.CChainAPI().GetBalance(ethAddress)
+	if err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Failed to get the C Chain balance of %s.", ethAddress))
+	}
+	// The network has no tx fee, so the import credits exactly the amount that was transferred
+	expectedCChainBalance := new(big.Int).Add(startingCChainBalance, helpers.NanoAvaxToWei(transferAmount))
+	context.AssertTrue(
+		cChainBalance.Cmp(expectedCChainBalance) == 0,
+		stacktrace.NewError("C Chain balance of %s is %v wei, but expected %v wei", ethAddress, cChainBalance, expectedCChainBalance))
+	blockNumber, err := client.CChainAPI().BlockNumber()
+	if err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Failed to get the C Chain block number."))
+	}
+	context.AssertTrue(blockNumber > 0, stacktrace.NewError("C Chain is still at block 0 after the import was credited"))
+	logrus.Infof("C Chain balance of %s is %v wei at block %v.", ethAddress, cChainBalance, blockNumber)
+
+	// ====================================== C CHAIN -> X CHAIN ===============================
+	xChainAddress, err := client.XChainAPI().CreateAddress(user)
+	if err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Failed to create X Chain address."))
+	}
+	logrus.Infof("Transferring %v nAVAX from %s back to %s on the X Chain...", returnTransferAmount, ethAddress, xChainAddress)
+	if err := runner.TransferAvaCChainToXChain(xChainAddress, returnTransferAmount); err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Failed to transfer AVAX from the C Chain to the X Chain."))
+	}
+	if err := runner.VerifyXChainAVABalance(xChainAddress, returnTransferAmount); err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Unexpected X Chain balance after the transfer back from the C Chain."))
+	}
+	logrus.Infof("C Chain workflow completed successfully.")
+}
+
+// GetNetworkLoader implements the Kurtosis Test interface
+func (test StakingNetworkCChainWorkflowTest) GetNetworkLoader() (networks.NetworkLoader, error) {
+	return avalancheNetwork.NewTestAvalancheNetworkLoader(
+		true,
+		test.ImageName,
+		avalancheService.DEBUG,
+		2,
+		2,
+		0,
+		2*time.Second,
+		avalancheNetwork.DefaultLocalNetGenesisConfig,
+		make(map[networks.ConfigurationID]avalancheNetwork.TestAvalancheNetworkServiceConfig),
+		make(map[networks.ServiceID]networks.ConfigurationID),
+	)
+}
+
+// GetExecutionTimeout implements the Kurtosis Test interface
+func (test StakingNetworkCChainWorkflowTest) GetExecutionTimeout() time.Duration {
+	return 5 * time.Minute
+}
+
+// GetSetupBuffer implements the Kurtosis Test interface
+func (test StakingNetworkCChainWorkflowTest) GetSetupBuffer() time.Duration {
+	return 3 * time.Minute
+}
@@ -12,18 +12,16 @@ import (
 )
 
 const (
-	normalNodeConfigID          networks.ConfigurationID = "normal-config"
-	byzantineConfigID           networks.ConfigurationID = "byzantine-config"
-	byzantineUsername                                    = "byzantine_avalanche"
-	byzantinePassword                                    = "byzant1n3!"
-	byzantineBehavior                                    = "byzantine-behavior"
-	conflictingTxVertexBehavior                          = "conflicting-txs-vertex"
-	stakerUsername                                       = "staker_avalanche"
-	stakerPassword                                       = "test34test!23"
-	byzantineNodeServiceID                               = "byzantine-node"
-	normalNodeServiceID                                  = "virtuous-node"
-	seedAmount                                           = int64(50000000000000)
-	stakeAmount                                          = int64(30000000000000)
+	normalNodeConfigID     networks.ConfigurationID = "normal-config"
+	byzantineConfigID      networks.ConfigurationID = "byzantine-config"
+	byzantineUsername                               = "byzantine_avalanche"
+	byzantinePassword                               = "byzant1n3!"
+	stakerUsername                                  = "staker_avalanche"
+	stakerPassword                                  = "test34test!23"
+	byzantineNodeServiceID                          = "byzantine-node"
+	normalNodeServiceID                             = "virtuous-node"
+	seedAmount                                      = int64(50000000000000)
+	stakeAmount                                     = int64(30000000000000)
 )
 
 // StakingNetworkConflictingTxsVertexTest creates a byzantine node to issue conflicting transactions into a single
@@ -32,6 +30,7 @@ const (
 type StakingNetworkConflictingTxsVertexTest struct {
 	ByzantineImageName string
 	NormalImageName    string
+	TxFee              uint64
 }
 
 // Run implements the Kurtosis Test interface
@@ -46,7 +45,7 @@ func (test StakingNetworkConflictingTxsVertexTest) Run(network networks.Network,
 	if err != nil {
 		context.Fatal(stacktrace.Propagate(err, "Failed to get virtuous client."))
 	}
-	executor := NewConflictingTxsVertexExecutor(virtuousClient, byzantineClient)
+	executor := NewConflictingTxsVertexExecutor(virtuousClient, byzantineClient, test.TxFee)
 	logrus.Infof("Executing conflicting transaction vertex test...")
 	if err := executor.ExecuteTest(); err != nil {
 		context.Fatal(stacktrace.Propagate(err, "Conflicting Transactions Vertex Test failed."))
@@ -60,7 +59,7 @@ func (test StakingNetworkConflictingTxsVertexTest) GetNetworkLoader() (networks.
 	desiredServices[byzantineNodeServiceID] = byzantineConfigID
 	desiredServices[normalNodeServiceID] = normalNodeConfigID
 
-	return getByzantineNetworkLoader(desiredServices, test.ByzantineImageName, test.NormalImageName)
+	return getByzantineNetworkLoader(desiredServices, test.ByzantineImageName, test.NormalImageName, test.TxFee)
 }
 
 // GetExecutionTimeout implements the Kurtosis Test interface
@@ -78,26 +77,30 @@ func (test StakingNetworkConflictingTxsVertexTest) GetSetupBuffer() time.Duratio
 /*
 Args:
 	desiredServices: Mapping of service_id -> configuration_id for all services *in addition to the boot nodes* that the user wants
+	txFee: The transaction fee of the network, which the test's transactions are built for
 */
-func getByzantineNetworkLoader(desiredServices map[networks.ServiceID]networks.ConfigurationID, byzantineImageName string, normalImageName string) (networks.NetworkLoader, error) {
+func getByzantineNetworkLoader(desiredServices map[networks.ServiceID]networks.ConfigurationID, byzantineImageName string, normalImageName string, txFee uint64) (networks.NetworkLoader, error) {
 	serviceConfigs := map[networks.ConfigurationID]avalancheNetwork.TestAvalancheNetworkServiceConfig{
 		normalNodeConfigID: *avalancheNetwork.NewTestAvalancheNetworkServiceConfig(
 			true,
-			avalancheService.DEBUG,
 			normalImageName,
-			2,
-			2,
-			2*time.Second,
-			make(map[string]string),
+			avalancheService.NodeConfig{
+				LogLevel:              avalancheService.DEBUG,
+				SnowQuorumSize:        2,
+				SnowSampleSize:        2,
+				NetworkInitialTimeout: 2 * time.Second,
+			},
 		),
 		byzantineConfigID: *avalancheNetwork.NewTestAvalancheNetworkServiceConfig(
 			true,
-			avalancheService.DEBUG,
 			byzantineImageName,
-			2,
-			2,
-			2*time.Second,
-			map[string]string{byzantineBehavior: conflictingTxVertexBehavior},
+			avalancheService.NodeConfig{
+				LogLevel:              avalancheService.DEBUG,
+				SnowQuorumSize:        2,
+				SnowSampleSize:        2,
+				NetworkInitialTimeout: 2 * time.Second,
+				ByzantineBehavior:     avalancheService.ConflictingTxsVertexBehavior,
+			},
 		),
 	}
 	logrus.Debugf("Byzantine Image Name: %s", byzantineImageName)
@@ -109,8 +112,9 @@ func getByzantineNetworkLoader(desiredServices map[networks.ServiceID]networks.C
 		avalancheService.DEBUG,
 		2,
 		2,
-		1000000,
+		txFee,
 		2*time.Second,
+		avalancheNetwork.DefaultLocalNetGenesisConfig,
 		serviceConfigs,
 		desiredServices,
 	)
@@ -1,27 +1,38 @@
 package conflictvtx
 
 import (
-	"fmt"
 	"time"
 
 	"github.com/ava-labs/avalanche-testing/avalanche_client/apis"
+	"github.com/ava-labs/avalanche-testing/testsuite/helpers"
 	"github.com/ava-labs/avalanche-testing/testsuite/tester"
+	"github.com/ava-labs/avalanche-testing/testsuite/txbuilder"
 	"github.com/ava-labs/avalanchego/snow/choices"
-	"github.com/ava-labs/avalanchego/utils/formatting"
+	"github.com/ava-labs/avalanchego/utils/constants"
 	"github.com/palantir/stacktrace"
 	"github.com/sirupsen/logrus"
 )
 
+const (
+	byzantineAssetName = "Byzantine Conflict Asset"
+	virtuousAssetName  = "Virtuous Conflict Asset"
+
+	// The number of conflicting spends the byzantine node batches into its vertex
+	numConflictingSpends = 2
+)
+
 type executor struct {
 	virtuousClient  *apis.Client
 	byzantineClient *apis.Client
+	txFee           uint64
 }
 
 // NewConflictingTxsVertexExecutor ...
-func NewConflictingTxsVertexExecutor(virtuousClient, byzantineClient *apis.Client) tester.AvalancheTester {
+func NewConflictingTxsVertexExecutor(virtuousClient, byzantineClient *apis.Client, txFee uint64) tester.AvalancheTester {
 	return &executor{
 		virtuousClient:  virtuousClient,
 		byzantineClient: byzantineClient,
+		txFee:           txFee,
 	}
 }
 
@@ -29,35 +40,26 @@ func NewConflictingTxsVertexExecutor(virtuousClient, byzantineClient *apis.Clien
 func (e *executor) ExecuteTest() error {
 	byzantineXChainAPI := e.byzantineClient.XChainAPI()
 
-	// TODO switch to test vectors or come up with method to reliably generate conflicting transactions
-	// how to create a conflicting transaction???
-	// test vector create asset tx and conflicting transactions
-	// Note: these hardcoded transactions are based on everest-deployment with a txFee of 1,000,000 nAVAX
-	cb58 := formatting.CB58{}
-	if err := cb58.FromString("111118ErNmgStuDR9eVYXkdx1HbGsq4t7nSMq136wpmmsDU8ZAYv2W1A26yz2rnXeV1pzSSA7qNdKQqUnW3PNDgusduVzMNjSq183wbFJUGhYYvZEQ769aih5CUB2fHqu6vHc3cEXw3W6XotZhPEb82NZUMUNzpYSYpFrSi85eV46eDyYQGq49NFGGGZz7xhWsJyiiH3nWf3SwbGrkWmWRYdZUgwgKP2zqzvReu1yc9T41T2svGmDabA5KKKtzJWDP3JowGv9Dem53RPG8AQHkUY1Zn6s3atvgsz2a71dGggKdgBaJifE7Z9mknboaz2xkhL3VRzREEjcEquRBNg9RP9yQPv1ScXHFoF3PgrHW6y5RzPun59gGPrVg7Cfvh1n2rqpi73jB1nrLNyNFFXywtmeTv12p7Uz7qbEQ1ePP3rhJKciqmUcbqdGC9QajdhvKasQedCxPmFKVM9AnV789xUF5Z7QuXNraugc5cZG2HiuJc1XRyK6Yp4U6xmSie3umVGeuh"); err != nil {
-		return stacktrace.Propagate(err, "Problem parsing create asset tx")
-	}
-	createAssetTx := cb58.Bytes
-	if err := cb58.FromString("11111111BcUqA7u1jUPNAG82pPwFcGneFJihdTTr5ub5aUPwBoC7gtP3ZbSnJGaKGf6oJJMpd9LC21BjzzHE5Qtg6L5UMmkuHrmprvDPfqzpewRcoyuu5n8xKXv2kSmCTXUBXsURxZNSuZ2z3AQmg7xK3e91evtEVQNRUm8sSXpsxcBoESXKRygAAJVyUA2h54FHEi6gQtY4GsXjzDqXoxvktdpvUWynFwt4zWWjAiLmsq2A4aBUtzY5sTEs5KaYQ2Vf9sLdV9RLkFrDeGVdWBcqbYLyTY5eYp6a7J8HwurXACF5iEzwvxBMByhUqmVSMCbWcuiTWTWTU98SZTzH5PVHufGKEJCk269pZMRe6M1oUNhqsGMgQ6A5RSKzrT5V5dkAikvjt4K9Fs72tGbk4Lxmmy7dSDToRXGskHqccm4rQQjtdCbYVZQRMu6dqgrQkGa3mNkFPqHZDvgryPL288NJEsfmjR2SWTR8Eco5nRJ4FzZmViCXFVsPeLuLhsKSAWYTzaWNWEEsrPvz2MymW37gmK9pufsXVqnFZehfHS68VvJDW1LNLjV2dqDPXmH8Aep3PorqG9SqYCA5FPjts8qk1YkAFjjRPKSNehcbocNMBvnsPwHH4dJjDLfwYHet2ncs7zZSLqhqnj1kv37JgNSumqB9UBbAqavNycdcRjAUDSRSdYm4wuNd1PZx176Y1NkMeRGWphn7d15cYSLdcs7gUQ148iXxxPzu"); err != nil {
-		return stacktrace.Propagate(err, "Problem parsing first send tx")
+	// Both the byzantine and the virtuous transactions are built from the same genesis UTXO, for the network's fee
+	genesisKey, genesisUTXO, err := helpers.GetGenesisKeyAndUTXO(e.virtuousClient)
+	if err != nil {
+		return stacktrace.Propagate(err, "Failed to get a genesis UTXO to build the transactions from")
 	}
-	conflictingTx1 := cb58.Bytes
-
-	if err := cb58.FromString("11111111BcUqA7u1jUPNAG82pPwFcGneFJihdTTr5ub5aUPwBoC7gtP3ZbSnJGaKGf6oJJMpd9LC21BjzzHE5Qtg6L5UMmkuHrmprvDPfqzpewRcoyuu5n8xKXv2kSmCTXUBXsURxZNSuZ2z3AQmg7xK3e91evtEVQNRUm8sSXpsxcBoESXKRygAAJVyUA2h54FHEi6gQtY4GsXjzDqXoxvktdpvUWynFwt4zWWjAiLmsq2A4aBUtzY5sTFMpLDeMj74Bdp99ueWJfYP5iooGm7osougGL3SogSfhcsTxQiLaNsPa2yUB9JjoFkZwMgyA75frQRKbMgcLoyT7beZwkZF7gTG3VCaEWCeZS9L9M96rdMi1FDTSm95AN4miUBzynVXsgJcUKhaz3qGkr72dkrr2gxi5zPpBekW5cZMyB7MUAggyMn9RyEgBBoBS8U5PwZPgNQnfMhBrmEUQjJ4L1kKCbT2t1cQPcoJNEzSL56eoJxtWvG1rnhnrkmGSkQVy5J3bXoyL5twwwz8zDpEuvNcQ9dacNP6NJcMS8yMpf55ARrHH6GNvQ6ekLrftBQCfM5wKbs4TtUEgXEvUSbkAfsecbbmY2iHUTvEdj1bPba2fXJ6XoApShjKgAyoMmYdzgSVtdq5ffQLmK2zmGrf3GVdHauCfzzj87S2DTf2DtRHbEyFrDYpbZ2VaE1FdhyVoH5KjfLa2XbePhKZyeBFGfzjq9YmvDCmpi7k"); err != nil {
-		return stacktrace.Propagate(err, "Problem parsing second send tx")
+	byzantineSet, err := txbuilder.NewConflictSet(constants.LocalID, genesisKey, genesisUTXO, byzantineAssetName, numConflictingSpends, e.txFee)
+	if err != nil {
+		return stacktrace.Propagate(err, "Failed to build the conflicting transactions")
 	}
-	conflictingTx2 := cb58.Bytes
 
 	logrus.Infof("Issuing conflicting transactions to a byzantine node...")
-	nonConflictID, err := byzantineXChainAPI.IssueTx(createAssetTx)
+	nonConflictID, err := byzantineXChainAPI.IssueTx(byzantineSet.CreateAssetTx.Bytes())
 	if err != nil {
 		return stacktrace.Propagate(err, "Failed to issue first transaction to byzantine node.")
 	}
-	conflictID1, err := byzantineXChainAPI.IssueTx(conflictingTx1)
+	conflictID1, err := byzantineXChainAPI.IssueTx(byzantineSet.Spends[0].Bytes())
 	if err != nil {
 		return stacktrace.Propagate(err, "Failed to issue second transaction to byzantine node.")
 	}
-	conflictID2, err := byzantineXChainAPI.IssueTx(conflictingTx2)
+	conflictID2, err := byzantineXChainAPI.IssueTx(byzantineSet.Spends[1].Bytes())
 	if err != nil {
 		return stacktrace.Propagate(err, "Failed to issue third transaction to byzantine node.")
 	}
@@ -70,24 +72,24 @@ func (e *executor) ExecuteTest() error {
 	// controller that the vertex was successfully issued
 	status, err := byzantineXChainAPI.GetTxStatus(nonConflictID)
 	if err != nil {
-		return stacktrace.Propagate(err, fmt.Sprintf("Failed to get status of Transaction: %s", nonConflictID))
+		return stacktrace.Propagate(err, "Failed to get status of Transaction: %s", nonConflictID)
 	}
 	if status != choices.Accepted {
-		return stacktrace.Propagate(err, fmt.Sprintf("Transaction: %s was not accepted, status: %s", nonConflictID, status))
+		return stacktrace.NewError("Transaction: %s was not accepted, status: %s", nonConflictID, status)
 	}
 
 	logrus.Infof("Status of non-conflict transactions on byzantine node is: %s", status)
 
 	conflictStatus1, err := byzantineXChainAPI.GetTxStatus(conflictID1)
 	if err != nil {
-		return stacktrace.Propagate(err, fmt.Sprintf("Failed to get status of Transaction: %s", conflictID1))
+		return stacktrace.Propagate(err, "Failed to get status of Transaction: %s", conflictID1)
 	}
 
 	logrus.Infof("Status of conflict tx1: %s on byzantine node is: %s", conflictID1, conflictStatus1)
 
 	conflictStatus2, err := byzantineXChainAPI.GetTxStatus(conflictID2)
 	if err != nil {
-		return stacktrace.Propagate(err, fmt.Sprintf("Failed to get status of Transaction: %s", conflictID2))
+		return stacktrace.Propagate(err, "Failed to get status of Transaction: %s", conflictID2)
 	}
 
 	logrus.Infof("Status of conflict tx2: %s on byzantine node is: %s", conflictID2, conflictStatus2)
@@ -95,7 +97,7 @@ func (e *executor) ExecuteTest() error {
 	// Byzantine node should try to accept both conflicting transactions, but will fail to accept one due to the missing UTXO
 	// after the other consumes it.
 	if conflictStatus1 != choices.Accepted && conflictStatus2 != choices.Accepted {
-		return fmt.Errorf("Byzantine node did not accept either of the conflicting transactions, status1: %s. status2: %s", conflictStatus1, conflictStatus2)
+		return stacktrace.NewError("Byzantine node did not accept either of the conflicting transactions, status1: %s. status2: %s", conflictStatus1, conflictStatus2)
 	}
 
 	// The issued vertex should be dropped completely, so the virtuous nodes should drop the vertex
@@ -105,23 +107,18 @@ func (e *executor) ExecuteTest() error {
 	// This is meant to remove the need to wait an arbitrary amount of time to see if the vertex gets accepted
 	// and instead confirm the valid transaction as a measure of the time to finality before checking if
 	// the transactions that should have been dropped were in fact dropped successfully.
-	// TODO move to test vector
 	virtuousXChainAPI := e.virtuousClient.XChainAPI()
-	if err := cb58.FromString("111118ErNmgStuDR9eVYXkdx1HbGsq4t7nSMq136wpmmsDU8ZAYv2W1A26yz2rnXeV1pzSSA7qNdKQqUnW3PNDgusduVzMNjSq183wbFJUGhYYvZEQ769aih5CUB2fHqu6vHc3cEXw3W6XotZhPEb82NZUMUNzpYSYpFrSi85eV46eDyYQGq49NFGGGZz7xhWsJyiiH3nWf3SwbGrkWmWRYdZUgwgKP2zqzvReu1yc9T41T2svGmDabA5KKKtzJWDP3JowGv9Dem53RPG8AQHkUY1Zn6s3atvgsz2a71dGggKdgBaJifE7Z9mknboaz2xkhL3VRzREEjcEquRBNg9RP9yQPv1ScXHFoF2n7XcphjFd3ER7y1uRjDffbe5AQh4nFKZEQEfcgYcshAcXAokuGBiGnBm6JapPd813F4hdXjF6zQJScg99KE9s5nR5w7mGBbqvoenn1MTQCfj9CRxsUqqDAaLL38khHhWQN46osZxLV67qvJMfQ3FWjoY8whnsDZfbV"); err != nil {
-		return stacktrace.Propagate(err, "Failed to parse virtuous create asset tx")
-	}
-	virtuousCreateAssetTx := cb58.Bytes
-	if err := cb58.FromString("11111111BcUqA7u1jUPNAG82pPwFcGneFJihdTTr5ub5aUPwBoC7gtP3ZbSnJGaKGf6oJJMpd9LC21BjzzHE5Qtg6L5UMmkuHrmprvDPfqzpewRcoyuu5n8xKXv2kSmCTXUBXsURxZNSuZ2z3AQmg7xK3e91evtEVQNRUm8sShjK6pMPDpQsPaNDPTbTMZwwryRmy9KiYBjnjyHM8kFwbCkP7Lr9AyVHU5p3YKfAa3yFuEPfGRC277Lmrt6fMUWf5LTB3ivdXiXUF1zU5QbRDSx7WUVzewJhhNW6yCKcAbLpL3B3yvVsjGTspLkLbXtnfYEY4yiGqKt8vv9fA62CQoA9KfJJRW316Ymv8qgERt4bwnSot8tfmAUgmFQQs5gQwfXLtSXbiyCAeATUysheLN1SGGmqRwaRLYU5tYxGNMdSnNZKGhQpLyxopRArLE5jhSWke7Aihx6w4MQw1ff3yFRiCStQC1onCcCQSqRkUob4UvQFoankxhKmrVqPqTqb4DH6MvBimV2w7VqXaT8gzAQWGrhvnEcRJFSr9ZcY62HL2zVisweSxHurcpeGBfHLAhD7rWUrVkTaNrwzu3KZhrFqH3ae1ZLPZ2wu6euUyV4QWWvXEA7RSfp2uDU5cnnddsVcop1dfNNPmq5gd2wTziDEvFZPSHb6kG4Pn9vABsfheeE3iTRS1LRAfabFurxifAPzCFMVtH9XUxWXvXQsjXztLikUW9eLtrGV"); err != nil {
-		return stacktrace.Propagate(err, "Failed to parse virtuous spend tx")
+	virtuousSet, err := txbuilder.NewConflictSet(constants.LocalID, genesisKey, genesisUTXO, virtuousAssetName, 1, e.txFee)
+	if err != nil {
+		return stacktrace.Propagate(err, "Failed to build the virtuous transactions")
 	}
-	virtuousSpendTx := cb58.Bytes
 
 	// Ignore the TxID of this because it should be accepted immediately after entering consensus
-	_, err = virtuousXChainAPI.IssueTx(virtuousCreateAssetTx)
+	_, err = virtuousXChainAPI.IssueTx(virtuousSet.CreateAssetTx.Bytes())
 	if err != nil {
 		return stacktrace.Propagate(err, "Failed to issue virtuous create asset transaction after issuing illegal vertex from byzantine node.")
 	}
-	virtuousSpendTxID, err := virtuousXChainAPI.IssueTx(virtuousSpendTx)
+	virtuousSpendTxID, err := virtuousXChainAPI.IssueTx(virtuousSet.Spends[0].Bytes())
 	if err != nil {
 		return stacktrace.Propagate(err, "Failed to issue virtuous transaction spending created asset after issuing byzantine vertex")
 	}
@@ -150,7 +147,7 @@ func (e *executor) ExecuteTest() error {
 	// If the transaction was Accepted, the test should fail because virtuous nodes should not issue the vertex and
 	// the underlying transactions into consensus
 	if status == choices.Accepted {
-		return stacktrace.Propagate(err, fmt.Sprintf("Expected status of non-conflicting transaction issued in bad vertex to be Processing, but found %s", status))
+		return stacktrace.NewError("Expected status of non-conflicting transaction issued in bad vertex to be Processing, but found %s", status)
 	}
 	return nil
 }
@@ -6,7 +6,6 @@ import (
 	"github.com/ava-labs/avalanchego/api"
 	avalancheNetwork "github.com/ava-labs/avalanche-testing/avalanche/networks"
 	avalancheService "github.com/ava-labs/avalanche-testing/avalanche/services"
-	"github.com/ava-labs/avalanche-testing/avalanche_client/apis"
 	"github.com/ava-labs/avalanche-testing/testsuite/helpers"
 	"github.com/ava-labs/avalanche-testing/testsuite/verifier"
 	"github.com/kurtosis-tech/kurtosis/commons/networks"
@@ -26,6 +25,9 @@ const (
 	networkAcceptanceTimeoutRatio                    = 0.3
 	nonBootValidatorServiceID     networks.ServiceID = "validator-service"
 	nonBootNonValidatorServiceID  networks.ServiceID = "non-validator-service"
+
+	// Connected peers ping each other well within this, so a peer that's been silent for longer is stale
+	maxPeerSilence = time.Minute
 )
 
 // StakingNetworkFullyConnectedTest adds nodes to the network and verifies that the network stays fully connected
@@ -49,7 +51,10 @@ func (test StakingNetworkFullyConnectedTest) Run(network networks.Network, conte
 	allServiceIDs[nonBootValidatorServiceID] = true
 	allServiceIDs[nonBootNonValidatorServiceID] = true
 
-	allNodeIDs, allAvalancheClients := getNodeIDsAndClients(context, castedNetwork, allServiceIDs)
+	allNodeIDs, allAvalancheClients, err := helpers.GetNodeIDsAndClients(castedNetwork, allServiceIDs)
+	if err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Failed to get the node IDs and clients of the network's services"))
+	}
 	logrus.Infof("Verifying that the network is fully connected...")
 	if err := test.Verifier.VerifyNetworkFullyConnected(allServiceIDs, stakerIDs, allNodeIDs, allAvalancheClients); err != nil {
 		context.Fatal(stacktrace.Propagate(err, "An error occurred verifying the network's state"))
@@ -82,6 +87,17 @@ func (test StakingNetworkFullyConnectedTest) Run(network networks.Network, conte
 		context.Fatal(stacktrace.Propagate(err, "An error occurred verifying that the network is fully connected after gossip"))
 	}
 	logrus.Infof("The network is fully connected.")
+
+	logrus.Infof("Verifying that every node runs the same version and hears from all of its peers...")
+	if err := test.Verifier.VerifyNodeVersions(allAvalancheClients, ""); err != nil {
+		context.Fatal(stacktrace.Propagate(err, "An error occurred verifying the nodes' versions"))
+	}
+	for serviceID, client := range allAvalancheClients {
+		if err := test.Verifier.VerifyNoStalePeers(serviceID, client, maxPeerSilence); err != nil {
+			context.Fatal(stacktrace.Propagate(err, "An error occurred verifying that the network has no stale peers"))
+		}
+	}
+	logrus.Infof("Every node runs the same version and has no stale peers.")
 }
 
 // GetNetworkLoader implements the Kurtosis Test interface
@@ -89,12 +105,13 @@ func (test StakingNetworkFullyConnectedTest) GetNetworkLoader() (networks.Networ
 	serviceConfigs := map[networks.ConfigurationID]avalancheNetwork.TestAvalancheNetworkServiceConfig{
 		normalNodeConfigID: *avalancheNetwork.NewTestAvalancheNetworkServiceConfig(
 			true,
-			avalancheService.DEBUG,
 			test.ImageName,
-			2,
-			2,
-			2*time.Second,
-			make(map[string]string),
+			avalancheService.NodeConfig{
+				LogLevel:              avalancheService.DEBUG,
+				SnowQuorumSize:        2,
+				SnowSampleSize:        2,
+				NetworkInitialTimeout: 2 * time.Second,
+			},
 		),
 	}
 	desiredServices := map[networks.ServiceID]networks.ConfigurationID{
@@ -109,6 +126,7 @@ func (test StakingNetworkFullyConnectedTest) GetNetworkLoader() (networks.Networ
 		2,
 		0,
 		2*time.Second,
+		avalancheNetwork.DefaultLocalNetGenesisConfig,
 		serviceConfigs,
 		desiredServices,
 	)
@@ -121,33 +139,5 @@ func (test StakingNetworkFullyConnectedTest) GetExecutionTimeout() time.Duration
 
 // GetSetupBuffer implements the Kurtosis Test interface
 func (test StakingNetworkFullyConnectedTest) GetSetupBuffer() time.Duration {
-	// TODO drop this when the availabilityChecker doesn't have a sleep (because we spin up a bunch of nodes before running the test)
-	return 6 * time.Minute
-}
-
-// ================ Helper functions =========================
-/*
-This helper function will grab node IDs and Avalanche clients
-*/
-func getNodeIDsAndClients(
-	testContext testsuite.TestContext,
-	network avalancheNetwork.TestAvalancheNetwork,
-	allServiceIDs map[networks.ServiceID]bool,
-) (allNodeIDs map[networks.ServiceID]string, allAvalancheClients map[networks.ServiceID]*apis.Client) {
-	allAvalancheClients = make(map[networks.ServiceID]*apis.Client)
-	allNodeIDs = make(map[networks.ServiceID]string)
-	for serviceID := range allServiceIDs {
-		client, err := network.GetAvalancheClient(serviceID)
-		if err != nil {
-			testContext.Fatal(stacktrace.Propagate(err, "An error occurred getting the Avalanche client for service with ID %v", serviceID))
-		}
-		allAvalancheClients[serviceID] = client
-		nodeID, err := client.InfoAPI().GetNodeID()
-
-		if err != nil {
-			testContext.Fatal(stacktrace.Propagate(err, "An error occurred getting the Avalanche node ID for service with ID %v", serviceID))
-		}
-		allNodeIDs[serviceID] = nodeID
-	}
-	return
+	return 4 * time.Minute
 }
@@ -0,0 +1,516 @@
+package crosschain
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	avalancheNetwork "github.com/ava-labs/avalanche-testing/avalanche/networks"
+	avalancheService "github.com/ava-labs/avalanche-testing/avalanche/services"
+	"github.com/ava-labs/avalanche-testing/avalanche_client/apis"
+	testingConstants "github.com/ava-labs/avalanche-testing/avalanche_client/utils/constants"
+	"github.com/ava-labs/avalanche-testing/testsuite/helpers"
+	"github.com/ava-labs/avalanche-testing/testsuite/wallet"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/snow/choices"
+	"github.com/ava-labs/avalanchego/utils/constants"
+	"github.com/ava-labs/avalanchego/utils/crypto"
+	"github.com/ava-labs/avalanchego/vms/platformvm"
+	"github.com/kurtosis-tech/kurtosis/commons/networks"
+	"github.com/kurtosis-tech/kurtosis/commons/testsuite"
+	"github.com/palantir/stacktrace"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	normalNodeConfigID networks.ConfigurationID = "normal-config"
+
+	nodeServiceIDPrefix = "atomic-node-"
+
+	// How long to wait for a transaction built during the test to be decided before accounting for it
+	decisionTimeout      = 2 * time.Minute
+	decisionPollInterval = 2 * time.Second
+
+	// How long to keep retrying to resync a user's wallet after a failed cycle, and to import an export that was
+	// left unimported, while nodes come back up
+	recoveryTimeout      = 2 * time.Minute
+	recoveryPollInterval = 5 * time.Second
+
+	networkAcceptanceTimeoutRatio = 0.1
+)
+
+// StakingNetworkAtomicStressTest has many users move AVAX back and forth between the X and P Chains at the same time,
+// each through its own node, while those nodes are crashed and started back up one after another. It then checks that
+// no accepted export was left unimportable, and that the users' total balance across the chains is what they were
+// funded with minus the fees of the transactions that were accepted.
+// NOTE: The C Chain isn't part of the cycles, as its atomic transactions can only be issued through the keystore and
+// 	so can't be accounted for when they fail mid-transfer
+type StakingNetworkAtomicStressTest struct {
+	ImageName string
+
+	// The number of non-staking nodes that the users issue their transactions through, and that are restarted
+	NumNodes int
+
+	NumUsers int
+
+	// The number of X -> P -> X cycles each user runs
+	NumCycles int
+
+	// How much AVAX each user is funded with on the X Chain
+	UserFunds uint64
+
+	// How much AVAX each cycle exports from the X Chain
+	TransferAmount uint64
+
+	TxFee uint64
+
+	// How many times, and how often, a node is crashed and started back up while the users are running their cycles
+	NumRestarts     int
+	RestartInterval time.Duration
+}
+
+// Run implements the Kurtosis Test interface
+func (test StakingNetworkAtomicStressTest) Run(network networks.Network, context testsuite.TestContext) {
+	castedNetwork := network.(avalancheNetwork.TestAvalancheNetwork)
+	networkAcceptanceTimeout := time.Duration(networkAcceptanceTimeoutRatio * float64(test.GetExecutionTimeout().Nanoseconds()))
+	if err := test.validate(); err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Invalid test configuration."))
+	}
+
+	// Funding and accounting go through a staker, which is never restarted
+	var stakerClient *apis.Client
+	for stakerID := range castedNetwork.GetAllBootServiceIDs() {
+		client, err := castedNetwork.GetAvalancheClient(stakerID)
+		if err != nil {
+			context.Fatal(stacktrace.Propagate(err, "Failed to get Avalanche Client for boot node with serviceID: %s.", stakerID))
+		}
+		stakerClient = client
+		break
+	}
+	nodeServiceIDs := test.getNodeServiceIDs()
+	nodeClients := make([]*apis.Client, len(nodeServiceIDs))
+	for i, serviceID := range nodeServiceIDs {
+		client, err := castedNetwork.GetAvalancheClient(serviceID)
+		if err != nil {
+			context.Fatal(stacktrace.Propagate(err, "Failed to get Avalanche Client for node with serviceID: %s.", serviceID))
+		}
+		nodeClients[i] = client
+	}
+
+	// ================= FUND THE USERS =================
+	genesisWallet, err := wallet.NewWallet(stakerClient, constants.LocalID, test.TxFee, networkAcceptanceTimeout)
+	if err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Failed to create genesis wallet."))
+	}
+	if _, err := genesisWallet.ImportGenesisFunds(); err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Failed to import genesis funds."))
+	}
+	users := make([]*user, test.NumUsers)
+	xChainAddresses := make([]string, test.NumUsers)
+	for i := range users {
+		users[i], err = newUser(nodeClients[i%len(nodeClients)], test.TxFee, networkAcceptanceTimeout)
+		if err != nil {
+			context.Fatal(stacktrace.Propagate(err, "Failed to create user %v.", i))
+		}
+		xChainAddresses[i] = users[i].xChainAddress
+	}
+	if err := genesisWallet.FundXChainAddresses(xChainAddresses, test.UserFunds); err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Failed to fund the users."))
+	}
+	for i, user := range users {
+		if err := user.wallet.RefreshUTXOs(); err != nil {
+			context.Fatal(stacktrace.Propagate(err, "Failed to get the UTXOs of user %v.", i))
+		}
+	}
+	logrus.Infof("Funded %v users with %v nAVAX each.", test.NumUsers, test.UserFunds)
+
+	// ================= RUN THE CYCLES WHILE RESTARTING NODES =================
+	wg := sync.WaitGroup{}
+	for _, user := range users {
+		wg.Add(1)
+		go func(user *user) {
+			defer wg.Done()
+			test.runCycles(user)
+		}(user)
+	}
+	restartErrs := make(chan error, 1)
+	go func() {
+		restartErrs <- test.restartNodes(castedNetwork, nodeServiceIDs)
+	}()
+	wg.Wait()
+	if err := <-restartErrs; err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Failed to restart nodes during the transfers."))
+	}
+	numFailedCycles := 0
+	for _, user := range users {
+		numFailedCycles += user.numFailedCycles
+	}
+	logrus.Infof("Users finished their cycles; %v of %v cycles failed part way.", numFailedCycles, test.NumUsers*test.NumCycles)
+
+	// ================= ACCOUNT FOR EVERY TRANSACTION =================
+	accepted, err := awaitDecisions(stakerClient, users)
+	if err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Failed to wait for the users' transactions to be decided."))
+	}
+	totalFees := uint64(0)
+	for i, user := range users {
+		fees, err := test.settleUser(stakerClient, user, accepted, networkAcceptanceTimeout)
+		if err != nil {
+			context.Fatal(stacktrace.Propagate(err, "Failed to settle the transfers of user %v.", i))
+		}
+		totalFees += fees
+	}
+	totalBalance := uint64(0)
+	for i, user := range users {
+		balance, err := getTotalBalance(stakerClient, user)
+		if err != nil {
+			context.Fatal(stacktrace.Propagate(err, "Failed to get the balances of user %v.", i))
+		}
+		totalBalance += balance
+	}
+	totalFunds := uint64(test.NumUsers) * test.UserFunds
+	context.AssertTrue(
+		totalBalance == totalFunds-totalFees,
+		stacktrace.NewError("Users hold %v nAVAX across the X and P Chains, but were funded with %v nAVAX and paid %v nAVAX in fees, so %v nAVAX was created or lost",
+			totalBalance, totalFunds, totalFees, int64(totalBalance)-int64(totalFunds-totalFees)))
+	logrus.Infof("Users hold %v nAVAX after paying %v nAVAX in fees; funds were conserved.", totalBalance, totalFees)
+}
+
+// GetNetworkLoader implements the Kurtosis Test interface
+func (test StakingNetworkAtomicStressTest) GetNetworkLoader() (networks.NetworkLoader, error) {
+	serviceConfigs := map[networks.ConfigurationID]avalancheNetwork.TestAvalancheNetworkServiceConfig{
+		normalNodeConfigID: *avalancheNetwork.NewTestAvalancheNetworkServiceConfig(
+			true,
+			test.ImageName,
+			avalancheService.NodeConfig{
+				LogLevel:              avalancheService.DEBUG,
+				SnowQuorumSize:        2,
+				SnowSampleSize:        2,
+				NetworkInitialTimeout: 2 * time.Second,
+			},
+		),
+	}
+	desiredServices := make(map[networks.ServiceID]networks.ConfigurationID)
+	for _, serviceID := range test.getNodeServiceIDs() {
+		desiredServices[serviceID] = normalNodeConfigID
+	}
+	return avalancheNetwork.NewTestAvalancheNetworkLoader(
+		true,
+		test.ImageName,
+		avalancheService.DEBUG,
+		2,
+		2,
+		test.TxFee,
+		2*time.Second,
+		avalancheNetwork.DefaultLocalNetGenesisConfig,
+		serviceConfigs,
+		desiredServices,
+	)
+}
+
+// GetExecutionTimeout implements the Kurtosis Test interface
+func (test StakingNetworkAtomicStressTest) GetExecutionTimeout() time.Duration {
+	return 15 * time.Minute
+}
+
+// GetSetupBuffer implements the Kurtosis Test interface
+func (test StakingNetworkAtomicStressTest) GetSetupBuffer() time.Duration {
+	return 4 * time.Minute
+}
+
+// ================= Helper functions ===================
+
+// user is a wallet with a single key that moves AVAX back and forth between that key's X and P Chain addresses,
+// along with a record of every transaction it built, so that they can be accounted for once they're decided even if
+// issuing them or waiting for them failed
+type user struct {
+	wallet        *wallet.Wallet
+	key           *crypto.PrivateKeySECP256K1R
+	xChainAddress string
+	pChainAddress string
+
+	exports []*wallet.Export
+
+	// The IDs of the transactions that tried to import each export, by the bytes of the export's ID
+	imports map[[32]byte][]ids.ID
+
+	numFailedCycles int
+}
+
+func newUser(client *apis.Client, txFee uint64, networkAcceptanceTimeout time.Duration) (*user, error) {
+	userWallet, err := wallet.NewWallet(client, constants.LocalID, txFee, networkAcceptanceTimeout)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to create wallet")
+	}
+	key, err := userWallet.NewKey()
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to create key")
+	}
+	xChainAddress, err := userWallet.XChainAddress(key.PublicKey().Address())
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to format X Chain address")
+	}
+	pChainAddress, err := userWallet.PChainAddress(key.PublicKey().Address())
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to format P Chain address")
+	}
+	return &user{
+		wallet:        userWallet,
+		key:           key,
+		xChainAddress: xChainAddress,
+		pChainAddress: pChainAddress,
+		imports:       make(map[[32]byte][]ids.ID),
+	}, nil
+}
+
+func (test StakingNetworkAtomicStressTest) validate() error {
+	if test.NumNodes < 1 || test.NumUsers < 1 {
+		return stacktrace.NewError("The test needs at least one node and one user, but has %v nodes and %v users", test.NumNodes, test.NumUsers)
+	}
+	// The X Chain export, the P Chain import and export, and the X Chain import each take a fee out of the transfer
+	if test.TransferAmount <= 3*test.TxFee {
+		return stacktrace.NewError("Transfer amount %v can't pay for the fees of a cycle with a fee of %v", test.TransferAmount, test.TxFee)
+	}
+	cycleCost := 4 * test.TxFee
+	if test.UserFunds < test.TransferAmount+test.TxFee+uint64(test.NumCycles)*cycleCost {
+		return stacktrace.NewError("User funds of %v can't pay for %v cycles transferring %v with a fee of %v", test.UserFunds, test.NumCycles, test.TransferAmount, test.TxFee)
+	}
+	return nil
+}
+
+func (test StakingNetworkAtomicStressTest) getNodeServiceIDs() []networks.ServiceID {
+	serviceIDs := make([]networks.ServiceID, test.NumNodes)
+	for i := range serviceIDs {
+		serviceIDs[i] = networks.ServiceID(fmt.Sprintf("%v%v", nodeServiceIDPrefix, i))
+	}
+	return serviceIDs
+}
+
+// runCycles runs the user's cycles, resyncing the user's wallet with its node after every cycle that fails
+func (test StakingNetworkAtomicStressTest) runCycles(user *user) {
+	for cycle := 0; cycle < test.NumCycles; cycle++ {
+		err := test.runCycle(user)
+		if err == nil {
+			continue
+		}
+		user.numFailedCycles++
+		logrus.Warnf("Cycle %v of user %s failed part way: %v", cycle, user.xChainAddress, err)
+		// Transactions that failed to be issued or accepted may have spent the UTXOs the wallet was tracking or not,
+		// so start over from the node's view once it's back up
+		err = helpers.AwaitCondition(recoveryTimeout, recoveryPollInterval, user.wallet.RefreshUTXOs)
+		if err != nil {
+			logrus.Warnf("Failed to resync the wallet of user %s; giving up on its remaining cycles: %v", user.xChainAddress, err)
+			return
+		}
+	}
+}
+
+// runCycle exports AVAX from the user's X Chain address to its P Chain address, imports it, and sends what's left of
+// it after the fees back the same way
+func (test StakingNetworkAtomicStressTest) runCycle(user *user) error {
+	export, err := user.wallet.ExportAvaXChainToPChain(user.pChainAddress, test.TransferAmount)
+	user.recordExport(export)
+	if err != nil {
+		return stacktrace.Propagate(err, "Failed to export AVAX to the P Chain")
+	}
+	importTxID, err := user.wallet.ImportAvaToPChain(user.pChainAddress, export)
+	user.recordImport(export, importTxID)
+	if err != nil {
+		return stacktrace.Propagate(err, "Failed to import AVAX to the P Chain")
+	}
+
+	// The P Chain import's fee came out of the transfer, and the P Chain export's fee must come out of it too
+	export, err = user.wallet.ExportAvaPChainToXChain(user.xChainAddress, test.TransferAmount-2*test.TxFee)
+	user.recordExport(export)
+	if err != nil {
+		return stacktrace.Propagate(err, "Failed to export AVAX to the X Chain")
+	}
+	importTxID, err = user.wallet.ImportAvaToXChain(user.xChainAddress, export)
+	user.recordImport(export, importTxID)
+	if err != nil {
+		return stacktrace.Propagate(err, "Failed to import AVAX to the X Chain")
+	}
+	return nil
+}
+
+func (user *user) recordExport(export *wallet.Export) {
+	if export != nil {
+		user.exports = append(user.exports, export)
+	}
+}
+
+func (user *user) recordImport(export *wallet.Export, importTxID ids.ID) {
+	if !importTxID.Equals(ids.Empty) {
+		exportKey := export.TxID.Key()
+		user.imports[exportKey] = append(user.imports[exportKey], importTxID)
+	}
+}
+
+// restartNodes crashes the nodes the users issue their transactions through one at a time, and starts each back up
+func (test StakingNetworkAtomicStressTest) restartNodes(network avalancheNetwork.TestAvalancheNetwork, serviceIDs []networks.ServiceID) error {
+	for i := 0; i < test.NumRestarts; i++ {
+		time.Sleep(test.RestartInterval)
+		serviceID := serviceIDs[i%len(serviceIDs)]
+		logrus.Infof("Crashing service %v mid-transfer...", serviceID)
+		if err := network.KillService(serviceID); err != nil {
+			return stacktrace.Propagate(err, "An error occurred killing service %v", serviceID)
+		}
+		if err := network.StartService(serviceID); err != nil {
+			return stacktrace.Propagate(err, "An error occurred starting service %v back up after crashing it", serviceID)
+		}
+		logrus.Infof("Service %v is back up.", serviceID)
+	}
+	return nil
+}
+
+// settleUser imports any accepted export that none of the user's imports was accepted for through the given node,
+// and returns the fees of the user's accepted transactions
+// Args:
+// 	accepted: Whether each of the user's transactions was accepted, by the bytes of its ID, as returned by awaitDecisions
+func (test StakingNetworkAtomicStressTest) settleUser(
+	client *apis.Client,
+	user *user,
+	accepted map[[32]byte]bool,
+	networkAcceptanceTimeout time.Duration) (uint64, error) {
+	settlingWallet, err := wallet.NewWallet(client, constants.LocalID, test.TxFee, networkAcceptanceTimeout, user.key)
+	if err != nil {
+		return 0, stacktrace.Propagate(err, "Failed to create wallet")
+	}
+	if err := settlingWallet.RefreshUTXOs(); err != nil {
+		return 0, stacktrace.Propagate(err, "Failed to get the X Chain UTXOs")
+	}
+
+	numAccepted := uint64(0)
+	for _, export := range user.exports {
+		if !accepted[export.TxID.Key()] {
+			continue
+		}
+		numAccepted++
+
+		imported := false
+		for _, importTxID := range user.imports[export.TxID.Key()] {
+			if accepted[importTxID.Key()] {
+				numAccepted++
+				imported = true
+			}
+		}
+		if imported {
+			continue
+		}
+
+		logrus.Infof("Export %s was accepted but never imported; importing it now...", export.TxID)
+		err = helpers.AwaitCondition(recoveryTimeout, recoveryPollInterval, func() error {
+			if export.DestinationChain.Equals(testingConstants.PlatformChainID) {
+				_, err := settlingWallet.ImportAvaToPChain(user.pChainAddress, export)
+				return err
+			}
+			_, err := settlingWallet.ImportAvaToXChain(user.xChainAddress, export)
+			return err
+		})
+		if err != nil {
+			return 0, stacktrace.Propagate(err, "Export %s was accepted, but its %v nAVAX can't be imported", export.TxID, export.Amount)
+		}
+		numAccepted++
+	}
+	return numAccepted * test.TxFee, nil
+}
+
+// pendingTx is an X or P Chain transaction that's waited on to be decided
+type pendingTx struct {
+	chainID ids.ID
+	txID    ids.ID
+
+	// Whether the node didn't know of the transaction the last time it was asked
+	unknown bool
+
+	// Why the transaction wasn't decided the last time its status was asked for
+	err error
+}
+
+// awaitDecisions waits for every transaction the users built to be decided, asking for the status of each undecided
+// one every poll until a single shared deadline, and returns whether each was accepted by the bytes of its ID
+// NOTE: A transaction that's still unknown to the node once the wait is over never made it into the network, and so
+// 	is treated as not accepted
+func awaitDecisions(client *apis.Client, users []*user) (map[[32]byte]bool, error) {
+	undecided := []*pendingTx{}
+	for _, user := range users {
+		for _, export := range user.exports {
+			undecided = append(undecided, &pendingTx{chainID: export.SourceChain, txID: export.TxID})
+			for _, importTxID := range user.imports[export.TxID.Key()] {
+				undecided = append(undecided, &pendingTx{chainID: export.DestinationChain, txID: importTxID})
+			}
+		}
+	}
+
+	accepted := make(map[[32]byte]bool)
+	deadline := time.Now().Add(decisionTimeout)
+	for {
+		stillUndecided := []*pendingTx{}
+		for _, tx := range undecided {
+			if decided, txAccepted := tx.poll(client); decided {
+				accepted[tx.txID.Key()] = txAccepted
+			} else {
+				stillUndecided = append(stillUndecided, tx)
+			}
+		}
+		undecided = stillUndecided
+		if len(undecided) == 0 {
+			return accepted, nil
+		}
+		if time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(decisionPollInterval)
+	}
+	for _, tx := range undecided {
+		if !tx.unknown {
+			return nil, stacktrace.Propagate(tx.err, "Transaction %s wasn't decided in time", tx.txID)
+		}
+	}
+	logrus.Infof("%v transactions were never known to the node, so are treated as not accepted.", len(undecided))
+	return accepted, nil
+}
+
+// poll asks the node for the transaction's status, and returns whether it's been decided and, if so, whether it was
+// accepted
+func (tx *pendingTx) poll(client *apis.Client) (bool, bool) {
+	if tx.chainID.Equals(testingConstants.XChainID) {
+		status, err := client.XChainAPI().GetTxStatus(tx.txID)
+		if err != nil {
+			tx.unknown = false
+			tx.err = stacktrace.Propagate(err, "Failed to get the status of X Chain transaction %s", tx.txID)
+			return false, false
+		}
+		tx.unknown = status == choices.Unknown
+		if !status.Decided() {
+			tx.err = stacktrace.NewError("X Chain transaction %s has status %s", tx.txID, status)
+			return false, false
+		}
+		return true, status == choices.Accepted
+	}
+	status, err := client.PChainAPI().GetTxStatus(tx.txID)
+	if err != nil {
+		tx.unknown = false
+		tx.err = stacktrace.Propagate(err, "Failed to get the status of P Chain transaction %s", tx.txID)
+		return false, false
+	}
+	tx.unknown = status == platformvm.Unknown
+	if status != platformvm.Committed && status != platformvm.Aborted && status != platformvm.Dropped {
+		tx.err = stacktrace.NewError("P Chain transaction %s has status %v", tx.txID, status)
+		return false, false
+	}
+	return true, status == platformvm.Committed
+}
+
+// getTotalBalance returns the AVAX the user holds on its X and P Chain addresses
+func getTotalBalance(client *apis.Client, user *user) (uint64, error) {
+	xChainBalance, err := client.XChainAPI().GetBalance(user.xChainAddress, helpers.AvaxAssetID)
+	if err != nil {
+		return 0, stacktrace.Propagate(err, "Failed to get the X Chain balance of %s", user.xChainAddress)
+	}
+	pChainBalance, err := client.PChainAPI().GetBalance(user.pChainAddress)
+	if err != nil {
+		return 0, stacktrace.Propagate(err, "Failed to get the P Chain balance of %s", user.pChainAddress)
+	}
+	return uint64(xChainBalance.Balance) + uint64(pChainBalance.Balance), nil
+}
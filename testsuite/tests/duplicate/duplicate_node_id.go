@@ -5,7 +5,7 @@ import (
 
 	avalancheNetwork "github.com/ava-labs/avalanche-testing/avalanche/networks"
 	avalancheService "github.com/ava-labs/avalanche-testing/avalanche/services"
-	"github.com/ava-labs/avalanche-testing/avalanche_client/apis"
+	"github.com/ava-labs/avalanche-testing/testsuite/helpers"
 	"github.com/ava-labs/avalanche-testing/testsuite/verifier"
 	"github.com/kurtosis-tech/kurtosis/commons/networks"
 	"github.com/kurtosis-tech/kurtosis/commons/testsuite"
@@ -41,7 +41,10 @@ func (test DuplicateNodeIDTest) Run(network networks.Network, context testsuite.
 	}
 	allServiceIDs[vanillaNodeServiceID] = true
 
-	allNodeIDs, allAvalancheClients := getNodeIDsAndClients(context, castedNetwork, allServiceIDs)
+	allNodeIDs, allAvalancheClients, err := helpers.GetNodeIDsAndClients(castedNetwork, allServiceIDs)
+	if err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Failed to get the node IDs and clients of the network's services"))
+	}
 	if err := test.Verifier.VerifyNetworkFullyConnected(allServiceIDs, bootServiceIDs, allNodeIDs, allAvalancheClients); err != nil {
 		context.Fatal(stacktrace.Propagate(err, "An error occurred verifying the network's state"))
 	}
@@ -57,11 +60,10 @@ func (test DuplicateNodeIDTest) Run(network networks.Network, context testsuite.
 
 	// Add the first dupe node ID (should look normal from a network perspective
 	logrus.Info("Adding first node with soon-to-be-duplicated node ID...")
-	checker1, err := castedNetwork.AddService(sameCertConfigID, badServiceID1)
-	if err != nil {
+	if _, err := castedNetwork.AddService(sameCertConfigID, badServiceID1); err != nil {
 		context.Fatal(stacktrace.Propagate(err, "Failed to create first dupe node ID service with ID %v", badServiceID1))
 	}
-	if err := checker1.WaitForStartup(); err != nil {
+	if err := castedNetwork.WaitForServiceUp(badServiceID1); err != nil {
 		context.Fatal(stacktrace.Propagate(err, "An error occurred waiting for first dupe node ID service with ID %v to start", badServiceID1))
 	}
 	allServiceIDs[badServiceID1] = true
@@ -89,11 +91,10 @@ func (test DuplicateNodeIDTest) Run(network networks.Network, context testsuite.
 
 	// Now, add a second node with the same ID
 	logrus.Infof("Adding second node with service ID %v which will be a duplicated node ID...", badServiceID2)
-	checker2, err := castedNetwork.AddService(sameCertConfigID, badServiceID2)
-	if err != nil {
+	if _, err := castedNetwork.AddService(sameCertConfigID, badServiceID2); err != nil {
 		context.Fatal(stacktrace.Propagate(err, "Failed to create second dupe node ID service with ID %v", badServiceID2))
 	}
-	if err := checker2.WaitForStartup(); err != nil {
+	if err := castedNetwork.WaitForServiceUp(badServiceID2); err != nil {
 		context.Fatal(stacktrace.Propagate(err, "An error occurred waiting for second dupe node ID service to start"))
 	}
 	allServiceIDs[badServiceID2] = true
@@ -166,21 +167,23 @@ func (test DuplicateNodeIDTest) GetNetworkLoader() (networks.NetworkLoader, erro
 	serviceConfigs := map[networks.ConfigurationID]avalancheNetwork.TestAvalancheNetworkServiceConfig{
 		normalNodeConfigID: *avalancheNetwork.NewTestAvalancheNetworkServiceConfig(
 			true,
-			avalancheService.DEBUG,
 			test.ImageName,
-			2,
-			2,
-			2*time.Second,
-			make(map[string]string),
+			avalancheService.NodeConfig{
+				LogLevel:              avalancheService.DEBUG,
+				SnowQuorumSize:        2,
+				SnowSampleSize:        2,
+				NetworkInitialTimeout: 2 * time.Second,
+			},
 		),
 		sameCertConfigID: *avalancheNetwork.NewTestAvalancheNetworkServiceConfig(
 			false,
-			avalancheService.DEBUG,
 			test.ImageName,
-			2,
-			2,
-			2*time.Second,
-			make(map[string]string),
+			avalancheService.NodeConfig{
+				LogLevel:              avalancheService.DEBUG,
+				SnowQuorumSize:        2,
+				SnowSampleSize:        2,
+				NetworkInitialTimeout: 2 * time.Second,
+			},
 		),
 	}
 	desiredServices := map[networks.ServiceID]networks.ConfigurationID{
@@ -194,6 +197,7 @@ func (test DuplicateNodeIDTest) GetNetworkLoader() (networks.NetworkLoader, erro
 		2,
 		0,
 		2*time.Second,
+		avalancheNetwork.DefaultLocalNetGenesisConfig,
 		serviceConfigs,
 		desiredServices,
 	)
@@ -206,32 +210,5 @@ func (test DuplicateNodeIDTest) GetExecutionTimeout() time.Duration {
 
 // GetSetupBuffer implements the Kurtosis Test interface
 func (test DuplicateNodeIDTest) GetSetupBuffer() time.Duration {
-	// TODO drop this when the availabilityChecker doesn't have a sleep (because we spin up a bunch of nodes before execution)
-	return 6 * time.Minute
-}
-
-// ================ Helper functions ==================================
-/*
-This helper function will grab node IDs and Avalanche clients
-*/
-func getNodeIDsAndClients(
-	testContext testsuite.TestContext,
-	network avalancheNetwork.TestAvalancheNetwork,
-	allServiceIDs map[networks.ServiceID]bool,
-) (allNodeIDs map[networks.ServiceID]string, allAvalancheClients map[networks.ServiceID]*apis.Client) {
-	allAvalancheClients = make(map[networks.ServiceID]*apis.Client)
-	allNodeIDs = make(map[networks.ServiceID]string)
-	for serviceID := range allServiceIDs {
-		client, err := network.GetAvalancheClient(serviceID)
-		if err != nil {
-			testContext.Fatal(stacktrace.Propagate(err, "An error occurred getting the Avalanche client for service with ID %v", serviceID))
-		}
-		allAvalancheClients[serviceID] = client
-		nodeID, err := client.InfoAPI().GetNodeID()
-		if err != nil {
-			testContext.Fatal(stacktrace.Propagate(err, "An error occurred getting the Avalanche node ID for service with ID %v", serviceID))
-		}
-		allNodeIDs[serviceID] = nodeID
-	}
-	return
+	return 4 * time.Minute
 }
@@ -0,0 +1,108 @@
+package load
+
+import (
+	"time"
+
+	avalancheNetwork "github.com/ava-labs/avalanche-testing/avalanche/networks"
+	avalancheService "github.com/ava-labs/avalanche-testing/avalanche/services"
+	"github.com/ava-labs/avalanche-testing/avalanche_client/apis"
+	"github.com/ava-labs/avalanche-testing/testsuite/helpers"
+	"github.com/ava-labs/avalanche-testing/testsuite/loadgen"
+	"github.com/ava-labs/avalanchego/api"
+	"github.com/kurtosis-tech/kurtosis/commons/networks"
+	"github.com/kurtosis-tech/kurtosis/commons/testsuite"
+	"github.com/palantir/stacktrace"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	funderUsername = "load_generator_funder"
+	funderPassword = "l0adG3n3rat0r!"
+
+	// How long funding the UTXO chains may take to be accepted
+	fundingAcceptanceTimeout = 30 * time.Second
+)
+
+// StakingNetworkSustainedLoadTest holds a target TPS on the X Chain over many UTXO chains, ramping the load up and down,
+// and checks that the network keeps accepting the transactions within the latency budget
+type StakingNetworkSustainedLoadTest struct {
+	ImageName  string
+	LoadConfig loadgen.Config
+
+	// The minimum fraction of the scheduled transactions that must be accepted
+	MinAcceptedRatio float64
+	// The maximum 99th percentile of the issue-to-acceptance latency
+	MaxP99Latency time.Duration
+}
+
+// Run implements the Kurtosis Test interface
+func (test StakingNetworkSustainedLoadTest) Run(network networks.Network, context testsuite.TestContext) {
+	castedNetwork := network.(avalancheNetwork.TestAvalancheNetwork)
+	bootServiceIDs := castedNetwork.GetAllBootServiceIDs()
+	clients := make([]*apis.Client, 0, len(bootServiceIDs))
+	for serviceID := range bootServiceIDs {
+		client, err := castedNetwork.GetAvalancheClient(serviceID)
+		if err != nil {
+			context.Fatal(stacktrace.Propagate(err, "Failed to get Avalanche Client for boot node with serviceID: %s.", serviceID))
+		}
+		clients = append(clients, client)
+	}
+
+	funder := helpers.NewRPCWorkFlowRunner(
+		clients[0],
+		api.UserPass{Username: funderUsername, Password: funderPassword},
+		fundingAcceptanceTimeout,
+	)
+	if _, err := funder.ImportGenesisFunds(); err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Failed to import genesis funds."))
+	}
+
+	generator, err := loadgen.NewGenerator(funder, clients, test.LoadConfig)
+	if err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Failed to create load generator."))
+	}
+	loadStartTime := time.Now()
+	results := generator.Run()
+	logrus.Infof("Sustained load results: %v", results)
+
+	err = castedNetwork.GetHealthMonitor().AssertStayedHealthy(loadStartTime)
+	context.AssertTrue(err == nil, stacktrace.Propagate(err, "A node went unhealthy under load"))
+
+	context.AssertTrue(results.Rejected == 0, stacktrace.NewError("%v transactions were rejected", results.Rejected))
+	context.AssertTrue(results.TimedOut == 0, stacktrace.NewError("%v transactions weren't decided in time", results.TimedOut))
+	minAccepted := int(test.MinAcceptedRatio * float64(results.Scheduled))
+	context.AssertTrue(
+		results.Accepted >= minAccepted,
+		stacktrace.NewError("Only %v of %v scheduled transactions were accepted, below the minimum of %v", results.Accepted, results.Scheduled, minAccepted))
+	p99Latency := results.LatencyPercentile(99)
+	context.AssertTrue(
+		p99Latency <= test.MaxP99Latency,
+		stacktrace.NewError("p99 acceptance latency of %v exceeds the maximum of %v", p99Latency, test.MaxP99Latency))
+}
+
+// GetNetworkLoader implements the Kurtosis Test interface
+func (test StakingNetworkSustainedLoadTest) GetNetworkLoader() (networks.NetworkLoader, error) {
+	return avalancheNetwork.NewTestAvalancheNetworkLoader(
+		true,
+		test.ImageName,
+		avalancheService.INFO,
+		2,
+		2,
+		test.LoadConfig.TxFee,
+		2*time.Second,
+		avalancheNetwork.DefaultLocalNetGenesisConfig,
+		make(map[networks.ConfigurationID]avalancheNetwork.TestAvalancheNetworkServiceConfig),
+		make(map[networks.ServiceID]networks.ConfigurationID),
+	)
+}
+
+// GetExecutionTimeout implements the Kurtosis Test interface
+func (test StakingNetworkSustainedLoadTest) GetExecutionTimeout() time.Duration {
+	// Funding the UTXO chains, the load itself, and deciding the last transactions
+	return 5*time.Minute + test.LoadConfig.TotalDuration() + test.LoadConfig.AcceptanceTimeout
+}
+
+// GetSetupBuffer implements the Kurtosis Test interface
+func (test StakingNetworkSustainedLoadTest) GetSetupBuffer() time.Duration {
+	return 2 * time.Minute
+}
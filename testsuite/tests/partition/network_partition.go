@@ -0,0 +1,124 @@
+package partition
+
+import (
+	"time"
+
+	avalancheNetwork "github.com/ava-labs/avalanche-testing/avalanche/networks"
+	avalancheService "github.com/ava-labs/avalanche-testing/avalanche/services"
+	"github.com/ava-labs/avalanche-testing/avalanche_client/apis"
+	"github.com/ava-labs/avalanche-testing/testsuite/helpers"
+	"github.com/ava-labs/avalanche-testing/testsuite/verifier"
+	"github.com/kurtosis-tech/kurtosis/commons/networks"
+	"github.com/kurtosis-tech/kurtosis/commons/testsuite"
+	"github.com/palantir/stacktrace"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// How long to wait for nodes to notice that the peers on the other side of a partition (or heal) have gone (or returned)
+	peerChangeTimeout = 3 * time.Minute
+
+	// How often to check the peer lists while waiting for them to change
+	peerPollInterval = 5 * time.Second
+)
+
+// StakingNetworkPartitionTest splits the bootstrapper nodes of the network into two groups, verifies that each node only
+// keeps the peers on its own side of the split, and then heals the split and verifies the network becomes fully connected again
+type StakingNetworkPartitionTest struct {
+	ImageName string
+	Verifier  verifier.NetworkStateVerifier
+}
+
+// Run implements the Kurtosis Test interface
+func (test StakingNetworkPartitionTest) Run(network networks.Network, context testsuite.TestContext) {
+	castedNetwork := network.(avalancheNetwork.TestAvalancheNetwork)
+
+	stakerIDs := castedNetwork.GetAllBootServiceIDs()
+	allNodeIDs := make(map[networks.ServiceID]string)
+	allAvalancheClients := make(map[networks.ServiceID]*apis.Client)
+	for serviceID := range stakerIDs {
+		client, err := castedNetwork.GetAvalancheClient(serviceID)
+		if err != nil {
+			context.Fatal(stacktrace.Propagate(err, "An error occurred getting the Avalanche client for service with ID %v", serviceID))
+		}
+		nodeID, err := client.InfoAPI().GetNodeID()
+		if err != nil {
+			context.Fatal(stacktrace.Propagate(err, "An error occurred getting the Avalanche node ID for service with ID %v", serviceID))
+		}
+		allAvalancheClients[serviceID] = client
+		allNodeIDs[serviceID] = nodeID
+	}
+
+	// Split the bootstrappers into a majority group and a minority group
+	majorityGroup := make(map[networks.ServiceID]bool)
+	minorityGroup := make(map[networks.ServiceID]bool)
+	for serviceID := range stakerIDs {
+		if len(majorityGroup) <= len(stakerIDs)/2 {
+			majorityGroup[serviceID] = true
+		} else {
+			minorityGroup[serviceID] = true
+		}
+	}
+
+	logrus.Infof("Partitioning the network into %v and %v...", majorityGroup, minorityGroup)
+	if err := castedNetwork.PartitionNetwork([]map[networks.ServiceID]bool{majorityGroup, minorityGroup}); err != nil {
+		context.Fatal(stacktrace.Propagate(err, "An error occurred partitioning the network"))
+	}
+	partitionMap := castedNetwork.GetPartitionMap()
+	err := helpers.AwaitCondition(peerChangeTimeout, peerPollInterval, func() error {
+		for serviceID, groupIdx := range partitionMap {
+			acceptableNodeIDs := make(map[string]bool)
+			for comparisonID, comparisonGroupIdx := range partitionMap {
+				if serviceID != comparisonID && groupIdx == comparisonGroupIdx {
+					acceptableNodeIDs[allNodeIDs[comparisonID]] = true
+				}
+			}
+			if err := test.Verifier.VerifyExpectedPeers(serviceID, allAvalancheClients[serviceID], acceptableNodeIDs, len(acceptableNodeIDs), false); err != nil {
+				return stacktrace.Propagate(err, "Service %v doesn't have only the peers in its partition yet", serviceID)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Nodes didn't drop the peers on the other side of the partition"))
+	}
+	logrus.Infof("Every node only has the peers in its own partition.")
+
+	logrus.Infof("Healing the partition...")
+	if err := castedNetwork.HealPartitions(); err != nil {
+		context.Fatal(stacktrace.Propagate(err, "An error occurred healing the partition"))
+	}
+	err = helpers.AwaitCondition(peerChangeTimeout, peerPollInterval, func() error {
+		return test.Verifier.VerifyNetworkFullyConnected(stakerIDs, stakerIDs, allNodeIDs, allAvalancheClients)
+	})
+	if err != nil {
+		context.Fatal(stacktrace.Propagate(err, "The network didn't become fully connected again after healing the partition"))
+	}
+	logrus.Infof("The network is fully connected again.")
+}
+
+// GetNetworkLoader implements the Kurtosis Test interface
+func (test StakingNetworkPartitionTest) GetNetworkLoader() (networks.NetworkLoader, error) {
+	return avalancheNetwork.NewTestAvalancheNetworkLoader(
+		true,
+		test.ImageName,
+		avalancheService.DEBUG,
+		2,
+		2,
+		0,
+		2*time.Second,
+		avalancheNetwork.DefaultLocalNetGenesisConfig,
+		make(map[networks.ConfigurationID]avalancheNetwork.TestAvalancheNetworkServiceConfig),
+		make(map[networks.ServiceID]networks.ConfigurationID),
+	)
+}
+
+// GetExecutionTimeout implements the Kurtosis Test interface
+func (test StakingNetworkPartitionTest) GetExecutionTimeout() time.Duration {
+	return 2*peerChangeTimeout + time.Minute
+}
+
+// GetSetupBuffer implements the Kurtosis Test interface
+func (test StakingNetworkPartitionTest) GetSetupBuffer() time.Duration {
+	return 3 * time.Minute
+}
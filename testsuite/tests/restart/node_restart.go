@@ -0,0 +1,171 @@
+package restart
+
+import (
+	"time"
+
+	avalancheNetwork "github.com/ava-labs/avalanche-testing/avalanche/networks"
+	avalancheService "github.com/ava-labs/avalanche-testing/avalanche/services"
+	"github.com/ava-labs/avalanche-testing/avalanche_client/apis"
+	"github.com/ava-labs/avalanche-testing/testsuite/helpers"
+	"github.com/ava-labs/avalanche-testing/testsuite/verifier"
+	"github.com/ava-labs/avalanchego/api"
+	"github.com/kurtosis-tech/kurtosis/commons/networks"
+	"github.com/kurtosis-tech/kurtosis/commons/testsuite"
+	"github.com/palantir/stacktrace"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	normalNodeConfigID networks.ConfigurationID = "normal-config"
+
+	restartedNodeServiceID networks.ServiceID = "restarted-node"
+
+	username = "restart-test-user"
+	password = "restart-test-password!123"
+
+	// How long to wait for a node that came back up to get its peers back
+	rejoinTimeout      = 2 * time.Minute
+	rejoinPollInterval = 5 * time.Second
+
+	networkAcceptanceTimeoutRatio = 0.3
+)
+
+// NodeRestartTest restarts a normal node gracefully and crashes a bootstrapper, and verifies that each comes back with the
+// same node ID and database, rebootstraps, and rejoins the network
+type NodeRestartTest struct {
+	ImageName string
+	Verifier  verifier.NetworkStateVerifier
+}
+
+// Run implements the Kurtosis Test interface
+func (test NodeRestartTest) Run(network networks.Network, context testsuite.TestContext) {
+	castedNetwork := network.(avalancheNetwork.TestAvalancheNetwork)
+	networkAcceptanceTimeout := time.Duration(networkAcceptanceTimeoutRatio * float64(test.GetExecutionTimeout().Nanoseconds()))
+
+	stakerIDs := castedNetwork.GetAllBootServiceIDs()
+	allServiceIDs := make(map[networks.ServiceID]bool)
+	for stakerID := range stakerIDs {
+		allServiceIDs[stakerID] = true
+	}
+	allServiceIDs[restartedNodeServiceID] = true
+
+	allNodeIDs, allAvalancheClients, err := helpers.GetNodeIDsAndClients(castedNetwork, allServiceIDs)
+	if err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Failed to get the node IDs and clients of the network's services"))
+	}
+	if err := test.Verifier.VerifyNetworkFullyConnected(allServiceIDs, stakerIDs, allNodeIDs, allAvalancheClients); err != nil {
+		context.Fatal(stacktrace.Propagate(err, "An error occurred verifying the network's state"))
+	}
+
+	// Create some state in the restarted node's database, so we can check that it survives the restart
+	restartedNodeClient := allAvalancheClients[restartedNodeServiceID]
+	runner := helpers.NewRPCWorkFlowRunner(
+		restartedNodeClient,
+		api.UserPass{Username: username, Password: password},
+		networkAcceptanceTimeout)
+	if _, err := runner.ImportGenesisFunds(); err != nil {
+		context.Fatal(stacktrace.Propagate(err, "An error occurred importing the genesis funds into the node to restart"))
+	}
+
+	logrus.Infof("Restarting service %v...", restartedNodeServiceID)
+	if err := castedNetwork.RestartService(restartedNodeServiceID); err != nil {
+		context.Fatal(stacktrace.Propagate(err, "An error occurred restarting service %v", restartedNodeServiceID))
+	}
+	test.verifyNodeRejoined(context, castedNetwork, restartedNodeServiceID, allServiceIDs, stakerIDs, allNodeIDs, allAvalancheClients)
+
+	users, err := restartedNodeClient.KeystoreAPI().ListUsers()
+	if err != nil {
+		context.Fatal(stacktrace.Propagate(err, "An error occurred listing the keystore users of the restarted node"))
+	}
+	userFound := false
+	for _, user := range users {
+		userFound = userFound || user == username
+	}
+	if !userFound {
+		context.Fatal(stacktrace.NewError("Keystore user %v didn't survive the restart of service %v; users were: %v", username, restartedNodeServiceID, users))
+	}
+	logrus.Infof("Service %v kept its database across the restart.", restartedNodeServiceID)
+
+	var crashedServiceID networks.ServiceID
+	for stakerID := range stakerIDs {
+		crashedServiceID = stakerID
+		break
+	}
+	logrus.Infof("Crashing service %v...", crashedServiceID)
+	if err := castedNetwork.KillService(crashedServiceID); err != nil {
+		context.Fatal(stacktrace.Propagate(err, "An error occurred killing service %v", crashedServiceID))
+	}
+	if err := castedNetwork.StartService(crashedServiceID); err != nil {
+		context.Fatal(stacktrace.Propagate(err, "An error occurred starting service %v back up after crashing it", crashedServiceID))
+	}
+	test.verifyNodeRejoined(context, castedNetwork, crashedServiceID, allServiceIDs, stakerIDs, allNodeIDs, allAvalancheClients)
+}
+
+// GetNetworkLoader implements the Kurtosis Test interface
+func (test NodeRestartTest) GetNetworkLoader() (networks.NetworkLoader, error) {
+	serviceConfigs := map[networks.ConfigurationID]avalancheNetwork.TestAvalancheNetworkServiceConfig{
+		normalNodeConfigID: *avalancheNetwork.NewTestAvalancheNetworkServiceConfig(
+			true,
+			test.ImageName,
+			avalancheService.NodeConfig{
+				LogLevel:              avalancheService.DEBUG,
+				SnowQuorumSize:        2,
+				SnowSampleSize:        2,
+				NetworkInitialTimeout: 2 * time.Second,
+			},
+		),
+	}
+	desiredServices := map[networks.ServiceID]networks.ConfigurationID{
+		restartedNodeServiceID: normalNodeConfigID,
+	}
+	return avalancheNetwork.NewTestAvalancheNetworkLoader(
+		true,
+		test.ImageName,
+		avalancheService.DEBUG,
+		2,
+		2,
+		0,
+		2*time.Second,
+		avalancheNetwork.DefaultLocalNetGenesisConfig,
+		serviceConfigs,
+		desiredServices,
+	)
+}
+
+// GetExecutionTimeout implements the Kurtosis Test interface
+func (test NodeRestartTest) GetExecutionTimeout() time.Duration {
+	return 8 * time.Minute
+}
+
+// GetSetupBuffer implements the Kurtosis Test interface
+func (test NodeRestartTest) GetSetupBuffer() time.Duration {
+	return 3 * time.Minute
+}
+
+// ================ Helper functions =========================
+// verifyNodeRejoined verifies that a node that came back up has the same node ID as before, and that the network becomes
+// fully connected again
+func (test NodeRestartTest) verifyNodeRejoined(
+	context testsuite.TestContext,
+	network avalancheNetwork.TestAvalancheNetwork,
+	serviceID networks.ServiceID,
+	allServiceIDs map[networks.ServiceID]bool,
+	stakerIDs map[networks.ServiceID]bool,
+	allNodeIDs map[networks.ServiceID]string,
+	allAvalancheClients map[networks.ServiceID]*apis.Client) {
+	nodeID, err := allAvalancheClients[serviceID].InfoAPI().GetNodeID()
+	if err != nil {
+		context.Fatal(stacktrace.Propagate(err, "An error occurred getting the node ID of service %v after it came back up", serviceID))
+	}
+	if nodeID != allNodeIDs[serviceID] {
+		context.Fatal(stacktrace.NewError("Service %v came back up with node ID %v instead of %v", serviceID, nodeID, allNodeIDs[serviceID]))
+	}
+
+	err = helpers.AwaitCondition(rejoinTimeout, rejoinPollInterval, func() error {
+		return test.Verifier.VerifyNetworkFullyConnected(allServiceIDs, stakerIDs, allNodeIDs, allAvalancheClients)
+	})
+	if err != nil {
+		context.Fatal(stacktrace.Propagate(err, "The network didn't become fully connected again after service %v came back up", serviceID))
+	}
+	logrus.Infof("Service %v rejoined the network with the same node ID.", serviceID)
+}
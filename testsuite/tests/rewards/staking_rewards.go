@@ -0,0 +1,389 @@
+package rewards
+
+import (
+	"math"
+	"time"
+
+	"github.com/ava-labs/avalanche-testing/avalanche/ipc"
+	avalancheNetwork "github.com/ava-labs/avalanche-testing/avalanche/networks"
+	avalancheService "github.com/ava-labs/avalanche-testing/avalanche/services"
+	"github.com/ava-labs/avalanche-testing/avalanche_client/apis"
+	"github.com/ava-labs/avalanche-testing/testsuite/helpers"
+	"github.com/ava-labs/avalanche-testing/testsuite/wallet"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/constants"
+	"github.com/kurtosis-tech/kurtosis/commons/networks"
+	"github.com/kurtosis-tech/kurtosis/commons/testsuite"
+	"github.com/palantir/stacktrace"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	normalNodeConfigID networks.ConfigurationID = "normal-config"
+
+	lowFeeValidatorServiceID  networks.ServiceID = "low-fee-validator"
+	fullFeeValidatorServiceID networks.ServiceID = "full-fee-validator"
+
+	// The delegation fee rate of the second validator, which takes all of its delegator's reward
+	fullDelegationFeeRate = 100
+
+	// How long the validators and delegators are added after one another, which the validation periods have to
+	// cover on top of the delegation periods
+	stakerSetupTime = 2 * time.Minute
+
+	// How long after the last validation period ends to wait for the stakers to be removed and rewarded
+	removalTimeout      = 2 * time.Minute
+	removalPollInterval = 5 * time.Second
+
+	networkAcceptanceTimeoutRatio = 0.1
+
+	pChainAlias = "P"
+)
+
+// StakingNetworkRewardsTest adds two validators that charge different delegation fees, along with a delegator to
+// each, for staking periods short enough to finish during the test. Once the periods are over, it checks that the
+// stakers were removed from the current validators, that every staker got its stake back, and that the rewards were
+// split between the validators and delegators by the validators' delegation fees.
+// NOTE: The nodes don't expose how they computed a reward, so the delegation fee is checked by comparing the two
+// 	validators, which stake the same amount for the same period and so earn the same reward of their own
+type StakingNetworkRewardsTest struct {
+	ImageName string
+
+	// The minimum stake duration that every node of the network is started with, which has to be at most the
+	// delegation period
+	MinStakeDuration time.Duration
+
+	StakingPeriod    time.Duration
+	DelegationPeriod time.Duration
+
+	ValidatorStake uint64
+	DelegatorStake uint64
+
+	// The delegation fee rate of the first validator, as a percentage; the second one charges 100%
+	DelegationFeeRate float64
+
+	// How far apart, relative to the first delegator's reward, the rewards may be from what the delegation fees make
+	// them, to allow for the network's supply changing as rewards are paid
+	RewardTolerance float64
+
+	TxFee uint64
+}
+
+// Run implements the Kurtosis Test interface
+func (test StakingNetworkRewardsTest) Run(network networks.Network, context testsuite.TestContext) {
+	castedNetwork := network.(avalancheNetwork.TestAvalancheNetwork)
+	networkAcceptanceTimeout := time.Duration(networkAcceptanceTimeoutRatio * float64(test.GetExecutionTimeout().Nanoseconds()))
+	if err := test.validate(); err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Invalid test configuration."))
+	}
+
+	var stakerClient *apis.Client
+	for stakerID := range castedNetwork.GetAllBootServiceIDs() {
+		client, err := castedNetwork.GetAvalancheClient(stakerID)
+		if err != nil {
+			context.Fatal(stacktrace.Propagate(err, "Failed to get Avalanche Client for boot node with serviceID: %s.", stakerID))
+		}
+		stakerClient = client
+		break
+	}
+	validatorServiceIDs := []networks.ServiceID{lowFeeValidatorServiceID, fullFeeValidatorServiceID}
+	nodeIDs := make([]string, len(validatorServiceIDs))
+	for i, serviceID := range validatorServiceIDs {
+		client, err := castedNetwork.GetAvalancheClient(serviceID)
+		if err != nil {
+			context.Fatal(stacktrace.Propagate(err, "Failed to get Avalanche Client for node with serviceID: %s.", serviceID))
+		}
+		if nodeIDs[i], err = client.InfoAPI().GetNodeID(); err != nil {
+			context.Fatal(stacktrace.Propagate(err, "Failed to get the node ID of %s.", serviceID))
+		}
+	}
+	// Every node has to accept the P Chain's blocks, which add and remove the stakers, in the same order
+	pChainSubscribers := make(map[networks.ServiceID]*ipc.Subscriber, len(validatorServiceIDs))
+	for _, serviceID := range validatorServiceIDs {
+		subscriber, err := castedNetwork.SubscribeToConsensus(serviceID, pChainAlias)
+		if err != nil {
+			context.Fatal(stacktrace.Propagate(err, "Failed to subscribe to the P Chain blocks of %s.", serviceID))
+		}
+		defer subscriber.Close()
+		pChainSubscribers[serviceID] = subscriber
+	}
+
+	// ================= FUND THE STAKERS =================
+	genesisWallet, err := wallet.NewWallet(stakerClient, constants.LocalID, test.TxFee, networkAcceptanceTimeout)
+	if err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Failed to create genesis wallet."))
+	}
+	if _, err := genesisWallet.ImportGenesisFunds(); err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Failed to import genesis funds."))
+	}
+	lowFeeValidator, err := test.newStaker(stakerClient, genesisWallet, test.ValidatorStake, networkAcceptanceTimeout)
+	if err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Failed to fund the low fee validator."))
+	}
+	fullFeeValidator, err := test.newStaker(stakerClient, genesisWallet, test.ValidatorStake, networkAcceptanceTimeout)
+	if err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Failed to fund the full fee validator."))
+	}
+	lowFeeDelegator, err := test.newStaker(stakerClient, genesisWallet, test.DelegatorStake, networkAcceptanceTimeout)
+	if err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Failed to fund the delegator of the low fee validator."))
+	}
+	fullFeeDelegator, err := test.newStaker(stakerClient, genesisWallet, test.DelegatorStake, networkAcceptanceTimeout)
+	if err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Failed to fund the delegator of the full fee validator."))
+	}
+	if err := lowFeeValidator.wallet.SetDelegationFeeRate(test.DelegationFeeRate); err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Failed to set the delegation fee rate of the low fee validator."))
+	}
+	if err := fullFeeValidator.wallet.SetDelegationFeeRate(fullDelegationFeeRate); err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Failed to set the delegation fee rate of the full fee validator."))
+	}
+	logrus.Infof("Funded the validators with %v nAVAX and the delegators with %v nAVAX on the P Chain.", test.ValidatorStake, test.DelegatorStake)
+
+	// ================= ADD THE STAKERS =================
+	if err := lowFeeValidator.wallet.AddValidatorToPrimaryNetwork(nodeIDs[0], lowFeeValidator.pChainAddress, test.ValidatorStake); err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Failed to add the low fee validator."))
+	}
+	if err := fullFeeValidator.wallet.AddValidatorToPrimaryNetwork(nodeIDs[1], fullFeeValidator.pChainAddress, test.ValidatorStake); err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Failed to add the full fee validator."))
+	}
+	if err := lowFeeDelegator.wallet.AddDelegatorToPrimaryNetwork(nodeIDs[0], lowFeeDelegator.pChainAddress, test.DelegatorStake); err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Failed to delegate to the low fee validator."))
+	}
+	if err := fullFeeDelegator.wallet.AddDelegatorToPrimaryNetwork(nodeIDs[1], fullFeeDelegator.pChainAddress, test.DelegatorStake); err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Failed to delegate to the full fee validator."))
+	}
+	logrus.Infof("Added validators %v with delegators; waiting for their staking periods to end.", nodeIDs)
+
+	// ================= WAIT FOR THE STAKERS TO BE REMOVED =================
+	if err := helpers.AwaitCondition(test.StakingPeriod+removalTimeout, removalPollInterval, func() error {
+		return checkRemoved(stakerClient, nodeIDs)
+	}); err != nil {
+		context.Fatal(stacktrace.Propagate(err, "The stakers weren't removed after their staking periods ended."))
+	}
+	logrus.Infof("Validators %v and their delegators were removed from the current validators.", nodeIDs)
+
+	// ================= CHECK THE PAYOUTS =================
+	lowFeeValidatorReward, err := test.getReward(stakerClient, lowFeeValidator)
+	if err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Failed to get the reward of the low fee validator."))
+	}
+	fullFeeValidatorReward, err := test.getReward(stakerClient, fullFeeValidator)
+	if err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Failed to get the reward of the full fee validator."))
+	}
+	lowFeeDelegatorReward, err := test.getReward(stakerClient, lowFeeDelegator)
+	if err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Failed to get the reward of the delegator of the low fee validator."))
+	}
+	fullFeeDelegatorReward, err := test.getReward(stakerClient, fullFeeDelegator)
+	if err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Failed to get the reward of the delegator of the full fee validator."))
+	}
+	logrus.Infof(
+		"Rewards: low fee validator %v, its delegator %v, full fee validator %v, its delegator %v.",
+		lowFeeValidatorReward,
+		lowFeeDelegatorReward,
+		fullFeeValidatorReward,
+		fullFeeDelegatorReward)
+
+	if lowFeeValidatorReward == 0 || fullFeeValidatorReward == 0 {
+		context.Fatal(stacktrace.NewError("The validators weren't rewarded for their staking periods."))
+	}
+	if lowFeeDelegatorReward == 0 {
+		context.Fatal(stacktrace.NewError("The delegator of the low fee validator wasn't rewarded for its delegation period."))
+	}
+	if fullFeeDelegatorReward != 0 {
+		context.Fatal(stacktrace.NewError(
+			"The delegator of the full fee validator was rewarded %v, even though the validator takes its whole reward as the fee.",
+			fullFeeDelegatorReward))
+	}
+	// Both validators earned the same reward of their own, so the full fee validator got more than the low fee one
+	// by the part of its delegator's reward that the low fee validator left to its delegator
+	feeDifference := float64(fullFeeValidatorReward) - float64(lowFeeValidatorReward)
+	if math.Abs(feeDifference-float64(lowFeeDelegatorReward)) > test.RewardTolerance*float64(lowFeeDelegatorReward) {
+		context.Fatal(stacktrace.NewError(
+			"The full fee validator was rewarded %v more than the low fee validator, but the low fee validator's delegator was rewarded %v.",
+			feeDifference,
+			lowFeeDelegatorReward))
+	}
+	logrus.Infof("The rewards were split by the validators' delegation fees.")
+
+	acceptedBlocks := make(map[networks.ServiceID][]ids.ID, len(pChainSubscribers))
+	for serviceID, subscriber := range pChainSubscribers {
+		acceptedBlocks[serviceID] = subscriber.GetAccepted()
+	}
+	if err := ipc.VerifySameOrder(acceptedBlocks); err != nil {
+		context.Fatal(stacktrace.Propagate(err, "The validators accepted the P Chain's blocks in different orders."))
+	}
+}
+
+// GetNetworkLoader implements the Kurtosis Test interface
+func (test StakingNetworkRewardsTest) GetNetworkLoader() (networks.NetworkLoader, error) {
+	serviceConfigs := map[networks.ConfigurationID]avalancheNetwork.TestAvalancheNetworkServiceConfig{
+		normalNodeConfigID: *avalancheNetwork.NewTestAvalancheNetworkServiceConfig(
+			true,
+			test.ImageName,
+			avalancheService.NodeConfig{
+				LogLevel:              avalancheService.DEBUG,
+				SnowQuorumSize:        2,
+				SnowSampleSize:        2,
+				NetworkInitialTimeout: 2 * time.Second,
+				MinStakeDuration:      test.MinStakeDuration,
+				IPCsAPIEnabled:        avalancheService.Bool(true),
+				ShareIPCs:             true,
+			},
+		),
+	}
+	desiredServices := map[networks.ServiceID]networks.ConfigurationID{
+		lowFeeValidatorServiceID:  normalNodeConfigID,
+		fullFeeValidatorServiceID: normalNodeConfigID,
+	}
+	loader, err := avalancheNetwork.NewTestAvalancheNetworkLoader(
+		true,
+		test.ImageName,
+		avalancheService.DEBUG,
+		2,
+		2,
+		test.TxFee,
+		2*time.Second,
+		avalancheNetwork.DefaultLocalNetGenesisConfig,
+		serviceConfigs,
+		desiredServices,
+	)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to create the network loader")
+	}
+	// The boot nodes validate the staking transactions too, so they need to accept the short periods as well
+	if err := loader.UpdateBootNodeConfig(func(config *avalancheService.NodeConfig) {
+		config.MinStakeDuration = test.MinStakeDuration
+	}); err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to set the minimum stake duration of the boot nodes")
+	}
+	return loader, nil
+}
+
+// GetExecutionTimeout implements the Kurtosis Test interface
+func (test StakingNetworkRewardsTest) GetExecutionTimeout() time.Duration {
+	return 10 * time.Minute
+}
+
+// GetSetupBuffer implements the Kurtosis Test interface
+func (test StakingNetworkRewardsTest) GetSetupBuffer() time.Duration {
+	return 4 * time.Minute
+}
+
+// ================= Helper functions ===================
+
+// staker is a wallet with a single key whose P Chain address stakes, and gets the stake and reward back
+type staker struct {
+	wallet        *wallet.Wallet
+	pChainAddress string
+
+	// The P Chain balance of the staker before it staked
+	balanceBeforeStaking uint64
+}
+
+// newStaker funds a new staker with enough AVAX on the P Chain to stake [stakeAmount]
+func (test StakingNetworkRewardsTest) newStaker(
+	client *apis.Client,
+	fundingWallet *wallet.Wallet,
+	stakeAmount uint64,
+	networkAcceptanceTimeout time.Duration) (*staker, error) {
+	stakerWallet, err := wallet.NewWallet(client, constants.LocalID, test.TxFee, networkAcceptanceTimeout)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to create wallet")
+	}
+	stakerWallet.SetStakingPeriods(test.StakingPeriod, test.DelegationPeriod)
+	key, err := stakerWallet.NewKey()
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to create key")
+	}
+	xChainAddress, err := stakerWallet.XChainAddress(key.PublicKey().Address())
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to format X Chain address")
+	}
+	pChainAddress, err := stakerWallet.PChainAddress(key.PublicKey().Address())
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to format P Chain address")
+	}
+
+	// The X Chain export, the P Chain import and the staking transaction each take a fee
+	if err := fundingWallet.FundXChainAddresses([]string{xChainAddress}, stakeAmount+3*test.TxFee); err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to fund %s", xChainAddress)
+	}
+	if err := stakerWallet.RefreshUTXOs(); err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to get the UTXOs of %s", xChainAddress)
+	}
+	if err := stakerWallet.TransferAvaXChainToPChain(pChainAddress, stakeAmount+2*test.TxFee); err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to transfer the stake of %s to the P Chain", pChainAddress)
+	}
+	balance, err := client.PChainAPI().GetBalance(pChainAddress)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to get the P Chain balance of %s", pChainAddress)
+	}
+	return &staker{
+		wallet:               stakerWallet,
+		pChainAddress:        pChainAddress,
+		balanceBeforeStaking: uint64(balance.Balance),
+	}, nil
+}
+
+// getReward returns how much more than it staked a removed staker got back, checking that it got back at least its
+// stake
+func (test StakingNetworkRewardsTest) getReward(client *apis.Client, staker *staker) (uint64, error) {
+	balance, err := client.PChainAPI().GetBalance(staker.pChainAddress)
+	if err != nil {
+		return 0, stacktrace.Propagate(err, "Failed to get the P Chain balance of %s", staker.pChainAddress)
+	}
+	// Only the fee of the staking transaction is gone once the stake is returned
+	balanceWithoutReward := staker.balanceBeforeStaking - test.TxFee
+	if uint64(balance.Balance) < balanceWithoutReward {
+		return 0, stacktrace.NewError(
+			"Staker %s has a balance of %v, which is less than the %v it should have with its stake returned",
+			staker.pChainAddress,
+			balance.Balance,
+			balanceWithoutReward)
+	}
+	return uint64(balance.Balance) - balanceWithoutReward, nil
+}
+
+// checkRemoved returns an error if any of the given nodes is still a current validator, or still has a delegator
+func checkRemoved(client *apis.Client, nodeIDs []string) error {
+	validators, delegators, err := client.PChainAPI().GetCurrentValidators(ids.Empty)
+	if err != nil {
+		return stacktrace.Propagate(err, "Failed to get the current validators")
+	}
+	for _, staker := range append(validators, delegators...) {
+		stakerMap, ok := staker.(map[string]interface{})
+		if !ok {
+			return stacktrace.NewError("Unexpected staker format: %v", staker)
+		}
+		for _, nodeID := range nodeIDs {
+			if stakerMap["nodeID"] == nodeID {
+				return stacktrace.NewError("Node %s is still staked by %v", nodeID, staker)
+			}
+		}
+	}
+	return nil
+}
+
+func (test StakingNetworkRewardsTest) validate() error {
+	if test.MinStakeDuration <= 0 || test.DelegationPeriod < test.MinStakeDuration {
+		return stacktrace.NewError(
+			"The delegation period %v must be at least the minimum stake duration %v, which must be positive",
+			test.DelegationPeriod,
+			test.MinStakeDuration)
+	}
+	if test.StakingPeriod < test.DelegationPeriod+stakerSetupTime {
+		return stacktrace.NewError(
+			"The staking period %v must cover the delegation period %v and the %v it takes to add the stakers",
+			test.StakingPeriod,
+			test.DelegationPeriod,
+			stakerSetupTime)
+	}
+	if test.DelegationFeeRate < 0 || test.DelegationFeeRate >= fullDelegationFeeRate {
+		return stacktrace.NewError("The delegation fee rate %v must leave the first delegator part of its reward", test.DelegationFeeRate)
+	}
+	return nil
+}
@@ -29,8 +29,6 @@ const (
 	stakeAmount                                     = uint64(30000000000000)
 
 	networkAcceptanceTimeoutRatio = 0.3
-	byzantineBehavior             = "byzantine-behavior"
-	chitSpammerBehavior           = "chit-spammer"
 )
 
 // StakingNetworkUnrequestedChitSpammerTest tests that a node is able to continue to work normally
@@ -69,11 +67,10 @@ func (test StakingNetworkUnrequestedChitSpammerTest) Run(network networks.Networ
 
 	// =================== ADD NORMAL NODE AS A VALIDATOR ON THE NETWORK =======================
 	logrus.Infof("Adding normal node as a staker...")
-	availabilityChecker, err := castedNetwork.AddService(normalNodeConfigID, normalNodeServiceID)
-	if err != nil {
+	if _, err := castedNetwork.AddService(normalNodeConfigID, normalNodeServiceID); err != nil {
 		context.Fatal(stacktrace.Propagate(err, "Failed to add normal node with high quorum and sample to network."))
 	}
-	if err = availabilityChecker.WaitForStartup(); err != nil {
+	if err := castedNetwork.WaitForServiceUp(normalNodeServiceID); err != nil {
 		context.Fatal(stacktrace.Propagate(err, "Failed to wait for startup of normal node."))
 	}
 	normalClient, err := castedNetwork.GetAvalancheClient(normalNodeServiceID)
@@ -118,23 +115,24 @@ func (test StakingNetworkUnrequestedChitSpammerTest) GetNetworkLoader() (network
 	serviceConfigs := map[networks.ConfigurationID]avalancheNetwork.TestAvalancheNetworkServiceConfig{
 		byzantineConfigID: *avalancheNetwork.NewTestAvalancheNetworkServiceConfig(
 			true,
-			avalancheService.DEBUG,
 			test.ByzantineImageName,
-			2,
-			2,
-			2*time.Second,
-			map[string]string{
-				byzantineBehavior: chitSpammerBehavior,
+			avalancheService.NodeConfig{
+				LogLevel:              avalancheService.DEBUG,
+				SnowQuorumSize:        2,
+				SnowSampleSize:        2,
+				NetworkInitialTimeout: 2 * time.Second,
+				ByzantineBehavior:     avalancheService.ChitSpammerBehavior,
 			},
 		),
 		normalNodeConfigID: *avalancheNetwork.NewTestAvalancheNetworkServiceConfig(
 			true,
-			avalancheService.DEBUG,
 			test.NormalImageName,
-			6,
-			8,
-			2*time.Second,
-			make(map[string]string),
+			avalancheService.NodeConfig{
+				LogLevel:              avalancheService.DEBUG,
+				SnowQuorumSize:        6,
+				SnowSampleSize:        8,
+				NetworkInitialTimeout: 2 * time.Second,
+			},
 		),
 	}
 
@@ -154,6 +152,7 @@ func (test StakingNetworkUnrequestedChitSpammerTest) GetNetworkLoader() (network
 		2,
 		0,
 		2*time.Second,
+		avalancheNetwork.DefaultLocalNetGenesisConfig,
 		serviceConfigs,
 		serviceIDConfigMap,
 	)
@@ -161,12 +160,11 @@ func (test StakingNetworkUnrequestedChitSpammerTest) GetNetworkLoader() (network
 
 // GetExecutionTimeout implements the Kurtosis Test interface
 func (test StakingNetworkUnrequestedChitSpammerTest) GetExecutionTimeout() time.Duration {
-	// TODO drop this when the availabilityChecker doesn't have a sleep, because we spin up a *bunch* of byzantine
-	// nodes during test execution
-	return 10 * time.Minute
+	// We spin up a *bunch* of byzantine nodes during test execution
+	return 7 * time.Minute
 }
 
 // GetSetupBuffer implements the Kurtosis Test interface
 func (test StakingNetworkUnrequestedChitSpammerTest) GetSetupBuffer() time.Duration {
-	return 4 * time.Minute
+	return 3 * time.Minute
 }
@@ -0,0 +1,202 @@
+package subnet
+
+import (
+	"strconv"
+	"time"
+
+	avalancheNetwork "github.com/ava-labs/avalanche-testing/avalanche/networks"
+	avalancheService "github.com/ava-labs/avalanche-testing/avalanche/services"
+	"github.com/ava-labs/avalanche-testing/avalanche_client/apis"
+	"github.com/ava-labs/avalanche-testing/testsuite/helpers"
+	"github.com/ava-labs/avalanchego/api"
+	"github.com/ava-labs/avalanchego/utils/units"
+	"github.com/ava-labs/avalanchego/vms/platformvm"
+	"github.com/kurtosis-tech/kurtosis/commons/networks"
+	"github.com/kurtosis-tech/kurtosis/commons/testsuite"
+	"github.com/palantir/stacktrace"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	subnetValidatorConfigID networks.ConfigurationID = "subnet-validator-config"
+	subnetValidatorPrefix                            = "subnet-validator-"
+
+	subnetOwnerUsername     = "subnet_owner"
+	subnetOwnerPassword     = "Subn3tOwn3r!"
+	subnetValidatorUsername = "subnet_validator"
+	subnetValidatorPassword = "Subn3tVal1dator!"
+
+	// The network's tx fee, which the subnet owner pays for the import of its funds to the P Chain, the CreateSubnet
+	//  and CreateBlockchain txs, and one AddSubnetValidator tx per subnet validator
+	subnetTxFee          = units.MilliAvax
+	numOwnerTxsPerSubnet = 3
+
+	validatorSeedAmount   = 5 * units.KiloAvax
+	validatorStakeAmount  = 3 * units.KiloAvax
+	subnetValidatorWeight = 10
+
+	blockchainName = "e2eSubnetChain"
+
+	networkAcceptanceTimeoutRatio = 0.2
+)
+
+// StakingNetworkSubnetLifecycleTest creates a subnet, makes several new nodes its validators, launches a blockchain on
+// it, and checks that the blockchain comes up on the subnet validators and that every node agrees on the membership
+type StakingNetworkSubnetLifecycleTest struct {
+	ImageName string
+
+	// The number of nodes to add as subnet validators
+	NumSubnetValidators int
+
+	// The ID or alias of the VM the subnet's blockchain runs, and the genesis data it's created with
+	VMID        string
+	GenesisData []byte
+}
+
+// Run implements the Kurtosis Test interface
+func (test StakingNetworkSubnetLifecycleTest) Run(network networks.Network, context testsuite.TestContext) {
+	castedNetwork := network.(avalancheNetwork.TestAvalancheNetwork)
+	networkAcceptanceTimeout := time.Duration(networkAcceptanceTimeoutRatio * float64(test.GetExecutionTimeout().Nanoseconds()))
+
+	allClients := make(map[networks.ServiceID]*apis.Client)
+	var ownerClient *apis.Client
+	for serviceID := range castedNetwork.GetAllBootServiceIDs() {
+		client, err := castedNetwork.GetAvalancheClient(serviceID)
+		if err != nil {
+			context.Fatal(stacktrace.Propagate(err, "Failed to get Avalanche Client for boot node with serviceID: %s.", serviceID))
+		}
+		allClients[serviceID] = client
+		ownerClient = client
+	}
+
+	// ====================================== CREATE SUBNET ===============================
+	owner := helpers.NewRPCWorkFlowRunner(
+		ownerClient,
+		api.UserPass{Username: subnetOwnerUsername, Password: subnetOwnerPassword},
+		networkAcceptanceTimeout)
+	if _, err := owner.ImportGenesisFunds(); err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Failed to import genesis funds for the subnet owner."))
+	}
+	ownerPChainAddress, err := ownerClient.PChainAPI().CreateAddress(owner.User())
+	if err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Failed to create the subnet owner's P Chain address."))
+	}
+	ownerSeedAmount := uint64(numOwnerTxsPerSubnet+test.NumSubnetValidators) * subnetTxFee
+	if err := owner.TransferAvaXChainToPChain(ownerPChainAddress, ownerSeedAmount); err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Failed to fund the subnet owner's P Chain address."))
+	}
+	subnetID, err := owner.CreateSubnet([]string{ownerPChainAddress}, 1)
+	if err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Failed to create subnet."))
+	}
+	logrus.Infof("Created subnet %s.", subnetID)
+
+	// ============================ ADD SUBNET VALIDATOR NODES ============================
+	// The subnet ID is only known now, so the validators are started with it whitelisted now
+	whitelistSubnet := func(nodeConfig *avalancheService.NodeConfig) {
+		nodeConfig.WhitelistedSubnets = append(nodeConfig.WhitelistedSubnets, subnetID.String())
+	}
+	if err := castedNetwork.UpdateNodeConfig(subnetValidatorConfigID, whitelistSubnet); err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Failed to whitelist the subnet for the subnet validator configuration."))
+	}
+	validatorServiceIDs := make([]networks.ServiceID, 0, test.NumSubnetValidators)
+	validatorNodeIDs := make([]string, 0, test.NumSubnetValidators)
+	for i := 0; i < test.NumSubnetValidators; i++ {
+		serviceID := networks.ServiceID(subnetValidatorPrefix + strconv.Itoa(i))
+		if _, err := castedNetwork.AddService(subnetValidatorConfigID, serviceID); err != nil {
+			context.Fatal(stacktrace.Propagate(err, "Failed to add %s to the network.", serviceID))
+		}
+		if err := castedNetwork.WaitForServiceUp(serviceID); err != nil {
+			context.Fatal(stacktrace.Propagate(err, "Failed to wait for startup of %s.", serviceID))
+		}
+		client, err := castedNetwork.GetAvalancheClient(serviceID)
+		if err != nil {
+			context.Fatal(stacktrace.Propagate(err, "Failed to get Avalanche Client for %s.", serviceID))
+		}
+		nodeID, err := client.InfoAPI().GetNodeID()
+		if err != nil {
+			context.Fatal(stacktrace.Propagate(err, "Failed to get the node ID of %s.", serviceID))
+		}
+
+		// Subnet validators must validate the primary network too
+		validator := helpers.NewRPCWorkFlowRunner(
+			client,
+			api.UserPass{Username: subnetValidatorUsername, Password: subnetValidatorPassword},
+			networkAcceptanceTimeout)
+		if _, err := validator.ImportGenesisFundsAndStartValidating(validatorSeedAmount, validatorStakeAmount); err != nil {
+			context.Fatal(stacktrace.Propagate(err, "Failed to add %s as a primary network validator.", serviceID))
+		}
+		logrus.Infof("%s (%s) is validating the primary network.", serviceID, nodeID)
+
+		allClients[serviceID] = client
+		validatorServiceIDs = append(validatorServiceIDs, serviceID)
+		validatorNodeIDs = append(validatorNodeIDs, nodeID)
+	}
+	if err := owner.AddSubnetValidators(subnetID, validatorNodeIDs, subnetValidatorWeight); err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Failed to add the subnet validators."))
+	}
+	logrus.Infof("Added %v validators to subnet %s.", len(validatorNodeIDs), subnetID)
+
+	// ================================= CREATE BLOCKCHAIN ================================
+	blockchainID, err := owner.CreateBlockchain(subnetID, test.VMID, []string{}, blockchainName, test.GenesisData)
+	if err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Failed to create blockchain."))
+	}
+	logrus.Infof("Created blockchain %s on subnet %s; waiting for the subnet validators to validate it...", blockchainID, subnetID)
+	for _, serviceID := range validatorServiceIDs {
+		validator := helpers.NewRPCWorkFlowRunner(
+			allClients[serviceID],
+			api.UserPass{Username: subnetValidatorUsername, Password: subnetValidatorPassword},
+			networkAcceptanceTimeout)
+		if err := validator.AwaitBlockchainStatus(blockchainID, platformvm.Validating); err != nil {
+			context.Fatal(stacktrace.Propagate(err, "Blockchain never reached Validating status on %s.", serviceID))
+		}
+	}
+
+	// ================================= VERIFY MEMBERSHIP ================================
+	for serviceID, client := range allClients {
+		if err := helpers.VerifySubnetMembership(client, subnetID, blockchainID, validatorNodeIDs); err != nil {
+			context.Fatal(stacktrace.Propagate(err, "%s has an unexpected view of subnet %s.", serviceID, subnetID))
+		}
+	}
+	logrus.Infof("All nodes agree on the membership of subnet %s.", subnetID)
+}
+
+// GetNetworkLoader implements the Kurtosis Test interface
+func (test StakingNetworkSubnetLifecycleTest) GetNetworkLoader() (networks.NetworkLoader, error) {
+	serviceConfigs := map[networks.ConfigurationID]avalancheNetwork.TestAvalancheNetworkServiceConfig{
+		subnetValidatorConfigID: *avalancheNetwork.NewTestAvalancheNetworkServiceConfig(
+			true,
+			test.ImageName,
+			avalancheService.NodeConfig{
+				LogLevel:              avalancheService.DEBUG,
+				SnowQuorumSize:        2,
+				SnowSampleSize:        2,
+				NetworkInitialTimeout: 2 * time.Second,
+			},
+		),
+	}
+	return avalancheNetwork.NewTestAvalancheNetworkLoader(
+		true,
+		test.ImageName,
+		avalancheService.DEBUG,
+		2,
+		2,
+		subnetTxFee,
+		2*time.Second,
+		avalancheNetwork.DefaultLocalNetGenesisConfig,
+		serviceConfigs,
+		make(map[networks.ServiceID]networks.ConfigurationID),
+	)
+}
+
+// GetExecutionTimeout implements the Kurtosis Test interface
+func (test StakingNetworkSubnetLifecycleTest) GetExecutionTimeout() time.Duration {
+	// Each subnet validator has to start up and then wait out the staking delay of the primary network
+	return 5*time.Minute + time.Duration(test.NumSubnetValidators)*time.Minute
+}
+
+// GetSetupBuffer implements the Kurtosis Test interface
+func (test StakingNetworkSubnetLifecycleTest) GetSetupBuffer() time.Duration {
+	return 3 * time.Minute
+}
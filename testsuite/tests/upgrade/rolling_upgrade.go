@@ -0,0 +1,294 @@
+package upgrade
+
+import (
+	"sort"
+	"time"
+
+	avalancheNetwork "github.com/ava-labs/avalanche-testing/avalanche/networks"
+	avalancheService "github.com/ava-labs/avalanche-testing/avalanche/services"
+	"github.com/ava-labs/avalanche-testing/avalanche_client/apis"
+	"github.com/ava-labs/avalanche-testing/testsuite/helpers"
+	"github.com/ava-labs/avalanche-testing/testsuite/loadgen"
+	"github.com/ava-labs/avalanche-testing/testsuite/verifier"
+	"github.com/ava-labs/avalanchego/api"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/kurtosis-tech/kurtosis/commons/networks"
+	"github.com/kurtosis-tech/kurtosis/commons/testsuite"
+	"github.com/palantir/stacktrace"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	normalNodeConfigID networks.ConfigurationID = "normal-config"
+
+	normalNodeServiceID networks.ServiceID = "normal-node"
+
+	funderUsername  = "upgrade_test_funder"
+	funderPassword  = "Upgr4deFund3r!"
+	witnessUsername = "upgrade_test_witness"
+	witnessPassword = "Upgr4deW1tness!"
+
+	// The amount sent to the witness address, whose balance must survive the upgrade
+	witnessAmount = uint64(1000000000)
+
+	// How long to wait for an upgraded node to get its peers back
+	rejoinTimeout      = 2 * time.Minute
+	rejoinPollInterval = 5 * time.Second
+
+	networkAcceptanceTimeoutRatio = 0.3
+)
+
+// StakingNetworkRollingUpgradeTest starts a network on one Avalanche image and upgrades its nodes to another one at a
+// time while load is put on the X Chain, checking that every node comes back with its node ID and database on a new
+// version, and that balances, the validator set and the network's connectivity are the same after the upgrade as
+// before it
+type StakingNetworkRollingUpgradeTest struct {
+	OldImageName string
+	NewImageName string
+
+	// The load put on the network while it's upgraded
+	LoadConfig loadgen.Config
+
+	// The minimum fraction of the scheduled transactions that must be accepted, which is below 1 because transactions
+	//  sent to a node while it's being upgraded fail to be issued
+	MinAcceptedRatio float64
+
+	Verifier verifier.NetworkStateVerifier
+}
+
+// Run implements the Kurtosis Test interface
+func (test StakingNetworkRollingUpgradeTest) Run(network networks.Network, context testsuite.TestContext) {
+	castedNetwork := network.(avalancheNetwork.TestAvalancheNetwork)
+	networkAcceptanceTimeout := time.Duration(networkAcceptanceTimeoutRatio * float64(test.GetExecutionTimeout().Nanoseconds()))
+
+	stakerIDs := castedNetwork.GetAllBootServiceIDs()
+	allServiceIDs := make(map[networks.ServiceID]bool)
+	for stakerID := range stakerIDs {
+		allServiceIDs[stakerID] = true
+	}
+	allServiceIDs[normalNodeServiceID] = true
+
+	allNodeIDs, allAvalancheClients, err := helpers.GetNodeIDsAndClients(castedNetwork, allServiceIDs)
+	if err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Failed to get the node IDs and clients of the network's services"))
+	}
+	if err := test.Verifier.VerifyNetworkFullyConnected(allServiceIDs, stakerIDs, allNodeIDs, allAvalancheClients); err != nil {
+		context.Fatal(stacktrace.Propagate(err, "An error occurred verifying the network's state before the upgrade"))
+	}
+	if err := test.Verifier.VerifyNodeVersions(allAvalancheClients, ""); err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Nodes run different versions before the upgrade."))
+	}
+	oldVersion, err := allAvalancheClients[normalNodeServiceID].InfoAPI().GetNodeVersion()
+	if err != nil {
+		context.Fatal(stacktrace.Propagate(err, "An error occurred getting the node version of service %v before the upgrade", normalNodeServiceID))
+	}
+
+	// Upgrade the normal node first, then the stakers in a fixed order
+	upgradeOrder := []networks.ServiceID{normalNodeServiceID}
+	sortedStakerIDs := []networks.ServiceID{}
+	for stakerID := range stakerIDs {
+		sortedStakerIDs = append(sortedStakerIDs, stakerID)
+	}
+	sort.Slice(sortedStakerIDs, func(i, j int) bool { return sortedStakerIDs[i] < sortedStakerIDs[j] })
+	upgradeOrder = append(upgradeOrder, sortedStakerIDs...)
+
+	// ================= CREATE THE STATE THAT MUST SURVIVE THE UPGRADE =================
+	funder := helpers.NewRPCWorkFlowRunner(
+		allAvalancheClients[sortedStakerIDs[0]],
+		api.UserPass{Username: funderUsername, Password: funderPassword},
+		networkAcceptanceTimeout)
+	if _, err := funder.ImportGenesisFunds(); err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Failed to import genesis funds."))
+	}
+	witness := helpers.NewRPCWorkFlowRunner(
+		allAvalancheClients[normalNodeServiceID],
+		api.UserPass{Username: witnessUsername, Password: witnessPassword},
+		networkAcceptanceTimeout)
+	witnessAddress, _, err := witness.CreateDefaultAddresses()
+	if err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Failed to create the witness addresses."))
+	}
+	txID, err := funder.SendAVAX(witnessAddress, witnessAmount)
+	if err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Failed to fund the witness address."))
+	}
+	if err := funder.AwaitXChainTxs(txID); err != nil {
+		context.Fatal(stacktrace.Propagate(err, "The funding of the witness address wasn't accepted."))
+	}
+	validatorNodeIDs, err := helpers.GetValidatorNodeIDs(allAvalancheClients[sortedStakerIDs[0]], ids.Empty)
+	if err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Failed to get the validators before the upgrade."))
+	}
+	if err := verifyNetworkState(allAvalancheClients, witnessAddress, validatorNodeIDs); err != nil {
+		context.Fatal(stacktrace.Propagate(err, "The nodes disagree about the network's state before the upgrade."))
+	}
+
+	// ================= UPGRADE THE NODES ONE AT A TIME UNDER LOAD =================
+	clients := make([]*apis.Client, 0, len(allAvalancheClients))
+	for _, serviceID := range upgradeOrder {
+		clients = append(clients, allAvalancheClients[serviceID])
+	}
+	generator, err := loadgen.NewGenerator(funder, clients, test.LoadConfig)
+	if err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Failed to create load generator."))
+	}
+	resultsChan := make(chan loadgen.Results, 1)
+	go func() {
+		resultsChan <- generator.Run()
+	}()
+
+	// Kurtosis doesn't know about the containers of upgraded services, so they're removed even if the test fails
+	upgradedServiceIDs := []networks.ServiceID{}
+	defer func() {
+		for _, serviceID := range upgradedServiceIDs {
+			if err := castedNetwork.RemoveService(serviceID); err != nil {
+				logrus.Warnf("Failed to remove upgraded service %v: %v", serviceID, err)
+			}
+		}
+	}()
+	for _, serviceID := range upgradeOrder {
+		logrus.Infof("Upgrading service %v to image %v...", serviceID, test.NewImageName)
+		if err := castedNetwork.UpgradeService(serviceID, test.NewImageName); err != nil {
+			context.Fatal(stacktrace.Propagate(err, "An error occurred upgrading service %v", serviceID))
+		}
+		upgradedServiceIDs = append(upgradedServiceIDs, serviceID)
+		test.verifyNodeRejoined(context, serviceID, oldVersion, allServiceIDs, stakerIDs, allNodeIDs, allAvalancheClients)
+	}
+	logrus.Infof("Upgraded all %v nodes; waiting for the load generator to finish...", len(upgradeOrder))
+
+	results := <-resultsChan
+	logrus.Infof("Load results during the upgrade: %v", results)
+	context.AssertTrue(results.Rejected == 0, stacktrace.NewError("%v transactions were rejected during the upgrade", results.Rejected))
+	minAccepted := int(test.MinAcceptedRatio * float64(results.Scheduled))
+	context.AssertTrue(
+		results.Accepted >= minAccepted,
+		stacktrace.NewError("Only %v of %v scheduled transactions were accepted during the upgrade, below the minimum of %v", results.Accepted, results.Scheduled, minAccepted))
+
+	// ================= CHECK THE STATE SURVIVED THE UPGRADE =================
+	if err := verifyNetworkState(allAvalancheClients, witnessAddress, validatorNodeIDs); err != nil {
+		context.Fatal(stacktrace.Propagate(err, "The network's state changed during the upgrade."))
+	}
+	if err := test.Verifier.VerifyNodeVersions(allAvalancheClients, ""); err != nil {
+		context.Fatal(stacktrace.Propagate(err, "Nodes run different versions after the upgrade."))
+	}
+	users, err := allAvalancheClients[normalNodeServiceID].KeystoreAPI().ListUsers()
+	if err != nil {
+		context.Fatal(stacktrace.Propagate(err, "An error occurred listing the keystore users of service %v", normalNodeServiceID))
+	}
+	userFound := false
+	for _, user := range users {
+		userFound = userFound || user == witnessUsername
+	}
+	if !userFound {
+		context.Fatal(stacktrace.NewError("Keystore user %v didn't survive the upgrade of service %v; users were: %v", witnessUsername, normalNodeServiceID, users))
+	}
+	logrus.Infof("All nodes were upgraded from %v to %v with their node IDs, data and the network's state intact.", oldVersion, test.NewImageName)
+}
+
+// GetNetworkLoader implements the Kurtosis Test interface
+func (test StakingNetworkRollingUpgradeTest) GetNetworkLoader() (networks.NetworkLoader, error) {
+	serviceConfigs := map[networks.ConfigurationID]avalancheNetwork.TestAvalancheNetworkServiceConfig{
+		normalNodeConfigID: *avalancheNetwork.NewTestAvalancheNetworkServiceConfig(
+			true,
+			test.OldImageName,
+			avalancheService.NodeConfig{
+				LogLevel:              avalancheService.DEBUG,
+				SnowQuorumSize:        2,
+				SnowSampleSize:        2,
+				NetworkInitialTimeout: 2 * time.Second,
+			},
+		),
+	}
+	desiredServices := map[networks.ServiceID]networks.ConfigurationID{
+		normalNodeServiceID: normalNodeConfigID,
+	}
+	return avalancheNetwork.NewTestAvalancheNetworkLoader(
+		true,
+		test.OldImageName,
+		avalancheService.DEBUG,
+		2,
+		2,
+		test.LoadConfig.TxFee,
+		2*time.Second,
+		avalancheNetwork.DefaultLocalNetGenesisConfig,
+		serviceConfigs,
+		desiredServices,
+	)
+}
+
+// GetExecutionTimeout implements the Kurtosis Test interface
+func (test StakingNetworkRollingUpgradeTest) GetExecutionTimeout() time.Duration {
+	// Funding, the upgrades (which may outlast the load), and deciding the last transactions
+	return 10*time.Minute + test.LoadConfig.TotalDuration() + test.LoadConfig.AcceptanceTimeout
+}
+
+// GetSetupBuffer implements the Kurtosis Test interface
+func (test StakingNetworkRollingUpgradeTest) GetSetupBuffer() time.Duration {
+	return 3 * time.Minute
+}
+
+// ================ Helper functions =========================
+// verifyNodeRejoined verifies that an upgraded node has the same node ID as before but no longer runs [oldVersion], and
+// that the network becomes fully connected again
+func (test StakingNetworkRollingUpgradeTest) verifyNodeRejoined(
+	context testsuite.TestContext,
+	serviceID networks.ServiceID,
+	oldVersion string,
+	allServiceIDs map[networks.ServiceID]bool,
+	stakerIDs map[networks.ServiceID]bool,
+	allNodeIDs map[networks.ServiceID]string,
+	allAvalancheClients map[networks.ServiceID]*apis.Client) {
+	nodeID, err := allAvalancheClients[serviceID].InfoAPI().GetNodeID()
+	if err != nil {
+		context.Fatal(stacktrace.Propagate(err, "An error occurred getting the node ID of service %v after its upgrade", serviceID))
+	}
+	if nodeID != allNodeIDs[serviceID] {
+		context.Fatal(stacktrace.NewError("Service %v came back from its upgrade with node ID %v instead of %v", serviceID, nodeID, allNodeIDs[serviceID]))
+	}
+	version, err := allAvalancheClients[serviceID].InfoAPI().GetNodeVersion()
+	if err != nil {
+		context.Fatal(stacktrace.Propagate(err, "An error occurred getting the node version of service %v after its upgrade", serviceID))
+	}
+	if version == oldVersion {
+		context.Fatal(stacktrace.NewError("Service %v still runs version %v after its upgrade to image %v", serviceID, version, test.NewImageName))
+	}
+
+	err = helpers.AwaitCondition(rejoinTimeout, rejoinPollInterval, func() error {
+		return test.Verifier.VerifyNetworkFullyConnected(allServiceIDs, stakerIDs, allNodeIDs, allAvalancheClients)
+	})
+	if err != nil {
+		context.Fatal(stacktrace.Propagate(err, "The network didn't become fully connected again after service %v was upgraded", serviceID))
+	}
+	logrus.Infof("Service %v rejoined the network with the same node ID after its upgrade.", serviceID)
+}
+
+// verifyNetworkState verifies that every node reports the given balance for the witness address and the given
+// validator set
+func verifyNetworkState(
+	allAvalancheClients map[networks.ServiceID]*apis.Client,
+	witnessAddress string,
+	expectedValidatorNodeIDs map[string]bool) error {
+	for serviceID, client := range allAvalancheClients {
+		balance, err := client.XChainAPI().GetBalance(witnessAddress, helpers.AvaxAssetID)
+		if err != nil {
+			return stacktrace.Propagate(err, "Failed to get the witness balance from service %v", serviceID)
+		}
+		if uint64(balance.Balance) != witnessAmount {
+			return stacktrace.NewError("Service %v reports a witness balance of %v instead of %v", serviceID, balance.Balance, witnessAmount)
+		}
+
+		validatorNodeIDs, err := helpers.GetValidatorNodeIDs(client, ids.Empty)
+		if err != nil {
+			return stacktrace.Propagate(err, "Failed to get the validators from service %v", serviceID)
+		}
+		if len(validatorNodeIDs) != len(expectedValidatorNodeIDs) {
+			return stacktrace.NewError("Service %v reports validators %v instead of %v", serviceID, validatorNodeIDs, expectedValidatorNodeIDs)
+		}
+		for nodeID := range expectedValidatorNodeIDs {
+			if !validatorNodeIDs[nodeID] {
+				return stacktrace.NewError("Service %v reports validators %v instead of %v", serviceID, validatorNodeIDs, expectedValidatorNodeIDs)
+			}
+		}
+	}
+	return nil
+}
@@ -27,14 +27,16 @@ const (
 
 type executor struct {
 	stakerClient, delegatorClient *apis.Client
+	numGenesisStakers             int
 	acceptanceTimeout             time.Duration
 }
 
 // NewRPCWorkflowTestExecutor ...
-func NewRPCWorkflowTestExecutor(stakerClient, delegatorClient *apis.Client, acceptanceTimeout time.Duration) tester.AvalancheTester {
+func NewRPCWorkflowTestExecutor(stakerClient, delegatorClient *apis.Client, numGenesisStakers int, acceptanceTimeout time.Duration) tester.AvalancheTester {
 	return &executor{
 		stakerClient:      stakerClient,
 		delegatorClient:   delegatorClient,
+		numGenesisStakers: numGenesisStakers,
 		acceptanceTimeout: acceptanceTimeout,
 	}
 }
@@ -118,7 +120,8 @@ func (e *executor) ExecuteTest() error {
 	}
 	actualNumStakers := len(currentStakers)
 	logrus.Debugf("Number of current stakers: %d", actualNumStakers)
-	expectedNumStakers := 6
+	// The genesis stakers plus the staker that was just added
+	expectedNumStakers := e.numGenesisStakers + 1
 	if actualNumStakers != expectedNumStakers {
 		return stacktrace.NewError("Actual number of stakers, %v, != expected number of stakers, %v", actualNumStakers, expectedNumStakers)
 	}
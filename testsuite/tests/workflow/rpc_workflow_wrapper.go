@@ -5,6 +5,7 @@ import (
 
 	avalancheNetwork "github.com/ava-labs/avalanche-testing/avalanche/networks"
 	avalancheService "github.com/ava-labs/avalanche-testing/avalanche/services"
+	"github.com/ava-labs/avalanche-testing/avalanche/services/certs"
 	"github.com/kurtosis-tech/kurtosis/commons/networks"
 	"github.com/kurtosis-tech/kurtosis/commons/testsuite"
 	"github.com/palantir/stacktrace"
@@ -22,6 +23,13 @@ const (
 // StakingNetworkRPCWorkflowTest ...
 type StakingNetworkRPCWorkflowTest struct {
 	ImageName string
+
+	// If non-zero, the network boots from a generated genesis with this many stakers (on a custom network ID) instead
+	//  of avalanchego's hardcoded local genesis
+	NumGeneratedStakers int
+
+	// The generator that the certs of the generated stakers are derived from, or nil to generate random certs
+	CertGenerator *certs.DeterministicCertGenerator
 }
 
 // Run implements the Kurtosis Test interface
@@ -39,7 +47,11 @@ func (test StakingNetworkRPCWorkflowTest) Run(network networks.Network, context
 		context.Fatal(stacktrace.Propagate(err, "Could not get delegator client"))
 	}
 
-	executor := NewRPCWorkflowTestExecutor(stakerClient, delegatorClient, networkAcceptanceTimeout)
+	numGenesisStakers := len(avalancheNetwork.DefaultLocalNetGenesisConfig.Stakers)
+	if test.NumGeneratedStakers > 0 {
+		numGenesisStakers = test.NumGeneratedStakers
+	}
+	executor := NewRPCWorkflowTestExecutor(stakerClient, delegatorClient, numGenesisStakers, networkAcceptanceTimeout)
 
 	logrus.Infof("Set up RPCWorkFlowTest. Executing...")
 	if err := executor.ExecuteTest(); err != nil {
@@ -53,12 +65,13 @@ func (test StakingNetworkRPCWorkflowTest) GetNetworkLoader() (networks.NetworkLo
 	serviceConfigs := map[networks.ConfigurationID]avalancheNetwork.TestAvalancheNetworkServiceConfig{
 		normalNodeConfigID: *avalancheNetwork.NewTestAvalancheNetworkServiceConfig(
 			true,
-			avalancheService.DEBUG,
 			test.ImageName,
-			2,
-			2,
-			2*time.Second,
-			make(map[string]string),
+			avalancheService.NodeConfig{
+				LogLevel:              avalancheService.DEBUG,
+				SnowQuorumSize:        2,
+				SnowSampleSize:        2,
+				NetworkInitialTimeout: 2 * time.Second,
+			},
 		),
 	}
 	// Define which services use which configurations.
@@ -66,6 +79,14 @@ func (test StakingNetworkRPCWorkflowTest) GetNetworkLoader() (networks.NetworkLo
 		regularNodeServiceID:   normalNodeConfigID,
 		delegatorNodeServiceID: normalNodeConfigID,
 	}
+	genesisConfig := &avalancheNetwork.DefaultLocalNetGenesisConfig
+	if test.NumGeneratedStakers > 0 {
+		generatedGenesisConfig, err := avalancheNetwork.NewGeneratedNetworkGenesisConfig(test.NumGeneratedStakers, nil, test.CertGenerator)
+		if err != nil {
+			return nil, stacktrace.Propagate(err, "Could not generate a genesis config with %v stakers", test.NumGeneratedStakers)
+		}
+		genesisConfig = generatedGenesisConfig
+	}
 	// Return an Avalanche Test Network with this service:configuration mapping.
 	return avalancheNetwork.NewTestAvalancheNetworkLoader(
 		true,
@@ -75,6 +96,7 @@ func (test StakingNetworkRPCWorkflowTest) GetNetworkLoader() (networks.NetworkLo
 		2,
 		0,
 		2*time.Second,
+		*genesisConfig,
 		serviceConfigs,
 		desiredServices,
 	)
@@ -87,6 +109,5 @@ func (test StakingNetworkRPCWorkflowTest) GetExecutionTimeout() time.Duration {
 
 // GetSetupBuffer implements the Kurtosis Test interface
 func (test StakingNetworkRPCWorkflowTest) GetSetupBuffer() time.Duration {
-	// TODO drop this down when the availability checker doesn't have a sleep (becuase we spin up a bunch of nodes before the test starts executing)
-	return 6 * time.Minute
+	return 4 * time.Minute
 }
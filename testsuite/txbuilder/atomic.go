@@ -0,0 +1,96 @@
+package txbuilder
+
+import (
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/vms/avm"
+	"github.com/ava-labs/avalanchego/vms/components/avax"
+	"github.com/ava-labs/avalanchego/vms/platformvm"
+	"github.com/ava-labs/avalanchego/vms/secp256k1fx"
+	"github.com/palantir/stacktrace"
+)
+
+// ExportedUTXOs returns the UTXOs that an X Chain export transaction puts into the destination chain's shared memory
+// once it's accepted, so that they can be imported without asking a node for them
+func ExportedUTXOs(tx *avm.Tx) ([]*avax.UTXO, error) {
+	exportTx, ok := tx.UnsignedTx.(*avm.ExportTx)
+	if !ok {
+		return nil, stacktrace.NewError("Transaction %s is not an export transaction", tx.ID())
+	}
+	return exportedUTXOs(tx.ID(), len(exportTx.Outs), exportTx.ExportedOuts), nil
+}
+
+// PChainExportedUTXOs returns the UTXOs that a P Chain export transaction puts into the destination chain's shared
+// memory once it's committed, so that they can be imported without asking a node for them
+func PChainExportedUTXOs(tx *platformvm.Tx) ([]*avax.UTXO, error) {
+	exportTx, ok := tx.UnsignedTx.(*platformvm.UnsignedExportTx)
+	if !ok {
+		return nil, stacktrace.NewError("Transaction %s is not an export transaction", tx.ID())
+	}
+	return exportedUTXOs(tx.ID(), len(exportTx.Outs), exportTx.ExportedOutputs), nil
+}
+
+// ================= Helper functions ===================
+
+// exportedUTXOs returns the UTXOs for an export transaction's exported outputs, which are indexed after the
+// transaction's regular outputs
+func exportedUTXOs(txID ids.ID, numOuts int, exportedOuts []*avax.TransferableOutput) []*avax.UTXO {
+	utxos := make([]*avax.UTXO, len(exportedOuts))
+	for i, out := range exportedOuts {
+		utxos[i] = &avax.UTXO{
+			UTXOID: avax.UTXOID{
+				TxID:        txID,
+				OutputIndex: uint32(numOuts + i),
+			},
+			Asset: avax.Asset{ID: out.AssetID()},
+			Out:   out.Out,
+		}
+	}
+	return utxos
+}
+
+// importSpend selects all of the given atomic UTXOs that the builder can spend now to import, and - if the imported
+// AVAX can't pay the fee - UTXOs to pay the fee from. It returns both selections, along with unsorted outputs that
+// send the imported funds to the given owners and the change of the fee back to the change address.
+func (builder *Builder) importSpend(
+	utxos []*avax.UTXO,
+	atomicUTXOs []*avax.UTXO,
+	to secp256k1fx.OutputOwners,
+	changeAddress ids.ShortID,
+	fee uint64) (*spendResult, *spendResult, []*avax.TransferableOutput, error) {
+	// Import everything that the builder's keys can spend now
+	importedAmounts := make(map[[32]byte]uint64)
+	now := builder.clock()
+	for _, utxo := range atomicUTXOs {
+		out, ok := utxo.Out.(*secp256k1fx.TransferOutput)
+		if !ok {
+			continue
+		}
+		if _, _, canSpend := builder.match(out.OutputOwners, now); canSpend {
+			importedAmounts[utxo.AssetID().Key()] += out.Amt
+		}
+	}
+	imported, err := builder.spend(atomicUTXOs, importedAmounts)
+	if err != nil {
+		return nil, nil, nil, stacktrace.Propagate(err, "Failed to select the UTXOs to import")
+	}
+	if len(imported.ins) == 0 {
+		return nil, nil, nil, stacktrace.NewError("None of the %v UTXOs given can be imported by the builder's keys", len(atomicUTXOs))
+	}
+
+	avaxAssetKey := builder.avaxAssetID.Key()
+	spent := &spendResult{change: make(map[[32]byte]uint64)}
+	if importedAmounts[avaxAssetKey] >= fee {
+		importedAmounts[avaxAssetKey] -= fee
+	} else {
+		if spent, err = builder.spend(utxos, map[[32]byte]uint64{avaxAssetKey: fee}); err != nil {
+			return nil, nil, nil, stacktrace.Propagate(err, "Failed to select the UTXOs to pay the fee from")
+		}
+	}
+	outs := builder.changeOutputs(spent.change, changeAddress)
+	for assetKey, amount := range importedAmounts {
+		if amount > 0 {
+			outs = append(outs, transferableOutput(ids.NewID(assetKey), amount, to))
+		}
+	}
+	return spent, imported, outs, nil
+}
@@ -0,0 +1,250 @@
+package txbuilder
+
+import (
+	"time"
+
+	testingConstants "github.com/ava-labs/avalanche-testing/avalanche_client/utils/constants"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/codec"
+	"github.com/ava-labs/avalanchego/utils/crypto"
+	"github.com/ava-labs/avalanchego/utils/hashing"
+	"github.com/ava-labs/avalanchego/vms/avm"
+	"github.com/ava-labs/avalanchego/vms/components/avax"
+	"github.com/ava-labs/avalanchego/vms/components/verify"
+	"github.com/ava-labs/avalanchego/vms/nftfx"
+	"github.com/ava-labs/avalanchego/vms/secp256k1fx"
+	"github.com/palantir/stacktrace"
+)
+
+// Builder builds and signs X and P Chain transactions locally, spending UTXOs owned by its keys, so that tests can
+// issue raw transactions without going through a node's keystore
+// NOTE: Builder doesn't track which UTXOs it has spent; callers pass in the UTXOs each transaction may spend, and are
+// responsible for not spending a UTXO twice (unless that's what they're testing)
+type Builder struct {
+	networkID   uint32
+	xChainID    ids.ID
+	pChainID    ids.ID
+	avaxAssetID ids.ID
+
+	xChainCodec codec.Codec
+
+	// Keys by the bytes of their addresses
+	keys map[[20]byte]*crypto.PrivateKeySECP256K1R
+
+	// Returns the current Unix time, which the locktimes of UTXOs are compared against
+	clock func() uint64
+}
+
+// NewBuilder creates a Builder for the local test network's X and P Chains
+// Args:
+// 	networkID: The ID of the network the transactions are for
+// 	keys: The keys whose UTXOs the builder can spend
+func NewBuilder(networkID uint32, keys ...*crypto.PrivateKeySECP256K1R) (*Builder, error) {
+	xChainCodec, err := NewXChainCodec()
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to initialize the X Chain codec")
+	}
+	builder := &Builder{
+		networkID:   networkID,
+		xChainID:    testingConstants.XChainID,
+		pChainID:    testingConstants.PlatformChainID,
+		avaxAssetID: testingConstants.AvaxAssetID,
+		xChainCodec: xChainCodec,
+		keys:        make(map[[20]byte]*crypto.PrivateKeySECP256K1R),
+		clock:       func() uint64 { return uint64(time.Now().Unix()) },
+	}
+	for _, key := range keys {
+		builder.AddKey(key)
+	}
+	return builder, nil
+}
+
+// AddKey lets the builder spend UTXOs owned by the given key
+func (builder *Builder) AddKey(key *crypto.PrivateKeySECP256K1R) {
+	builder.keys[key.PublicKey().Address().Key()] = key
+}
+
+// XChainCodec returns the codec the builder serializes X Chain transactions with
+func (builder *Builder) XChainCodec() codec.Codec {
+	return builder.xChainCodec
+}
+
+// Owners returns the owners of an output that can be spent after the given locktime, with signatures from the given
+// number of the given addresses
+// Args:
+// 	threshold: How many of the addresses must sign to spend the output
+// 	locktime: The Unix time before which the output can't be spent, or 0 if it can be spent right away
+// 	addresses: The addresses that own the output
+func Owners(threshold uint32, locktime uint64, addresses ...ids.ShortID) secp256k1fx.OutputOwners {
+	sortedAddresses := make([]ids.ShortID, len(addresses))
+	copy(sortedAddresses, addresses)
+	ids.SortShortIDs(sortedAddresses)
+	return secp256k1fx.OutputOwners{
+		Locktime:  locktime,
+		Threshold: threshold,
+		Addrs:     sortedAddresses,
+	}
+}
+
+// ================= Helper functions ===================
+
+// spendResult holds the inputs that coin selection picked, along with what's left over
+type spendResult struct {
+	ins     []*avax.TransferableInput
+	signers [][]*crypto.PrivateKeySECP256K1R
+
+	// How much more of each asset was consumed than was asked for, by the bytes of the asset ID
+	change map[[32]byte]uint64
+}
+
+// spend selects, in order, enough of the given UTXOs that the builder can spend right now to cover the given amount of
+// each asset, and returns the inputs that consume them sorted along with their signers
+// Args:
+// 	utxos: The UTXOs to select from
+// 	amounts: The amount of each asset to consume, by the bytes of the asset ID
+func (builder *Builder) spend(utxos []*avax.UTXO, amounts map[[32]byte]uint64) (*spendResult, error) {
+	remaining := make(map[[32]byte]uint64)
+	for assetKey, amount := range amounts {
+		if amount > 0 {
+			remaining[assetKey] = amount
+		}
+	}
+	result := &spendResult{change: make(map[[32]byte]uint64)}
+	now := builder.clock()
+	for _, utxo := range utxos {
+		assetID := utxo.AssetID()
+		assetKey := assetID.Key()
+		needed := remaining[assetKey]
+		if needed == 0 {
+			continue
+		}
+		out, ok := utxo.Out.(*secp256k1fx.TransferOutput)
+		if !ok {
+			continue
+		}
+		sigIndices, signers, ok := builder.match(out.OutputOwners, now)
+		if !ok {
+			continue
+		}
+		result.ins = append(result.ins, &avax.TransferableInput{
+			UTXOID: utxo.UTXOID,
+			Asset:  avax.Asset{ID: assetID},
+			In: &secp256k1fx.TransferInput{
+				Amt:   out.Amt,
+				Input: secp256k1fx.Input{SigIndices: sigIndices},
+			},
+		})
+		result.signers = append(result.signers, signers)
+		if out.Amt > needed {
+			result.change[assetKey] += out.Amt - needed
+			delete(remaining, assetKey)
+		} else {
+			remaining[assetKey] = needed - out.Amt
+			if remaining[assetKey] == 0 {
+				delete(remaining, assetKey)
+			}
+		}
+	}
+	for assetKey, needed := range remaining {
+		return nil, stacktrace.NewError("Insufficient spendable funds: %v more of asset %s is needed", needed, ids.NewID(assetKey))
+	}
+	avax.SortTransferableInputsWithSigners(result.ins, result.signers)
+	return result, nil
+}
+
+// changeOutputs returns outputs that send the change of a spend back to the given address
+func (builder *Builder) changeOutputs(change map[[32]byte]uint64, changeAddress ids.ShortID) []*avax.TransferableOutput {
+	outs := []*avax.TransferableOutput{}
+	for assetKey, amount := range change {
+		outs = append(outs, transferableOutput(ids.NewID(assetKey), amount, Owners(1, 0, changeAddress)))
+	}
+	return outs
+}
+
+// match returns the signature indices and keys that the builder can spend an output owned by the given owners with,
+// and whether it can spend it at the given time at all
+func (builder *Builder) match(owners secp256k1fx.OutputOwners, now uint64) ([]uint32, []*crypto.PrivateKeySECP256K1R, bool) {
+	if owners.Locktime > now {
+		return nil, nil, false
+	}
+	sigIndices := []uint32{}
+	signers := []*crypto.PrivateKeySECP256K1R{}
+	for i, address := range owners.Addrs {
+		if uint32(len(sigIndices)) == owners.Threshold {
+			break
+		}
+		if key, found := builder.keys[address.Key()]; found {
+			sigIndices = append(sigIndices, uint32(i))
+			signers = append(signers, key)
+		}
+	}
+	return sigIndices, signers, uint32(len(sigIndices)) == owners.Threshold
+}
+
+// findUTXO returns the first of the given UTXOs of the given asset whose output passes the given filter and that the
+// builder can spend now, along with the signature indices and keys to spend it with
+func (builder *Builder) findUTXO(
+	utxos []*avax.UTXO,
+	assetID ids.ID,
+	filter func(out verify.State) (secp256k1fx.OutputOwners, bool),
+) (*avax.UTXO, []uint32, []*crypto.PrivateKeySECP256K1R, error) {
+	now := builder.clock()
+	for _, utxo := range utxos {
+		if !utxo.AssetID().Equals(assetID) {
+			continue
+		}
+		owners, ok := filter(utxo.Out)
+		if !ok {
+			continue
+		}
+		if sigIndices, signers, ok := builder.match(owners, now); ok {
+			return utxo, sigIndices, signers, nil
+		}
+	}
+	return nil, nil, nil, stacktrace.NewError("None of the %v UTXOs given is a matching output of asset %s that can be spent now", len(utxos), assetID)
+}
+
+// signXChainTx signs the transaction with one credential per signer group, in the order of the transaction's inputs
+// and then its operations
+// Args:
+// 	tx: The transaction to sign
+// 	signers: The keys that sign each input and operation
+// 	nftCredentials: The indices of the signer groups that sign NFT Fx operations, which take NFT Fx credentials
+func (builder *Builder) signXChainTx(tx *avm.Tx, signers [][]*crypto.PrivateKeySECP256K1R, nftCredentials map[int]bool) error {
+	unsignedBytes, err := builder.xChainCodec.Marshal(&tx.UnsignedTx)
+	if err != nil {
+		return stacktrace.Propagate(err, "Failed to marshal the unsigned transaction")
+	}
+	hash := hashing.ComputeHash256(unsignedBytes)
+	for i, keys := range signers {
+		credential := secp256k1fx.Credential{Sigs: make([][crypto.SECP256K1RSigLen]byte, len(keys))}
+		for j, key := range keys {
+			sig, err := key.SignHash(hash)
+			if err != nil {
+				return stacktrace.Propagate(err, "Failed to sign the transaction")
+			}
+			copy(credential.Sigs[j][:], sig)
+		}
+		if nftCredentials[i] {
+			tx.Creds = append(tx.Creds, &nftfx.Credential{Credential: credential})
+		} else {
+			tx.Creds = append(tx.Creds, &credential)
+		}
+	}
+	signedBytes, err := builder.xChainCodec.Marshal(tx)
+	if err != nil {
+		return stacktrace.Propagate(err, "Failed to marshal the signed transaction")
+	}
+	tx.Initialize(unsignedBytes, signedBytes)
+	return nil
+}
+
+func transferableOutput(assetID ids.ID, amount uint64, owners secp256k1fx.OutputOwners) *avax.TransferableOutput {
+	return &avax.TransferableOutput{
+		Asset: avax.Asset{ID: assetID},
+		Out: &secp256k1fx.TransferOutput{
+			Amt:          amount,
+			OutputOwners: owners,
+		},
+	}
+}
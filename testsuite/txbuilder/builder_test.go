@@ -0,0 +1,411 @@
+package txbuilder
+
+import (
+	"testing"
+	"time"
+
+	testingConstants "github.com/ava-labs/avalanche-testing/avalanche_client/utils/constants"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/codec"
+	"github.com/ava-labs/avalanchego/utils/constants"
+	"github.com/ava-labs/avalanchego/utils/crypto"
+	"github.com/ava-labs/avalanchego/utils/hashing"
+	"github.com/ava-labs/avalanchego/vms/avm"
+	"github.com/ava-labs/avalanchego/vms/components/avax"
+	"github.com/ava-labs/avalanchego/vms/components/verify"
+	"github.com/ava-labs/avalanchego/vms/nftfx"
+	"github.com/ava-labs/avalanchego/vms/platformvm"
+	"github.com/ava-labs/avalanchego/vms/secp256k1fx"
+	"github.com/stretchr/testify/assert"
+)
+
+const testTime = uint64(1000)
+
+func newTestKeys(t *testing.T, numKeys int) []*crypto.PrivateKeySECP256K1R {
+	factory := crypto.FactorySECP256K1R{}
+	keys := make([]*crypto.PrivateKeySECP256K1R, numKeys)
+	for i := range keys {
+		key, err := factory.NewPrivateKey()
+		assert.NoError(t, err)
+		keys[i] = key.(*crypto.PrivateKeySECP256K1R)
+	}
+	return keys
+}
+
+func newTestBuilder(t *testing.T, keys ...*crypto.PrivateKeySECP256K1R) *Builder {
+	builder, err := NewBuilder(constants.LocalID, keys...)
+	assert.NoError(t, err)
+	builder.clock = func() uint64 { return testTime }
+	return builder
+}
+
+func newTestUTXO(index byte, amount uint64, owners secp256k1fx.OutputOwners) *avax.UTXO {
+	return newTestAssetUTXO(index, testingConstants.AvaxAssetID, &secp256k1fx.TransferOutput{
+		Amt:          amount,
+		OutputOwners: owners,
+	})
+}
+
+func newTestAssetUTXO(index byte, assetID ids.ID, out verify.State) *avax.UTXO {
+	return &avax.UTXO{
+		UTXOID: avax.UTXOID{TxID: ids.NewID([32]byte{index})},
+		Asset:  avax.Asset{ID: assetID},
+		Out:    out,
+	}
+}
+
+// assertXChainRoundTrip asserts that the transaction survives being serialized and parsed with the X Chain codec, and
+// that the parsed transaction's credentials are signed by the given signers
+func assertXChainRoundTrip(t *testing.T, builder *Builder, tx *avm.Tx, signers [][]*crypto.PrivateKeySECP256K1R) *avm.Tx {
+	parsed := &avm.Tx{}
+	assert.NoError(t, builder.xChainCodec.Unmarshal(tx.Bytes(), parsed))
+	parsedBytes, err := builder.xChainCodec.Marshal(parsed)
+	assert.NoError(t, err)
+	assert.Equal(t, tx.Bytes(), parsedBytes)
+	assertSignedBy(t, builder.xChainCodec, &parsed.UnsignedTx, parsed.Creds, signers)
+	return parsed
+}
+
+// assertPChainRoundTrip asserts that the transaction survives being serialized and parsed with the P Chain codec, and
+// that the parsed transaction's credentials are signed by the given signers
+func assertPChainRoundTrip(t *testing.T, tx *platformvm.Tx, signers [][]*crypto.PrivateKeySECP256K1R) *platformvm.Tx {
+	txBytes, err := platformvm.Codec.Marshal(tx)
+	assert.NoError(t, err)
+	parsed := &platformvm.Tx{}
+	assert.NoError(t, platformvm.Codec.Unmarshal(txBytes, parsed))
+	parsedBytes, err := platformvm.Codec.Marshal(parsed)
+	assert.NoError(t, err)
+	assert.Equal(t, txBytes, parsedBytes)
+	assertSignedBy(t, platformvm.Codec, &parsed.UnsignedTx, parsed.Creds, signers)
+	return parsed
+}
+
+// assertSignedBy asserts that each credential holds one signature of the unsigned transaction per key of its signers,
+// in order
+func assertSignedBy(t *testing.T, c codec.Codec, unsignedTx interface{}, creds []verify.Verifiable, signers [][]*crypto.PrivateKeySECP256K1R) {
+	unsignedBytes, err := c.Marshal(unsignedTx)
+	assert.NoError(t, err)
+	hash := hashing.ComputeHash256(unsignedBytes)
+	factory := crypto.FactorySECP256K1R{}
+	if !assert.Len(t, creds, len(signers)) {
+		return
+	}
+	for i, cred := range creds {
+		var sigs [][crypto.SECP256K1RSigLen]byte
+		switch cred := cred.(type) {
+		case *secp256k1fx.Credential:
+			sigs = cred.Sigs
+		case *nftfx.Credential:
+			sigs = cred.Sigs
+		default:
+			t.Fatalf("Credential %v has unexpected type %T", i, cred)
+		}
+		if !assert.Len(t, sigs, len(signers[i])) {
+			continue
+		}
+		for j, sig := range sigs {
+			publicKey, err := factory.RecoverHashPublicKey(hash, sig[:])
+			assert.NoError(t, err)
+			assert.Equal(t, signers[i][j].PublicKey().Address(), publicKey.Address())
+		}
+	}
+}
+
+func TestSpendSelectsMultipleInputs(t *testing.T) {
+	keys := newTestKeys(t, 1)
+	builder := newTestBuilder(t, keys...)
+	owners := Owners(1, 0, keys[0].PublicKey().Address())
+	utxos := []*avax.UTXO{newTestUTXO(1, 5, owners), newTestUTXO(2, 5, owners), newTestUTXO(3, 5, owners)}
+
+	spent, err := builder.spend(utxos, map[[32]byte]uint64{testingConstants.AvaxAssetID.Key(): 12})
+	assert.NoError(t, err)
+	assert.Len(t, spent.ins, 3)
+	assert.Len(t, spent.signers, 3)
+	assert.Equal(t, uint64(3), spent.change[testingConstants.AvaxAssetID.Key()])
+
+	_, err = builder.spend(utxos, map[[32]byte]uint64{testingConstants.AvaxAssetID.Key(): 16})
+	assert.Error(t, err)
+}
+
+func TestSpendSkipsLockedAndUnownedUTXOs(t *testing.T) {
+	keys := newTestKeys(t, 2)
+	builder := newTestBuilder(t, keys[0])
+	utxos := []*avax.UTXO{
+		newTestUTXO(1, 5, Owners(1, testTime+1, keys[0].PublicKey().Address())),
+		newTestUTXO(2, 5, Owners(1, 0, keys[1].PublicKey().Address())),
+		newTestUTXO(3, 5, Owners(1, testTime, keys[0].PublicKey().Address())),
+	}
+
+	spent, err := builder.spend(utxos, map[[32]byte]uint64{testingConstants.AvaxAssetID.Key(): 5})
+	assert.NoError(t, err)
+	assert.Len(t, spent.ins, 1)
+	assert.True(t, spent.ins[0].UTXOID.TxID.Equals(utxos[2].UTXOID.TxID))
+
+	_, err = builder.spend(utxos, map[[32]byte]uint64{testingConstants.AvaxAssetID.Key(): 6})
+	assert.Error(t, err)
+}
+
+func TestMatchMultisig(t *testing.T) {
+	keys := newTestKeys(t, 3)
+	owners := Owners(2, 0, keys[0].PublicKey().Address(), keys[1].PublicKey().Address(), keys[2].PublicKey().Address())
+
+	sigIndices, signers, ok := newTestBuilder(t, keys...).match(owners, testTime)
+	assert.True(t, ok)
+	assert.Equal(t, []uint32{0, 1}, sigIndices)
+	assert.Len(t, signers, 2)
+
+	_, _, ok = newTestBuilder(t, keys[0]).match(owners, testTime)
+	assert.False(t, ok)
+}
+
+func TestBaseTx(t *testing.T) {
+	keys := newTestKeys(t, 2)
+	builder := newTestBuilder(t, keys[0])
+	address := keys[0].PublicKey().Address()
+	utxos := []*avax.UTXO{newTestUTXO(1, 60, Owners(1, 0, address)), newTestUTXO(2, 60, Owners(1, 0, address))}
+
+	to := Owners(1, 0, keys[1].PublicKey().Address())
+	tx, err := builder.BaseTx(utxos, testingConstants.AvaxAssetID, 100, to, address, 10)
+	assert.NoError(t, err)
+	baseTx := tx.UnsignedTx.(*avm.BaseTx)
+	assert.Equal(t, constants.LocalID, baseTx.NetworkID)
+	assert.Len(t, baseTx.Ins, 2)
+	assert.Len(t, tx.Creds, 2)
+
+	outputAmounts := []uint64{}
+	for _, out := range baseTx.Outs {
+		outputAmounts = append(outputAmounts, out.Out.(*secp256k1fx.TransferOutput).Amt)
+	}
+	assert.ElementsMatch(t, []uint64{100, 10}, outputAmounts)
+	assert.NotEmpty(t, tx.Bytes())
+}
+
+func TestImportExportedUTXOs(t *testing.T) {
+	keys := newTestKeys(t, 1)
+	builder := newTestBuilder(t, keys...)
+	address := keys[0].PublicKey().Address()
+	owners := Owners(1, 0, address)
+	utxos := []*avax.UTXO{newTestUTXO(1, 100, owners)}
+
+	exportTx, err := builder.ExportTx(utxos, testingConstants.PlatformChainID, testingConstants.AvaxAssetID, 50, owners, address, 10)
+	assert.NoError(t, err)
+	atomicUTXOs, err := ExportedUTXOs(exportTx)
+	assert.NoError(t, err)
+	assert.Len(t, atomicUTXOs, 1)
+	// The exported output is indexed after the change output
+	assert.True(t, atomicUTXOs[0].TxID.Equals(exportTx.ID()))
+	assert.Equal(t, uint32(1), atomicUTXOs[0].OutputIndex)
+
+	// The import fee is paid from the imported AVAX
+	importTx, err := builder.PChainImportTx(nil, atomicUTXOs, testingConstants.XChainID, owners, address, 10)
+	assert.NoError(t, err)
+	importedOuts := importTx.UnsignedTx.(*platformvm.UnsignedImportTx).Outs
+	assert.Len(t, importedOuts, 1)
+	assert.Equal(t, uint64(40), importedOuts[0].Out.(*secp256k1fx.TransferOutput).Amt)
+	assert.Len(t, importTx.Creds, 1)
+
+	assertXChainRoundTrip(t, builder, exportTx, [][]*crypto.PrivateKeySECP256K1R{keys})
+	assertPChainRoundTrip(t, importTx, [][]*crypto.PrivateKeySECP256K1R{keys})
+}
+
+func TestPChainExportXChainImport(t *testing.T) {
+	keys := newTestKeys(t, 1)
+	builder := newTestBuilder(t, keys...)
+	address := keys[0].PublicKey().Address()
+	owners := Owners(1, 0, address)
+
+	exportTx, err := builder.PChainExportTx([]*avax.UTXO{newTestUTXO(1, 100, owners)}, testingConstants.XChainID, 50, owners, address, 10)
+	assert.NoError(t, err)
+	assertPChainRoundTrip(t, exportTx, [][]*crypto.PrivateKeySECP256K1R{keys})
+	atomicUTXOs, err := PChainExportedUTXOs(exportTx)
+	assert.NoError(t, err)
+	assert.Len(t, atomicUTXOs, 1)
+
+	// The imported AVAX can't pay the fee, so it's paid from the X Chain UTXO
+	importTx, err := builder.ImportTx([]*avax.UTXO{newTestUTXO(2, 100, owners)}, atomicUTXOs, testingConstants.PlatformChainID, owners, address, 60)
+	assert.NoError(t, err)
+	parsed := assertXChainRoundTrip(t, builder, importTx, [][]*crypto.PrivateKeySECP256K1R{keys, keys})
+	parsedImportTx := parsed.UnsignedTx.(*avm.ImportTx)
+	assert.True(t, parsedImportTx.SourceChain.Equals(testingConstants.PlatformChainID))
+	assert.Len(t, parsedImportTx.Ins, 1)
+	assert.Len(t, parsedImportTx.ImportedIns, 1)
+}
+
+func TestAddValidatorTx(t *testing.T) {
+	keys := newTestKeys(t, 2)
+	builder := newTestBuilder(t, keys[0])
+	address := keys[0].PublicKey().Address()
+	utxos := []*avax.UTXO{newTestUTXO(1, 100, Owners(1, 0, address))}
+	nodeID := ids.NewShortID([20]byte{1})
+	startTime := time.Unix(int64(testTime), 0)
+	endTime := startTime.Add(24 * time.Hour)
+
+	tx, err := builder.AddValidatorTx(utxos, nodeID, startTime, endTime, 60, Owners(1, 0, keys[1].PublicKey().Address()), 20000, address, 10)
+	assert.NoError(t, err)
+	parsed := assertPChainRoundTrip(t, tx, [][]*crypto.PrivateKeySECP256K1R{{keys[0]}})
+	addValidatorTx := parsed.UnsignedTx.(*platformvm.UnsignedAddValidatorTx)
+	assert.True(t, addValidatorTx.Validator.NodeID.Equals(nodeID))
+	assert.Equal(t, uint64(startTime.Unix()), addValidatorTx.Validator.Start)
+	assert.Equal(t, uint64(endTime.Unix()), addValidatorTx.Validator.End)
+	assert.Equal(t, uint64(60), addValidatorTx.Validator.Wght)
+	assert.Equal(t, uint32(20000), addValidatorTx.Shares)
+	assert.Len(t, addValidatorTx.Stake, 1)
+	assert.Equal(t, uint64(60), addValidatorTx.Stake[0].Out.(*secp256k1fx.TransferOutput).Amt)
+	assert.Len(t, addValidatorTx.Outs, 1)
+	assert.Equal(t, uint64(30), addValidatorTx.Outs[0].Out.(*secp256k1fx.TransferOutput).Amt)
+}
+
+func TestAddDelegatorTx(t *testing.T) {
+	keys := newTestKeys(t, 1)
+	builder := newTestBuilder(t, keys...)
+	address := keys[0].PublicKey().Address()
+	owners := Owners(1, 0, address)
+	utxos := []*avax.UTXO{newTestUTXO(1, 40, owners), newTestUTXO(2, 40, owners)}
+	nodeID := ids.NewShortID([20]byte{1})
+	startTime := time.Unix(int64(testTime), 0)
+
+	tx, err := builder.AddDelegatorTx(utxos, nodeID, startTime, startTime.Add(time.Hour), 70, owners, address, 10)
+	assert.NoError(t, err)
+	parsed := assertPChainRoundTrip(t, tx, [][]*crypto.PrivateKeySECP256K1R{keys, keys})
+	addDelegatorTx := parsed.UnsignedTx.(*platformvm.UnsignedAddDelegatorTx)
+	assert.True(t, addDelegatorTx.Validator.NodeID.Equals(nodeID))
+	assert.Equal(t, uint64(70), addDelegatorTx.Validator.Wght)
+	assert.Len(t, addDelegatorTx.Stake, 1)
+	assert.Equal(t, uint64(70), addDelegatorTx.Stake[0].Out.(*secp256k1fx.TransferOutput).Amt)
+	// Everything but the stake and the fee is spent, so there's no change
+	assert.Empty(t, addDelegatorTx.Outs)
+}
+
+func TestCreateSubnetTx(t *testing.T) {
+	keys := newTestKeys(t, 3)
+	builder := newTestBuilder(t, keys[0])
+	address := keys[0].PublicKey().Address()
+	utxos := []*avax.UTXO{newTestUTXO(1, 100, Owners(1, 0, address))}
+	owner := Owners(2, 0, keys[1].PublicKey().Address(), keys[2].PublicKey().Address())
+
+	tx, err := builder.CreateSubnetTx(utxos, owner, address, 10)
+	assert.NoError(t, err)
+	parsed := assertPChainRoundTrip(t, tx, [][]*crypto.PrivateKeySECP256K1R{{keys[0]}})
+	createSubnetTx := parsed.UnsignedTx.(*platformvm.UnsignedCreateSubnetTx)
+	assert.Equal(t, &owner, createSubnetTx.Owner)
+}
+
+func TestCreateAssetTx(t *testing.T) {
+	keys := newTestKeys(t, 1)
+	builder := newTestBuilder(t, keys...)
+	address := keys[0].PublicKey().Address()
+	owners := Owners(1, 0, address)
+	utxos := []*avax.UTXO{newTestUTXO(1, 100, owners)}
+
+	// Neither the states nor their outputs are given in sorted order
+	states := []*avm.InitialState{
+		InitialState(NFTFxIndex, &nftfx.MintOutput{GroupID: 1, OutputOwners: owners}),
+		InitialState(
+			SECP256K1FxIndex,
+			&secp256k1fx.TransferOutput{Amt: 5, OutputOwners: owners},
+			&secp256k1fx.TransferOutput{Amt: 3, OutputOwners: owners},
+		),
+	}
+
+	tx, err := builder.CreateAssetTx(utxos, "Test Asset", "TEST", 2, states, address, 10)
+	assert.NoError(t, err)
+	parsed := assertXChainRoundTrip(t, builder, tx, [][]*crypto.PrivateKeySECP256K1R{keys})
+	createAssetTx := parsed.UnsignedTx.(*avm.CreateAssetTx)
+	assert.Equal(t, "Test Asset", createAssetTx.Name)
+	assert.Equal(t, "TEST", createAssetTx.Symbol)
+	assert.Equal(t, byte(2), createAssetTx.Denomination)
+	assert.Len(t, createAssetTx.States, 2)
+	assert.Equal(t, SECP256K1FxIndex, createAssetTx.States[0].FxID)
+	assert.Equal(t, NFTFxIndex, createAssetTx.States[1].FxID)
+	assert.Equal(t, uint64(3), createAssetTx.States[0].Outs[0].(*secp256k1fx.TransferOutput).Amt)
+
+	// The caller's states are left as they were
+	assert.Equal(t, NFTFxIndex, states[0].FxID)
+	assert.Equal(t, uint64(5), states[1].Outs[0].(*secp256k1fx.TransferOutput).Amt)
+}
+
+func TestMintTx(t *testing.T) {
+	keys := newTestKeys(t, 2)
+	builder := newTestBuilder(t, keys[0])
+	address := keys[0].PublicKey().Address()
+	owners := Owners(1, 0, address)
+	assetID := ids.NewID([32]byte{42})
+	utxos := []*avax.UTXO{
+		newTestUTXO(1, 100, owners),
+		newTestAssetUTXO(2, assetID, &secp256k1fx.MintOutput{OutputOwners: owners}),
+	}
+
+	to := Owners(1, 0, keys[1].PublicKey().Address())
+	tx, err := builder.MintTx(utxos, assetID, 50, to, address, 10)
+	assert.NoError(t, err)
+	// The fee input's credential comes before the mint operation's
+	parsed := assertXChainRoundTrip(t, builder, tx, [][]*crypto.PrivateKeySECP256K1R{{keys[0]}, {keys[0]}})
+	operationTx := parsed.UnsignedTx.(*avm.OperationTx)
+	assert.Len(t, operationTx.Ops, 1)
+	assert.True(t, operationTx.Ops[0].UTXOIDs[0].TxID.Equals(utxos[1].TxID))
+	mintOperation := operationTx.Ops[0].Op.(*secp256k1fx.MintOperation)
+	assert.Equal(t, uint64(50), mintOperation.TransferOutput.Amt)
+	assert.Equal(t, to, mintOperation.TransferOutput.OutputOwners)
+	assert.Equal(t, owners, mintOperation.MintOutput.OutputOwners)
+	assert.IsType(t, &secp256k1fx.Credential{}, parsed.Creds[1])
+}
+
+func TestMintNFTTx(t *testing.T) {
+	keys := newTestKeys(t, 3)
+	builder := newTestBuilder(t, keys[0])
+	address := keys[0].PublicKey().Address()
+	owners := Owners(1, 0, address)
+	assetID := ids.NewID([32]byte{42})
+	utxos := []*avax.UTXO{
+		newTestUTXO(1, 100, owners),
+		newTestAssetUTXO(2, assetID, &nftfx.MintOutput{GroupID: 1, OutputOwners: owners}),
+		newTestAssetUTXO(3, assetID, &nftfx.MintOutput{GroupID: 2, OutputOwners: owners}),
+	}
+
+	to := []secp256k1fx.OutputOwners{
+		Owners(1, 0, keys[1].PublicKey().Address()),
+		Owners(1, 0, keys[2].PublicKey().Address()),
+	}
+	tx, err := builder.MintNFTTx(utxos, assetID, 2, []byte("payload"), to, address, 10)
+	assert.NoError(t, err)
+	parsed := assertXChainRoundTrip(t, builder, tx, [][]*crypto.PrivateKeySECP256K1R{{keys[0]}, {keys[0]}})
+	operationTx := parsed.UnsignedTx.(*avm.OperationTx)
+	assert.Len(t, operationTx.Ops, 1)
+	assert.True(t, operationTx.Ops[0].UTXOIDs[0].TxID.Equals(utxos[2].TxID))
+	mintOperation := operationTx.Ops[0].Op.(*nftfx.MintOperation)
+	assert.Equal(t, uint32(2), mintOperation.GroupID)
+	assert.Equal(t, []byte("payload"), mintOperation.Payload)
+	assert.Len(t, mintOperation.Outputs, 2)
+	// NFT operations are signed with NFT Fx credentials
+	assert.IsType(t, &nftfx.Credential{}, parsed.Creds[1])
+}
+
+func TestLockedOutput(t *testing.T) {
+	keys := newTestKeys(t, 1)
+	builder := newTestBuilder(t, keys...)
+	address := keys[0].PublicKey().Address()
+	utxos := []*avax.UTXO{newTestUTXO(1, 100, Owners(1, 0, address))}
+
+	locked := Owners(1, testTime+100, address)
+	tx, err := builder.BaseTx(utxos, testingConstants.AvaxAssetID, 50, locked, address, 10)
+	assert.NoError(t, err)
+	parsed := assertXChainRoundTrip(t, builder, tx, [][]*crypto.PrivateKeySECP256K1R{keys})
+	var lockedUTXO *avax.UTXO
+	for i, out := range parsed.UnsignedTx.(*avm.BaseTx).Outs {
+		if out.Out.(*secp256k1fx.TransferOutput).Locktime != 0 {
+			lockedUTXO = newTestAssetUTXO(2, out.AssetID(), out.Out)
+			lockedUTXO.OutputIndex = uint32(i)
+		}
+	}
+	if !assert.NotNil(t, lockedUTXO) {
+		return
+	}
+	assert.Equal(t, uint64(50), lockedUTXO.Out.(*secp256k1fx.TransferOutput).Amt)
+
+	// The output can only be spent once its locktime has passed
+	_, err = builder.BaseTx([]*avax.UTXO{lockedUTXO}, testingConstants.AvaxAssetID, 40, locked, address, 10)
+	assert.Error(t, err)
+	builder.clock = func() uint64 { return locked.Locktime }
+	_, err = builder.BaseTx([]*avax.UTXO{lockedUTXO}, testingConstants.AvaxAssetID, 40, locked, address, 10)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,75 @@
+package txbuilder
+
+import (
+	"github.com/ava-labs/avalanchego/utils/codec"
+	"github.com/ava-labs/avalanchego/utils/wrappers"
+	"github.com/ava-labs/avalanchego/vms/avm"
+	"github.com/ava-labs/avalanchego/vms/components/avax"
+	"github.com/ava-labs/avalanchego/vms/nftfx"
+	"github.com/ava-labs/avalanchego/vms/platformvm"
+	"github.com/ava-labs/avalanchego/vms/propertyfx"
+	"github.com/ava-labs/avalanchego/vms/secp256k1fx"
+	"github.com/palantir/stacktrace"
+)
+
+// The indices of the X Chain's Fxs, which are what InitialState.FxID refers to
+const (
+	SECP256K1FxIndex uint32 = 0
+	NFTFxIndex       uint32 = 1
+	PropertyFxIndex  uint32 = 2
+)
+
+// NewXChainCodec returns a codec that (de)serializes X Chain transactions and UTXOs
+// NOTE: types are given IDs in the order they're registered, so this must follow the order the AVM registers its
+// transactions and then the types of each of its Fxs in
+func NewXChainCodec() (codec.Codec, error) {
+	c := codec.NewDefault()
+	errs := wrappers.Errs{}
+	errs.Add(
+		c.RegisterType(&avm.BaseTx{}),
+		c.RegisterType(&avm.CreateAssetTx{}),
+		c.RegisterType(&avm.OperationTx{}),
+		c.RegisterType(&avm.ImportTx{}),
+		c.RegisterType(&avm.ExportTx{}),
+
+		c.RegisterType(&secp256k1fx.TransferInput{}),
+		c.RegisterType(&secp256k1fx.MintOutput{}),
+		c.RegisterType(&secp256k1fx.TransferOutput{}),
+		c.RegisterType(&secp256k1fx.MintOperation{}),
+		c.RegisterType(&secp256k1fx.Credential{}),
+
+		c.RegisterType(&nftfx.MintOutput{}),
+		c.RegisterType(&nftfx.TransferOutput{}),
+		c.RegisterType(&nftfx.MintOperation{}),
+		c.RegisterType(&nftfx.TransferOperation{}),
+		c.RegisterType(&nftfx.Credential{}),
+
+		c.RegisterType(&propertyfx.MintOutput{}),
+		c.RegisterType(&propertyfx.OwnedOutput{}),
+		c.RegisterType(&propertyfx.MintOperation{}),
+		c.RegisterType(&propertyfx.BurnOperation{}),
+		c.RegisterType(&propertyfx.Credential{}),
+	)
+	return c, errs.Err
+}
+
+// ParseUTXOs unmarshals UTXOs, as returned by getUTXOs, with the given codec
+// Args:
+// 	c: The codec of the chain the UTXOs are from - NewXChainCodec's for the X Chain, or platformvm.Codec for the P Chain
+// 	utxosBytes: The serialized UTXOs
+func ParseUTXOs(c codec.Codec, utxosBytes [][]byte) ([]*avax.UTXO, error) {
+	utxos := make([]*avax.UTXO, 0, len(utxosBytes))
+	for i, utxoBytes := range utxosBytes {
+		utxo := &avax.UTXO{}
+		if err := c.Unmarshal(utxoBytes, utxo); err != nil {
+			return nil, stacktrace.Propagate(err, "Failed to unmarshal UTXO %v", i)
+		}
+		utxos = append(utxos, utxo)
+	}
+	return utxos, nil
+}
+
+// ParsePChainUTXOs unmarshals P Chain UTXOs, as returned by the P Chain's getUTXOs
+func ParsePChainUTXOs(utxosBytes [][]byte) ([]*avax.UTXO, error) {
+	return ParseUTXOs(platformvm.Codec, utxosBytes)
+}
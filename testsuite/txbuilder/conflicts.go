@@ -0,0 +1,87 @@
+package txbuilder
+
+import (
+	"github.com/ava-labs/avalanchego/utils/crypto"
+	"github.com/ava-labs/avalanchego/vms/avm"
+	"github.com/ava-labs/avalanchego/vms/components/avax"
+	"github.com/ava-labs/avalanchego/vms/secp256k1fx"
+	"github.com/palantir/stacktrace"
+)
+
+const conflictingAssetSymbol = "CNFL"
+
+// ConflictSet is a transaction that creates an asset, along with transactions that all spend its AVAX change output
+// and so conflict with each other
+type ConflictSet struct {
+	CreateAssetTx *avm.Tx
+	Spends        []*avm.Tx
+}
+
+// NewConflictSet builds and signs a create asset transaction that spends the given UTXO, and transactions that each
+// spend the whole of its change output back to the key - burning a different amount on top of the fee, so that every
+// spend has its own ID
+// Args:
+// 	networkID: The ID of the network the transactions are for
+// 	key: The key that owns the UTXO
+// 	utxo: An AVAX UTXO owned by the key alone, which must hold enough to pay the fee of every transaction
+// 	assetName: The name of the created asset; conflict sets built from the same UTXO with different asset names have
+// 		create asset transactions that conflict with each other too
+// 	numSpends: The number of conflicting spends to build
+// 	fee: The network's transaction fee
+func NewConflictSet(
+	networkID uint32,
+	key *crypto.PrivateKeySECP256K1R,
+	utxo *avax.UTXO,
+	assetName string,
+	numSpends int,
+	fee uint64) (*ConflictSet, error) {
+	builder, err := NewBuilder(networkID, key)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to create the transaction builder")
+	}
+	address := key.PublicKey().Address()
+	owners := Owners(1, 0, address)
+
+	// The asset itself is just a single unit held by the key
+	holding := &secp256k1fx.TransferOutput{Amt: 1, OutputOwners: owners}
+	createAssetTx, err := builder.CreateAssetTx(
+		[]*avax.UTXO{utxo},
+		assetName,
+		conflictingAssetSymbol,
+		0,
+		[]*avm.InitialState{InitialState(SECP256K1FxIndex, holding)},
+		address,
+		fee)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to build the create asset transaction")
+	}
+
+	var changeUTXO *avax.UTXO
+	for _, createdUTXO := range createAssetTx.UTXOs() {
+		if createdUTXO.AssetID().Equals(builder.avaxAssetID) {
+			changeUTXO = createdUTXO
+			break
+		}
+	}
+	if changeUTXO == nil {
+		return nil, stacktrace.NewError("The UTXO holds only enough to pay the fee of the create asset transaction")
+	}
+	balance := changeUTXO.Out.(*secp256k1fx.TransferOutput).Amt
+	if balance <= fee+uint64(numSpends) {
+		return nil, stacktrace.NewError("The create asset transaction's change of %v is too little to pay for %v spends with a fee of %v", balance, numSpends, fee)
+	}
+
+	spends := make([]*avm.Tx, numSpends)
+	for i := range spends {
+		burned := fee + uint64(i)
+		spend, err := builder.BaseTx([]*avax.UTXO{changeUTXO}, builder.avaxAssetID, balance-burned, owners, address, burned)
+		if err != nil {
+			return nil, stacktrace.Propagate(err, "Failed to build conflicting spend %v", i)
+		}
+		spends[i] = spend
+	}
+	return &ConflictSet{
+		CreateAssetTx: createAssetTx,
+		Spends:        spends,
+	}, nil
+}
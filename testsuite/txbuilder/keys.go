@@ -0,0 +1,86 @@
+package txbuilder
+
+import (
+	"strings"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/constants"
+	"github.com/ava-labs/avalanchego/utils/crypto"
+	"github.com/ava-labs/avalanchego/utils/formatting"
+	"github.com/palantir/stacktrace"
+)
+
+const (
+	xChainAlias = "X"
+	pChainAlias = "P"
+
+	// Prefix that avalanchego puts in front of the string form of a node ID
+	nodeIDPrefix = "NodeID-"
+)
+
+// ParsePrivateKey parses a private key in the "PrivateKey-..." form that the keystore exports keys in
+func ParsePrivateKey(privateKeyStr string) (*crypto.PrivateKeySECP256K1R, error) {
+	if !strings.HasPrefix(privateKeyStr, constants.SecretKeyPrefix) {
+		return nil, stacktrace.NewError("Private key is missing the %v prefix", constants.SecretKeyPrefix)
+	}
+	formattedPrivateKey := formatting.CB58{}
+	if err := formattedPrivateKey.FromString(strings.TrimPrefix(privateKeyStr, constants.SecretKeyPrefix)); err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to parse private key")
+	}
+	factory := crypto.FactorySECP256K1R{}
+	privateKey, err := factory.ToPrivateKey(formattedPrivateKey.Bytes)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to convert bytes to a private key")
+	}
+	return privateKey.(*crypto.PrivateKeySECP256K1R), nil
+}
+
+// XChainAddress returns the given address in the "X-..." form, for the builder's network
+func (builder *Builder) XChainAddress(address ids.ShortID) (string, error) {
+	return formatting.FormatAddress(xChainAlias, constants.GetHRP(builder.networkID), address.Bytes())
+}
+
+// PChainAddress returns the given address in the "P-..." form, for the builder's network
+func (builder *Builder) PChainAddress(address ids.ShortID) (string, error) {
+	return formatting.FormatAddress(pChainAlias, constants.GetHRP(builder.networkID), address.Bytes())
+}
+
+// ParseXChainAddress parses an address in the "X-..." form
+func ParseXChainAddress(address string) (ids.ShortID, error) {
+	return parseAddress(address, xChainAlias)
+}
+
+// ParsePChainAddress parses an address in the "P-..." form
+func ParsePChainAddress(address string) (ids.ShortID, error) {
+	return parseAddress(address, pChainAlias)
+}
+
+// ParseNodeID parses a node ID in the "NodeID-..." form that avalanchego APIs return node IDs in
+func ParseNodeID(nodeID string) (ids.ShortID, error) {
+	if !strings.HasPrefix(nodeID, nodeIDPrefix) {
+		return ids.ShortEmpty, stacktrace.NewError("Node ID %s is missing the %v prefix", nodeID, nodeIDPrefix)
+	}
+	shortID, err := ids.ShortFromString(strings.TrimPrefix(nodeID, nodeIDPrefix))
+	if err != nil {
+		return ids.ShortEmpty, stacktrace.Propagate(err, "Failed to parse node ID %s", nodeID)
+	}
+	return shortID, nil
+}
+
+// ================= Helper functions ===================
+
+// parseAddress parses an address in the "<chain alias>-..." form, checking that it's for the given chain
+func parseAddress(address string, chainAlias string) (ids.ShortID, error) {
+	parsedAlias, _, addressBytes, err := formatting.ParseAddress(address)
+	if err != nil {
+		return ids.ShortEmpty, stacktrace.Propagate(err, "Failed to parse address %s", address)
+	}
+	if parsedAlias != chainAlias {
+		return ids.ShortEmpty, stacktrace.NewError("Address %s is for chain %s, not chain %s", address, parsedAlias, chainAlias)
+	}
+	shortID, err := ids.ToShortID(addressBytes)
+	if err != nil {
+		return ids.ShortEmpty, stacktrace.Propagate(err, "Failed to convert the bytes of address %s to an ID", address)
+	}
+	return shortID, nil
+}
@@ -0,0 +1,219 @@
+package txbuilder
+
+import (
+	"time"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/crypto"
+	"github.com/ava-labs/avalanchego/vms/components/avax"
+	"github.com/ava-labs/avalanchego/vms/platformvm"
+	"github.com/ava-labs/avalanchego/vms/secp256k1fx"
+	"github.com/palantir/stacktrace"
+)
+
+// AddValidatorTx builds and signs a P Chain transaction that adds a node as a validator of the primary network
+// Args:
+// 	utxos: The P Chain UTXOs the stake and the fee may be paid from
+// 	nodeID: The ID of the node to add
+// 	startTime: When the node starts validating
+// 	endTime: When the node stops validating, and the stake and reward are paid out
+// 	stakeAmount: How much AVAX to stake
+// 	rewardsOwner: The owners of the reward
+// 	shares: The fee the validator charges its delegators, in millionths of their reward
+// 	changeAddress: The address that the stake is returned to, along with anything left over after the fee
+// 	fee: The AVAX transaction fee
+func (builder *Builder) AddValidatorTx(
+	utxos []*avax.UTXO,
+	nodeID ids.ShortID,
+	startTime time.Time,
+	endTime time.Time,
+	stakeAmount uint64,
+	rewardsOwner secp256k1fx.OutputOwners,
+	shares uint32,
+	changeAddress ids.ShortID,
+	fee uint64) (*platformvm.Tx, error) {
+	baseTx, stake, signers, err := builder.stake(utxos, stakeAmount, changeAddress, fee)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to select the UTXOs to stake from")
+	}
+	tx := &platformvm.Tx{UnsignedTx: &platformvm.UnsignedAddValidatorTx{
+		BaseTx: baseTx,
+		Validator: platformvm.Validator{
+			NodeID: nodeID,
+			Start:  uint64(startTime.Unix()),
+			End:    uint64(endTime.Unix()),
+			Wght:   stakeAmount,
+		},
+		Stake:        stake,
+		RewardsOwner: &rewardsOwner,
+		Shares:       shares,
+	}}
+	if err := tx.Sign(platformvm.Codec, signers); err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to sign the add validator transaction")
+	}
+	return tx, nil
+}
+
+// AddDelegatorTx builds and signs a P Chain transaction that delegates stake to a validator of the primary network
+// Args:
+// 	utxos: The P Chain UTXOs the stake and the fee may be paid from
+// 	nodeID: The ID of the validator to delegate to
+// 	startTime: When the delegation starts
+// 	endTime: When the delegation ends, and the stake and reward are paid out
+// 	stakeAmount: How much AVAX to delegate
+// 	rewardsOwner: The owners of the reward
+// 	changeAddress: The address that the stake is returned to, along with anything left over after the fee
+// 	fee: The AVAX transaction fee
+func (builder *Builder) AddDelegatorTx(
+	utxos []*avax.UTXO,
+	nodeID ids.ShortID,
+	startTime time.Time,
+	endTime time.Time,
+	stakeAmount uint64,
+	rewardsOwner secp256k1fx.OutputOwners,
+	changeAddress ids.ShortID,
+	fee uint64) (*platformvm.Tx, error) {
+	baseTx, stake, signers, err := builder.stake(utxos, stakeAmount, changeAddress, fee)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to select the UTXOs to delegate from")
+	}
+	tx := &platformvm.Tx{UnsignedTx: &platformvm.UnsignedAddDelegatorTx{
+		BaseTx: baseTx,
+		Validator: platformvm.Validator{
+			NodeID: nodeID,
+			Start:  uint64(startTime.Unix()),
+			End:    uint64(endTime.Unix()),
+			Wght:   stakeAmount,
+		},
+		Stake:        stake,
+		RewardsOwner: &rewardsOwner,
+	}}
+	if err := tx.Sign(platformvm.Codec, signers); err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to sign the add delegator transaction")
+	}
+	return tx, nil
+}
+
+// CreateSubnetTx builds and signs a P Chain transaction that creates a subnet
+// Args:
+// 	utxos: The P Chain UTXOs the fee may be paid from
+// 	owner: The control keys of the subnet and how many of them must sign to add validators to it
+// 	changeAddress: The address that anything left over after the fee is returned to
+// 	fee: The AVAX transaction fee
+func (builder *Builder) CreateSubnetTx(
+	utxos []*avax.UTXO,
+	owner secp256k1fx.OutputOwners,
+	changeAddress ids.ShortID,
+	fee uint64) (*platformvm.Tx, error) {
+	spent, err := builder.spend(utxos, map[[32]byte]uint64{builder.avaxAssetID.Key(): fee})
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to select the UTXOs to pay the fee from")
+	}
+	outs := builder.changeOutputs(spent.change, changeAddress)
+	avax.SortTransferableOutputs(outs, platformvm.Codec)
+
+	tx := &platformvm.Tx{UnsignedTx: &platformvm.UnsignedCreateSubnetTx{
+		BaseTx: builder.pChainBaseTx(spent.ins, outs),
+		Owner:  &owner,
+	}}
+	if err := tx.Sign(platformvm.Codec, spent.signers); err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to sign the create subnet transaction")
+	}
+	return tx, nil
+}
+
+// PChainImportTx builds and signs a P Chain transaction that imports UTXOs that another chain exported to the P Chain
+// The fee is paid from the imported AVAX if there's enough of it, and from the given P Chain UTXOs otherwise.
+// Args:
+// 	utxos: The P Chain UTXOs the fee may be paid from
+// 	atomicUTXOs: The exported UTXOs to import, as returned by ExportedUTXOs
+// 	sourceChain: The chain the UTXOs were exported from
+// 	to: The owners of the imported outputs
+// 	changeAddress: The address that anything left over after the fee is returned to
+// 	fee: The AVAX transaction fee
+func (builder *Builder) PChainImportTx(
+	utxos []*avax.UTXO,
+	atomicUTXOs []*avax.UTXO,
+	sourceChain ids.ID,
+	to secp256k1fx.OutputOwners,
+	changeAddress ids.ShortID,
+	fee uint64) (*platformvm.Tx, error) {
+	spent, imported, outs, err := builder.importSpend(utxos, atomicUTXOs, to, changeAddress, fee)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to select the UTXOs to import and to pay the fee from")
+	}
+	avax.SortTransferableOutputs(outs, platformvm.Codec)
+
+	tx := &platformvm.Tx{UnsignedTx: &platformvm.UnsignedImportTx{
+		BaseTx:         builder.pChainBaseTx(spent.ins, outs),
+		SourceChain:    sourceChain,
+		ImportedInputs: imported.ins,
+	}}
+	// Credentials for the inputs come before the ones for the imported inputs
+	if err := tx.Sign(platformvm.Codec, append(spent.signers, imported.signers...)); err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to sign the import transaction")
+	}
+	return tx, nil
+}
+
+// PChainExportTx builds and signs a P Chain transaction that exports AVAX to another chain, where it can be imported
+// by the given owners
+// Args:
+// 	utxos: The P Chain UTXOs the transaction may spend
+// 	destinationChain: The chain to export to
+// 	amount: How much AVAX to export
+// 	to: The owners of the exported output
+// 	changeAddress: The address that anything left over after the export and the fee is returned to
+// 	fee: The AVAX transaction fee
+func (builder *Builder) PChainExportTx(
+	utxos []*avax.UTXO,
+	destinationChain ids.ID,
+	amount uint64,
+	to secp256k1fx.OutputOwners,
+	changeAddress ids.ShortID,
+	fee uint64) (*platformvm.Tx, error) {
+	spent, err := builder.spend(utxos, map[[32]byte]uint64{builder.avaxAssetID.Key(): amount + fee})
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to select the UTXOs to export from")
+	}
+	outs := builder.changeOutputs(spent.change, changeAddress)
+	avax.SortTransferableOutputs(outs, platformvm.Codec)
+
+	tx := &platformvm.Tx{UnsignedTx: &platformvm.UnsignedExportTx{
+		BaseTx:           builder.pChainBaseTx(spent.ins, outs),
+		DestinationChain: destinationChain,
+		ExportedOutputs:  []*avax.TransferableOutput{transferableOutput(builder.avaxAssetID, amount, to)},
+	}}
+	if err := tx.Sign(platformvm.Codec, spent.signers); err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to sign the export transaction")
+	}
+	return tx, nil
+}
+
+// ================= Helper functions ===================
+
+// stake selects UTXOs to pay the stake and the fee from, and returns the base transaction that consumes them, the
+// stake outputs that return the stake to the change address once staking ends, and the inputs' signers
+func (builder *Builder) stake(
+	utxos []*avax.UTXO,
+	stakeAmount uint64,
+	changeAddress ids.ShortID,
+	fee uint64) (platformvm.BaseTx, []*avax.TransferableOutput, [][]*crypto.PrivateKeySECP256K1R, error) {
+	spent, err := builder.spend(utxos, map[[32]byte]uint64{builder.avaxAssetID.Key(): stakeAmount + fee})
+	if err != nil {
+		return platformvm.BaseTx{}, nil, nil, err
+	}
+	outs := builder.changeOutputs(spent.change, changeAddress)
+	avax.SortTransferableOutputs(outs, platformvm.Codec)
+	stake := []*avax.TransferableOutput{transferableOutput(builder.avaxAssetID, stakeAmount, Owners(1, 0, changeAddress))}
+	return builder.pChainBaseTx(spent.ins, outs), stake, spent.signers, nil
+}
+
+func (builder *Builder) pChainBaseTx(ins []*avax.TransferableInput, outs []*avax.TransferableOutput) platformvm.BaseTx {
+	return platformvm.BaseTx{BaseTx: avax.BaseTx{
+		NetworkID:    builder.networkID,
+		BlockchainID: builder.pChainID,
+		Outs:         outs,
+		Ins:          ins,
+	}}
+}
@@ -0,0 +1,305 @@
+package txbuilder
+
+import (
+	"sort"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/crypto"
+	"github.com/ava-labs/avalanchego/vms/avm"
+	"github.com/ava-labs/avalanchego/vms/components/avax"
+	"github.com/ava-labs/avalanchego/vms/components/verify"
+	"github.com/ava-labs/avalanchego/vms/nftfx"
+	"github.com/ava-labs/avalanchego/vms/secp256k1fx"
+	"github.com/palantir/stacktrace"
+)
+
+// BaseTx builds and signs an X Chain transaction that sends an amount of an asset to the given owners
+// Args:
+// 	utxos: The UTXOs the transaction may spend
+// 	assetID: The asset to send
+// 	amount: How much of the asset to send
+// 	to: The owners of the sent output
+// 	changeAddress: The address that anything left over after the send and the fee is returned to
+// 	fee: The AVAX transaction fee
+func (builder *Builder) BaseTx(
+	utxos []*avax.UTXO,
+	assetID ids.ID,
+	amount uint64,
+	to secp256k1fx.OutputOwners,
+	changeAddress ids.ShortID,
+	fee uint64) (*avm.Tx, error) {
+	amounts := map[[32]byte]uint64{assetID.Key(): amount}
+	amounts[builder.avaxAssetID.Key()] += fee
+	spent, err := builder.spend(utxos, amounts)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to select the UTXOs to send from")
+	}
+	outs := append(builder.changeOutputs(spent.change, changeAddress), transferableOutput(assetID, amount, to))
+	avax.SortTransferableOutputs(outs, builder.xChainCodec)
+
+	tx := &avm.Tx{UnsignedTx: &avm.BaseTx{BaseTx: builder.xChainBaseTx(spent.ins, outs)}}
+	if err := builder.signXChainTx(tx, spent.signers, nil); err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to sign the base transaction")
+	}
+	return tx, nil
+}
+
+// CreateAssetTx builds and signs an X Chain transaction that creates a new asset
+// Args:
+// 	utxos: The UTXOs the fee may be paid from
+// 	name: The name of the asset
+// 	symbol: The ticker symbol of the asset
+// 	denomination: How many decimal places the asset's amounts are displayed with
+// 	states: The initial outputs of the asset, per Fx, which can be built with InitialState
+// 	changeAddress: The address that anything left over after the fee is returned to
+// 	fee: The AVAX transaction fee
+func (builder *Builder) CreateAssetTx(
+	utxos []*avax.UTXO,
+	name string,
+	symbol string,
+	denomination byte,
+	states []*avm.InitialState,
+	changeAddress ids.ShortID,
+	fee uint64) (*avm.Tx, error) {
+	spent, err := builder.spend(utxos, map[[32]byte]uint64{builder.avaxAssetID.Key(): fee})
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to select the UTXOs to pay the fee from")
+	}
+	outs := builder.changeOutputs(spent.change, changeAddress)
+	avax.SortTransferableOutputs(outs, builder.xChainCodec)
+
+	// Sorting is done on copies so that the caller's states are left as they were
+	sortedStates := make([]*avm.InitialState, len(states))
+	for i, state := range states {
+		outs := make([]verify.State, len(state.Outs))
+		copy(outs, state.Outs)
+		sortedStates[i] = &avm.InitialState{FxID: state.FxID, Outs: outs}
+	}
+	sort.Slice(sortedStates, func(i, j int) bool { return sortedStates[i].FxID < sortedStates[j].FxID })
+	for _, state := range sortedStates {
+		state.Sort(builder.xChainCodec)
+	}
+
+	tx := &avm.Tx{UnsignedTx: &avm.CreateAssetTx{
+		BaseTx:       avm.BaseTx{BaseTx: builder.xChainBaseTx(spent.ins, outs)},
+		Name:         name,
+		Symbol:       symbol,
+		Denomination: denomination,
+		States:       sortedStates,
+	}}
+	if err := builder.signXChainTx(tx, spent.signers, nil); err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to sign the create asset transaction")
+	}
+	return tx, nil
+}
+
+// InitialState returns the initial outputs of a new asset for one of the X Chain's Fxs
+// Args:
+// 	fxIndex: The Fx the outputs belong to, like SECP256K1FxIndex
+// 	outs: The outputs, like *secp256k1fx.TransferOutput for a fixed supply, *secp256k1fx.MintOutput for a variable
+// 		supply, or *nftfx.MintOutput for an NFT group
+func InitialState(fxIndex uint32, outs ...verify.State) *avm.InitialState {
+	return &avm.InitialState{
+		FxID: fxIndex,
+		Outs: outs,
+	}
+}
+
+// MintTx builds and signs an X Chain transaction that mints more of a variable supply asset, using a mint output of
+// the asset that the builder's keys control
+// Args:
+// 	utxos: The UTXOs the mint output and the fee may come from
+// 	assetID: The asset to mint
+// 	amount: How much of the asset to mint
+// 	to: The owners of the minted output
+// 	changeAddress: The address that anything left over after the fee is returned to
+// 	fee: The AVAX transaction fee
+func (builder *Builder) MintTx(
+	utxos []*avax.UTXO,
+	assetID ids.ID,
+	amount uint64,
+	to secp256k1fx.OutputOwners,
+	changeAddress ids.ShortID,
+	fee uint64) (*avm.Tx, error) {
+	mintUTXO, mintSigIndices, mintSigners, err := builder.findUTXO(utxos, assetID, func(out verify.State) (secp256k1fx.OutputOwners, bool) {
+		mintOut, ok := out.(*secp256k1fx.MintOutput)
+		if !ok {
+			return secp256k1fx.OutputOwners{}, false
+		}
+		return mintOut.OutputOwners, true
+	})
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to find a mint output of asset %s", assetID)
+	}
+	mintOut := mintUTXO.Out.(*secp256k1fx.MintOutput)
+	operation := &avm.Operation{
+		Asset:   avax.Asset{ID: assetID},
+		UTXOIDs: []*avax.UTXOID{&mintUTXO.UTXOID},
+		Op: &secp256k1fx.MintOperation{
+			MintInput:  secp256k1fx.Input{SigIndices: mintSigIndices},
+			MintOutput: secp256k1fx.MintOutput{OutputOwners: mintOut.OutputOwners},
+			TransferOutput: secp256k1fx.TransferOutput{
+				Amt:          amount,
+				OutputOwners: to,
+			},
+		},
+	}
+	return builder.operationTx(utxos, operation, mintSigners, false, changeAddress, fee)
+}
+
+// MintNFTTx builds and signs an X Chain transaction that mints an NFT of a group of an NFT asset, using the group's
+// mint output that the builder's keys control
+// Args:
+// 	utxos: The UTXOs the mint output and the fee may come from
+// 	assetID: The NFT asset to mint
+// 	groupID: The group of the asset to mint an NFT of
+// 	payload: The NFT's payload
+// 	to: The owners of the minted NFTs, which get one each
+// 	changeAddress: The address that anything left over after the fee is returned to
+// 	fee: The AVAX transaction fee
+func (builder *Builder) MintNFTTx(
+	utxos []*avax.UTXO,
+	assetID ids.ID,
+	groupID uint32,
+	payload []byte,
+	to []secp256k1fx.OutputOwners,
+	changeAddress ids.ShortID,
+	fee uint64) (*avm.Tx, error) {
+	mintUTXO, mintSigIndices, mintSigners, err := builder.findUTXO(utxos, assetID, func(out verify.State) (secp256k1fx.OutputOwners, bool) {
+		mintOut, ok := out.(*nftfx.MintOutput)
+		if !ok || mintOut.GroupID != groupID {
+			return secp256k1fx.OutputOwners{}, false
+		}
+		return mintOut.OutputOwners, true
+	})
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to find a mint output of group %v of asset %s", groupID, assetID)
+	}
+	outputs := make([]*secp256k1fx.OutputOwners, len(to))
+	for i := range to {
+		outputs[i] = &to[i]
+	}
+	operation := &avm.Operation{
+		Asset:   avax.Asset{ID: assetID},
+		UTXOIDs: []*avax.UTXOID{&mintUTXO.UTXOID},
+		Op: &nftfx.MintOperation{
+			MintInput: secp256k1fx.Input{SigIndices: mintSigIndices},
+			GroupID:   groupID,
+			Payload:   payload,
+			Outputs:   outputs,
+		},
+	}
+	return builder.operationTx(utxos, operation, mintSigners, true, changeAddress, fee)
+}
+
+// ImportTx builds and signs an X Chain transaction that imports UTXOs that another chain exported to the X Chain
+// The fee is paid from the imported AVAX if there's enough of it, and from the given X Chain UTXOs otherwise.
+// Args:
+// 	utxos: The X Chain UTXOs the fee may be paid from
+// 	atomicUTXOs: The exported UTXOs to import, as returned by getUTXOs with the source chain or by ExportedUTXOs
+// 	sourceChain: The chain the UTXOs were exported from
+// 	to: The owners of the imported outputs, which get one output per imported asset
+// 	changeAddress: The address that anything left over after the fee is returned to
+// 	fee: The AVAX transaction fee
+func (builder *Builder) ImportTx(
+	utxos []*avax.UTXO,
+	atomicUTXOs []*avax.UTXO,
+	sourceChain ids.ID,
+	to secp256k1fx.OutputOwners,
+	changeAddress ids.ShortID,
+	fee uint64) (*avm.Tx, error) {
+	spent, imported, outs, err := builder.importSpend(utxos, atomicUTXOs, to, changeAddress, fee)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to select the UTXOs to import and to pay the fee from")
+	}
+	avax.SortTransferableOutputs(outs, builder.xChainCodec)
+
+	tx := &avm.Tx{UnsignedTx: &avm.ImportTx{
+		BaseTx:      avm.BaseTx{BaseTx: builder.xChainBaseTx(spent.ins, outs)},
+		SourceChain: sourceChain,
+		ImportedIns: imported.ins,
+	}}
+	// Credentials for the inputs come before the ones for the imported inputs
+	if err := builder.signXChainTx(tx, append(spent.signers, imported.signers...), nil); err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to sign the import transaction")
+	}
+	return tx, nil
+}
+
+// ExportTx builds and signs an X Chain transaction that exports an amount of an asset to another chain, where it can
+// be imported by the given owners
+// Args:
+// 	utxos: The UTXOs the transaction may spend
+// 	destinationChain: The chain to export to
+// 	assetID: The asset to export
+// 	amount: How much of the asset to export
+// 	to: The owners of the exported output
+// 	changeAddress: The address that anything left over after the export and the fee is returned to
+// 	fee: The AVAX transaction fee
+func (builder *Builder) ExportTx(
+	utxos []*avax.UTXO,
+	destinationChain ids.ID,
+	assetID ids.ID,
+	amount uint64,
+	to secp256k1fx.OutputOwners,
+	changeAddress ids.ShortID,
+	fee uint64) (*avm.Tx, error) {
+	amounts := map[[32]byte]uint64{assetID.Key(): amount}
+	amounts[builder.avaxAssetID.Key()] += fee
+	spent, err := builder.spend(utxos, amounts)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to select the UTXOs to export from")
+	}
+	outs := builder.changeOutputs(spent.change, changeAddress)
+	avax.SortTransferableOutputs(outs, builder.xChainCodec)
+
+	tx := &avm.Tx{UnsignedTx: &avm.ExportTx{
+		BaseTx:           avm.BaseTx{BaseTx: builder.xChainBaseTx(spent.ins, outs)},
+		DestinationChain: destinationChain,
+		ExportedOuts:     []*avax.TransferableOutput{transferableOutput(assetID, amount, to)},
+	}}
+	if err := builder.signXChainTx(tx, spent.signers, nil); err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to sign the export transaction")
+	}
+	return tx, nil
+}
+
+// ================= Helper functions ===================
+
+// operationTx builds and signs an X Chain transaction that performs a single operation and pays the fee from the
+// given UTXOs
+func (builder *Builder) operationTx(
+	utxos []*avax.UTXO,
+	operation *avm.Operation,
+	operationSigners []*crypto.PrivateKeySECP256K1R,
+	isNFTOperation bool,
+	changeAddress ids.ShortID,
+	fee uint64) (*avm.Tx, error) {
+	spent, err := builder.spend(utxos, map[[32]byte]uint64{builder.avaxAssetID.Key(): fee})
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to select the UTXOs to pay the fee from")
+	}
+	outs := builder.changeOutputs(spent.change, changeAddress)
+	avax.SortTransferableOutputs(outs, builder.xChainCodec)
+
+	tx := &avm.Tx{UnsignedTx: &avm.OperationTx{
+		BaseTx: avm.BaseTx{BaseTx: builder.xChainBaseTx(spent.ins, outs)},
+		Ops:    []*avm.Operation{operation},
+	}}
+	// The operation's credential comes after the ones for the inputs
+	signers := append(spent.signers, operationSigners)
+	nftCredentials := map[int]bool{len(signers) - 1: isNFTOperation}
+	if err := builder.signXChainTx(tx, signers, nftCredentials); err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to sign the operation transaction")
+	}
+	return tx, nil
+}
+
+func (builder *Builder) xChainBaseTx(ins []*avax.TransferableInput, outs []*avax.TransferableOutput) avax.BaseTx {
+	return avax.BaseTx{
+		NetworkID:    builder.networkID,
+		BlockchainID: builder.xChainID,
+		Outs:         outs,
+		Ins:          ins,
+	}
+}
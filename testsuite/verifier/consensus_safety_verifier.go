@@ -0,0 +1,288 @@
+package verifier
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ava-labs/avalanche-testing/avalanche_client/apis"
+	"github.com/ava-labs/avalanche-testing/testsuite/helpers"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/snow/choices"
+	"github.com/ava-labs/avalanchego/vms/platformvm"
+	"github.com/kurtosis-tech/kurtosis/commons/networks"
+	"github.com/palantir/stacktrace"
+	"github.com/sirupsen/logrus"
+)
+
+// ConsensusSafetyVerifier contains logic for verifying that nodes agree on the state they've accepted
+// Like NetworkStateVerifier, the struct only exists to group the functions around a common purpose.
+type ConsensusSafetyVerifier struct {
+	// How many blocks the P Chain heights reported by two nodes may differ by, since nodes accept blocks at slightly
+	//  different times
+	HeightTolerance uint64
+}
+
+// TrackedState is the state that ConsensusSafetyVerifier compares across nodes, on top of the P Chain height and the
+// current validators of the primary network
+type TrackedState struct {
+	XChainTxIDs []ids.ID
+	PChainTxIDs []ids.ID
+
+	// The addresses whose AVAX balances are compared
+	XChainAddresses []string
+	PChainAddresses []string
+}
+
+// NodeState is what a single node reports about the tracked state. Transactions are keyed by their string IDs.
+type NodeState struct {
+	XChainTxStatuses map[string]choices.Status
+	PChainTxStatuses map[string]platformvm.Status
+	PChainHeight     uint64
+	XChainBalances   map[string]uint64
+	PChainBalances   map[string]uint64
+	ValidatorNodeIDs map[string]bool
+}
+
+// VerifyConsensusSafety asserts that the given nodes agree on the tracked state
+// Meaning:
+// 		1) No tracked transaction is accepted on one node but rejected on another
+// 		2) The nodes' P Chain heights are within HeightTolerance of each other
+// 		3) The nodes report the same balances for the tracked addresses
+// 		4) The nodes report the same current validators
+// Transactions that are still processing on some nodes don't count as disagreements, since those nodes may simply not
+// have caught up yet.
+// Args:
+// 	allAvalancheClients: The clients of the nodes to compare, by service ID
+// 	tracked: The transactions and addresses to compare
+// Returns:
+// 	An error listing, for every disagreement, what each node reports
+func (verifier ConsensusSafetyVerifier) VerifyConsensusSafety(
+	allAvalancheClients map[networks.ServiceID]*apis.Client,
+	tracked TrackedState,
+) error {
+	nodeStates := make(map[networks.ServiceID]*NodeState)
+	for serviceID, client := range allAvalancheClients {
+		nodeState, err := verifier.GetNodeState(client, tracked)
+		if err != nil {
+			return stacktrace.Propagate(err, "An error occurred getting the state of service with ID %v", serviceID)
+		}
+		nodeStates[serviceID] = nodeState
+	}
+	return verifier.CompareNodeStates(nodeStates)
+}
+
+// GetNodeState queries the given node for the tracked state
+func (verifier ConsensusSafetyVerifier) GetNodeState(client *apis.Client, tracked TrackedState) (*NodeState, error) {
+	nodeState := &NodeState{
+		XChainTxStatuses: make(map[string]choices.Status),
+		PChainTxStatuses: make(map[string]platformvm.Status),
+		XChainBalances:   make(map[string]uint64),
+		PChainBalances:   make(map[string]uint64),
+	}
+	for _, txID := range tracked.XChainTxIDs {
+		status, err := client.XChainAPI().GetTxStatus(txID)
+		if err != nil {
+			return nil, stacktrace.Propagate(err, "Failed to get the status of X Chain transaction %s", txID)
+		}
+		nodeState.XChainTxStatuses[txID.String()] = status
+	}
+	for _, txID := range tracked.PChainTxIDs {
+		status, err := client.PChainAPI().GetTxStatus(txID)
+		if err != nil {
+			return nil, stacktrace.Propagate(err, "Failed to get the status of P Chain transaction %s", txID)
+		}
+		nodeState.PChainTxStatuses[txID.String()] = status
+	}
+
+	height, err := client.PChainAPI().GetHeight()
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to get the P Chain height")
+	}
+	nodeState.PChainHeight = height
+
+	for _, address := range tracked.XChainAddresses {
+		balance, err := client.XChainAPI().GetBalance(address, helpers.AvaxAssetID)
+		if err != nil {
+			return nil, stacktrace.Propagate(err, "Failed to get the X Chain balance of %s", address)
+		}
+		nodeState.XChainBalances[address] = uint64(balance.Balance)
+	}
+	for _, address := range tracked.PChainAddresses {
+		balance, err := client.PChainAPI().GetBalance(address)
+		if err != nil {
+			return nil, stacktrace.Propagate(err, "Failed to get the P Chain balance of %s", address)
+		}
+		nodeState.PChainBalances[address] = uint64(balance.Balance)
+	}
+
+	validatorNodeIDs, err := helpers.GetValidatorNodeIDs(client, ids.Empty)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to get the validators of the primary network")
+	}
+	nodeState.ValidatorNodeIDs = validatorNodeIDs
+	return nodeState, nil
+}
+
+// CompareNodeStates asserts that the given node states agree, in the way described on VerifyConsensusSafety
+func (verifier ConsensusSafetyVerifier) CompareNodeStates(nodeStates map[networks.ServiceID]*NodeState) error {
+	serviceIDs := make([]networks.ServiceID, 0, len(nodeStates))
+	for serviceID := range nodeStates {
+		serviceIDs = append(serviceIDs, serviceID)
+	}
+	sort.Slice(serviceIDs, func(i, j int) bool { return serviceIDs[i] < serviceIDs[j] })
+
+	// Every value that's reported by some node, so that nodes missing one are compared too
+	xChainTxIDs, pChainTxIDs := map[string]bool{}, map[string]bool{}
+	xChainAddresses, pChainAddresses := map[string]bool{}, map[string]bool{}
+	allValidatorNodeIDs := map[string]bool{}
+	for _, nodeState := range nodeStates {
+		for txID := range nodeState.XChainTxStatuses {
+			xChainTxIDs[txID] = true
+		}
+		for txID := range nodeState.PChainTxStatuses {
+			pChainTxIDs[txID] = true
+		}
+		for address := range nodeState.XChainBalances {
+			xChainAddresses[address] = true
+		}
+		for address := range nodeState.PChainBalances {
+			pChainAddresses[address] = true
+		}
+		for nodeID := range nodeState.ValidatorNodeIDs {
+			allValidatorNodeIDs[nodeID] = true
+		}
+	}
+
+	violations := []string{}
+	for _, txID := range sortedKeys(xChainTxIDs) {
+		accepted, rejected := false, false
+		for _, nodeState := range nodeStates {
+			accepted = accepted || nodeState.XChainTxStatuses[txID] == choices.Accepted
+			rejected = rejected || nodeState.XChainTxStatuses[txID] == choices.Rejected
+		}
+		if accepted && rejected {
+			violations = append(violations, describeDiff(
+				fmt.Sprintf("X Chain transaction %s was accepted on some nodes but rejected on others", txID),
+				serviceIDs,
+				func(nodeState *NodeState) string { return nodeState.XChainTxStatuses[txID].String() },
+				nodeStates))
+		}
+	}
+	for _, txID := range sortedKeys(pChainTxIDs) {
+		committed, aborted := false, false
+		for _, nodeState := range nodeStates {
+			committed = committed || nodeState.PChainTxStatuses[txID] == platformvm.Committed
+			aborted = aborted || nodeState.PChainTxStatuses[txID] == platformvm.Aborted
+		}
+		if committed && aborted {
+			violations = append(violations, describeDiff(
+				fmt.Sprintf("P Chain transaction %s was committed on some nodes but aborted on others", txID),
+				serviceIDs,
+				func(nodeState *NodeState) string { return fmt.Sprintf("%v", nodeState.PChainTxStatuses[txID]) },
+				nodeStates))
+		}
+	}
+
+	if len(nodeStates) > 0 {
+		minHeight, maxHeight := ^uint64(0), uint64(0)
+		for _, nodeState := range nodeStates {
+			if nodeState.PChainHeight < minHeight {
+				minHeight = nodeState.PChainHeight
+			}
+			if nodeState.PChainHeight > maxHeight {
+				maxHeight = nodeState.PChainHeight
+			}
+		}
+		if maxHeight-minHeight > verifier.HeightTolerance {
+			violations = append(violations, describeDiff(
+				fmt.Sprintf("P Chain heights differ by %v, which is more than the tolerance of %v", maxHeight-minHeight, verifier.HeightTolerance),
+				serviceIDs,
+				func(nodeState *NodeState) string { return fmt.Sprintf("%v", nodeState.PChainHeight) },
+				nodeStates))
+		}
+	}
+
+	for _, address := range sortedKeys(xChainAddresses) {
+		if !allAgree(nodeStates, func(nodeState *NodeState) string { return balanceString(nodeState.XChainBalances, address) }) {
+			violations = append(violations, describeDiff(
+				fmt.Sprintf("X Chain balances of %s differ", address),
+				serviceIDs,
+				func(nodeState *NodeState) string { return balanceString(nodeState.XChainBalances, address) },
+				nodeStates))
+		}
+	}
+	for _, address := range sortedKeys(pChainAddresses) {
+		if !allAgree(nodeStates, func(nodeState *NodeState) string { return balanceString(nodeState.PChainBalances, address) }) {
+			violations = append(violations, describeDiff(
+				fmt.Sprintf("P Chain balances of %s differ", address),
+				serviceIDs,
+				func(nodeState *NodeState) string { return balanceString(nodeState.PChainBalances, address) },
+				nodeStates))
+		}
+	}
+
+	// Each node's validators are described by the ones it's missing from the union of what all nodes report
+	describeValidators := func(nodeState *NodeState) string {
+		missing := []string{}
+		for _, nodeID := range sortedKeys(allValidatorNodeIDs) {
+			if !nodeState.ValidatorNodeIDs[nodeID] {
+				missing = append(missing, nodeID)
+			}
+		}
+		if len(missing) == 0 {
+			return "all"
+		}
+		return fmt.Sprintf("missing %v", missing)
+	}
+	if !allAgree(nodeStates, describeValidators) {
+		violations = append(violations, describeDiff("Current validators differ", serviceIDs, describeValidators, nodeStates))
+	}
+
+	if len(violations) > 0 {
+		return stacktrace.NewError("Nodes disagree on the accepted state:\n%v", strings.Join(violations, "\n"))
+	}
+	logrus.Debugf("All %v nodes agree on the accepted state", len(nodeStates))
+	return nil
+}
+
+// ================= Helper functions ===================
+
+// describeDiff describes a disagreement, with a line per node giving the value it reports
+func describeDiff(
+	summary string,
+	serviceIDs []networks.ServiceID,
+	describe func(nodeState *NodeState) string,
+	nodeStates map[networks.ServiceID]*NodeState) string {
+	lines := []string{summary + ":"}
+	for _, serviceID := range serviceIDs {
+		lines = append(lines, fmt.Sprintf("\t%v: %v", serviceID, describe(nodeStates[serviceID])))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// allAgree returns whether the given description is the same for every node
+func allAgree(nodeStates map[networks.ServiceID]*NodeState, describe func(nodeState *NodeState) string) bool {
+	descriptions := make(map[string]bool)
+	for _, nodeState := range nodeStates {
+		descriptions[describe(nodeState)] = true
+	}
+	return len(descriptions) <= 1
+}
+
+func balanceString(balances map[string]uint64, address string) string {
+	balance, found := balances[address]
+	if !found {
+		return "unknown"
+	}
+	return fmt.Sprintf("%v", balance)
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,79 @@
+package verifier
+
+import (
+	"testing"
+
+	"github.com/ava-labs/avalanchego/snow/choices"
+	"github.com/ava-labs/avalanchego/vms/platformvm"
+	"github.com/kurtosis-tech/kurtosis/commons/networks"
+	"github.com/stretchr/testify/assert"
+)
+
+func newNodeState(xChainTxStatus choices.Status, pChainTxStatus platformvm.Status, height uint64, balance uint64, validators ...string) *NodeState {
+	validatorNodeIDs := make(map[string]bool)
+	for _, nodeID := range validators {
+		validatorNodeIDs[nodeID] = true
+	}
+	return &NodeState{
+		XChainTxStatuses: map[string]choices.Status{"xTx": xChainTxStatus},
+		PChainTxStatuses: map[string]platformvm.Status{"pTx": pChainTxStatus},
+		PChainHeight:     height,
+		XChainBalances:   map[string]uint64{"X-address": balance},
+		PChainBalances:   map[string]uint64{"P-address": balance},
+		ValidatorNodeIDs: validatorNodeIDs,
+	}
+}
+
+func TestCompareNodeStatesAgreeing(t *testing.T) {
+	verifier := ConsensusSafetyVerifier{HeightTolerance: 2}
+	nodeStates := map[networks.ServiceID]*NodeState{
+		"node-1": newNodeState(choices.Accepted, platformvm.Committed, 10, 5, "a", "b"),
+		"node-2": newNodeState(choices.Accepted, platformvm.Committed, 12, 5, "a", "b"),
+		// Lagging nodes that haven't decided the transactions yet don't disagree
+		"node-3": newNodeState(choices.Processing, platformvm.Processing, 11, 5, "a", "b"),
+	}
+	assert.NoError(t, verifier.CompareNodeStates(nodeStates))
+	assert.NoError(t, verifier.CompareNodeStates(map[networks.ServiceID]*NodeState{}))
+}
+
+func TestCompareNodeStatesDisagreeing(t *testing.T) {
+	verifier := ConsensusSafetyVerifier{HeightTolerance: 2}
+
+	err := verifier.CompareNodeStates(map[networks.ServiceID]*NodeState{
+		"node-1": newNodeState(choices.Accepted, platformvm.Committed, 10, 5, "a", "b"),
+		"node-2": newNodeState(choices.Rejected, platformvm.Committed, 10, 5, "a", "b"),
+	})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "X Chain transaction xTx was accepted on some nodes but rejected on others")
+	assert.Contains(t, err.Error(), "node-2: Rejected")
+
+	err = verifier.CompareNodeStates(map[networks.ServiceID]*NodeState{
+		"node-1": newNodeState(choices.Accepted, platformvm.Committed, 10, 5, "a", "b"),
+		"node-2": newNodeState(choices.Accepted, platformvm.Aborted, 10, 5, "a", "b"),
+	})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "P Chain transaction pTx was committed on some nodes but aborted on others")
+
+	err = verifier.CompareNodeStates(map[networks.ServiceID]*NodeState{
+		"node-1": newNodeState(choices.Accepted, platformvm.Committed, 10, 5, "a", "b"),
+		"node-2": newNodeState(choices.Accepted, platformvm.Committed, 13, 5, "a", "b"),
+	})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "node-2: 13")
+
+	err = verifier.CompareNodeStates(map[networks.ServiceID]*NodeState{
+		"node-1": newNodeState(choices.Accepted, platformvm.Committed, 10, 5, "a", "b"),
+		"node-2": newNodeState(choices.Accepted, platformvm.Committed, 10, 6, "a", "b"),
+	})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "X Chain balances of X-address differ")
+	assert.Contains(t, err.Error(), "P Chain balances of P-address differ")
+
+	err = verifier.CompareNodeStates(map[networks.ServiceID]*NodeState{
+		"node-1": newNodeState(choices.Accepted, platformvm.Committed, 10, 5, "a", "b"),
+		"node-2": newNodeState(choices.Accepted, platformvm.Committed, 10, 5, "a"),
+	})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "node-1: all")
+	assert.Contains(t, err.Error(), "node-2: missing [b]")
+}
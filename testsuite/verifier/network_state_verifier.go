@@ -1,6 +1,8 @@
 package verifier
 
 import (
+	"time"
+
 	"github.com/ava-labs/avalanche-testing/avalanche_client/apis"
 	"github.com/kurtosis-tech/kurtosis/commons/networks"
 	"github.com/palantir/stacktrace"
@@ -104,3 +106,69 @@ func (verifier NetworkStateVerifier) VerifyExpectedPeers(
 	}
 	return nil
 }
+
+// VerifyNodeVersions verifies that every node runs the same version, both as each node reports it itself and as its
+// peers see it
+// Args:
+// 		allAvalancheClients: The mapping of service_id -> avalanche client, for every node that's checked
+// 		expectedVersion: The version every node must run (e.g. "avalanche/0.8.3"), or empty if they only need to agree
+func (verifier NetworkStateVerifier) VerifyNodeVersions(
+	allAvalancheClients map[networks.ServiceID]*apis.Client,
+	expectedVersion string) error {
+	// Mapping of version -> a service that reported it, to name one in the error if they disagree
+	reportedVersions := make(map[string]networks.ServiceID)
+	for serviceID, client := range allAvalancheClients {
+		version, err := client.InfoAPI().GetNodeVersion()
+		if err != nil {
+			return stacktrace.Propagate(err, "Failed to get the node version of service with ID %v", serviceID)
+		}
+		reportedVersions[version] = serviceID
+
+		peers, err := client.InfoAPI().Peers()
+		if err != nil {
+			return stacktrace.Propagate(err, "Failed to get peers from service with ID %v", serviceID)
+		}
+		for _, peer := range peers {
+			reportedVersions[peer.Version] = serviceID
+		}
+	}
+
+	if expectedVersion != "" {
+		for version, serviceID := range reportedVersions {
+			if version != expectedVersion {
+				return stacktrace.NewError("Service ID %v reported version %v, but every node should run %v", serviceID, version, expectedVersion)
+			}
+		}
+	}
+	if len(reportedVersions) > 1 {
+		return stacktrace.NewError("Nodes run different versions; mapping of version -> a service that reported it: %v", reportedVersions)
+	}
+	return nil
+}
+
+// VerifyNoStalePeers verifies that a node has both sent a message to and received a message from each of its peers
+// recently, which connected peers always do because they ping each other
+// Args:
+// 		serviceID: Service ID of the node whose peers are being examined
+// 		client: avalanche client for the node being examined
+// 		maxSilence: How long ago the last message to or from a peer may have been
+func (verifier NetworkStateVerifier) VerifyNoStalePeers(
+	serviceID networks.ServiceID,
+	client *apis.Client,
+	maxSilence time.Duration) error {
+	peers, err := client.InfoAPI().Peers()
+	if err != nil {
+		return stacktrace.Propagate(err, "Failed to get peers from service with ID %v", serviceID)
+	}
+
+	now := time.Now()
+	for _, peer := range peers {
+		if silence := now.Sub(peer.LastSent); silence > maxSilence {
+			return stacktrace.NewError("Service ID %v hasn't sent a message to peer %v for %v", serviceID, peer.ID, silence)
+		}
+		if silence := now.Sub(peer.LastReceived); silence > maxSilence {
+			return stacktrace.NewError("Service ID %v hasn't received a message from peer %v for %v", serviceID, peer.ID, silence)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package wallet
+
+import (
+	testingConstants "github.com/ava-labs/avalanche-testing/avalanche_client/utils/constants"
+	"github.com/ava-labs/avalanche-testing/testsuite/txbuilder"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/vms/components/avax"
+	"github.com/palantir/stacktrace"
+)
+
+// Export is an export transaction that the wallet issued, along with the UTXOs that it puts into the destination
+// chain's shared memory once it's accepted
+type Export struct {
+	TxID             ids.ID
+	SourceChain      ids.ID
+	DestinationChain ids.ID
+	Amount           uint64
+	UTXOs            []*avax.UTXO
+}
+
+// ExportAvaXChainToPChain exports [amount] AVAX from the X Chain to P Chain address [pChainAddress], and blocks until
+// the export has been accepted
+// NOTE: Once the export has been built, it's returned even if issuing it or waiting for it fails, as it may still
+// end up accepted
+func (wallet *Wallet) ExportAvaXChainToPChain(pChainAddress string, amount uint64) (*Export, error) {
+	address, err := txbuilder.ParsePChainAddress(pChainAddress)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to parse the P Chain address")
+	}
+	tx, err := wallet.builder.ExportTx(
+		wallet.xChainUTXOs,
+		testingConstants.PlatformChainID,
+		testingConstants.AvaxAssetID,
+		amount,
+		txbuilder.Owners(1, 0, address),
+		wallet.changeAddress(),
+		wallet.txFee)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to build the transaction exporting AVAX to pchainAddress %s", pChainAddress)
+	}
+	utxos, err := txbuilder.ExportedUTXOs(tx)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to get the UTXOs exported by %s", tx.ID())
+	}
+	export := &Export{
+		TxID:             tx.ID(),
+		SourceChain:      testingConstants.XChainID,
+		DestinationChain: testingConstants.PlatformChainID,
+		Amount:           amount,
+		UTXOs:            utxos,
+	}
+	if _, err := wallet.issueXChainTx(tx); err != nil {
+		return export, stacktrace.Propagate(err, "Failed to issue the transaction exporting AVAX to pchainAddress %s", pChainAddress)
+	}
+	if err := wallet.AwaitXChainTxs(export.TxID); err != nil {
+		return export, stacktrace.Propagate(err, "Failed to accept ExportTx: %s", export.TxID)
+	}
+	return export, nil
+}
+
+// ExportAvaPChainToXChain exports [amount] AVAX from the P Chain to X Chain address [xChainAddress], and blocks until
+// the export has been committed
+// NOTE: Once the export has been built, it's returned even if issuing it or waiting for it fails, as it may still
+// end up committed
+func (wallet *Wallet) ExportAvaPChainToXChain(xChainAddress string, amount uint64) (*Export, error) {
+	address, err := txbuilder.ParseXChainAddress(xChainAddress)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to parse the X Chain address")
+	}
+	pChainUTXOs, err := wallet.getPChainUTXOs()
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to get the P Chain UTXOs to export from")
+	}
+	tx, err := wallet.builder.PChainExportTx(
+		pChainUTXOs,
+		testingConstants.XChainID,
+		amount,
+		txbuilder.Owners(1, 0, address),
+		wallet.changeAddress(),
+		wallet.txFee)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to build the transaction exporting AVAX to xChainAddress %s", xChainAddress)
+	}
+	utxos, err := txbuilder.PChainExportedUTXOs(tx)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to get the UTXOs exported by %s", tx.ID())
+	}
+	export := &Export{
+		TxID:             tx.ID(),
+		SourceChain:      testingConstants.PlatformChainID,
+		DestinationChain: testingConstants.XChainID,
+		Amount:           amount,
+		UTXOs:            utxos,
+	}
+	if err := wallet.issuePChainTx(tx); err != nil {
+		return export, stacktrace.Propagate(err, "Failed to issue the transaction exporting AVAX to xChainAddress %s", xChainAddress)
+	}
+	return export, nil
+}
+
+// ImportAvaToPChain imports the UTXOs of an export to the P Chain to P Chain address [pChainAddress], which must be
+// the wallet's key that the UTXOs were exported to, and blocks until the import has been committed
+// The fee of the import is paid from the imported AVAX.
+// NOTE: Once the import has been built, its ID is returned even if issuing it or waiting for it fails, as it may
+// still end up committed
+func (wallet *Wallet) ImportAvaToPChain(pChainAddress string, export *Export) (ids.ID, error) {
+	address, err := txbuilder.ParsePChainAddress(pChainAddress)
+	if err != nil {
+		return ids.Empty, stacktrace.Propagate(err, "Failed to parse the P Chain address")
+	}
+	pChainUTXOs, err := wallet.getPChainUTXOs()
+	if err != nil {
+		return ids.Empty, stacktrace.Propagate(err, "Failed to get the P Chain UTXOs to pay the import fee from")
+	}
+	tx, err := wallet.builder.PChainImportTx(
+		pChainUTXOs,
+		export.UTXOs,
+		export.SourceChain,
+		txbuilder.Owners(1, 0, address),
+		address,
+		wallet.txFee)
+	if err != nil {
+		return ids.Empty, stacktrace.Propagate(err, "Failed to build the transaction importing %s to pchainAddress %s", export.TxID, pChainAddress)
+	}
+	if err := wallet.issuePChainTx(tx); err != nil {
+		return tx.ID(), stacktrace.Propagate(err, "Failed to issue the transaction importing %s to pchainAddress %s", export.TxID, pChainAddress)
+	}
+	return tx.ID(), nil
+}
+
+// ImportAvaToXChain imports the UTXOs of an export to the X Chain to X Chain address [xChainAddress], which must be
+// the wallet's key that the UTXOs were exported to, and blocks until the import has been accepted
+// The fee of the import is paid from the imported AVAX.
+// NOTE: Once the import has been built, its ID is returned even if issuing it or waiting for it fails, as it may
+// still end up accepted
+func (wallet *Wallet) ImportAvaToXChain(xChainAddress string, export *Export) (ids.ID, error) {
+	address, err := txbuilder.ParseXChainAddress(xChainAddress)
+	if err != nil {
+		return ids.Empty, stacktrace.Propagate(err, "Failed to parse the X Chain address")
+	}
+	tx, err := wallet.builder.ImportTx(
+		wallet.xChainUTXOs,
+		export.UTXOs,
+		export.SourceChain,
+		txbuilder.Owners(1, 0, address),
+		wallet.changeAddress(),
+		wallet.txFee)
+	if err != nil {
+		return ids.Empty, stacktrace.Propagate(err, "Failed to build the transaction importing %s to xChainAddress %s", export.TxID, xChainAddress)
+	}
+	txID := tx.ID()
+	if _, err := wallet.issueXChainTx(tx); err != nil {
+		return txID, stacktrace.Propagate(err, "Failed to issue the transaction importing %s to xChainAddress %s", export.TxID, xChainAddress)
+	}
+	if err := wallet.AwaitXChainTxs(txID); err != nil {
+		return txID, stacktrace.Propagate(err, "Failed to wait for acceptance of transaction on XChain.")
+	}
+	return txID, nil
+}
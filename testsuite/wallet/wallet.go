@@ -0,0 +1,503 @@
+package wallet
+
+import (
+	"time"
+
+	avalancheNetwork "github.com/ava-labs/avalanche-testing/avalanche/networks"
+	"github.com/ava-labs/avalanche-testing/avalanche_client/apis"
+	testingConstants "github.com/ava-labs/avalanche-testing/avalanche_client/utils/constants"
+	"github.com/ava-labs/avalanche-testing/testsuite/helpers"
+	"github.com/ava-labs/avalanche-testing/testsuite/txbuilder"
+	"github.com/ava-labs/avalanchego/api"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/crypto"
+	"github.com/ava-labs/avalanchego/vms/avm"
+	"github.com/ava-labs/avalanchego/vms/components/avax"
+	"github.com/ava-labs/avalanchego/vms/platformvm"
+	"github.com/ava-labs/avalanchego/vms/secp256k1fx"
+	"github.com/palantir/stacktrace"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// The most UTXOs that are fetched for the wallet's X Chain addresses
+	maxXChainUTXOs = 1024
+
+	// The delegation fee rate that validators are added with, in the millionths of the delegators' rewards that the
+	// P Chain expects rather than the percentage that the API takes
+	defaultDelegationShares = uint32(helpers.DefaultDelegationFeeRate * 10000)
+
+	// How long after a staking period begins to wait before treating it as having begun, to allow for clock skew
+	// between the test controller and the nodes
+	stakingPeriodSynchronyDelay = 3 * time.Second
+)
+
+// Wallet executes the same standard testing workflows as helpers.RPCWorkFlowRunner, but holds its keys locally and
+// builds, signs and issues the transactions itself instead of going through a node's keystore
+// It tracks the X Chain UTXOs of its keys, updating them with the outputs of every X Chain transaction it issues, and
+// fetches the P Chain UTXOs of its keys before every P Chain transaction, which it always waits for the acceptance of.
+// NOTE: Funds that are sent to the wallet's X Chain addresses by anyone else aren't tracked until RefreshUTXOs is
+// called. Wallet isn't safe for concurrent use, and doesn't store its keys in a secure way; it is only suitable for
+// testing purposes.
+type Wallet struct {
+	client  *apis.Client
+	builder *txbuilder.Builder
+	txFee   uint64
+
+	// The addresses of the wallet's keys, in the order they were added; the first is where change is returned to
+	addresses []ids.ShortID
+
+	// The X Chain UTXOs the wallet can spend
+	xChainUTXOs []*avax.UTXO
+
+	// How long the validators and delegators that the wallet adds stake for, and the delegation fee its validators
+	// charge in millionths of the delegators' rewards
+	stakingPeriod    time.Duration
+	delegationPeriod time.Duration
+	delegationShares uint32
+
+	// Only used for its workflows that don't touch the keystore: issuing raw transactions, awaiting their
+	// acceptance and verifying balances
+	runner *helpers.RPCWorkFlowRunner
+}
+
+// NewWallet creates a Wallet that issues its transactions to the given node
+// Args:
+// 	client: The client of the node to issue transactions to
+// 	networkID: The ID of the network the node is in
+// 	txFee: The network's transaction fee
+// 	networkAcceptanceTimeout: How long to wait for a transaction to be accepted
+// 	keys: The keys the wallet starts out with; the wallet's UTXOs should be refreshed before spending them
+func NewWallet(
+	client *apis.Client,
+	networkID uint32,
+	txFee uint64,
+	networkAcceptanceTimeout time.Duration,
+	keys ...*crypto.PrivateKeySECP256K1R) (*Wallet, error) {
+	builder, err := txbuilder.NewBuilder(networkID)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to create the transaction builder")
+	}
+	wallet := &Wallet{
+		client:  client,
+		builder: builder,
+		txFee:   txFee,
+		runner:  helpers.NewRPCWorkFlowRunner(client, api.UserPass{}, networkAcceptanceTimeout),
+
+		stakingPeriod:    helpers.DefaultStakingPeriod,
+		delegationPeriod: helpers.DefaultDelegationPeriod,
+		delegationShares: defaultDelegationShares,
+	}
+	for _, key := range keys {
+		wallet.AddKey(key)
+	}
+	return wallet, nil
+}
+
+// AddKey lets the wallet spend the funds of the given key, which are only picked up on the next call to RefreshUTXOs
+func (wallet *Wallet) AddKey(key *crypto.PrivateKeySECP256K1R) {
+	wallet.builder.AddKey(key)
+	wallet.addresses = append(wallet.addresses, key.PublicKey().Address())
+}
+
+// SetStakingPeriods makes the wallet add validators and delegators for the given periods rather than
+// helpers.DefaultStakingPeriod and helpers.DefaultDelegationPeriod
+// NOTE: The nodes reject periods shorter than their minimum stake duration, so short periods need the network's
+// validators to be started with a lower NodeConfig.MinStakeDuration
+func (wallet *Wallet) SetStakingPeriods(stakingPeriod time.Duration, delegationPeriod time.Duration) {
+	wallet.stakingPeriod = stakingPeriod
+	wallet.delegationPeriod = delegationPeriod
+}
+
+// SetDelegationFeeRate makes the wallet add validators that charge delegators the given fee rate, as the percentage
+// of their rewards that the API takes, rather than helpers.DefaultDelegationFeeRate
+func (wallet *Wallet) SetDelegationFeeRate(delegationFeeRate float64) error {
+	if delegationFeeRate < 0 || delegationFeeRate > 100 {
+		return stacktrace.NewError("Delegation fee rate must be a percentage between 0 and 100 but was %v", delegationFeeRate)
+	}
+	wallet.delegationShares = uint32(delegationFeeRate * 10000)
+	return nil
+}
+
+// NewKey generates a new key and adds it to the wallet
+func (wallet *Wallet) NewKey() (*crypto.PrivateKeySECP256K1R, error) {
+	factory := crypto.FactorySECP256K1R{}
+	key, err := factory.NewPrivateKey()
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to generate a private key")
+	}
+	privateKey := key.(*crypto.PrivateKeySECP256K1R)
+	wallet.AddKey(privateKey)
+	return privateKey, nil
+}
+
+// XChainAddress returns the given address in the "X-..." form
+func (wallet *Wallet) XChainAddress(address ids.ShortID) (string, error) {
+	return wallet.builder.XChainAddress(address)
+}
+
+// PChainAddress returns the given address in the "P-..." form
+func (wallet *Wallet) PChainAddress(address ids.ShortID) (string, error) {
+	return wallet.builder.PChainAddress(address)
+}
+
+// XChainUTXOs returns the X Chain UTXOs the wallet is tracking
+func (wallet *Wallet) XChainUTXOs() []*avax.UTXO {
+	return wallet.xChainUTXOs
+}
+
+// RefreshUTXOs replaces the X Chain UTXOs the wallet is tracking with the ones the node has for the wallet's keys
+func (wallet *Wallet) RefreshUTXOs() error {
+	if len(wallet.addresses) == 0 {
+		wallet.xChainUTXOs = nil
+		return nil
+	}
+	addresses, err := wallet.formatAddresses(wallet.builder.XChainAddress)
+	if err != nil {
+		return stacktrace.Propagate(err, "Failed to format the wallet's X Chain addresses")
+	}
+	utxoReply, err := wallet.client.XChainAPI().GetUTXOs(addresses, maxXChainUTXOs, "", "")
+	if err != nil {
+		return stacktrace.Propagate(err, "Failed to get the X Chain UTXOs of the wallet's addresses")
+	}
+	utxosBytes := make([][]byte, len(utxoReply.UTXOs))
+	for i, formattedUTXO := range utxoReply.UTXOs {
+		utxosBytes[i] = formattedUTXO.Bytes
+	}
+	utxos, err := txbuilder.ParseUTXOs(wallet.builder.XChainCodec(), utxosBytes)
+	if err != nil {
+		return stacktrace.Propagate(err, "Failed to parse the X Chain UTXOs of the wallet's addresses")
+	}
+	wallet.xChainUTXOs = utxos
+	return nil
+}
+
+// ImportGenesisFunds adds the key of the genesis funded address to the wallet, and returns the address
+func (wallet *Wallet) ImportGenesisFunds() (string, error) {
+	genesisKey, err := txbuilder.ParsePrivateKey(avalancheNetwork.DefaultLocalNetGenesisConfig.FundedAddresses.PrivateKey)
+	if err != nil {
+		return "", stacktrace.Propagate(err, "Failed to parse the genesis private key")
+	}
+	wallet.AddKey(genesisKey)
+	if err := wallet.RefreshUTXOs(); err != nil {
+		return "", stacktrace.Propagate(err, "Failed to get the UTXOs of the genesis address")
+	}
+	genesisAccountAddress, err := wallet.builder.XChainAddress(genesisKey.PublicKey().Address())
+	if err != nil {
+		return "", stacktrace.Propagate(err, "Failed to format the genesis address")
+	}
+	logrus.Debugf("Genesis Address: %s.", genesisAccountAddress)
+	return genesisAccountAddress, nil
+}
+
+// ImportGenesisFundsAndStartValidating imports the genesis funds, moves [seedAmount] of them to a new P Chain address
+// and stakes [stakeAmount] of that to add the wallet's node as a validator
+func (wallet *Wallet) ImportGenesisFundsAndStartValidating(
+	seedAmount uint64,
+	stakeAmount uint64) (string, error) {
+	stakerNodeID, err := wallet.client.InfoAPI().GetNodeID()
+	if err != nil {
+		return "", stacktrace.Propagate(err, "Could not get staker node ID.")
+	}
+	if _, err := wallet.ImportGenesisFunds(); err != nil {
+		return "", stacktrace.Propagate(err, "Could not seed XChain account from Genesis.")
+	}
+	_, pChainAddress, err := wallet.CreateDefaultAddresses()
+	if err != nil {
+		return "", stacktrace.Propagate(err, "Failed to create new address on PChain")
+	}
+	if err := wallet.TransferAvaXChainToPChain(pChainAddress, seedAmount); err != nil {
+		return "", stacktrace.Propagate(err, "Could not transfer AVAX from XChain to PChain account information")
+	}
+	if err := wallet.AddValidatorToPrimaryNetwork(stakerNodeID, pChainAddress, stakeAmount); err != nil {
+		return "", stacktrace.Propagate(err, "Could not add staker %s to primary network.", stakerNodeID)
+	}
+	return pChainAddress, nil
+}
+
+// CreateDefaultAddresses generates a new key, and returns its address in the X and P Chain forms
+func (wallet *Wallet) CreateDefaultAddresses() (string, string, error) {
+	key, err := wallet.NewKey()
+	if err != nil {
+		return "", "", err
+	}
+	address := key.PublicKey().Address()
+	xAddress, err := wallet.builder.XChainAddress(address)
+	if err != nil {
+		return "", "", err
+	}
+	pAddress, err := wallet.builder.PChainAddress(address)
+	return xAddress, pAddress, err
+}
+
+// SendAVAX issues a transaction that sends [amount] AVAX to X Chain address [to], without waiting for it to be
+// accepted, and returns its ID
+func (wallet *Wallet) SendAVAX(to string, amount uint64) (ids.ID, error) {
+	toAddress, err := txbuilder.ParseXChainAddress(to)
+	if err != nil {
+		return ids.Empty, stacktrace.Propagate(err, "Failed to parse the recipient's address")
+	}
+	tx, err := wallet.builder.BaseTx(
+		wallet.xChainUTXOs,
+		testingConstants.AvaxAssetID,
+		amount,
+		txbuilder.Owners(1, 0, toAddress),
+		wallet.changeAddress(),
+		wallet.txFee)
+	if err != nil {
+		return ids.Empty, stacktrace.Propagate(err, "Failed to build the transaction sending %v AVAX to %s", amount, to)
+	}
+	return wallet.issueXChainTx(tx)
+}
+
+// FundXChainAddresses sends [amount] AVAX to each address in [addresses], waiting for each transaction to be
+// accepted in turn
+func (wallet *Wallet) FundXChainAddresses(addresses []string, amount uint64) error {
+	for _, address := range addresses {
+		txID, err := wallet.SendAVAX(address, amount)
+		if err != nil {
+			return stacktrace.Propagate(err, "Failed to fund address %s", address)
+		}
+		if err := wallet.AwaitXChainTxs(txID); err != nil {
+			return stacktrace.Propagate(err, "Failed to accept the transaction funding address %s", address)
+		}
+	}
+	return nil
+}
+
+// TransferAvaXChainToPChain exports [amount] AVAX from the X Chain and then imports it to P Chain address
+// [pChainAddress], which must be one of the wallet's, and blocks until both transactions have been accepted
+// The fee of the import is paid from the imported AVAX.
+func (wallet *Wallet) TransferAvaXChainToPChain(pChainAddress string, amount uint64) error {
+	export, err := wallet.ExportAvaXChainToPChain(pChainAddress, amount)
+	if err != nil {
+		return stacktrace.Propagate(err, "Failed to export AVAX to pchainAddress %s", pChainAddress)
+	}
+	if _, err := wallet.ImportAvaToPChain(pChainAddress, export); err != nil {
+		return stacktrace.Propagate(err, "Failed import AVAX to pchainAddress %s", pChainAddress)
+	}
+	return nil
+}
+
+// TransferAvaPChainToXChain exports [amount] AVAX from the P Chain and then imports it to X Chain address
+// [xChainAddress], which must be one of the wallet's, and blocks until both transactions have been accepted
+// The fee of the import is paid from the imported AVAX.
+func (wallet *Wallet) TransferAvaPChainToXChain(xChainAddress string, amount uint64) error {
+	export, err := wallet.ExportAvaPChainToXChain(xChainAddress, amount)
+	if err != nil {
+		return stacktrace.Propagate(err, "Failed to export AVAX to xChainAddress %s", xChainAddress)
+	}
+	if _, err := wallet.ImportAvaToXChain(xChainAddress, export); err != nil {
+		return stacktrace.Propagate(err, "Failed import AVAX to xChainAddress %s", xChainAddress)
+	}
+	return nil
+}
+
+// AddValidatorToPrimaryNetwork stakes [stakeAmount] of the wallet's P Chain AVAX to add [nodeID] as a validator, with
+// the stake and reward going to [pChainAddress], and blocks until the transaction is confirmed and the validation
+// period begins
+func (wallet *Wallet) AddValidatorToPrimaryNetwork(
+	nodeID string,
+	pChainAddress string,
+	stakeAmount uint64,
+) error {
+	shortNodeID, rewardAddress, pChainUTXOs, err := wallet.prepareStake(nodeID, pChainAddress)
+	if err != nil {
+		return stacktrace.Propagate(err, "Failed to prepare to stake for node %s", nodeID)
+	}
+	stakingStartTime := time.Now().Add(helpers.DefaultStakingDelay)
+	tx, err := wallet.builder.AddValidatorTx(
+		pChainUTXOs,
+		shortNodeID,
+		stakingStartTime,
+		stakingStartTime.Add(wallet.stakingPeriod),
+		stakeAmount,
+		txbuilder.Owners(1, 0, rewardAddress),
+		wallet.delegationShares,
+		rewardAddress,
+		wallet.txFee)
+	if err != nil {
+		return stacktrace.Propagate(err, "Failed to build the transaction adding validator %s", nodeID)
+	}
+	if err := wallet.issuePChainTx(tx); err != nil {
+		return stacktrace.Propagate(err, "Failed to add validator to primary network %s", nodeID)
+	}
+
+	time.Sleep(time.Until(stakingStartTime) + stakingPeriodSynchronyDelay)
+	return nil
+}
+
+// AddDelegatorToPrimaryNetwork delegates [stakeAmount] of the wallet's P Chain AVAX to [delegateeNodeID], with the
+// stake and reward going to [pChainAddress], and blocks until the transaction is confirmed and the delegation period
+// begins
+func (wallet *Wallet) AddDelegatorToPrimaryNetwork(
+	delegateeNodeID string,
+	pChainAddress string,
+	stakeAmount uint64,
+) error {
+	shortNodeID, rewardAddress, pChainUTXOs, err := wallet.prepareStake(delegateeNodeID, pChainAddress)
+	if err != nil {
+		return stacktrace.Propagate(err, "Failed to prepare to delegate to node %s", delegateeNodeID)
+	}
+	delegatorStartTime := time.Now().Add(helpers.DefaultDelegationDelay)
+	tx, err := wallet.builder.AddDelegatorTx(
+		pChainUTXOs,
+		shortNodeID,
+		delegatorStartTime,
+		delegatorStartTime.Add(wallet.delegationPeriod),
+		stakeAmount,
+		txbuilder.Owners(1, 0, rewardAddress),
+		rewardAddress,
+		wallet.txFee)
+	if err != nil {
+		return stacktrace.Propagate(err, "Failed to build the transaction delegating to %s", delegateeNodeID)
+	}
+	if err := wallet.issuePChainTx(tx); err != nil {
+		return stacktrace.Propagate(err, "Failed to add delegator %s", pChainAddress)
+	}
+
+	time.Sleep(time.Until(delegatorStartTime) + stakingPeriodSynchronyDelay)
+	return nil
+}
+
+// IssueTxList issues each of the given signed X Chain transactions in order, without tracking their UTXOs
+func (wallet *Wallet) IssueTxList(txList [][]byte) error {
+	return wallet.runner.IssueTxList(txList)
+}
+
+// AwaitXChainTxs confirms each transaction and returns an error if any of them are not confirmed
+func (wallet *Wallet) AwaitXChainTxs(txIDs ...ids.ID) error {
+	return wallet.runner.AwaitXChainTxs(txIDs...)
+}
+
+// AwaitPChainTxs confirms each transaction and returns an error if any of them are not confirmed
+func (wallet *Wallet) AwaitPChainTxs(txIDs ...ids.ID) error {
+	return wallet.runner.AwaitPChainTxs(txIDs...)
+}
+
+// VerifyPChainBalance verifies that the balance of P Chain Address: [address] is [expectedBalance]
+func (wallet *Wallet) VerifyPChainBalance(address string, expectedBalance uint64) error {
+	return wallet.runner.VerifyPChainBalance(address, expectedBalance)
+}
+
+// VerifyXChainAVABalance verifies that the balance of X Chain Address: [address] is [expectedBalance]
+func (wallet *Wallet) VerifyXChainAVABalance(address string, expectedBalance uint64) error {
+	return wallet.runner.VerifyXChainAVABalance(address, expectedBalance)
+}
+
+// ================= Helper functions ===================
+
+// changeAddress returns the address that the wallet returns change to
+// NOTE: A wallet without keys has nothing to spend, so the empty address it returns is never actually paid change
+func (wallet *Wallet) changeAddress() ids.ShortID {
+	if len(wallet.addresses) == 0 {
+		return ids.ShortEmpty
+	}
+	return wallet.addresses[0]
+}
+
+// formatAddresses returns the wallet's addresses in the form the given function formats them in
+func (wallet *Wallet) formatAddresses(format func(ids.ShortID) (string, error)) ([]string, error) {
+	formattedAddresses := make([]string, len(wallet.addresses))
+	for i, address := range wallet.addresses {
+		formattedAddress, err := format(address)
+		if err != nil {
+			return nil, err
+		}
+		formattedAddresses[i] = formattedAddress
+	}
+	return formattedAddresses, nil
+}
+
+// getPChainUTXOs fetches the P Chain UTXOs of the wallet's addresses from the node
+func (wallet *Wallet) getPChainUTXOs() ([]*avax.UTXO, error) {
+	addresses, err := wallet.formatAddresses(wallet.builder.PChainAddress)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to format the wallet's P Chain addresses")
+	}
+	utxosBytes, err := wallet.client.PChainAPI().GetUTXOs(addresses)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to get the P Chain UTXOs of the wallet's addresses")
+	}
+	utxos, err := txbuilder.ParsePChainUTXOs(utxosBytes)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Failed to parse the P Chain UTXOs of the wallet's addresses")
+	}
+	return utxos, nil
+}
+
+// prepareStake parses the node ID and P Chain address that a stake is for, and fetches the UTXOs to stake from
+func (wallet *Wallet) prepareStake(nodeID string, pChainAddress string) (ids.ShortID, ids.ShortID, []*avax.UTXO, error) {
+	shortNodeID, err := txbuilder.ParseNodeID(nodeID)
+	if err != nil {
+		return ids.ShortEmpty, ids.ShortEmpty, nil, stacktrace.Propagate(err, "Failed to parse the node ID")
+	}
+	address, err := txbuilder.ParsePChainAddress(pChainAddress)
+	if err != nil {
+		return ids.ShortEmpty, ids.ShortEmpty, nil, stacktrace.Propagate(err, "Failed to parse the P Chain address")
+	}
+	utxos, err := wallet.getPChainUTXOs()
+	if err != nil {
+		return ids.ShortEmpty, ids.ShortEmpty, nil, stacktrace.Propagate(err, "Failed to get the P Chain UTXOs to stake from")
+	}
+	return shortNodeID, address, utxos, nil
+}
+
+// issueXChainTx issues the transaction to the X Chain and updates the tracked UTXOs with it
+func (wallet *Wallet) issueXChainTx(tx *avm.Tx) (ids.ID, error) {
+	txID, err := wallet.client.XChainAPI().IssueTx(tx.Bytes())
+	if err != nil {
+		return ids.Empty, stacktrace.Propagate(err, "Failed to issue transaction %s", tx.ID())
+	}
+	wallet.trackXChainTx(tx)
+	return txID, nil
+}
+
+// trackXChainTx removes the UTXOs that the transaction consumes from the tracked UTXOs, and adds the ones it produces
+// that the wallet's keys own
+func (wallet *Wallet) trackXChainTx(tx *avm.Tx) {
+	consumed := make(map[[32]byte]bool)
+	for _, utxoID := range tx.InputUTXOs() {
+		consumed[utxoID.InputID().Key()] = true
+	}
+	utxos := make([]*avax.UTXO, 0, len(wallet.xChainUTXOs))
+	for _, utxo := range wallet.xChainUTXOs {
+		if !consumed[utxo.InputID().Key()] {
+			utxos = append(utxos, utxo)
+		}
+	}
+	for _, utxo := range tx.UTXOs() {
+		if wallet.owns(utxo) {
+			utxos = append(utxos, utxo)
+		}
+	}
+	wallet.xChainUTXOs = utxos
+}
+
+// owns returns whether any of the wallet's keys is one of the owners of the UTXO's output
+func (wallet *Wallet) owns(utxo *avax.UTXO) bool {
+	out, ok := utxo.Out.(*secp256k1fx.TransferOutput)
+	if !ok {
+		return false
+	}
+	for _, owner := range out.Addrs {
+		for _, address := range wallet.addresses {
+			if owner.Equals(address) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// issuePChainTx issues the transaction to the P Chain and waits for it to be committed
+func (wallet *Wallet) issuePChainTx(tx *platformvm.Tx) error {
+	txID, err := wallet.client.PChainAPI().IssueTx(tx.Bytes())
+	if err != nil {
+		return stacktrace.Propagate(err, "Failed to issue transaction %s", tx.ID())
+	}
+	if err := wallet.AwaitPChainTxs(txID); err != nil {
+		return stacktrace.Propagate(err, "Failed to confirm transaction %s", txID)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package wallet
+
+import (
+	"testing"
+	"time"
+
+	testingConstants "github.com/ava-labs/avalanche-testing/avalanche_client/utils/constants"
+	"github.com/ava-labs/avalanche-testing/testsuite/txbuilder"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/constants"
+	"github.com/ava-labs/avalanchego/vms/components/avax"
+	"github.com/ava-labs/avalanchego/vms/secp256k1fx"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestUTXO(index byte, amount uint64, owners secp256k1fx.OutputOwners) *avax.UTXO {
+	return &avax.UTXO{
+		UTXOID: avax.UTXOID{TxID: ids.NewID([32]byte{index})},
+		Asset:  avax.Asset{ID: testingConstants.AvaxAssetID},
+		Out: &secp256k1fx.TransferOutput{
+			Amt:          amount,
+			OutputOwners: owners,
+		},
+	}
+}
+
+func TestTrackXChainTx(t *testing.T) {
+	wallet, err := NewWallet(nil, constants.LocalID, 10, time.Second)
+	assert.NoError(t, err)
+	key, err := wallet.NewKey()
+	assert.NoError(t, err)
+	address := key.PublicKey().Address()
+	spentUTXO := newTestUTXO(1, 100, txbuilder.Owners(1, 0, address))
+	unspentUTXO := newTestUTXO(2, 100, txbuilder.Owners(1, 0, address))
+	wallet.xChainUTXOs = []*avax.UTXO{spentUTXO, unspentUTXO}
+
+	recipient := ids.NewShortID([20]byte{1})
+	tx, err := wallet.builder.BaseTx(
+		[]*avax.UTXO{spentUTXO},
+		testingConstants.AvaxAssetID,
+		60,
+		txbuilder.Owners(1, 0, recipient),
+		address,
+		10)
+	assert.NoError(t, err)
+	wallet.trackXChainTx(tx)
+
+	// The unspent UTXO and the change are tracked, but not the spent UTXO or the recipient's output
+	assert.Len(t, wallet.xChainUTXOs, 2)
+	assert.True(t, wallet.xChainUTXOs[0].InputID().Equals(unspentUTXO.InputID()))
+	change := wallet.xChainUTXOs[1]
+	assert.True(t, change.TxID.Equals(tx.ID()))
+	assert.Equal(t, uint64(30), change.Out.(*secp256k1fx.TransferOutput).Amt)
+}